@@ -2,16 +2,20 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/Kopleman/metcol/internal/agent/config"
+	"github.com/Kopleman/metcol/internal/agent/ingest"
 	metricscollector "github.com/Kopleman/metcol/internal/agent/metrics-collector"
+	"github.com/Kopleman/metcol/internal/common/envelope"
 	"github.com/Kopleman/metcol/internal/common/grpc"
 	httpclient "github.com/Kopleman/metcol/internal/common/http-client"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/common/tlsconfig"
 	"github.com/Kopleman/metcol/internal/common/utils"
 )
 
@@ -42,20 +46,52 @@ func run(logger log.Logger) error {
 		return fmt.Errorf("failed to parse the agent's config: %w", err)
 	}
 
-	httpClient := httpclient.NewHTTPClient(agentConfig, logger)
-	var grpcClient *grpc.MetricsClient
-	if agentConfig.GRPCEndPoint.String() != "" {
-		grpcClient, err = grpc.NewMetricsClient(agentConfig.GRPCEndPoint.String(), agentConfig.Key)
-		if err == nil {
-			return fmt.Errorf("failed to connect to grpc endpoint: %s", agentConfig.GRPCEndPoint.String())
+	var tlsConfig *tls.Config
+	httpClientOpts := make([]httpclient.Option, 0)
+	if agentConfig.TLSEnabled() {
+		tlsConfig, err = tlsconfig.NewClientConfig(agentConfig.TLSCAPath, agentConfig.TLSCertPath, agentConfig.TLSKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to init tls: %w", err)
 		}
+		httpClientOpts = append(httpClientOpts, httpclient.WithTLSConfig(tlsConfig))
+	}
+
+	httpClient := httpclient.NewHTTPClient(agentConfig, logger, httpClientOpts...)
+	var grpcClient metricscollector.GRPCClient
+	if agentConfig.GRPCEndPoint != nil {
+		var publicKey *envelope.PublicKey
+		if agentConfig.PublicKeyPath != "" {
+			if publicKey, err = envelope.LoadPublicKey(agentConfig.PublicKeyPath); err != nil {
+				return fmt.Errorf("failed to load public key: %w", err)
+			}
+		}
+		metricsClient, grpcErr := grpc.NewMetricsClient(
+			agentConfig.GRPCEndPoint.String(),
+			agentConfig.Key,
+			agentConfig.Token,
+			agentConfig.Namespace,
+			tlsConfig,
+			publicKey,
+		)
+		if grpcErr != nil {
+			return fmt.Errorf("failed to connect to grpc endpoint %s: %w", agentConfig.GRPCEndPoint.String(), grpcErr)
+		}
+		defer metricsClient.Close() //nolint:all //safe
+		grpcClient = metricsClient
 	}
-	defer grpcClient.Close() //nolint:all //safe
 	collector := metricscollector.NewMetricsCollector(agentConfig, logger, httpClient, grpcClient)
 	if initErr := collector.Init(); initErr != nil {
 		return fmt.Errorf("failed to initialize the collector: %w", initErr)
 	}
 
+	if agentConfig.IngestAddr != "" || agentConfig.IngestUDPAddr != "" {
+		ingestServer := ingest.NewServer(logger, collector)
+		if err = ingestServer.Start(agentConfig.IngestAddr, agentConfig.IngestUDPAddr); err != nil {
+			return fmt.Errorf("failed to start ingest server: %w", err)
+		}
+		defer ingestServer.Stop()
+	}
+
 	if err = collector.Handler(sig); err != nil {
 		return fmt.Errorf("metrics collector error: %w", err)
 	}
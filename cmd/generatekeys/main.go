@@ -26,6 +26,14 @@ func main() {
 		logger.Fatalf("unable to parse config for generator: %w", err)
 	}
 	generator := cryptokeysgenerator.NewGenerator()
+	if config.KeysDir != "" {
+		privateKeyPath, genErr := generator.GenerateKeysToDir(config.KeysDir, config.KeyID, config.PublicKeyPath)
+		if genErr != nil {
+			logger.Fatalf("unable to generate keys: %w", genErr)
+		}
+		logger.Infof("Keys generated successfully, private=%s, public=%s", privateKeyPath, config.PublicKeyPath)
+		return
+	}
 	if genErr := generator.GenerateKeys(config.PrivateKeyPath, config.PublicKeyPath); genErr != nil {
 		logger.Fatalf("unable to generate keys: %w", genErr)
 	}
@@ -0,0 +1,73 @@
+// Package collector defines sources of metrics collected by agent.
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+// Metric collected metric value. Gauge replaces previous value of metric, counter delta is
+// added to it. Labels distinguish series of same metric, e.g. disk usage of each mount.
+type Metric struct {
+	Labels map[string]string
+	Type   common.MetricType
+	Value  float64
+	Delta  int64
+}
+
+// Gauge creates gauge metric.
+func Gauge(value float64) Metric {
+	return Metric{Type: common.GaugeMetricType, Value: value}
+}
+
+// Counter creates counter metric increased by delta.
+func Counter(delta int64) Metric {
+	return Metric{Type: common.CounterMetricType, Delta: delta}
+}
+
+// FromDTO converts gauge or counter dto to metric, counter delta is increment of metric.
+func FromDTO(metricDto *dto.MetricDTO) (Metric, error) {
+	if metricDto == nil || metricDto.ID == "" {
+		return Metric{}, errors.New("metric name is empty")
+	}
+
+	var metric Metric
+	switch {
+	case metricDto.MType == common.GaugeMetricType && metricDto.Value != nil:
+		metric = Gauge(*metricDto.Value)
+	case metricDto.MType == common.CounterMetricType && metricDto.Delta != nil:
+		metric = Counter(*metricDto.Delta)
+	default:
+		return Metric{}, fmt.Errorf("metric %s: unsupported type '%s' or missing value", metricDto.ID, metricDto.MType)
+	}
+	metric.Labels = metricDto.Labels
+	return metric, nil
+}
+
+// SeriesKey builds key of labeled metric in collected metrics, e.g. `DiskFree{mount="/"}`.
+func SeriesKey(name string, labels map[string]string) string {
+	labelsKey := dto.LabelsKey(labels)
+	if labelsKey == "" {
+		return name
+	}
+	return name + "{" + labelsKey + "}"
+}
+
+// SeriesName returns metric name of series key.
+func SeriesName(key string) string {
+	name, _, _ := strings.Cut(key, "{")
+	return name
+}
+
+// Collector source of metrics. Collect must return once ctx is done.
+type Collector interface {
+	// Name identifies collector in config.
+	Name() string
+	// Collect returns current metrics by name, labeled metrics by SeriesKey.
+	Collect(ctx context.Context) (map[string]Metric, error)
+}
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemStats_Collect(t *testing.T) {
+	c := NewMemStats()
+	assert.Equal(t, MemStatsName, c.Name())
+
+	metrics, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, metrics, 27)
+	for name, metric := range metrics {
+		assert.Equal(t, common.GaugeMetricType, metric.Type, name)
+	}
+	assert.Positive(t, metrics["HeapAlloc"].Value)
+}
+
+func TestGopsutil_Collect(t *testing.T) {
+	c := NewGopsutil()
+	assert.Equal(t, GopsutilName, c.Name())
+
+	metrics, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, metrics, "TotalMemory")
+	assert.Contains(t, metrics, "FreeMemory")
+	assert.Contains(t, metrics, "CPUutilization1")
+}
+
+func TestSeriesKey(t *testing.T) {
+	assert.Equal(t, "Load1", SeriesKey("Load1", nil))
+	key := SeriesKey("DiskFree", map[string]string{"mount": "/"})
+	assert.Equal(t, `DiskFree{mount="/"}`, key)
+	assert.Equal(t, "DiskFree", SeriesName(key))
+	assert.Equal(t, "Load1", SeriesName("Load1"))
+}
+
+func TestCounterDeltas(t *testing.T) {
+	deltas := newCounterDeltas()
+	tests := []struct {
+		name  string
+		total uint64
+		want  int64
+	}{
+		{name: "first observation is baseline", total: 1000, want: 0},
+		{name: "growth since previous", total: 1500, want: 500},
+		{name: "no growth", total: 1500, want: 0},
+		{name: "reset counter", total: 200, want: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Counter(tt.want), deltas.counter("NetBytesSent", tt.total))
+		})
+	}
+	assert.Equal(t, Counter(0), deltas.counter("NetBytesRecv", 10), "keys are tracked separately")
+
+	// интерфейс пропал из выборки, его итог забыт
+	deltas.prune(series{"NetBytesRecv": Counter(0)})
+	assert.NotContains(t, deltas.last, "NetBytesSent")
+	assert.Contains(t, deltas.last, "NetBytesRecv")
+	assert.Equal(t, Counter(0), deltas.counter("NetBytesSent", 300), "returned interface starts from baseline")
+}
+
+func TestHostCollectors(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("host metrics are checked on linux")
+	}
+	tests := []struct {
+		collector Collector
+		name      string
+		metric    string
+		mType     common.MetricType
+	}{
+		{name: CPUTimeName, collector: NewCPUTime(), metric: "CPUTimeUser", mType: common.CounterMetricType},
+		{name: LoadName, collector: NewLoad(), metric: "Load1", mType: common.GaugeMetricType},
+		{name: DiskName, collector: NewDisk(), metric: "DiskTotal", mType: common.GaugeMetricType},
+		{name: DiskIOName, collector: NewDiskIO(), metric: "DiskReadBytes", mType: common.CounterMetricType},
+		{name: NetName, collector: NewNet(), metric: "NetBytesRecv", mType: common.CounterMetricType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.name, tt.collector.Name())
+			// второй сбор возвращает прирост счётчиков
+			_, err := tt.collector.Collect(context.Background())
+			require.NoError(t, err)
+			metrics, err := tt.collector.Collect(context.Background())
+			require.NoError(t, err)
+
+			found := false
+			for key, metric := range metrics {
+				if SeriesName(key) != tt.metric {
+					continue
+				}
+				found = true
+				assert.Equal(t, tt.mType, metric.Type, key)
+				assert.GreaterOrEqual(t, metric.Delta, int64(0), key)
+			}
+			if tt.name == DiskIOName && !found {
+				t.Skip("no block devices visible")
+			}
+			assert.True(t, found, "metric %s not collected", tt.metric)
+		})
+	}
+}
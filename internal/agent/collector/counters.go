@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"math"
+	"sync"
+)
+
+// series collected metrics being built by collector.
+type series map[string]Metric
+
+// add puts metric with labels to collected metrics.
+func (s series) add(name string, labels map[string]string, metric Metric) {
+	metric.Labels = labels
+	s[SeriesKey(name, labels)] = metric
+}
+
+// sum adds metric to one already collected with the same name and labels, so metrics of
+// several sources are reported as single series.
+func (s series) sum(name string, labels map[string]string, metric Metric) {
+	key := SeriesKey(name, labels)
+	if prev, ok := s[key]; ok {
+		metric.Value += prev.Value
+		metric.Delta += prev.Delta
+	}
+	metric.Labels = labels
+	s[key] = metric
+}
+
+// counterDeltas converts monotonic totals reported by kernel to counter deltas. Collect may
+// still run after its timeout, so deltas are guarded by mutex.
+type counterDeltas struct {
+	last map[string]uint64
+	mu   sync.Mutex
+}
+
+func newCounterDeltas() *counterDeltas {
+	return &counterDeltas{last: make(map[string]uint64)}
+}
+
+// counter returns counter metric increased by total growth since previous call for key.
+// First observation only sets baseline, so totals accumulated before agent start are not
+// reported. Total below previous one means kernel counter was reset, e.g. interface was
+// recreated, whole total is growth then.
+func (c *counterDeltas) counter(key string, total uint64) Metric {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, ok := c.last[key]
+	c.last[key] = total
+	if !ok {
+		return Counter(0)
+	}
+	delta := total
+	if total >= last {
+		delta = total - last
+	}
+	if delta > math.MaxInt64 {
+		delta = math.MaxInt64
+	}
+	return Counter(int64(delta))
+}
+
+// prune forgets totals of keys missing from latest sample, e.g. of removed interface, so
+// churning devices do not grow memory. Device which comes back starts from new baseline.
+func (c *counterDeltas) prune(metrics series) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.last {
+		if _, ok := metrics[key]; !ok {
+			delete(c.last, key)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+// CPUTimeName name of CPU time breakdown collector.
+const CPUTimeName = "cputime"
+
+// CPUTime collects time all CPUs spent in user, system, iowait and idle modes, as counters
+// in milliseconds.
+type CPUTime struct {
+	deltas *counterDeltas
+}
+
+// NewCPUTime creates CPU time breakdown collector.
+func NewCPUTime() *CPUTime {
+	return &CPUTime{deltas: newCounterDeltas()}
+}
+
+func (c *CPUTime) Name() string {
+	return CPUTimeName
+}
+
+func (c *CPUTime) Collect(ctx context.Context) (map[string]Metric, error) {
+	times, err := cpu.TimesWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not get cpu times: %w", err)
+	}
+	if len(times) == 0 {
+		return nil, errors.New("could not get cpu times: no data")
+	}
+
+	total := times[0]
+	metrics := make(series, 4)
+	for name, seconds := range map[string]float64{
+		"CPUTimeUser":   total.User,
+		"CPUTimeSystem": total.System,
+		"CPUTimeIowait": total.Iowait,
+		"CPUTimeIdle":   total.Idle,
+	} {
+		metrics.add(name, nil, c.deltas.counter(name, uint64(seconds*1000)))
+	}
+	return metrics, nil
+}
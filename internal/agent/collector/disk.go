@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+const (
+	// DiskName name of per-mount disk usage collector.
+	DiskName = "disk"
+	// DiskIOName name of per-device disk I/O collector.
+	DiskIOName = "diskio"
+)
+
+// Disk collects usage of each mounted filesystem, labeled by mount point.
+type Disk struct{}
+
+// NewDisk creates disk usage collector.
+func NewDisk() *Disk {
+	return &Disk{}
+}
+
+func (c *Disk) Name() string {
+	return DiskName
+}
+
+// Collect skips mounts which usage could not be read, e.g. due to permissions, error is
+// returned only if none of them was read.
+func (c *Disk) Collect(ctx context.Context) (map[string]Metric, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not get partitions: %w", err)
+	}
+
+	metrics := make(series, len(partitions)*4)
+	var errs []error
+	for _, partition := range partitions {
+		usage, usageErr := disk.UsageWithContext(ctx, partition.Mountpoint)
+		if usageErr != nil {
+			errs = append(errs, fmt.Errorf("mount %s: %w", partition.Mountpoint, usageErr))
+			continue
+		}
+		labels := map[string]string{"mount": partition.Mountpoint}
+		metrics.add("DiskTotal", labels, Gauge(float64(usage.Total)))
+		metrics.add("DiskUsed", labels, Gauge(float64(usage.Used)))
+		metrics.add("DiskFree", labels, Gauge(float64(usage.Free)))
+		metrics.add("DiskUsedPercent", labels, Gauge(usage.UsedPercent))
+	}
+	if len(metrics) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("could not get disk usage: %w", errors.Join(errs...))
+	}
+	return metrics, nil
+}
+
+// DiskIO collects I/O counters of each block device, labeled by device name.
+type DiskIO struct {
+	deltas *counterDeltas
+}
+
+// NewDiskIO creates disk I/O collector.
+func NewDiskIO() *DiskIO {
+	return &DiskIO{deltas: newCounterDeltas()}
+}
+
+func (c *DiskIO) Name() string {
+	return DiskIOName
+}
+
+func (c *DiskIO) Collect(ctx context.Context) (map[string]Metric, error) {
+	counters, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get disk io counters: %w", err)
+	}
+
+	metrics := make(series, len(counters)*4)
+	for device, stat := range counters {
+		labels := map[string]string{"device": device}
+		for name, total := range map[string]uint64{
+			"DiskReadBytes":  stat.ReadBytes,
+			"DiskWriteBytes": stat.WriteBytes,
+			"DiskReads":      stat.ReadCount,
+			"DiskWrites":     stat.WriteCount,
+		} {
+			metrics.add(name, labels, c.deltas.counter(SeriesKey(name, labels), total))
+		}
+	}
+	c.deltas.prune(metrics)
+	return metrics, nil
+}
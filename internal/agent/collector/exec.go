@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+)
+
+// ExecNamePrefix prefix of exec collector names, e.g. `exec.orders`.
+const ExecNamePrefix = "exec."
+
+// self-metrics of exec collector, labeled by command name.
+const (
+	execErrorsMetricName = "AgentExecErrors" // failed command runs
+	execUpMetricName     = "AgentExecUp"     // 1 if last run succeeded, 0 otherwise
+)
+
+const (
+	execWaitDelay     = time.Second // how long output of killed command is waited for
+	execStderrMaxSize = 512         // stderr bytes put to log on failure
+)
+
+// Exec runs command and parses its stdout as metrics. Output is either lines of
+// `name type value`, where counter value is increment since previous run, or JSON array of
+// dto.MetricDTO. Failed run is reported by self-metrics, so it never fails agent poll.
+type Exec struct {
+	logger  log.Logger
+	name    string
+	command []string
+}
+
+// NewExec creates collector of command output.
+func NewExec(name string, command []string, logger log.Logger) (*Exec, error) {
+	if name == "" {
+		return nil, errors.New("exec command name is empty")
+	}
+	if len(command) == 0 || command[0] == "" {
+		return nil, fmt.Errorf("exec command '%s' is empty", name)
+	}
+	return &Exec{logger: logger, name: name, command: command}, nil
+}
+
+func (c *Exec) Name() string {
+	return ExecNamePrefix + c.name
+}
+
+func (c *Exec) Collect(ctx context.Context) (map[string]Metric, error) {
+	labels := map[string]string{"command": c.name}
+	metrics, err := c.run(ctx)
+	if err != nil {
+		c.logger.Warnf("exec collector %s failed: %v", c.name, err)
+		metrics = make(series, 2)
+		metrics.add(execErrorsMetricName, labels, Counter(1))
+		metrics.add(execUpMetricName, labels, Gauge(0))
+		return metrics, nil
+	}
+
+	metrics.add(execErrorsMetricName, labels, Counter(0))
+	metrics.add(execUpMetricName, labels, Gauge(1))
+	return metrics, nil
+}
+
+func (c *Exec) run(ctx context.Context) (series, error) {
+	cmd := exec.CommandContext(ctx, c.command[0], c.command[1:]...) //nolint:gosec // command is set in agent config
+	cmd.WaitDelay = execWaitDelay
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("command timed out: %w", ctx.Err())
+		}
+		output := stderr.Bytes()[:min(stderr.Len(), execStderrMaxSize)]
+		return nil, fmt.Errorf("command failed: %w, stderr: %s", err, bytes.TrimSpace(output))
+	}
+
+	metrics, err := parseExecOutput(stdout.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid command output: %w", err)
+	}
+	return metrics, nil
+}
+
+// parseExecOutput parses command output. Whole output is rejected if any metric is invalid.
+func parseExecOutput(output []byte) (series, error) {
+	output = bytes.TrimSpace(output)
+	if bytes.HasPrefix(output, []byte("[")) {
+		return parseExecJSON(output)
+	}
+
+	metrics := make(series)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected 'name type value'", lineNum)
+		}
+		metric, err := parseExecValue(fields[1], fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		metrics.add(fields[0], nil, metric)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read output: %w", err)
+	}
+	return metrics, nil
+}
+
+func parseExecValue(metricType, value string) (Metric, error) {
+	mType, _ := common.StringToMetricType(metricType)
+	switch mType {
+	case common.GaugeMetricType:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Metric{}, fmt.Errorf("invalid gauge value '%s'", value)
+		}
+		return Gauge(v), nil
+	case common.CounterMetricType:
+		delta, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Metric{}, fmt.Errorf("invalid counter value '%s'", value)
+		}
+		return Counter(delta), nil
+	default:
+		return Metric{}, fmt.Errorf("unsupported metric type '%s'", metricType)
+	}
+}
+
+func parseExecJSON(output []byte) (series, error) {
+	var metricDtos []*dto.MetricDTO
+	if err := json.Unmarshal(output, &metricDtos); err != nil {
+		return nil, fmt.Errorf("failed to decode metrics: %w", err)
+	}
+
+	metrics := make(series, len(metricDtos))
+	for _, metricDto := range metricDtos {
+		metric, err := FromDTO(metricDto)
+		if err != nil {
+			return nil, err
+		}
+		metrics.add(metricDto.ID, metric.Labels, metric)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExecOutput(t *testing.T) {
+	tests := []struct {
+		want    series
+		name    string
+		output  string
+		wantErr string
+	}{
+		{
+			name:   "text format",
+			output: "# business metrics\nOrders counter 3\n\nQueueLength gauge 12.5\n",
+			want: series{
+				"Orders":      Counter(3),
+				"QueueLength": Gauge(12.5),
+			},
+		},
+		{
+			name:   "json format",
+			output: `[{"id": "Orders", "type": "counter", "delta": 3, "labels": {"shop": "eu"}}, {"id": "Rate", "type": "gauge", "value": 0.5}]`,
+			want: series{
+				`Orders{shop="eu"}`: {Labels: map[string]string{"shop": "eu"}, Type: common.CounterMetricType, Delta: 3},
+				"Rate":              Gauge(0.5),
+			},
+		},
+		{name: "empty output", output: "", want: series{}},
+		{name: "missing field", output: "Orders counter", wantErr: "line 1: expected 'name type value'"},
+		{name: "unknown type", output: "Orders meter 1", wantErr: "unsupported metric type 'meter'"},
+		{name: "fractional counter", output: "Orders gauge 1\nShipped counter 1.5", wantErr: "line 2: invalid counter value"},
+		{name: "json without value", output: `[{"id": "Rate", "type": "gauge"}]`, wantErr: "missing value"},
+		{name: "json histogram", output: `[{"id": "Latency", "type": "histogram"}]`, wantErr: "unsupported type"},
+		{name: "broken json", output: `[{"id": `, wantErr: "failed to decode metrics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExecOutput([]byte(tt.output))
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewExec(t *testing.T) {
+	_, err := NewExec("", []string{"true"}, log.MockLogger{})
+	require.ErrorContains(t, err, "name is empty")
+	_, err = NewExec("orders", nil, log.MockLogger{})
+	require.ErrorContains(t, err, "'orders' is empty")
+
+	c, err := NewExec("orders", []string{"true"}, log.MockLogger{})
+	require.NoError(t, err)
+	assert.Equal(t, "exec.orders", c.Name())
+}
+
+func TestExec_Collect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are run by sh")
+	}
+	labels := map[string]string{"command": "orders"}
+	up := SeriesKey(execUpMetricName, labels)
+	failures := SeriesKey(execErrorsMetricName, labels)
+
+	tests := []struct {
+		want    map[string]Metric
+		name    string
+		script  string
+		timeout time.Duration
+	}{
+		{
+			name:   "metrics are collected",
+			script: "echo 'Orders counter 2'",
+			want: map[string]Metric{
+				"Orders": Counter(2),
+				up:       {Labels: labels, Type: common.GaugeMetricType, Value: 1},
+				failures: {Labels: labels, Type: common.CounterMetricType},
+			},
+		},
+		{
+			name:   "non-zero exit is reported",
+			script: "echo 'Orders counter 2'; echo 'db is down' >&2; exit 1",
+			want: map[string]Metric{
+				up:       {Labels: labels, Type: common.GaugeMetricType},
+				failures: {Labels: labels, Type: common.CounterMetricType, Delta: 1},
+			},
+		},
+		{
+			name:   "invalid output is reported",
+			script: "echo 'Orders'",
+			want: map[string]Metric{
+				up:       {Labels: labels, Type: common.GaugeMetricType},
+				failures: {Labels: labels, Type: common.CounterMetricType, Delta: 1},
+			},
+		},
+		{
+			name:    "timeout is reported",
+			script:  "sleep 10",
+			timeout: 100 * time.Millisecond,
+			want: map[string]Metric{
+				up:       {Labels: labels, Type: common.GaugeMetricType},
+				failures: {Labels: labels, Type: common.CounterMetricType, Delta: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewExec("orders", []string{"sh", "-c", tt.script}, log.MockLogger{})
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			got, err := c.Collect(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
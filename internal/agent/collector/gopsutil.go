@@ -0,0 +1,46 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// GopsutilName name of system memory and CPU collector.
+const GopsutilName = "gopsutil"
+
+// Gopsutil collects system memory and utilization of each CPU core.
+type Gopsutil struct{}
+
+// NewGopsutil creates system memory and CPU collector.
+func NewGopsutil() *Gopsutil {
+	return &Gopsutil{}
+}
+
+func (c *Gopsutil) Name() string {
+	return GopsutilName
+}
+
+func (c *Gopsutil) Collect(ctx context.Context) (map[string]Metric, error) {
+	v, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get virtual memory info: %w", err)
+	}
+	usages, err := cpu.PercentWithContext(ctx, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("could not get cpu usage: %w", err)
+	}
+
+	metrics := map[string]Metric{
+		"TotalMemory": Gauge(float64(v.Total)),
+		"FreeMemory":  Gauge(float64(v.Free)),
+	}
+	for index, usage := range usages {
+		metricName := fmt.Sprintf("CPUutilization%d", index+1)
+		// utilization is reported in whole percents
+		metrics[metricName] = Gauge(float64(uint64(usage)))
+	}
+	return metrics, nil
+}
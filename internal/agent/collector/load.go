@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/load"
+)
+
+// LoadName name of load average collector.
+const LoadName = "load"
+
+// Load collects system load averages for 1, 5 and 15 minutes.
+type Load struct{}
+
+// NewLoad creates load average collector.
+func NewLoad() *Load {
+	return &Load{}
+}
+
+func (c *Load) Name() string {
+	return LoadName
+}
+
+func (c *Load) Collect(ctx context.Context) (map[string]Metric, error) {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get load average: %w", err)
+	}
+	return map[string]Metric{
+		"Load1":  Gauge(avg.Load1),
+		"Load5":  Gauge(avg.Load5),
+		"Load15": Gauge(avg.Load15),
+	}, nil
+}
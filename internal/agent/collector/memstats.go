@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"context"
+	"runtime"
+)
+
+// MemStatsName name of Go runtime memory statistics collector.
+const MemStatsName = "memstats"
+
+// MemStats collects Go runtime memory statistics of agent.
+type MemStats struct{}
+
+// NewMemStats creates runtime memory statistics collector.
+func NewMemStats() *MemStats {
+	return &MemStats{}
+}
+
+func (c *MemStats) Name() string {
+	return MemStatsName
+}
+
+func (c *MemStats) Collect(_ context.Context) (map[string]Metric, error) {
+	var memstats runtime.MemStats
+	runtime.ReadMemStats(&memstats)
+	return map[string]Metric{
+		"Alloc":         Gauge(float64(memstats.Alloc)),
+		"BuckHashSys":   Gauge(float64(memstats.BuckHashSys)),
+		"Frees":         Gauge(float64(memstats.Frees)),
+		"GCCPUFraction": Gauge(memstats.GCCPUFraction),
+		"GCSys":         Gauge(float64(memstats.GCSys)),
+		"HeapAlloc":     Gauge(float64(memstats.HeapAlloc)),
+		"HeapIdle":      Gauge(float64(memstats.HeapIdle)),
+		"HeapInuse":     Gauge(float64(memstats.HeapInuse)),
+		"HeapObjects":   Gauge(float64(memstats.HeapObjects)),
+		"HeapReleased":  Gauge(float64(memstats.HeapReleased)),
+		"HeapSys":       Gauge(float64(memstats.HeapSys)),
+		"LastGC":        Gauge(float64(memstats.LastGC)),
+		"Lookups":       Gauge(float64(memstats.Lookups)),
+		"MCacheInuse":   Gauge(float64(memstats.MCacheInuse)),
+		"MCacheSys":     Gauge(float64(memstats.MCacheSys)),
+		"MSpanInuse":    Gauge(float64(memstats.MSpanInuse)),
+		"MSpanSys":      Gauge(float64(memstats.MSpanSys)),
+		"Mallocs":       Gauge(float64(memstats.Mallocs)),
+		"NextGC":        Gauge(float64(memstats.NextGC)),
+		"NumForcedGC":   Gauge(float64(memstats.NumForcedGC)),
+		"NumGC":         Gauge(float64(memstats.NumGC)),
+		"OtherSys":      Gauge(float64(memstats.OtherSys)),
+		"PauseTotalNs":  Gauge(float64(memstats.PauseTotalNs)),
+		"StackInuse":    Gauge(float64(memstats.StackInuse)),
+		"StackSys":      Gauge(float64(memstats.StackSys)),
+		"Sys":           Gauge(float64(memstats.Sys)),
+		"TotalAlloc":    Gauge(float64(memstats.TotalAlloc)),
+	}, nil
+}
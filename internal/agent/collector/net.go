@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// NetName name of per-interface network collector.
+const NetName = "net"
+
+// Net collects traffic counters of each network interface, labeled by interface name.
+type Net struct {
+	deltas *counterDeltas
+}
+
+// NewNet creates network collector.
+func NewNet() *Net {
+	return &Net{deltas: newCounterDeltas()}
+}
+
+func (c *Net) Name() string {
+	return NetName
+}
+
+func (c *Net) Collect(ctx context.Context) (map[string]Metric, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("could not get network io counters: %w", err)
+	}
+
+	metrics := make(series, len(counters)*8)
+	for _, stat := range counters {
+		labels := map[string]string{"interface": stat.Name}
+		for name, total := range map[string]uint64{
+			"NetBytesSent":   stat.BytesSent,
+			"NetBytesRecv":   stat.BytesRecv,
+			"NetPacketsSent": stat.PacketsSent,
+			"NetPacketsRecv": stat.PacketsRecv,
+			"NetErrIn":       stat.Errin,
+			"NetErrOut":      stat.Errout,
+			"NetDropIn":      stat.Dropin,
+			"NetDropOut":     stat.Dropout,
+		} {
+			metrics.add(name, labels, c.deltas.counter(SeriesKey(name, labels), total))
+		}
+	}
+	c.deltas.prune(metrics)
+	return metrics, nil
+}
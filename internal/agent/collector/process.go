@@ -0,0 +1,226 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ProcessName name of process collector.
+const ProcessName = "process"
+
+// ProcessTarget selects processes by exactly one of pid file, executable name or command
+// line regexp.
+type ProcessTarget struct {
+	PIDFile string
+	Name    string
+	Cmdline string
+}
+
+// processMatcher compiled ProcessTarget.
+type processMatcher struct {
+	cmdline *regexp.Regexp
+	pidFile string
+	name    string
+}
+
+// trackedProcess process seen on previous poll, it keeps baselines of CPU percent and
+// I/O counters.
+type trackedProcess struct {
+	proc       *process.Process
+	deltas     *counterDeltas
+	createTime int64
+}
+
+// Process collects resource usage of selected processes, labeled by process name. Usage of
+// processes with the same name, e.g. workers, is summed and ProcessCount reports how many of
+// them run, so restarts do not produce new series. Processes are looked up on every poll, so
+// restarted process is picked up with its new pid.
+type Process struct {
+	tracked  map[int32]*trackedProcess
+	matchers []processMatcher
+	mu       sync.Mutex
+}
+
+// NewProcess creates process collector.
+func NewProcess(targets []ProcessTarget) (*Process, error) {
+	matchers := make([]processMatcher, 0, len(targets))
+	for i, target := range targets {
+		set := 0
+		for _, field := range []string{target.PIDFile, target.Name, target.Cmdline} {
+			if field != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("process target %d: exactly one of pid file, name or cmdline must be set", i)
+		}
+
+		matcher := processMatcher{pidFile: target.PIDFile, name: target.Name}
+		if target.Cmdline != "" {
+			re, err := regexp.Compile(target.Cmdline)
+			if err != nil {
+				return nil, fmt.Errorf("process target %d: invalid cmdline regexp: %w", i, err)
+			}
+			matcher.cmdline = re
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return &Process{tracked: make(map[int32]*trackedProcess), matchers: matchers}, nil
+}
+
+func (c *Process) Name() string {
+	return ProcessName
+}
+
+// Collect reports metrics of matched processes. Metrics which could not be read, e.g. I/O
+// counters of process owned by other user, are skipped. CPU percent and I/O counters of
+// newly found process are reported from the next poll.
+func (c *Process) Collect(ctx context.Context) (map[string]Metric, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pids, err := c.matchPIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make(series)
+	tracked := make(map[int32]*trackedProcess, len(pids))
+	for _, pid := range pids {
+		tp, isNew, trackErr := c.track(ctx, pid)
+		if trackErr != nil {
+			// process exited after it was matched
+			continue
+		}
+		tracked[pid] = tp
+		collectProcess(ctx, tp, isNew, metrics)
+	}
+	c.tracked = tracked
+
+	return metrics, nil
+}
+
+// matchPIDs returns pids of processes matching any target. Missing pid file means process
+// is not running. Error is returned only if nothing matched.
+func (c *Process) matchPIDs(ctx context.Context) ([]int32, error) {
+	matched := make(map[int32]struct{})
+	var procs []*process.Process
+	var errs []error
+	for _, matcher := range c.matchers {
+		if matcher.pidFile != "" {
+			pid, err := readPIDFile(matcher.pidFile)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			matched[pid] = struct{}{}
+			continue
+		}
+
+		if procs == nil {
+			var err error
+			if procs, err = process.ProcessesWithContext(ctx); err != nil {
+				return nil, fmt.Errorf("could not list processes: %w", err)
+			}
+		}
+		for _, proc := range procs {
+			if matcher.matches(ctx, proc) {
+				matched[proc.Pid] = struct{}{}
+			}
+		}
+	}
+	if len(matched) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	pids := make([]int32, 0, len(matched))
+	for pid := range matched {
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+// matches reports whether process matches target, process which exited does not match.
+func (m processMatcher) matches(ctx context.Context, proc *process.Process) bool {
+	if m.name != "" {
+		name, err := proc.NameWithContext(ctx)
+		return err == nil && name == m.name
+	}
+	cmdline, err := proc.CmdlineWithContext(ctx)
+	return err == nil && m.cmdline.MatchString(cmdline)
+}
+
+// track returns process seen on previous poll, or starts tracking new one. Pid reused by
+// another process is tracked from scratch.
+func (c *Process) track(ctx context.Context, pid int32) (*trackedProcess, bool, error) {
+	proc, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return nil, false, fmt.Errorf("process %d: %w", pid, err)
+	}
+	createTime, err := proc.CreateTimeWithContext(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("process %d: %w", pid, err)
+	}
+	if tp, ok := c.tracked[pid]; ok && tp.createTime == createTime {
+		return tp, false, nil
+	}
+	return &trackedProcess{proc: proc, deltas: newCounterDeltas(), createTime: createTime}, true, nil
+}
+
+func collectProcess(ctx context.Context, tp *trackedProcess, isNew bool, metrics series) {
+	proc := tp.proc
+	name, err := proc.NameWithContext(ctx)
+	if err != nil {
+		return
+	}
+	labels := map[string]string{"process": name}
+	metrics.sum("ProcessCount", labels, Gauge(1))
+
+	if mem, memErr := proc.MemoryInfoWithContext(ctx); memErr == nil {
+		metrics.sum("ProcessRSS", labels, Gauge(float64(mem.RSS)))
+	}
+	// first call only sets baseline of CPU time
+	if percent, cpuErr := proc.PercentWithContext(ctx, 0); cpuErr == nil && !isNew {
+		metrics.sum("ProcessCPUPercent", labels, Gauge(percent))
+	}
+	if fds, fdsErr := proc.NumFDsWithContext(ctx); fdsErr == nil {
+		metrics.sum("ProcessOpenFDs", labels, Gauge(float64(fds)))
+	}
+	if threads, threadsErr := proc.NumThreadsWithContext(ctx); threadsErr == nil {
+		metrics.sum("ProcessThreads", labels, Gauge(float64(threads)))
+	}
+	if io, ioErr := proc.IOCountersWithContext(ctx); ioErr == nil {
+		for metricName, total := range map[string]uint64{
+			"ProcessReadBytes":  io.ReadBytes,
+			"ProcessWriteBytes": io.WriteBytes,
+			"ProcessReads":      io.ReadCount,
+			"ProcessWrites":     io.WriteCount,
+		} {
+			metrics.sum(metricName, labels, tp.deltas.counter(metricName, total))
+		}
+	}
+}
+
+func readPIDFile(path string) (int32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not read pid file: %w", err)
+	}
+	pid, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid in file %s", path)
+	}
+	return int32(pid), nil
+}
@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProcess(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+		targets []ProcessTarget
+	}{
+		{name: "valid targets", targets: []ProcessTarget{{PIDFile: "/run/app.pid"}, {Name: "nginx"}, {Cmdline: "java .*"}}},
+		{name: "empty target", targets: []ProcessTarget{{}}, wantErr: "exactly one"},
+		{name: "ambiguous target", targets: []ProcessTarget{{Name: "nginx", Cmdline: "nginx"}}, wantErr: "exactly one"},
+		{name: "invalid regexp", targets: []ProcessTarget{{Cmdline: "("}}, wantErr: "invalid cmdline regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewProcess(tt.targets)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, ProcessName, c.Name())
+		})
+	}
+}
+
+// seriesOf returns collected series of metric.
+func seriesOf(metrics map[string]Metric, name string) []Metric {
+	result := make([]Metric, 0)
+	for key, metric := range metrics {
+		if SeriesName(key) == name {
+			result = append(result, metric)
+		}
+	}
+	return result
+}
+
+func TestProcess_Collect(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process metrics are checked on linux")
+	}
+	self := strconv.Itoa(os.Getpid())
+	pidFile := filepath.Join(t.TempDir(), "app.pid")
+	require.NoError(t, os.WriteFile(pidFile, []byte(self+"\n"), 0o600))
+
+	c, err := NewProcess([]ProcessTarget{{PIDFile: pidFile}})
+	require.NoError(t, err)
+
+	metrics, err := c.Collect(context.Background())
+	require.NoError(t, err)
+	rss := seriesOf(metrics, "ProcessRSS")
+	require.Len(t, rss, 1)
+	assert.NotEmpty(t, rss[0].Labels["process"])
+	assert.NotContains(t, rss[0].Labels, "pid")
+	assert.Len(t, seriesOf(metrics, "ProcessThreads"), 1)
+	assert.Empty(t, seriesOf(metrics, "ProcessCPUPercent"), "first poll sets baseline")
+
+	metrics, err = c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, seriesOf(metrics, "ProcessCPUPercent"), 1)
+
+	// процесс перезапущен с другим pid
+	parent := strconv.Itoa(os.Getppid())
+	require.NoError(t, os.WriteFile(pidFile, []byte(parent), 0o600))
+	metrics, err = c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, seriesOf(metrics, "ProcessRSS"), 1)
+
+	// процесс остановлен
+	require.NoError(t, os.Remove(pidFile))
+	metrics, err = c.Collect(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, metrics)
+}
+
+func TestProcess_CollectSameName(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process metrics are checked on linux")
+	}
+	dir := t.TempDir()
+	targets := make([]ProcessTarget, 0, 2)
+	for i := range 2 {
+		cmd := exec.Command("sleep", "30")
+		require.NoError(t, cmd.Start())
+		t.Cleanup(func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		})
+		pidFile := filepath.Join(dir, strconv.Itoa(i)+".pid")
+		require.NoError(t, os.WriteFile(pidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0o600))
+		targets = append(targets, ProcessTarget{PIDFile: pidFile})
+	}
+
+	c, err := NewProcess(targets)
+	require.NoError(t, err)
+	metrics, err := c.Collect(context.Background())
+	require.NoError(t, err)
+
+	// процессы с одним именем отдаются одной серией
+	labels := map[string]string{"process": "sleep"}
+	assert.Equal(t, Metric{Labels: labels, Type: common.GaugeMetricType, Value: 2},
+		metrics[SeriesKey("ProcessCount", labels)])
+	assert.Len(t, seriesOf(metrics, "ProcessRSS"), 1)
+}
+
+func TestProcess_CollectByName(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process metrics are checked on linux")
+	}
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	for _, target := range []ProcessTarget{
+		{Name: filepath.Base(exe)},
+		{Cmdline: regexp.QuoteMeta(exe)},
+	} {
+		c, err := NewProcess([]ProcessTarget{target})
+		require.NoError(t, err)
+		metrics, err := c.Collect(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, metrics, SeriesKey("ProcessCount", map[string]string{"process": filepath.Base(exe)}))
+	}
+}
+
+func TestProcess_InvalidPIDFile(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "app.pid")
+	require.NoError(t, os.WriteFile(pidFile, []byte("not a pid"), 0o600))
+
+	c, err := NewProcess([]ProcessTarget{{PIDFile: pidFile}})
+	require.NoError(t, err)
+	_, err = c.Collect(context.Background())
+	require.ErrorContains(t, err, "invalid pid")
+}
@@ -4,6 +4,9 @@ package config
 import (
 	"flag"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/Kopleman/metcol/internal/common/flags"
 	"github.com/Kopleman/metcol/internal/common/utils"
@@ -14,26 +17,78 @@ const defaultReportInterval int64 = 10
 const defaultPollInterval int64 = 2
 const defaultRateInterval int64 = 10
 const defaultAddress string = "localhost:8080"
+const defaultSpoolMaxSize int64 = 64 << 20
+const defaultSpoolMaxAge int64 = 86400
+
+// CollectorConfig settings of single metrics collector.
+type CollectorConfig struct {
+	Enabled      *bool `json:"enabled"`       // nil keeps collector default
+	PollInterval int64 `json:"poll_interval"` // how often collector runs in seconds, 0 means agent poll interval
+	Timeout      int64 `json:"timeout"`       // collect timeout in seconds, 0 means collector poll interval
+}
+
+// ProcessTarget selects processes reported by process collector, exactly one field is set.
+type ProcessTarget struct {
+	PIDFile string `json:"pid_file"` // file with pid of process, re-read on every poll
+	Name    string `json:"name"`     // process executable name
+	Cmdline string `json:"cmdline"`  // regexp matched against full command line
+}
+
+// ExecCommand command run by exec collector, its schedule is set in collector settings
+// under `exec.<name>`.
+type ExecCommand struct {
+	Name    string   `json:"name"`    // identifies command in collector settings and self-metrics
+	Command []string `json:"command"` // executable and its arguments
+}
 
 // Config contains all settled via envs or flags params.
 type Config struct {
-	EndPoint       *flags.NetAddress // where agent will send metrics
-	Key            string            // hash key for sign sent data
-	GRPCEndPoint   *flags.NetAddress // where agent will send metrics via grpc
-	PublicKeyPath  string            // path to public key
-	ReportInterval int64             // how often data will be sent
-	PollInterval   int64             // how often metrics will be collected
-	RateLimit      int64             // limits number of workers for sending
+	Collectors        map[string]CollectorConfig // per-collector settings by collector name
+	EndPoint          *flags.NetAddress          // where agent will send metrics
+	Key               string                     // hash key for sign sent data
+	Token             string                     // bearer token identifying agent on server
+	Namespace         string                     // tenant namespace metrics are written to
+	GRPCEndPoint      *flags.NetAddress          // where agent will send metrics via grpc
+	PublicKeyPath     string                     // path to public key
+	TLSCAPath         string                     // CA verifying server certificate, system roots if empty
+	TLSCertPath       string                     // agent certificate for mutual TLS, its CN identifies agent
+	TLSKeyPath        string                     // agent certificate private key
+	SpoolDir          string                     // dir of on-disk queue for undelivered batches, empty disables queue
+	IngestAddr        string                     // loopback HTTP address local apps push metrics to, empty disables
+	IngestUDPAddr     string                     // loopback UDP address local apps push metrics to, empty disables
+	EnabledCollectors []string                   // if set, only listed collectors run
+	Processes         []ProcessTarget            // processes reported by process collector
+	Exec              []ExecCommand              // commands which output is collected as metrics
+	ReportInterval    int64                      // how often data will be sent
+	PollInterval      int64                      // how often metrics will be collected
+	RateLimit         int64                      // limits number of workers for sending
+	SpoolMaxSize      int64                      // max total size of queued batches in bytes
+	SpoolMaxAge       int64                      // how long queued batch is kept, in seconds
 }
 
 type configFromSource struct {
-	EndPoint       string `json:"address" env:"ADDRESS"`
-	Key            string `json:"key" env:"KEY"`
-	GRPCEndPoint   string `json:"grpc_address" env:"GRPC_ADDRESS"`
-	PublicKeyPath  string `json:"crypto_key" env:"KEY_PATH"`
-	ReportInterval int64  `json:"report_interval" env:"REPORT_INTERVAL"`
-	PollInterval   int64  `json:"poll_interval" env:"POLL_INTERVAL"`
-	RateLimit      int64  `json:"rate_limit" env:"RATE_LIMIT"`
+	Collectors        map[string]CollectorConfig `json:"collectors"`
+	EnabledCollectors string                     `json:"enabled_collectors" env:"COLLECTORS"`
+	ProcessList       string                     `json:"-" env:"PROCESSES"`
+	EndPoint          string                     `json:"address" env:"ADDRESS"`
+	Key               string                     `json:"key" env:"KEY"`
+	Token             string                     `json:"token" env:"AGENT_TOKEN"`
+	Namespace         string                     `json:"namespace" env:"NAMESPACE"`
+	GRPCEndPoint      string                     `json:"grpc_address" env:"GRPC_ADDRESS"`
+	PublicKeyPath     string                     `json:"crypto_key" env:"KEY_PATH"`
+	TLSCAPath         string                     `json:"tls_ca" env:"TLS_CA"`
+	TLSCertPath       string                     `json:"tls_cert" env:"TLS_CERT"`
+	TLSKeyPath        string                     `json:"tls_key" env:"TLS_KEY"`
+	SpoolDir          string                     `json:"spool_dir" env:"SPOOL_DIR"`
+	IngestAddr        string                     `json:"ingest_address" env:"INGEST_ADDRESS"`
+	IngestUDPAddr     string                     `json:"ingest_udp_address" env:"INGEST_UDP_ADDRESS"`
+	Processes         []ProcessTarget            `json:"processes"`
+	Exec              []ExecCommand              `json:"exec"`
+	ReportInterval    int64                      `json:"report_interval" env:"REPORT_INTERVAL"`
+	PollInterval      int64                      `json:"poll_interval" env:"POLL_INTERVAL"`
+	RateLimit         int64                      `json:"rate_limit" env:"RATE_LIMIT"`
+	SpoolMaxSize      int64                      `json:"spool_max_size" env:"SPOOL_MAX_SIZE"`
+	SpoolMaxAge       int64                      `json:"spool_max_age" env:"SPOOL_MAX_AGE"`
 }
 
 func applyConfigFromSource(source *configFromSource, config *Config) error {
@@ -44,6 +99,9 @@ func applyConfigFromSource(source *configFromSource, config *Config) error {
 	}
 
 	if source.GRPCEndPoint != "" {
+		if config.GRPCEndPoint == nil {
+			config.GRPCEndPoint = new(flags.NetAddress)
+		}
 		if err := config.GRPCEndPoint.Set(source.GRPCEndPoint); err != nil {
 			return fmt.Errorf("failed to set grpc endpoint address for agent: %w", err)
 		}
@@ -69,13 +127,128 @@ func applyConfigFromSource(source *configFromSource, config *Config) error {
 		config.Key = source.Key
 	}
 
+	if source.Token != "" {
+		config.Token = source.Token
+	}
+
+	if source.Namespace != "" {
+		config.Namespace = source.Namespace
+	}
+
 	if source.RateLimit > 0 {
 		config.RateLimit = source.RateLimit
 	}
 
+	applyTLSConfig(source, config)
+	applySpoolConfig(source, config)
+	applyIngestConfig(source, config)
+	if err := applyCollectorsConfig(source, config); err != nil {
+		return err
+	}
+
+	if source.PublicKeyPath != "" {
+		config.PublicKeyPath = source.PublicKeyPath
+	}
+
+	return nil
+}
+
+func applyTLSConfig(source *configFromSource, config *Config) {
+	if source.TLSCAPath != "" {
+		config.TLSCAPath = source.TLSCAPath
+	}
+
+	if source.TLSCertPath != "" {
+		config.TLSCertPath = source.TLSCertPath
+	}
+
+	if source.TLSKeyPath != "" {
+		config.TLSKeyPath = source.TLSKeyPath
+	}
+}
+
+func applySpoolConfig(source *configFromSource, config *Config) {
+	if source.SpoolDir != "" {
+		config.SpoolDir = source.SpoolDir
+	}
+
+	if source.SpoolMaxSize > 0 {
+		config.SpoolMaxSize = source.SpoolMaxSize
+	}
+
+	if source.SpoolMaxAge > 0 {
+		config.SpoolMaxAge = source.SpoolMaxAge
+	}
+}
+
+func applyIngestConfig(source *configFromSource, config *Config) {
+	if source.IngestAddr != "" {
+		config.IngestAddr = source.IngestAddr
+	}
+
+	if source.IngestUDPAddr != "" {
+		config.IngestUDPAddr = source.IngestUDPAddr
+	}
+}
+
+func applyCollectorsConfig(source *configFromSource, config *Config) error {
+	if len(source.Collectors) > 0 && config.Collectors == nil {
+		config.Collectors = make(map[string]CollectorConfig, len(source.Collectors))
+	}
+	maps.Copy(config.Collectors, source.Collectors)
+
+	if len(source.Processes) > 0 {
+		config.Processes = source.Processes
+	}
+	if len(source.Exec) > 0 {
+		config.Exec = source.Exec
+	}
+	if source.ProcessList != "" {
+		processes, err := parseProcessList(source.ProcessList)
+		if err != nil {
+			return err
+		}
+		config.Processes = processes
+	}
+
+	if source.EnabledCollectors == "" {
+		return nil
+	}
+	config.EnabledCollectors = config.EnabledCollectors[:0]
+	for _, name := range strings.Split(source.EnabledCollectors, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			config.EnabledCollectors = append(config.EnabledCollectors, name)
+		}
+	}
 	return nil
 }
 
+// parseProcessList parses process targets separated by semicolon, e.g.
+// `name=nginx;pid_file=/run/app.pid;cmdline=java .*app\.jar`.
+func parseProcessList(list string) ([]ProcessTarget, error) {
+	var targets []ProcessTarget
+	for _, item := range strings.Split(list, ";") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		kind, value, _ := strings.Cut(item, "=")
+		if value == "" {
+			return nil, fmt.Errorf("invalid process target '%s': value is empty", item)
+		}
+		switch kind {
+		case "pid_file":
+			targets = append(targets, ProcessTarget{PIDFile: value})
+		case "name":
+			targets = append(targets, ProcessTarget{Name: value})
+		case "cmdline":
+			targets = append(targets, ProcessTarget{Cmdline: value})
+		default:
+			return nil, fmt.Errorf("invalid process target '%s': unknown kind '%s'", item, kind)
+		}
+	}
+	return targets, nil
+}
+
 func applyConfigFromFlags(cfgFromFlags *configFromSource, config *Config) error {
 	if cfgFromFlags.EndPoint != "" {
 		if err := config.EndPoint.Set(cfgFromFlags.EndPoint); err != nil {
@@ -94,6 +267,12 @@ func applyConfigFromFlags(cfgFromFlags *configFromSource, config *Config) error
 	if cfgFromFlags.Key != "" {
 		config.Key = cfgFromFlags.Key
 	}
+	if cfgFromFlags.Token != "" {
+		config.Token = cfgFromFlags.Token
+	}
+	if cfgFromFlags.Namespace != "" {
+		config.Namespace = cfgFromFlags.Namespace
+	}
 	if cfgFromFlags.PublicKeyPath != "" {
 		config.PublicKeyPath = cfgFromFlags.PublicKeyPath
 	}
@@ -106,6 +285,12 @@ func applyConfigFromFlags(cfgFromFlags *configFromSource, config *Config) error
 	if cfgFromFlags.RateLimit != 0 {
 		config.RateLimit = cfgFromFlags.RateLimit
 	}
+	applyTLSConfig(cfgFromFlags, config)
+	applySpoolConfig(cfgFromFlags, config)
+	applyIngestConfig(cfgFromFlags, config)
+	if err := applyCollectorsConfig(cfgFromFlags, config); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -137,6 +322,23 @@ func applyConfigFromEnv(config *Config) error {
 	return nil
 }
 
+// CollectorEnabled reports whether named collector runs. If EnabledCollectors is set, only
+// listed collectors run, otherwise collector settings apply, enabledByDefault if there are none.
+func (c *Config) CollectorEnabled(name string, enabledByDefault bool) bool {
+	if len(c.EnabledCollectors) > 0 {
+		return slices.Contains(c.EnabledCollectors, name)
+	}
+	if settings, ok := c.Collectors[name]; ok && settings.Enabled != nil {
+		return *settings.Enabled
+	}
+	return enabledByDefault
+}
+
+// TLSEnabled reports whether agent talks to server over TLS.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCAPath != "" || c.TLSCertPath != ""
+}
+
 // ParseAgentConfig produce config for agent via parsing env and flags(envs preferred).
 func ParseAgentConfig() (*Config, error) {
 	cfgFromFlags := new(configFromSource)
@@ -154,10 +356,34 @@ func ParseAgentConfig() (*Config, error) {
 
 	flag.StringVar(&cfgFromFlags.Key, "k", "", "cypher key")
 
+	flag.StringVar(&cfgFromFlags.Token, "token", "", "agent bearer token")
+
+	flag.StringVar(&cfgFromFlags.Namespace, "namespace", "", "tenant namespace of metrics")
+
 	flag.Int64Var(&cfgFromFlags.RateLimit, "l", defaultRateInterval, "output rate interval")
 
 	flag.StringVar(&cfgFromFlags.PublicKeyPath, "crypto-key", "", "cypher key")
 
+	flag.StringVar(&cfgFromFlags.TLSCAPath, "tls-ca", "", "path to CA verifying server certificate")
+
+	flag.StringVar(&cfgFromFlags.TLSCertPath, "tls-cert", "", "path to agent TLS certificate")
+
+	flag.StringVar(&cfgFromFlags.TLSKeyPath, "tls-key", "", "path to agent TLS private key")
+
+	flag.StringVar(&cfgFromFlags.SpoolDir, "spool-dir", "", "dir of on-disk queue for undelivered metrics")
+
+	flag.Int64Var(&cfgFromFlags.SpoolMaxSize, "spool-max-size", defaultSpoolMaxSize, "max size of on-disk queue in bytes")
+
+	flag.Int64Var(&cfgFromFlags.SpoolMaxAge, "spool-max-age", defaultSpoolMaxAge, "max age of queued metrics in seconds")
+
+	flag.StringVar(&cfgFromFlags.EnabledCollectors, "collectors", "", "comma-separated list of enabled collectors")
+
+	flag.StringVar(&cfgFromFlags.IngestAddr, "ingest-address", "", "loopback HTTP address for metrics of local apps")
+
+	flag.StringVar(&cfgFromFlags.IngestUDPAddr, "ingest-udp-address", "", "loopback UDP address for metrics of local apps")
+
+	flag.StringVar(&cfgFromFlags.ProcessList, "processes", "", "semicolon-separated processes to report, e.g. name=nginx")
+
 	pathToConfig := flag.String("c", "", "Path to config file")
 
 	flag.Parse()
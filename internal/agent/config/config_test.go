@@ -4,6 +4,7 @@ package config
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Kopleman/metcol/internal/common/flags"
@@ -26,6 +27,8 @@ func TestParseAgentConfig(t *testing.T) {
 				ReportInterval: defaultReportInterval,
 				PollInterval:   defaultPollInterval,
 				RateLimit:      defaultRateInterval,
+				SpoolMaxSize:   defaultSpoolMaxSize,
+				SpoolMaxAge:    defaultSpoolMaxAge,
 			},
 		},
 		{
@@ -36,13 +39,28 @@ func TestParseAgentConfig(t *testing.T) {
 				"-p=5",
 				"-k=secret",
 				"-l=5",
+				"-token=flag-token",
+				"-namespace=team-a",
+				"-spool-dir=/var/spool/agent",
+				"-spool-max-size=1024",
+				"-collectors=memstats, gopsutil",
+				"-ingest-address=127.0.0.1:8090",
+				"-ingest-udp-address=127.0.0.1:8125",
 			},
 			want: &Config{
-				EndPoint:       &flags.NetAddress{Host: "127.0.0.1", Port: "9090"},
-				Key:            "secret",
-				ReportInterval: 20,
-				PollInterval:   5,
-				RateLimit:      5,
+				EndPoint:          &flags.NetAddress{Host: "127.0.0.1", Port: "9090"},
+				Key:               "secret",
+				Token:             "flag-token",
+				Namespace:         "team-a",
+				SpoolDir:          "/var/spool/agent",
+				IngestAddr:        "127.0.0.1:8090",
+				IngestUDPAddr:     "127.0.0.1:8125",
+				ReportInterval:    20,
+				PollInterval:      5,
+				RateLimit:         5,
+				SpoolMaxSize:      1024,
+				SpoolMaxAge:       defaultSpoolMaxAge,
+				EnabledCollectors: []string{"memstats", "gopsutil"},
 			},
 		},
 		{
@@ -60,13 +78,22 @@ func TestParseAgentConfig(t *testing.T) {
 				"POLL_INTERVAL":   "3",
 				"KEY":             "envkey",
 				"RATE_LIMIT":      "10",
+				"AGENT_TOKEN":     "env-token",
+				"NAMESPACE":       "team-b",
+				"SPOOL_DIR":       "/tmp/spool",
+				"SPOOL_MAX_AGE":   "60",
 			},
 			want: &Config{
 				EndPoint:       &flags.NetAddress{Host: "192.168.1.1", Port: "8080"},
 				Key:            "envkey",
+				Token:          "env-token",
+				Namespace:      "team-b",
+				SpoolDir:       "/tmp/spool",
 				ReportInterval: 15,
 				PollInterval:   3,
 				RateLimit:      10,
+				SpoolMaxSize:   defaultSpoolMaxSize,
+				SpoolMaxAge:    60,
 			},
 		},
 		{
@@ -96,6 +123,8 @@ func TestParseAgentConfig(t *testing.T) {
 				ReportInterval: 20,
 				PollInterval:   5,
 				RateLimit:      defaultRateInterval,
+				SpoolMaxSize:   defaultSpoolMaxSize,
+				SpoolMaxAge:    defaultSpoolMaxAge,
 			},
 		},
 	}
@@ -132,6 +161,12 @@ func TestParseAgentConfig(t *testing.T) {
 			require.Equal(t, tt.want.ReportInterval, got.ReportInterval)
 			require.Equal(t, tt.want.PollInterval, got.PollInterval)
 			require.Equal(t, tt.want.RateLimit, got.RateLimit)
+			require.Equal(t, tt.want.SpoolDir, got.SpoolDir)
+			require.Equal(t, tt.want.SpoolMaxSize, got.SpoolMaxSize)
+			require.Equal(t, tt.want.SpoolMaxAge, got.SpoolMaxAge)
+			require.Equal(t, tt.want.EnabledCollectors, got.EnabledCollectors)
+			require.Equal(t, tt.want.IngestAddr, got.IngestAddr)
+			require.Equal(t, tt.want.IngestUDPAddr, got.IngestUDPAddr)
 		})
 	}
 }
@@ -179,3 +214,111 @@ func TestConfigValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAgentConfig_Collectors(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		os.Clearenv()
+	}()
+
+	path := filepath.Join(t.TempDir(), "agent.json")
+	data := `{"collectors": {"gopsutil": {"enabled": false}, "memstats": {"poll_interval": 10, "timeout": 3}}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "-c=" + path}
+
+	got, err := ParseAgentConfig()
+	require.NoError(t, err)
+	require.Equal(t, CollectorConfig{PollInterval: 10, Timeout: 3}, got.Collectors["memstats"])
+	require.False(t, got.CollectorEnabled("gopsutil", true))
+	require.True(t, got.CollectorEnabled("memstats", true))
+	require.False(t, got.CollectorEnabled("custom", false))
+}
+
+func TestConfig_CollectorEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	cfg := &Config{
+		Collectors: map[string]CollectorConfig{
+			"on":  {Enabled: &enabled},
+			"off": {Enabled: &disabled},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		collector        string
+		enabledList      []string
+		enabledByDefault bool
+		want             bool
+	}{
+		{name: "default enabled", collector: "other", enabledByDefault: true, want: true},
+		{name: "default disabled", collector: "other", want: false},
+		{name: "enabled in settings", collector: "on", want: true},
+		{name: "disabled in settings", collector: "off", enabledByDefault: true, want: false},
+		{name: "listed collector", collector: "off", enabledList: []string{"off"}, want: true},
+		{name: "not listed collector", collector: "on", enabledList: []string{"off"}, enabledByDefault: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.EnabledCollectors = tt.enabledList
+			require.Equal(t, tt.want, cfg.CollectorEnabled(tt.collector, tt.enabledByDefault))
+		})
+	}
+}
+
+func TestParseAgentConfig_ProcessesAndExec(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		os.Clearenv()
+	}()
+
+	path := filepath.Join(t.TempDir(), "agent.json")
+	data := `{"processes": [{"name": "nginx"}, {"pid_file": "/run/app.pid"}],
+		"exec": [{"name": "orders", "command": ["/opt/orders.sh", "--json"]}]}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "-c=" + path}
+
+	got, err := ParseAgentConfig()
+	require.NoError(t, err)
+	require.Equal(t, []ProcessTarget{{Name: "nginx"}, {PIDFile: "/run/app.pid"}}, got.Processes)
+	require.Equal(t, []ExecCommand{{Name: "orders", Command: []string{"/opt/orders.sh", "--json"}}}, got.Exec)
+
+	// env replaces list from file
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	t.Setenv("PROCESSES", `cmdline=java .*app\.jar; name=postgres`)
+	got, err = ParseAgentConfig()
+	require.NoError(t, err)
+	require.Equal(t, []ProcessTarget{{Cmdline: `java .*app\.jar`}, {Name: "postgres"}}, got.Processes)
+}
+
+func TestParseProcessList(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		wantErr string
+		want    []ProcessTarget
+	}{
+		{name: "all kinds", list: "pid_file=/run/a.pid;name=nginx;cmdline=a=b", want: []ProcessTarget{
+			{PIDFile: "/run/a.pid"}, {Name: "nginx"}, {Cmdline: "a=b"},
+		}},
+		{name: "empty items are skipped", list: ";name=nginx;", want: []ProcessTarget{{Name: "nginx"}}},
+		{name: "unknown kind", list: "user=root", wantErr: "unknown kind 'user'"},
+		{name: "empty value", list: "name=", wantErr: "value is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProcessList(tt.list)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Package ingest is agent-local endpoint applications on the same host push metrics to.
+// Agent forwards pushed metrics to server with its own encryption, signing and retries.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+)
+
+const (
+	maxPacketSize     = 65535
+	maxBodySize       = 1 << 20
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+// ErrNotLoopback returned when listen address is reachable from other hosts.
+var ErrNotLoopback = errors.New("ingest address must be loopback")
+
+type MetricsForUpdate interface {
+	AddMetrics(metrics []*dto.MetricDTO) error
+}
+
+// Server accepts JSON metrics, single dto.MetricDTO or array of them, over HTTP
+// `POST /updates` and UDP, one payload per packet.
+type Server struct {
+	logger     log.Logger
+	metrics    MetricsForUpdate
+	httpServer *http.Server
+	conn       net.PacketConn
+	wg         sync.WaitGroup
+	stopOnce   sync.Once
+}
+
+// NewServer creates instance of ingest server.
+func NewServer(logger log.Logger, metrics MetricsForUpdate) *Server {
+	return &Server{logger: logger, metrics: metrics}
+}
+
+// Start binds listeners and serves them in background, empty address disables listener.
+// Addresses must be loopback, so only local applications push metrics on behalf of agent.
+func (s *Server) Start(httpAddr, udpAddr string) error {
+	if httpAddr != "" {
+		listener, err := net.Listen("tcp", httpAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen ingest http: %w", err)
+		}
+		if err = checkLoopback(listener.Addr()); err != nil {
+			_ = listener.Close()
+			return err
+		}
+		s.httpServer = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: readHeaderTimeout}
+		s.logger.Infof("ingest http listening on %s", listener.Addr())
+		s.wg.Add(1)
+		go s.serveHTTP(listener)
+	}
+
+	if udpAddr != "" {
+		conn, err := net.ListenPacket("udp", udpAddr)
+		if err != nil {
+			s.Stop()
+			return fmt.Errorf("failed to listen ingest udp: %w", err)
+		}
+		if err = checkLoopback(conn.LocalAddr()); err != nil {
+			_ = conn.Close()
+			s.Stop()
+			return err
+		}
+		s.conn = conn
+		s.logger.Infof("ingest udp listening on %s", conn.LocalAddr())
+		s.wg.Add(1)
+		go s.serveUDP(conn)
+	}
+
+	return nil
+}
+
+// Stop closes listeners and waits until they are done.
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		if s.httpServer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if err := s.httpServer.Shutdown(ctx); err != nil {
+				s.logger.Errorf("failed to stop ingest http: %v", err)
+			}
+		}
+		if s.conn != nil {
+			if err := s.conn.Close(); err != nil {
+				s.logger.Errorf("failed to stop ingest udp: %v", err)
+			}
+		}
+		s.wg.Wait()
+	})
+}
+
+// Handler returns HTTP handler of ingest endpoint.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /updates", s.handleUpdates)
+	mux.HandleFunc("POST /updates/", s.handleUpdates)
+	return mux
+}
+
+func (s *Server) handleUpdates(w http.ResponseWriter, req *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+	metricsBatch, err := s.accept(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set(common.ContentType, "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(metricsBatch); err != nil {
+		s.logger.Errorf("failed to write ingest response: %v", err)
+	}
+}
+
+func (s *Server) serveHTTP(listener net.Listener) {
+	defer s.wg.Done()
+	if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Errorf("ingest http error: %v", err)
+	}
+}
+
+func (s *Server) serveUDP(conn net.PacketConn) {
+	defer s.wg.Done()
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Errorf("ingest udp error: %v", err)
+			}
+			return
+		}
+		if _, err = s.accept(buf[:n]); err != nil {
+			s.logger.Warnf("ingest packet from %s dropped: %v", src, err)
+		}
+	}
+}
+
+// accept parses payload and merges it into agent state.
+func (s *Server) accept(data []byte) ([]*dto.MetricDTO, error) {
+	metricsBatch, err := parsePayload(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.metrics.AddMetrics(metricsBatch); err != nil {
+		return nil, fmt.Errorf("unable to accept metrics: %w", err)
+	}
+	return metricsBatch, nil
+}
+
+// parsePayload parses array of metrics or single metric, like server `/updates` route.
+func parsePayload(data []byte) ([]*dto.MetricDTO, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		metricsBatch := make([]*dto.MetricDTO, 0)
+		if err := json.Unmarshal(data, &metricsBatch); err != nil {
+			return nil, fmt.Errorf("unable to parse dto: %w", err)
+		}
+		return metricsBatch, nil
+	}
+
+	metricDto := new(dto.MetricDTO)
+	if err := json.Unmarshal(data, metricDto); err != nil {
+		return nil, fmt.Errorf("unable to parse dto: %w", err)
+	}
+	return []*dto.MetricDTO{metricDto}, nil
+}
+
+func checkLoopback(addr net.Addr) error {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	}
+	if ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%w: %s", ErrNotLoopback, addr)
+	}
+	return nil
+}
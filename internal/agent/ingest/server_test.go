@@ -0,0 +1,123 @@
+package ingest
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsRecorder struct {
+	err     error
+	batches [][]*dto.MetricDTO
+	mu      sync.Mutex
+}
+
+func (r *metricsRecorder) AddMetrics(metrics []*dto.MetricDTO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, metrics)
+	return nil
+}
+
+func (r *metricsRecorder) all() []*dto.MetricDTO {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*dto.MetricDTO
+	for _, batch := range r.batches {
+		result = append(result, batch...)
+	}
+	return result
+}
+
+func TestServer_Handler(t *testing.T) {
+	tests := []struct {
+		recorderErr error
+		name        string
+		method      string
+		body        string
+		want        []*dto.MetricDTO
+		wantStatus  int
+	}{
+		{
+			name:   "batch of metrics",
+			method: http.MethodPost,
+			body:   `[{"id":"Orders","type":"counter","delta":2},{"id":"Temp","type":"gauge","value":1.5,"labels":{"room":"a"}}]`,
+			want: []*dto.MetricDTO{
+				{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(2))},
+				{ID: "Temp", MType: common.GaugeMetricType, Value: testutils.Pointer(1.5), Labels: map[string]string{"room": "a"}},
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "single metric",
+			method:     http.MethodPost,
+			body:       `{"id":"Orders","type":"counter","delta":1}`,
+			want:       []*dto.MetricDTO{{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1))}},
+			wantStatus: http.StatusOK,
+		},
+		{name: "broken json", method: http.MethodPost, body: `[{"id":`, wantStatus: http.StatusBadRequest},
+		{name: "unknown type", method: http.MethodPost, body: `{"id":"Orders","type":"meter"}`, wantStatus: http.StatusBadRequest},
+		{
+			name:        "rejected metrics",
+			method:      http.MethodPost,
+			body:        `{"id":"Orders","type":"counter"}`,
+			recorderErr: errors.New("metric Orders: missing value"),
+			wantStatus:  http.StatusBadRequest,
+		},
+		{name: "wrong method", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &metricsRecorder{err: tt.recorderErr}
+			s := NewServer(log.MockLogger{}, recorder)
+
+			req := httptest.NewRequest(tt.method, "/updates", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.want, recorder.all())
+		})
+	}
+}
+
+func TestServer_Start(t *testing.T) {
+	t.Run("non-loopback address is rejected", func(t *testing.T) {
+		s := NewServer(log.MockLogger{}, &metricsRecorder{})
+		require.ErrorIs(t, s.Start("0.0.0.0:0", ""), ErrNotLoopback)
+		require.ErrorIs(t, s.Start("", "0.0.0.0:0"), ErrNotLoopback)
+	})
+
+	t.Run("metrics are accepted over udp", func(t *testing.T) {
+		recorder := &metricsRecorder{}
+		s := NewServer(log.MockLogger{}, recorder)
+		require.NoError(t, s.Start("127.0.0.1:0", "127.0.0.1:0"))
+		defer s.Stop()
+
+		conn, err := net.Dial("udp", s.conn.LocalAddr().String())
+		require.NoError(t, err)
+		defer conn.Close() //nolint:errcheck // test
+		_, err = conn.Write([]byte("not json"))
+		require.NoError(t, err)
+		_, err = conn.Write([]byte(`[{"id":"Orders","type":"counter","delta":3}]`))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return len(recorder.all()) == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "Orders", recorder.all()[0].ID)
+	})
+}
@@ -0,0 +1,18 @@
+package metricscollector
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+const batchIDSize = 16
+
+// newBatchID generates random batch ID, server applies batch with the same ID only once.
+func newBatchID() (string, error) {
+	b := make([]byte, batchIDSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate batch id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,6 +1,47 @@
 package metricscollector
 
+import "time"
+
 const (
 	pollCountMetricName   = "PollCount"
 	randomValueMetricName = "RandomValue"
 )
+
+// self-metrics describing agent health.
+const (
+	collectErrorsMetricName = "AgentCollectErrors"    // counter of failed collector runs
+	sendErrorsMetricName    = "AgentSendErrors"       // counter of failed sends
+	breakerStateMetricName  = "AgentSendBreakerState" // 0 - closed, 1 - half-open, 2 - open
+	spoolBatchesMetricName  = "AgentSpoolBatches"     // batches waiting for delivery
+)
+
+// agentMetricNames metrics of agent itself, local applications can not push them as well as
+// metrics reported by registered collectors.
+var agentMetricNames = map[string]struct{}{
+	pollCountMetricName:     {},
+	randomValueMetricName:   {},
+	collectErrorsMetricName: {},
+	sendErrorsMetricName:    {},
+	breakerStateMetricName:  {},
+	spoolBatchesMetricName:  {},
+}
+
+// maxPushedSeries how many series local applications can push, so buggy application
+// generating label values can not exhaust agent memory.
+const maxPushedSeries = 10000
+
+// pushedSeriesTTL pushed series not updated that long are removed from state and stop being reported.
+const pushedSeriesTTL = 5 * time.Minute
+
+const (
+	breakerThreshold = 5                // consecutive send failures opening breaker
+	breakerCooldown  = 30 * time.Second // how long open breaker fails sends fast
+	backoffBase      = time.Second      // delay after first failure of job
+	backoffMax       = time.Minute      // max delay between job runs
+)
+
+const (
+	defaultCollectTimeout = 10 * time.Second       // collect timeout if neither timeout nor interval is set
+	scheduleSlack         = 100 * time.Millisecond // collector due that much earlier is run on current poll
+	collectGracePeriod    = time.Second            // how long timed out collector is waited for to return
+)
@@ -3,30 +3,26 @@ package metricscollector
 
 import (
 	"context"
-	cryptorand "crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
-	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"fmt"
-	"maps"
 	"math/rand/v2"
 	"os"
-	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Kopleman/metcol/internal/agent/collector"
 	"github.com/Kopleman/metcol/internal/agent/config"
+	"github.com/Kopleman/metcol/internal/agent/resilience"
+	"github.com/Kopleman/metcol/internal/agent/spool"
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/envelope"
 	"github.com/Kopleman/metcol/internal/common/log"
 	"github.com/Kopleman/metcol/internal/common/utils"
 	pb "github.com/Kopleman/metcol/proto/metrics"
-	"github.com/shirou/gopsutil/v4/cpu"
-	"github.com/shirou/gopsutil/v4/mem"
 )
 
 func (mc *MetricsCollector) GetState() map[string]MetricItem {
@@ -34,179 +30,242 @@ func (mc *MetricsCollector) GetState() map[string]MetricItem {
 }
 
 type CollectResult struct {
-	metrics map[string]MetricItem
+	metrics map[string]collector.Metric
 	err     error
+	source  *registeredCollector
 }
 
-func (mc *MetricsCollector) getMemStatMetrics(resultCh chan CollectResult) {
-	defer close(resultCh)
-	result := CollectResult{}
-	result.metrics = make(map[string]MetricItem)
-	var memstats runtime.MemStats
-	runtime.ReadMemStats(&memstats)
-	result.metrics["Alloc"] = MetricItem{
-		value:      strconv.FormatUint(memstats.Alloc, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["BuckHashSys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.BuckHashSys, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["Frees"] = MetricItem{
-		value:      strconv.FormatUint(memstats.Frees, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["GCCPUFraction"] = MetricItem{
-		value:      strconv.FormatFloat(memstats.GCCPUFraction, 'f', -1, 64),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["GCSys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.GCSys, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["HeapAlloc"] = MetricItem{
-		value:      strconv.FormatUint(memstats.HeapAlloc, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["HeapIdle"] = MetricItem{
-		value:      strconv.FormatUint(memstats.HeapIdle, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["HeapInuse"] = MetricItem{
-		value:      strconv.FormatUint(memstats.HeapInuse, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["HeapObjects"] = MetricItem{
-		value:      strconv.FormatUint(memstats.HeapObjects, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["HeapReleased"] = MetricItem{
-		value:      strconv.FormatUint(memstats.HeapReleased, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["HeapSys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.HeapSys, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["LastGC"] = MetricItem{
-		value:      strconv.FormatUint(memstats.LastGC, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["Lookups"] = MetricItem{
-		value:      strconv.FormatUint(memstats.Lookups, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["MCacheInuse"] = MetricItem{
-		value:      strconv.FormatUint(memstats.MCacheInuse, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["MCacheSys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.MCacheSys, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["MSpanInuse"] = MetricItem{
-		value:      strconv.FormatUint(memstats.MSpanInuse, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["MSpanSys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.MSpanSys, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["Mallocs"] = MetricItem{
-		value:      strconv.FormatUint(memstats.Mallocs, 10),
-		metricType: common.GaugeMetricType,
-	}
-	result.metrics["NextGC"] = MetricItem{
-		value:      strconv.FormatUint(memstats.NextGC, 10),
-		metricType: common.GaugeMetricType,
+// registeredCollector collector with its schedule.
+type registeredCollector struct {
+	nextRun   time.Time
+	collector collector.Collector
+	series    map[string]struct{} // keys of series put to state by last run, guarded by mu
+	interval  time.Duration
+	timeout   time.Duration
+}
+
+// RegisterCollector adds source of metrics unless it is disabled in config. Collectors run
+// on agent poll, so collector poll interval shorter than agent one has no effect.
+func (mc *MetricsCollector) RegisterCollector(c collector.Collector, enabledByDefault bool) {
+	enabled := enabledByDefault
+	var settings config.CollectorConfig
+	var pollInterval int64
+	if mc.cfg != nil {
+		enabled = mc.cfg.CollectorEnabled(c.Name(), enabledByDefault)
+		settings = mc.cfg.Collectors[c.Name()]
+		pollInterval = mc.cfg.PollInterval
+	}
+	if !enabled {
+		return
 	}
-	result.metrics["NumForcedGC"] = MetricItem{
-		value:      strconv.FormatUint(uint64(memstats.NumForcedGC), 10),
-		metricType: common.GaugeMetricType,
+
+	if settings.PollInterval > 0 {
+		pollInterval = settings.PollInterval
 	}
-	result.metrics["NumGC"] = MetricItem{
-		value:      strconv.FormatUint(uint64(memstats.NumGC), 10),
-		metricType: common.GaugeMetricType,
+	interval := time.Duration(pollInterval) * time.Second
+	timeout := interval
+	if settings.Timeout > 0 {
+		timeout = time.Duration(settings.Timeout) * time.Second
 	}
-	result.metrics["OtherSys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.OtherSys, 10),
-		metricType: common.GaugeMetricType,
+	if timeout == 0 {
+		timeout = defaultCollectTimeout
 	}
-	result.metrics["PauseTotalNs"] = MetricItem{
-		value:      strconv.FormatUint(memstats.PauseTotalNs, 10),
-		metricType: common.GaugeMetricType,
+
+	mc.collectorsMu.Lock()
+	defer mc.collectorsMu.Unlock()
+	mc.collectors = append(mc.collectors, &registeredCollector{
+		collector: c,
+		interval:  interval,
+		timeout:   timeout,
+	})
+}
+
+// dueCollectors returns collectors which interval has passed and schedules their next run.
+func (mc *MetricsCollector) dueCollectors(now time.Time) []*registeredCollector {
+	mc.collectorsMu.Lock()
+	defer mc.collectorsMu.Unlock()
+
+	due := make([]*registeredCollector, 0, len(mc.collectors))
+	for _, rc := range mc.collectors {
+		// poll ticks are not exact, collector is not postponed for whole interval by few ms
+		if now.Before(rc.nextRun.Add(-scheduleSlack)) {
+			continue
+		}
+		rc.nextRun = now.Add(rc.interval)
+		due = append(due, rc)
 	}
-	result.metrics["StackInuse"] = MetricItem{
-		value:      strconv.FormatUint(memstats.StackInuse, 10),
-		metricType: common.GaugeMetricType,
+	return due
+}
+
+// runCollector runs collector within its timeout.
+func runCollector(rc *registeredCollector, resultCh chan CollectResult) {
+	defer close(resultCh)
+	ctx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+	defer cancel()
+
+	// collector which ignores ctx is not waited for
+	done := make(chan CollectResult, 1)
+	go func() {
+		metrics, err := rc.collector.Collect(ctx)
+		done <- CollectResult{metrics: metrics, err: err}
+	}()
+
+	result := CollectResult{source: rc}
+	select {
+	case collected := <-done:
+		result.metrics, result.err = collected.metrics, collected.err
+	case <-ctx.Done():
+		// collector honouring ctx returns shortly, its own result is preferred
+		select {
+		case collected := <-done:
+			result.metrics, result.err = collected.metrics, collected.err
+		case <-time.After(collectGracePeriod):
+			result.err = ctx.Err()
+		}
 	}
-	result.metrics["StackSys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.StackSys, 10),
-		metricType: common.GaugeMetricType,
+	if result.err != nil {
+		result.err = fmt.Errorf("collector %s: %w", rc.collector.Name(), result.err)
 	}
-	result.metrics["Sys"] = MetricItem{
-		value:      strconv.FormatUint(memstats.Sys, 10),
-		metricType: common.GaugeMetricType,
+	resultCh <- result
+}
+
+// mergeMetrics puts collected metrics to state, counter deltas are accumulated until sent.
+// Series which source reported on previous run but not now, e.g. of exited process, are
+// removed from state once their counter deltas are sent. Nil source keeps series.
+func (mc *MetricsCollector) mergeMetrics(source *registeredCollector, metrics map[string]collector.Metric) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if source != nil {
+		mc.removeStaleSeries(source, metrics)
+		mc.claimNames(metrics)
 	}
-	result.metrics["TotalAlloc"] = MetricItem{
-		value:      strconv.FormatUint(memstats.TotalAlloc, 10),
-		metricType: common.GaugeMetricType,
+	mc.putMetrics(metrics)
+}
+
+// claimNames reserves names reported by collector, so local applications can not overwrite
+// collected values. Series already pushed under such name are handed over to collector,
+// caller holds mu.
+func (mc *MetricsCollector) claimNames(metrics map[string]collector.Metric) {
+	for key := range metrics {
+		name := collector.SeriesName(key)
+		if _, ok := mc.collectedNames[name]; ok {
+			continue
+		}
+		mc.collectedNames[name] = struct{}{}
+		for pushedKey := range mc.pushedSeries {
+			if collector.SeriesName(pushedKey) == name {
+				delete(mc.pushedSeries, pushedKey)
+			}
+		}
 	}
-	resultCh <- result
 }
 
-func (mc *MetricsCollector) getGopsutilMetrics(resultCh chan CollectResult) {
-	defer close(resultCh)
-	result := CollectResult{}
+// reservedName reports whether name belongs to agent or its collectors, caller holds mu.
+func (mc *MetricsCollector) reservedName(name string) bool {
+	if _, ok := agentMetricNames[name]; ok {
+		return true
+	}
+	_, ok := mc.collectedNames[name]
+	return ok
+}
 
-	v, err := mem.VirtualMemory()
-	if err != nil {
-		result.err = fmt.Errorf("could not get virtual memory info: %w", err)
-		resultCh <- result
-		return
+// expirePushedSeries removes pushed series not updated for pushedSeriesTTL once their counter
+// deltas are sent, caller holds mu.
+func (mc *MetricsCollector) expirePushedSeries(now time.Time) {
+	for key, pushedAt := range mc.pushedSeries {
+		if now.Sub(pushedAt) < pushedSeriesTTL {
+			continue
+		}
+		if item, ok := mc.currentMetricState[key]; ok && item.metricType == common.CounterMetricType && item.value != "0" {
+			// delta is not sent yet, series is checked again later
+			continue
+		}
+		delete(mc.currentMetricState, key)
+		delete(mc.pushedSeries, key)
 	}
-	usages, errPercent := cpu.Percent(0, false)
-	if errPercent != nil {
-		result.err = fmt.Errorf("could not get cpu usage: %w", err)
-		resultCh <- result
-		return
+}
+
+// putMetrics puts metrics to state, caller holds mu.
+func (mc *MetricsCollector) putMetrics(metrics map[string]collector.Metric) {
+
+	for key, metric := range metrics {
+		switch metric.Type {
+		case common.CounterMetricType:
+			mc.addCounter(key, metric.Labels, metric.Delta)
+		default:
+			mc.currentMetricState[key] = MetricItem{
+				labels:     metric.Labels,
+				value:      strconv.FormatFloat(metric.Value, 'f', -1, 64),
+				metricType: common.GaugeMetricType,
+			}
+		}
 	}
-	result.metrics = make(map[string]MetricItem)
+}
 
-	result.metrics["TotalMemory"] = MetricItem{
-		value:      strconv.FormatUint(v.Total, 10),
-		metricType: common.GaugeMetricType,
+// removeStaleSeries removes series source does not report anymore, caller holds mu.
+func (mc *MetricsCollector) removeStaleSeries(source *registeredCollector, metrics map[string]collector.Metric) {
+	reported := make(map[string]struct{}, len(metrics))
+	for key := range metrics {
+		reported[key] = struct{}{}
 	}
-	result.metrics["FreeMemory"] = MetricItem{
-		value:      strconv.FormatUint(v.Free, 10),
-		metricType: common.GaugeMetricType,
+	for key := range source.series {
+		if _, ok := reported[key]; ok {
+			continue
+		}
+		if item, ok := mc.currentMetricState[key]; ok && item.metricType == common.CounterMetricType && item.value != "0" {
+			// delta is not sent yet, series is checked again on next run
+			reported[key] = struct{}{}
+			continue
+		}
+		delete(mc.currentMetricState, key)
 	}
+	source.series = reported
+}
 
-	for index, usage := range usages {
-		metricName := fmt.Sprintf("CPUutilization%d", index+1)
-		result.metrics[metricName] = MetricItem{
-			value:      strconv.FormatUint(uint64(usage), 10),
-			metricType: common.GaugeMetricType,
+// addCounter adds delta to counter accumulated until sent, caller holds mu.
+func (mc *MetricsCollector) addCounter(key string, labels map[string]string, delta int64) {
+	if current, ok := mc.currentMetricState[key]; ok && current.metricType == common.CounterMetricType {
+		if value, err := strconv.ParseInt(current.value, 10, 64); err == nil {
+			delta += value
 		}
 	}
-	resultCh <- result
+	mc.currentMetricState[key] = MetricItem{
+		labels:     labels,
+		value:      strconv.FormatInt(delta, 10),
+		metricType: common.CounterMetricType,
+	}
 }
 
+// CollectAllMetrics runs due collectors concurrently and merges their results. Failed
+// collector does not discard results of others, error is returned only if all of them failed.
 func (mc *MetricsCollector) CollectAllMetrics() error {
-	gopsutilChan := make(chan CollectResult)
-	memstatChan := make(chan CollectResult)
+	due := mc.dueCollectors(time.Now())
+	resultChans := make([]chan CollectResult, 0, len(due))
+	for _, rc := range due {
+		resultCh := make(chan CollectResult, 1)
+		go runCollector(rc, resultCh)
+		resultChans = append(resultChans, resultCh)
+	}
 
-	go mc.getMemStatMetrics(memstatChan)
-	go mc.getGopsutilMetrics(gopsutilChan)
-	for result := range utils.FanIn(memstatChan, gopsutilChan) {
+	var errs []error
+	for result := range utils.FanIn(resultChans...) {
 		if result.err != nil {
-			fmt.Printf("CollectAllMetrics error: %s\n", result.err)
-			return result.err
+			mc.collectErrors.Add(1)
+			errs = append(errs, result.err)
+			continue
 		}
-		mc.mu.Lock()
-		maps.Copy(mc.currentMetricState, result.metrics)
-		mc.mu.Unlock()
+		mc.mergeMetrics(result.source, result.metrics)
 	}
+	if len(errs) > 0 {
+		if len(errs) == len(due) {
+			return fmt.Errorf("CollectAllMetrics error: %w", errors.Join(errs...))
+		}
+		mc.logger.Warnf("some collectors failed: %v", errors.Join(errs...))
+	}
+
+	mc.mu.Lock()
+	mc.expirePushedSeries(time.Now())
+	mc.mu.Unlock()
 
 	if err := mc.increasePollCounter(); err != nil {
 		return err
@@ -235,11 +294,80 @@ func (mc *MetricsCollector) increasePollCounter() error {
 	return nil
 }
 
-func (mc *MetricsCollector) resetPollCounter() {
-	mc.currentMetricState[pollCountMetricName] = MetricItem{
-		value:      "0",
-		metricType: common.CounterMetricType,
+// resetCounters zeroes counters once their deltas are delivered, caller holds mu.
+func (mc *MetricsCollector) resetCounters() {
+	for key, item := range mc.currentMetricState {
+		if item.metricType == common.CounterMetricType {
+			item.value = "0"
+			mc.currentMetricState[key] = item
+		}
+	}
+}
+
+// settleCounter subtracts delivered delta from counter, so increments merged while it was
+// being sent are kept for next report.
+func (mc *MetricsCollector) settleCounter(key string, delivered int64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	item, ok := mc.currentMetricState[key]
+	if !ok || item.metricType != common.CounterMetricType {
+		return
+	}
+	value, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return
+	}
+	item.value = strconv.FormatInt(value-delivered, 10)
+	mc.currentMetricState[key] = item
+}
+
+// AddMetrics merges metrics pushed by local applications into state, counters are summed
+// until next report. Batch is rejected as a whole if any metric is invalid, uses name reserved
+// by agent or its collectors, changes type of existing series or would exceed limit of pushed
+// series. Series not pushed again for pushedSeriesTTL are removed.
+func (mc *MetricsCollector) AddMetrics(metrics []*dto.MetricDTO) error {
+	pushed := make(map[string]collector.Metric, len(metrics))
+	for _, metricDto := range metrics {
+		metric, err := collector.FromDTO(metricDto)
+		if err != nil {
+			return fmt.Errorf("AddMetrics error: %w", err)
+		}
+		key := collector.SeriesKey(metricDto.ID, metric.Labels)
+		if prev, ok := pushed[key]; ok {
+			if prev.Type != metric.Type {
+				return fmt.Errorf("AddMetrics error: metric %s is pushed as both %s and %s", key, prev.Type, metric.Type)
+			}
+			metric.Delta += prev.Delta
+		}
+		pushed[key] = metric
+	}
+
+	now := time.Now()
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.expirePushedSeries(now)
+	newSeries := 0
+	for key, metric := range pushed {
+		if name := collector.SeriesName(key); mc.reservedName(name) {
+			return fmt.Errorf("AddMetrics error: metric name %s is reserved by agent", name)
+		}
+		if current, ok := mc.currentMetricState[key]; ok && current.metricType != metric.Type {
+			return fmt.Errorf("AddMetrics error: metric %s is %s, not %s", key, current.metricType, metric.Type)
+		}
+		if _, ok := mc.pushedSeries[key]; !ok {
+			newSeries++
+		}
+	}
+	if len(mc.pushedSeries)+newSeries > mc.maxPushedSeries {
+		return fmt.Errorf("AddMetrics error: limit of %d pushed series is reached", mc.maxPushedSeries)
+	}
+
+	for key := range pushed {
+		mc.pushedSeries[key] = now
 	}
+	mc.putMetrics(pushed)
+	return nil
 }
 
 func (mc *MetricsCollector) assignNewRandomValue() {
@@ -262,44 +390,55 @@ type sendMetricJob struct {
 }
 
 func (mc *MetricsCollector) sendMetricsViaWorkers(ctx context.Context) error {
-	metricsCount := len(mc.currentMetricState)
-
-	sendJobs := make(chan sendMetricJob, metricsCount)
-	results := make(chan sendMetricResult, metricsCount)
-	defer close(results)
-	defer close(sendJobs)
-	maxWorkerCount := int(mc.cfg.RateLimit)
+	// state may grow while metrics are sent, e.g. by AddMetrics, so only snapshot is sent
+	mc.mu.RLock()
+	jobs := make([]sendMetricJob, 0, len(mc.currentMetricState))
+	for name, item := range mc.currentMetricState {
+		jobs = append(jobs, sendMetricJob{name: name, metric: item})
+	}
+	mc.mu.RUnlock()
 
-	for w := 1; w <= maxWorkerCount; w++ {
-		go mc.sendMetricWorker(w, sendJobs, results)
+	sendJobs := make(chan sendMetricJob, len(jobs))
+	results := make(chan sendMetricResult, len(jobs))
+	for _, job := range jobs {
+		sendJobs <- job
 	}
+	close(sendJobs)
 
-	mc.mu.Lock()
-	for name, item := range mc.currentMetricState {
-		sendJobs <- sendMetricJob{name: name, metric: item}
+	wg := &sync.WaitGroup{}
+	for w := 1; w <= max(int(mc.cfg.RateLimit), 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mc.sendMetricWorker(ctx, w, sendJobs, results)
+		}()
 	}
-	mc.mu.Unlock()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
 	var err error
-	numOfDoneJobs := 0
-	for {
-		select {
-		case result := <-results:
-			numOfDoneJobs++
-			if result.err != nil {
-				err = fmt.Errorf("sendMetricsViaWorkers error: %w", result.err)
-			}
-			if numOfDoneJobs == metricsCount {
-				return err
-			}
-		case <-ctx.Done():
-			return nil
+	for result := range results {
+		if result.err != nil {
+			// keep all errors, permanent one must not be hidden by transient
+			err = errors.Join(err, fmt.Errorf("sendMetricsViaWorkers error: %w", result.err))
 		}
 	}
+	return err
 }
 
-func (mc *MetricsCollector) sendMetricWorker(workerID int, jobs <-chan sendMetricJob, results chan<- sendMetricResult) {
+// sendMetricWorker sends jobs until they are over or ctx is done.
+func (mc *MetricsCollector) sendMetricWorker(
+	ctx context.Context,
+	workerID int,
+	jobs <-chan sendMetricJob,
+	results chan<- sendMetricResult,
+) {
 	for j := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
 		result := sendMetricResult{
 			workerID: workerID,
 		}
@@ -310,10 +449,13 @@ func (mc *MetricsCollector) sendMetricWorker(workerID int, jobs <-chan sendMetri
 	}
 }
 
-func (mc *MetricsCollector) convertMetricItemToDto(name string, item MetricItem) (*dto.MetricDTO, error) {
+// convertMetricItemToDto converts state entry to dto, key of labeled metric is its series key.
+func (mc *MetricsCollector) convertMetricItemToDto(key string, item MetricItem) (*dto.MetricDTO, error) {
+	name := collector.SeriesName(key)
 	metricDto := &dto.MetricDTO{
-		ID:    name,
-		MType: item.metricType,
+		Labels: item.labels,
+		ID:     name,
+		MType:  item.metricType,
 	}
 	switch item.metricType {
 	case common.CounterMetricType:
@@ -338,28 +480,31 @@ func (mc *MetricsCollector) convertMetricItemToDto(name string, item MetricItem)
 func (mc *MetricsCollector) sendMetricItem(name string, item MetricItem) error {
 	metricDto, err := mc.convertMetricItemToDto(name, item)
 	if err != nil {
-		return err
+		return resilience.Permanent(err)
 	}
 	sendFunc := mc.sendMetricItemViaHTTP
-	if mc.grpcClient == nil {
+	if mc.grpcClient != nil {
 		sendFunc = mc.sendMetricItemViaGRPC
 	}
 
-	if err = sendFunc(name, metricDto); err != nil {
+	if err = mc.sendBreaker.Do(func() error { return sendFunc(name, metricDto) }); err != nil {
 		return fmt.Errorf("sendMetricItem error: %w", err)
 	}
+	if metricDto.Delta != nil {
+		mc.settleCounter(name, *metricDto.Delta)
+	}
 	return nil
 }
 
 func (mc *MetricsCollector) sendMetricItemViaHTTP(name string, metricDto *dto.MetricDTO) error {
 	body, marshalErr := json.Marshal(metricDto)
 	if marshalErr != nil {
-		return fmt.Errorf("unable to marshal metric dto: %w", marshalErr)
+		return resilience.Permanent(fmt.Errorf("unable to marshal metric dto: %w", marshalErr))
 	}
 	url := "/update"
 	cryptoBody, cryptErr := mc.cryptData(body)
 	if cryptErr != nil {
-		return fmt.Errorf("sendMetricItem crypt error: %w", cryptErr)
+		return resilience.Permanent(fmt.Errorf("sendMetricItem crypt error: %w", cryptErr))
 	}
 	respBytes, sendErr := mc.client.Post(url, "application/json", cryptoBody)
 	if sendErr != nil {
@@ -384,47 +529,106 @@ func (mc *MetricsCollector) sendMetricItemViaGRPC(name string, metricDto *dto.Me
 	return nil
 }
 
-// SendMetrics sends all metrics to config.Endpoint.
+// SendMetrics sends all metrics to config.Endpoint as one batch. Batch which was not delivered
+// is sent again with the same ID on next call, so server applies it only once, metrics collected
+// meanwhile go to next batch. Batch with payload server never accepts is dropped. Called by send job only.
 func (mc *MetricsCollector) SendMetrics() error {
-	metricsBatch := make([]*dto.MetricDTO, 0, len(mc.currentMetricState))
-	for name, item := range mc.currentMetricState {
-		metricDto, err := mc.convertMetricItemToDto(name, item)
+	if mc.pending == nil {
+		batch, err := mc.snapshotBatch()
 		if err != nil {
-			return err
+			return resilience.Permanent(fmt.Errorf("SendMetrics error: %w", err))
 		}
-		metricsBatch = append(metricsBatch, metricDto)
+		mc.pending = batch
 	}
 
-	if len(metricsBatch) == 0 {
-		return nil
+	err := mc.sendBatch(mc.pending.ID, mc.pending.Metrics)
+	if err != nil && !resilience.IsPermanent(err) {
+		return fmt.Errorf("SendMetrics error: %w", err)
+	}
+	if err != nil {
+		// server never accepts this payload, keeping it would block next reports
+		mc.logger.Errorf("metrics batch '%s' is malformed, dropped: %v", mc.pending.ID, err)
 	}
+	mc.pending = nil
 
+	return nil
+}
+
+func (mc *MetricsCollector) sendBatch(batchID string, metricsBatch []*dto.MetricDTO) error {
 	sendFunc := mc.sendMetricsViaHTTP
-	if mc.grpcClient == nil {
+	if mc.grpcClient != nil {
 		sendFunc = mc.sendMetricsViaGRPC
 	}
+	return mc.sendBreaker.Do(func() error { return sendFunc(batchID, metricsBatch) })
+}
 
-	if err := sendFunc(metricsBatch); err != nil {
-		return fmt.Errorf("SendMetrics error: %w", err)
+// spoolMetrics puts current state to on-disk queue and delivers queued batches oldest first.
+// Batches not delivered stay queued until next report.
+func (mc *MetricsCollector) spoolMetrics() error {
+	batch, err := mc.snapshotBatch()
+	if err != nil {
+		return resilience.Permanent(fmt.Errorf("spoolMetrics error: %w", err))
+	}
+	if err = mc.spool.Push(batch); err != nil {
+		if !errors.Is(err, spool.ErrTooLarge) {
+			return fmt.Errorf("spoolMetrics error: %w", err)
+		}
+		mc.logger.Errorf("metrics batch '%s' dropped: %v", batch.ID, err)
 	}
 
-	mc.resetPollCounter()
+	sent, err := mc.spool.Replay(func(batch *spool.Batch) error {
+		sendErr := mc.sendBatch(batch.ID, batch.Metrics)
+		if resilience.IsPermanent(sendErr) {
+			mc.logger.Errorf("metrics batch '%s' is malformed, dropped: %v", batch.ID, sendErr)
+		}
+		return sendErr
+	})
+	if err != nil {
+		return fmt.Errorf("%d metric batches stay queued: %w", mc.spool.Len(), err)
+	}
+	if sent > 1 {
+		mc.logger.Infof("%d queued metric batches delivered", sent)
+	}
 
 	return nil
 }
 
-func (mc *MetricsCollector) sendMetricsViaHTTP(metricsBatch []*dto.MetricDTO) error {
+// snapshotBatch converts current state to batch. Counters are reset, their deltas
+// are delivered with the batch.
+func (mc *MetricsCollector) snapshotBatch() (*spool.Batch, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	metricsBatch := make([]*dto.MetricDTO, 0, len(mc.currentMetricState))
+	for name, item := range mc.currentMetricState {
+		metricDto, err := mc.convertMetricItemToDto(name, item)
+		if err != nil {
+			return nil, err
+		}
+		metricsBatch = append(metricsBatch, metricDto)
+	}
+
+	batchID, err := newBatchID()
+	if err != nil {
+		return nil, err
+	}
+	mc.resetCounters()
+
+	return &spool.Batch{CreatedAt: time.Now(), ID: batchID, Metrics: metricsBatch}, nil
+}
+
+func (mc *MetricsCollector) sendMetricsViaHTTP(batchID string, metricsBatch []*dto.MetricDTO) error {
 	body, marshalErr := json.Marshal(metricsBatch)
 	if marshalErr != nil {
-		return fmt.Errorf("unable to marshal metrics batch: %w", marshalErr)
+		return resilience.Permanent(fmt.Errorf("unable to marshal metrics batch: %w", marshalErr))
 	}
 
 	url := "/updates"
 	cryptoBody, cryptErr := mc.cryptData(body)
 	if cryptErr != nil {
-		return fmt.Errorf("sendMetrics crypt error: %w", cryptErr)
+		return resilience.Permanent(fmt.Errorf("sendMetrics crypt error: %w", cryptErr))
 	}
-	respBytes, sendErr := mc.client.Post(url, "application/json", cryptoBody)
+	respBytes, sendErr := mc.client.PostBatch(url, "application/json", batchID, cryptoBody)
 	if sendErr != nil {
 		return fmt.Errorf("unable to sent metrics batch: %w", sendErr)
 	}
@@ -437,14 +641,13 @@ func (mc *MetricsCollector) sendMetricsViaHTTP(metricsBatch []*dto.MetricDTO) er
 	return nil
 }
 
-func (mc *MetricsCollector) sendMetricsViaGRPC(metricsBatch []*dto.MetricDTO) error {
+func (mc *MetricsCollector) sendMetricsViaGRPC(batchID string, metricsBatch []*dto.MetricDTO) error {
 	protoMetricsBatch := make([]*pb.Metric, 0, len(metricsBatch))
 	for _, metricDto := range metricsBatch {
 		protoMetric := utils.ConvertDTOToProtoMetric(metricDto)
 		protoMetricsBatch = append(protoMetricsBatch, protoMetric)
 	}
-	_, err := mc.grpcClient.UpdateMetrics(context.Background(), protoMetricsBatch)
-	if err != nil {
+	if err := mc.grpcClient.StreamMetricsBatch(context.Background(), batchID, protoMetricsBatch); err != nil {
 		return fmt.Errorf("unable to send metrics batch via grpc: %w", err)
 	}
 
@@ -466,7 +669,12 @@ func (mc *MetricsCollector) genIntervalJobParamsChan(
 			case currentTickerTime := <-tickerChan:
 				if currentTickerTime.After(args.nextJobTime) || currentTickerTime.Equal(args.nextJobTime) {
 					args.nextJobTime = currentTickerTime.Add(args.interval)
-					intervalChan <- struct{}{}
+					// job may already be stopped
+					select {
+					case intervalChan <- struct{}{}:
+					case <-ctx.Done():
+						return
+					}
 				}
 			case <-ctx.Done():
 				return
@@ -486,7 +694,8 @@ type jobsArg struct {
 	interval    time.Duration
 }
 
-// Handler performs all agent work - collecting and sending data.
+// Handler performs all agent work - collecting and sending data. Transient job errors
+// are retried with backoff, only permanent ones stop agent.
 func (mc *MetricsCollector) Handler(sig chan os.Signal) error {
 	innerCtx, cancelFunc := context.WithCancel(context.Background())
 	mc.logger.Info("Starting collect metrics")
@@ -498,7 +707,6 @@ func (mc *MetricsCollector) Handler(sig chan os.Signal) error {
 
 	now := time.Now()
 	resultChan := make(chan collectIntervalJobResults, 1)
-	defer close(resultChan)
 
 	pollDuration := time.Duration(mc.cfg.PollInterval) * time.Second
 	reportDuration := time.Duration(mc.cfg.ReportInterval) * time.Second
@@ -540,23 +748,58 @@ func (mc *MetricsCollector) Handler(sig chan os.Signal) error {
 	}
 }
 
+// handleJobError applies error policy to result of job run. Transient error delays next
+// run of job, permanent error is returned. errorsTotal, if set, counts failed runs.
+func (mc *MetricsCollector) handleJobError(
+	job string,
+	policy *resilience.Policy,
+	errorsTotal *atomic.Int64,
+	err error,
+) error {
+	if err == nil {
+		policy.Success()
+		return nil
+	}
+
+	if errorsTotal != nil {
+		errorsTotal.Add(1)
+	}
+	if resilience.IsPermanent(err) {
+		return err
+	}
+	delay := policy.Failure(time.Now())
+	mc.logger.Warnf("%s failed %d times in a row, next attempt in %s: %v", job, policy.Failures(), delay, err)
+	return nil
+}
+
+// reportJobError passes permanent job error to Handler unless agent is already stopping.
+func reportJobError(ctx context.Context, outputChan chan<- collectIntervalJobResults, err error) {
+	select {
+	case outputChan <- collectIntervalJobResults{jobError: err}:
+	case <-ctx.Done():
+	}
+}
+
 func (mc *MetricsCollector) collectIntervalJob(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	jobArgsCh <-chan struct{},
-	outputChan chan collectIntervalJobResults,
+	outputChan chan<- collectIntervalJobResults,
 ) {
 	defer wg.Done()
 	for {
 		select {
 		case <-jobArgsCh:
-			results := collectIntervalJobResults{}
+			if !mc.collectPolicy.Ready(time.Now()) {
+				continue
+			}
 			mc.logger.Info("collecting metrics")
-			err := mc.CollectAllMetrics()
+			// failed collectors are counted by CollectAllMetrics
+			err := mc.handleJobError("collect metrics", mc.collectPolicy, nil, mc.CollectAllMetrics())
+			mc.updateHealthMetrics()
 			if err != nil {
-				results.jobError = fmt.Errorf("collect metrics interval: %w", err)
+				reportJobError(ctx, outputChan, fmt.Errorf("collect metrics interval: %w", err))
 			}
-			outputChan <- results
 		case <-ctx.Done():
 			mc.logger.Infof("stopping collecting metrics job")
 			return
@@ -568,20 +811,30 @@ func (mc *MetricsCollector) sendMetricsIntervalJob(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	jobArgsCh <-chan struct{},
-	outputChan chan collectIntervalJobResults,
+	outputChan chan<- collectIntervalJobResults,
 ) {
 	defer wg.Done()
 	for {
 		select {
 		case <-jobArgsCh:
-			results := collectIntervalJobResults{}
+			if !mc.sendPolicy.Ready(time.Now()) {
+				continue
+			}
 			mc.logger.Info("sending metrics")
-			err := mc.sendMetricsViaWorkers(ctx)
-			if err != nil {
-				results.jobError = fmt.Errorf("send metrics interval: %w", err)
+			mc.updateHealthMetrics()
+			var sendErr error
+			if mc.spool != nil {
+				sendErr = mc.spoolMetrics()
+			} else {
+				sendErr = mc.SendMetrics()
+			}
+			if err := mc.handleJobError("send metrics", mc.sendPolicy, &mc.sendErrors, sendErr); err != nil {
+				reportJobError(ctx, outputChan, fmt.Errorf("send metrics interval: %w", err))
+				continue
+			}
+			if sendErr == nil {
+				mc.logger.Info("metrics sent")
 			}
-			mc.logger.Info("metrics sent")
-			outputChan <- results
 		case <-ctx.Done():
 			mc.logger.Infof("stopping send-metrics job")
 			return
@@ -589,13 +842,38 @@ func (mc *MetricsCollector) sendMetricsIntervalJob(
 	}
 }
 
+// updateHealthMetrics puts agent self-metrics to state, so they are sent with other metrics.
+// Errors are counters, increment since previous update is added to them.
+func (mc *MetricsCollector) updateHealthMetrics() {
+	health := map[string]int64{
+		breakerStateMetricName: int64(mc.sendBreaker.State()),
+	}
+	if mc.spool != nil {
+		health[spoolBatchesMetricName] = int64(mc.spool.Len())
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	// totals are read under lock, so concurrent updates never report negative increment
+	collectErrors, sendErrors := mc.collectErrors.Load(), mc.sendErrors.Load()
+	mc.addCounter(collectErrorsMetricName, nil, collectErrors-mc.collectReported)
+	mc.addCounter(sendErrorsMetricName, nil, sendErrors-mc.sendReported)
+	mc.collectReported, mc.sendReported = collectErrors, sendErrors
+
+	for name, value := range health {
+		mc.currentMetricState[name] = MetricItem{
+			value:      strconv.FormatInt(value, 10),
+			metricType: common.GaugeMetricType,
+		}
+	}
+}
+
 func (mc *MetricsCollector) cryptData(data []byte) ([]byte, error) {
 	if mc.publicKey == nil {
 		return data, nil
 	}
 
-	rng := cryptorand.Reader
-	cipherData, err := rsa.EncryptOAEP(sha256.New(), rng, mc.publicKey, data, nil)
+	cipherData, err := envelope.Seal(mc.publicKey, data)
 	if err != nil {
 		return nil, fmt.Errorf("encrypt data error: %w", err)
 	}
@@ -607,28 +885,62 @@ func (mc *MetricsCollector) loadPublicKey() error {
 		return nil
 	}
 
-	keyBytes, err := os.ReadFile(mc.cfg.PublicKeyPath)
+	pubKey, err := envelope.LoadPublicKey(mc.cfg.PublicKeyPath)
 	if err != nil {
-		return fmt.Errorf("unable to read public key file: %w", err)
+		return fmt.Errorf("failed to load public key: %w", err)
 	}
+	mc.publicKey = pubKey
+	return nil
+}
 
-	block, _ := pem.Decode(keyBytes)
-	if block == nil {
-		return errors.New("failed to parse public key PEM block")
+func (mc *MetricsCollector) openSpool() error {
+	if mc.cfg.SpoolDir == "" {
+		return nil
 	}
 
-	pubKey, parseErr := x509.ParsePKIXPublicKey(block.Bytes)
-	if parseErr != nil {
-		return errors.New("failed to parse public key bytes")
+	q, err := spool.Open(mc.cfg.SpoolDir, mc.cfg.SpoolMaxSize, time.Duration(mc.cfg.SpoolMaxAge)*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to open spool: %w", err)
+	}
+	if q.Len() > 0 {
+		mc.logger.Infof("%d undelivered metric batches found in spool", q.Len())
 	}
+	mc.spool = q
+	return nil
+}
 
-	switch pubKeyTyped := pubKey.(type) {
-	case *rsa.PublicKey:
-		mc.publicKey = pubKeyTyped
+// registerProcessCollector registers process collector if processes are set in config.
+func (mc *MetricsCollector) registerProcessCollector() error {
+	if len(mc.cfg.Processes) == 0 {
 		return nil
-	default:
-		return errors.New("not RSA public key")
 	}
+
+	targets := make([]collector.ProcessTarget, 0, len(mc.cfg.Processes))
+	for _, target := range mc.cfg.Processes {
+		targets = append(targets, collector.ProcessTarget{
+			PIDFile: target.PIDFile,
+			Name:    target.Name,
+			Cmdline: target.Cmdline,
+		})
+	}
+	c, err := collector.NewProcess(targets)
+	if err != nil {
+		return fmt.Errorf("failed to create process collector: %w", err)
+	}
+	mc.RegisterCollector(c, true)
+	return nil
+}
+
+// registerExecCollectors registers collector of each command set in config.
+func (mc *MetricsCollector) registerExecCollectors() error {
+	for _, command := range mc.cfg.Exec {
+		c, err := collector.NewExec(command.Name, command.Command, mc.logger)
+		if err != nil {
+			return fmt.Errorf("failed to create exec collector: %w", err)
+		}
+		mc.RegisterCollector(c, true)
+	}
+	return nil
 }
 
 func (mc *MetricsCollector) Init() error {
@@ -636,26 +948,60 @@ func (mc *MetricsCollector) Init() error {
 		return fmt.Errorf("unable to load public key: %w", err)
 	}
 
+	if err := mc.registerProcessCollector(); err != nil {
+		return fmt.Errorf("unable to register collectors: %w", err)
+	}
+
+	if err := mc.registerExecCollectors(); err != nil {
+		return fmt.Errorf("unable to register collectors: %w", err)
+	}
+
+	if err := mc.openSpool(); err != nil {
+		return fmt.Errorf("unable to open spool: %w", err)
+	}
+
 	return nil
 }
 
 type HTTPClient interface {
 	Post(url, contentType string, bodyBytes []byte) ([]byte, error)
+	PostBatch(url, contentType, batchID string, bodyBytes []byte) ([]byte, error)
 }
 
 type GRPCClient interface {
 	UpdateMetric(ctx context.Context, metric *pb.Metric) (*pb.Metric, error)
-	UpdateMetrics(ctx context.Context, metrics []*pb.Metric) ([]*pb.Metric, error)
+	StreamMetricsBatch(ctx context.Context, batchID string, metrics []*pb.Metric) error
+}
+
+// Spool queues batches which are not delivered yet.
+type Spool interface {
+	Push(batch *spool.Batch) error
+	Replay(send func(batch *spool.Batch) error) (int, error)
+	Len() int
 }
 
 type MetricsCollector struct {
 	cfg                *config.Config
 	currentMetricState map[string]MetricItem
+	pushedSeries       map[string]time.Time // last push of series pushed via AddMetrics, guarded by mu
+	collectedNames     map[string]struct{}  // names reported by registered collectors, guarded by mu
 	client             HTTPClient
 	grpcClient         GRPCClient
 	logger             log.Logger
+	spool              Spool
+	pending            *spool.Batch // batch of SendMetrics not delivered yet
 	mu                 *sync.RWMutex
-	publicKey          *rsa.PublicKey
+	publicKey          *envelope.PublicKey
+	sendBreaker        *resilience.Breaker
+	collectPolicy      *resilience.Policy
+	sendPolicy         *resilience.Policy
+	collectors         []*registeredCollector
+	collectErrors      atomic.Int64
+	sendErrors         atomic.Int64
+	collectReported    int64 // collectErrors already put to state, guarded by mu
+	sendReported       int64 // sendErrors already put to state, guarded by mu
+	maxPushedSeries    int
+	collectorsMu       sync.Mutex
 }
 
 // NewMetricsCollector creates instance of collector.
@@ -675,12 +1021,28 @@ func NewMetricsCollector(
 			metricType: common.CounterMetricType,
 		},
 	}
-	return &MetricsCollector{
+	mc := &MetricsCollector{
 		currentMetricState: baseState,
+		pushedSeries:       make(map[string]time.Time),
+		collectedNames:     make(map[string]struct{}),
+		maxPushedSeries:    maxPushedSeries,
 		client:             client,
 		grpcClient:         grpcClient,
 		cfg:                cfg,
 		logger:             logger,
 		mu:                 &sync.RWMutex{},
-	}
+		sendBreaker:        resilience.NewBreaker(breakerThreshold, breakerCooldown),
+		collectPolicy:      resilience.NewPolicy(resilience.NewBackoff(backoffBase, backoffMax)),
+		sendPolicy:         resilience.NewPolicy(resilience.NewBackoff(backoffBase, backoffMax)),
+	}
+	mc.RegisterCollector(collector.NewMemStats(), true)
+	mc.RegisterCollector(collector.NewGopsutil(), true)
+	// host collectors report many series, they are enabled in config
+	mc.RegisterCollector(collector.NewCPUTime(), false)
+	mc.RegisterCollector(collector.NewLoad(), false)
+	mc.RegisterCollector(collector.NewDisk(), false)
+	mc.RegisterCollector(collector.NewDiskIO(), false)
+	mc.RegisterCollector(collector.NewNet(), false)
+
+	return mc
 }
@@ -5,19 +5,25 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/Kopleman/metcol/internal/agent/collector"
 	"github.com/Kopleman/metcol/internal/agent/config"
+	"github.com/Kopleman/metcol/internal/agent/resilience"
 	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 type mockHTTP struct {
+	batchIDs      []string
 	postCallCount int
 }
 
@@ -26,6 +32,12 @@ func (m *mockHTTP) Post(_, _ string, _ []byte) ([]byte, error) {
 	return []byte("{}"), nil
 }
 
+func (m *mockHTTP) PostBatch(_, _, batchID string, _ []byte) ([]byte, error) {
+	m.postCallCount++
+	m.batchIDs = append(m.batchIDs, batchID)
+	return []byte("{}"), nil
+}
+
 func TestMetricsCollector_CollectMetrics(t *testing.T) {
 	mockCfg := config.Config{
 		EndPoint:       nil,
@@ -75,7 +87,15 @@ func TestMetricsCollector_CollectMetrics(t *testing.T) {
 			}
 
 			afterCallState := mc.GetState()
-			assert.Equal(t, 32, len(afterCallState))
+			// утилизация собирается по каждому ядру
+			cores := 0
+			for name := range afterCallState {
+				if strings.HasPrefix(name, "CPUutilization") {
+					cores++
+				}
+			}
+			assert.Positive(t, cores)
+			assert.Equal(t, 31, len(afterCallState)-cores)
 			assert.Equal(t, afterCallState["PollCount"].value, strconv.Itoa(tt.numOfRuns))
 		})
 	}
@@ -127,6 +147,48 @@ func TestMetricsCollector_SendMetrics(t *testing.T) {
 	}
 }
 
+func TestMetricsCollector_SendMetrics_BatchID(t *testing.T) {
+	mockClient := &mockHTTP{}
+	mc := NewMetricsCollector(&config.Config{}, log.MockLogger{}, mockClient, nil)
+	require.NoError(t, mc.CollectAllMetrics())
+
+	require.NoError(t, mc.SendMetrics())
+	require.NoError(t, mc.SendMetrics())
+
+	// каждая отправка получает собственный batch ID
+	require.Len(t, mockClient.batchIDs, 2)
+	assert.Len(t, mockClient.batchIDs[0], 2*batchIDSize)
+	assert.NotEqual(t, mockClient.batchIDs[0], mockClient.batchIDs[1])
+}
+
+func TestMetricsCollector_SendMetrics_Retry(t *testing.T) {
+	client := &flakyHTTP{err: errors.New("connection refused")}
+	mc := NewMetricsCollector(&config.Config{}, log.MockLogger{}, client, nil)
+	require.NoError(t, mc.CollectAllMetrics())
+
+	require.Error(t, mc.SendMetrics())
+	require.NoError(t, mc.CollectAllMetrics())
+	require.Error(t, mc.SendMetrics())
+	assert.Equal(t, "1", mc.currentMetricState[pollCountMetricName].value, "poll count is kept for next batch")
+
+	// недоставленная пачка повторяется с тем же batch ID
+	client.err = nil
+	require.NoError(t, mc.SendMetrics())
+	require.NoError(t, mc.SendMetrics())
+	require.Len(t, client.failedIDs, 2)
+	require.Len(t, client.batchIDs, 2)
+	assert.Equal(t, client.failedIDs[0], client.failedIDs[1])
+	assert.Equal(t, client.failedIDs[0], client.batchIDs[0])
+	assert.NotEqual(t, client.batchIDs[0], client.batchIDs[1])
+
+	// пачка, которую сервер не примет никогда, не блокирует следующие отчёты
+	client.err = resilience.Permanent(errors.New("status code 422"))
+	require.NoError(t, mc.SendMetrics())
+	client.err = nil
+	require.NoError(t, mc.SendMetrics())
+	assert.NotEqual(t, client.failedIDs[2], client.batchIDs[2])
+}
+
 type MockHTTPClient struct {
 	mock.Mock
 }
@@ -136,15 +198,23 @@ func (m *MockHTTPClient) Post(url, contentType string, body []byte) ([]byte, err
 	return args.Get(0).([]byte), args.Error(1) //nolint:all // tests
 }
 
+func (m *MockHTTPClient) PostBatch(url, contentType, batchID string, body []byte) ([]byte, error) {
+	args := m.Called(url, contentType, batchID, body)
+	return args.Get(0).([]byte), args.Error(1) //nolint:all // tests
+}
+
 func TestHandler(t *testing.T) {
 	t.Run("graceful shutdown", func(t *testing.T) {
+		mockClient := new(MockHTTPClient)
+		mockClient.On("PostBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]byte("{}"), nil).Maybe()
 		mc := NewMetricsCollector(
 			&config.Config{
 				PollInterval:   1,
 				ReportInterval: 1,
 			},
 			log.MockLogger{},
-			new(MockHTTPClient),
+			mockClient,
 			nil,
 		)
 
@@ -161,6 +231,88 @@ func TestHandler(t *testing.T) {
 		err := <-done
 		require.NoError(t, err)
 	})
+
+	t.Run("keeps running on transient send errors", func(t *testing.T) {
+		mockClient := new(MockHTTPClient)
+		mockClient.On("PostBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]byte{}, errors.New("status code 500"))
+		mc := NewMetricsCollector(
+			&config.Config{PollInterval: 1, ReportInterval: 1, RateLimit: 2},
+			log.MockLogger{},
+			mockClient,
+			nil,
+		)
+
+		sig := make(chan os.Signal, 1)
+		done := make(chan error)
+		go func() {
+			done <- mc.Handler(sig)
+		}()
+
+		time.Sleep(1500 * time.Millisecond)
+		sig <- os.Interrupt
+
+		require.NoError(t, <-done)
+		assert.Positive(t, mc.sendErrors.Load())
+	})
+
+	t.Run("stops on permanent error", func(t *testing.T) {
+		mc := NewMetricsCollector(
+			&config.Config{PollInterval: 1, ReportInterval: 1, RateLimit: 2},
+			log.MockLogger{},
+			&flakyHTTP{},
+			nil,
+		)
+		mc.currentMetricState["broken"] = MetricItem{value: "not a number", metricType: common.GaugeMetricType}
+
+		err := mc.Handler(make(chan os.Signal, 1))
+		require.Error(t, err)
+		assert.True(t, resilience.IsPermanent(err))
+	})
+}
+
+func TestMetricsCollector_HandleJobError(t *testing.T) {
+	mc := NewMetricsCollector(&config.Config{}, log.MockLogger{}, nil, nil)
+
+	require.NoError(t, mc.handleJobError("send", mc.sendPolicy, &mc.sendErrors, errors.New("timeout")))
+	assert.False(t, mc.sendPolicy.Ready(time.Now()), "next send waits for backoff")
+
+	permanent := resilience.Permanent(errors.New("bad key"))
+	require.ErrorIs(t, mc.handleJobError("send", mc.sendPolicy, &mc.sendErrors, permanent), permanent)
+
+	require.NoError(t, mc.handleJobError("send", mc.sendPolicy, &mc.sendErrors, nil))
+	assert.True(t, mc.sendPolicy.Ready(time.Now()))
+
+	mc.updateHealthMetrics()
+	sendErrorsItem := MetricItem{value: "2", metricType: common.CounterMetricType}
+	assert.Equal(t, sendErrorsItem, mc.currentMetricState[sendErrorsMetricName])
+	assert.Equal(t, "0", mc.currentMetricState[collectErrorsMetricName].value)
+	assert.Equal(t, "0", mc.currentMetricState[breakerStateMetricName].value)
+	assert.NotContains(t, mc.currentMetricState, spoolBatchesMetricName)
+
+	// после отправки счётчик ошибок получает только новый прирост
+	mc.resetCounters()
+	mc.updateHealthMetrics()
+	assert.Equal(t, "0", mc.currentMetricState[sendErrorsMetricName].value)
+	require.NoError(t, mc.handleJobError("send", mc.sendPolicy, &mc.sendErrors, errors.New("timeout")))
+	mc.updateHealthMetrics()
+	assert.Equal(t, "1", mc.currentMetricState[sendErrorsMetricName].value)
+}
+
+func TestMetricsCollector_SendBreaker(t *testing.T) {
+	client := &flakyHTTP{err: errors.New("connection refused")}
+	mc := NewMetricsCollector(&config.Config{}, log.MockLogger{}, client, nil)
+
+	for range breakerThreshold {
+		require.Error(t, mc.sendBatch("batch", nil))
+	}
+	assert.Equal(t, breakerThreshold, len(client.failedIDs))
+
+	// открытый выключатель не обращается к серверу
+	require.ErrorIs(t, mc.sendBatch("batch", nil), resilience.ErrOpen)
+	assert.Equal(t, breakerThreshold, len(client.failedIDs))
+	mc.updateHealthMetrics()
+	assert.Equal(t, "2", mc.currentMetricState[breakerStateMetricName].value)
 }
 
 func TestIncreasePollCounter(t *testing.T) {
@@ -196,6 +348,34 @@ func TestSendMetricsViaWorkers(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "worker error")
 	})
+
+	t.Run("state grows while sending", func(t *testing.T) {
+		// один воркер: mockHTTP не потокобезопасен
+		mc := NewMetricsCollector(&config.Config{RateLimit: 1}, log.MockLogger{}, &mockHTTP{}, nil)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := range 100 {
+				assert.NoError(t, mc.AddMetrics([]*dto.MetricDTO{
+					{ID: "Pushed" + strconv.Itoa(i), MType: common.GaugeMetricType, Value: testutils.Pointer(1.0)},
+				}))
+			}
+		}()
+		for range 10 {
+			require.NoError(t, mc.sendMetricsViaWorkers(context.Background()))
+		}
+		<-done
+	})
+
+	t.Run("stops on cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		client := &mockHTTP{}
+		mc := NewMetricsCollector(&config.Config{RateLimit: 1}, log.MockLogger{}, client, nil)
+
+		require.NoError(t, mc.sendMetricsViaWorkers(ctx))
+		assert.Zero(t, client.postCallCount)
+	})
 }
 
 func TestConcurrentAccess(t *testing.T) {
@@ -269,3 +449,405 @@ func TestConvertMetricItemToDto(t *testing.T) {
 		})
 	}
 }
+
+type flakyHTTP struct {
+	err       error
+	failedIDs []string
+	batchIDs  []string
+}
+
+func (m *flakyHTTP) Post(_, _ string, _ []byte) ([]byte, error) {
+	return nil, errors.New("not expected")
+}
+
+func (m *flakyHTTP) PostBatch(_, _, batchID string, _ []byte) ([]byte, error) {
+	if m.err != nil {
+		m.failedIDs = append(m.failedIDs, batchID)
+		return nil, m.err
+	}
+	m.batchIDs = append(m.batchIDs, batchID)
+	return []byte("{}"), nil
+}
+
+func TestMetricsCollector_SpoolMetrics(t *testing.T) {
+	client := &flakyHTTP{err: errors.New("connection refused")}
+	cfg := &config.Config{SpoolDir: t.TempDir()}
+	mc := NewMetricsCollector(cfg, log.MockLogger{}, client, nil)
+	require.NoError(t, mc.Init())
+	require.NotNil(t, mc.spool)
+
+	// сервер недоступен: пачки копятся в очереди, агент продолжает работу
+	for range 3 {
+		require.NoError(t, mc.CollectAllMetrics())
+		require.Error(t, mc.spoolMetrics())
+	}
+	assert.Equal(t, 3, mc.spool.Len())
+	assert.Equal(t, "0", mc.currentMetricState[pollCountMetricName].value, "poll count is stored in queued batch")
+
+	// очередь переживает перезапуск агента
+	restarted := NewMetricsCollector(cfg, log.MockLogger{}, client, nil)
+	require.NoError(t, restarted.Init())
+	assert.Equal(t, 3, restarted.spool.Len())
+
+	client.err = nil
+	require.NoError(t, restarted.CollectAllMetrics())
+	require.NoError(t, restarted.spoolMetrics())
+	assert.Equal(t, 0, restarted.spool.Len())
+	require.Len(t, client.batchIDs, 4)
+
+	// повтор пачки сохраняет её batch ID
+	client.err = errors.New("connection refused")
+	require.Error(t, restarted.spoolMetrics())
+	client.err = nil
+	require.NoError(t, restarted.spoolMetrics())
+	require.Len(t, client.batchIDs, 6)
+	assert.Equal(t, client.failedIDs[len(client.failedIDs)-1], client.batchIDs[4])
+	assert.NotEqual(t, client.batchIDs[4], client.batchIDs[5])
+
+	// пачка, которую сервер не примет никогда, удаляется из очереди
+	client.err = resilience.Permanent(errors.New("status code 422"))
+	require.NoError(t, restarted.spoolMetrics())
+	assert.Equal(t, 0, restarted.spool.Len())
+}
+
+type fakeCollector struct {
+	err     error
+	metrics map[string]collector.Metric
+	name    string
+	delay   time.Duration
+	runs    int
+}
+
+func (c *fakeCollector) Name() string {
+	return c.name
+}
+
+func (c *fakeCollector) Collect(ctx context.Context) (map[string]collector.Metric, error) {
+	c.runs++
+	if c.delay > 0 {
+		select {
+		case <-time.After(c.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return c.metrics, c.err
+}
+
+func TestMetricsCollector_RegisterCollector(t *testing.T) {
+	enabled, disabled := true, false
+	cfg := &config.Config{
+		EnabledCollectors: nil,
+		Collectors: map[string]config.CollectorConfig{
+			collector.GopsutilName: {Enabled: &disabled},
+			"custom":               {Enabled: &enabled, PollInterval: 10, Timeout: 3},
+		},
+		PollInterval: 2,
+	}
+	mc := NewMetricsCollector(cfg, log.MockLogger{}, nil, nil)
+	mc.RegisterCollector(&fakeCollector{name: "custom"}, false)
+	mc.RegisterCollector(&fakeCollector{name: "optional"}, false)
+
+	registered := make(map[string]*registeredCollector)
+	for _, rc := range mc.collectors {
+		registered[rc.collector.Name()] = rc
+	}
+	require.Len(t, registered, 2)
+	require.Contains(t, registered, collector.MemStatsName)
+	assert.Equal(t, 2*time.Second, registered[collector.MemStatsName].interval)
+	assert.Equal(t, 2*time.Second, registered[collector.MemStatsName].timeout)
+	require.Contains(t, registered, "custom")
+	assert.Equal(t, 10*time.Second, registered["custom"].interval)
+	assert.Equal(t, 3*time.Second, registered["custom"].timeout)
+}
+
+func TestMetricsCollector_CollectAllMetrics_Collectors(t *testing.T) {
+	newCollector := func(t *testing.T, collectors ...*fakeCollector) *MetricsCollector {
+		t.Helper()
+		names := make([]string, 0, len(collectors))
+		for _, c := range collectors {
+			names = append(names, c.name)
+		}
+		mc := NewMetricsCollector(&config.Config{EnabledCollectors: names}, log.MockLogger{}, nil, nil)
+		for _, c := range collectors {
+			mc.RegisterCollector(c, true)
+		}
+		require.Len(t, mc.collectors, len(collectors))
+		return mc
+	}
+
+	t.Run("results of collectors are merged", func(t *testing.T) {
+		mc := newCollector(t,
+			&fakeCollector{name: "a", metrics: map[string]collector.Metric{"Temp": collector.Gauge(36.6)}},
+			&fakeCollector{name: "b", metrics: map[string]collector.Metric{"Ticks": collector.Counter(2)}},
+		)
+		require.NoError(t, mc.CollectAllMetrics())
+		require.NoError(t, mc.CollectAllMetrics())
+
+		assert.Equal(t, MetricItem{value: "36.6", metricType: common.GaugeMetricType}, mc.currentMetricState["Temp"])
+		// дельты счётчика накапливаются до отправки
+		assert.Equal(t, MetricItem{value: "4", metricType: common.CounterMetricType}, mc.currentMetricState["Ticks"])
+	})
+
+	t.Run("series not reported anymore are removed", func(t *testing.T) {
+		pid1 := collector.SeriesKey("ProcessRSS", map[string]string{"pid": "1"})
+		pid2 := collector.SeriesKey("ProcessRSS", map[string]string{"pid": "2"})
+		reads := collector.SeriesKey("ProcessReads", map[string]string{"pid": "1"})
+		proc := &fakeCollector{name: "proc", metrics: map[string]collector.Metric{
+			pid1:  collector.Gauge(10),
+			reads: collector.Counter(3),
+		}}
+		mc := newCollector(t, proc)
+		require.NoError(t, mc.CollectAllMetrics())
+
+		// процесс 1 завершился: gauge удаляется сразу, счётчик - после отправки дельты
+		proc.metrics = map[string]collector.Metric{pid2: collector.Gauge(20)}
+		require.NoError(t, mc.CollectAllMetrics())
+		assert.NotContains(t, mc.currentMetricState, pid1)
+		assert.Equal(t, "3", mc.currentMetricState[reads].value)
+		assert.Contains(t, mc.currentMetricState, pid2)
+
+		mc.resetCounters()
+		require.NoError(t, mc.CollectAllMetrics())
+		assert.NotContains(t, mc.currentMetricState, reads)
+		assert.Contains(t, mc.currentMetricState, pollCountMetricName)
+	})
+
+	t.Run("failed collector does not discard others", func(t *testing.T) {
+		mc := newCollector(t,
+			&fakeCollector{name: "ok", metrics: map[string]collector.Metric{"Temp": collector.Gauge(1)}},
+			&fakeCollector{name: "broken", err: errors.New("sensor unavailable")},
+		)
+		require.NoError(t, mc.CollectAllMetrics())
+		assert.Contains(t, mc.currentMetricState, "Temp")
+		assert.Equal(t, int64(1), mc.collectErrors.Load())
+		assert.Equal(t, "1", mc.currentMetricState[pollCountMetricName].value)
+	})
+
+	t.Run("error when all collectors failed", func(t *testing.T) {
+		mc := newCollector(t, &fakeCollector{name: "broken", err: errors.New("sensor unavailable")})
+		err := mc.CollectAllMetrics()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "collector broken")
+	})
+
+	t.Run("slow collector times out", func(t *testing.T) {
+		slow := &fakeCollector{name: "slow", delay: time.Minute}
+		mc := NewMetricsCollector(&config.Config{
+			EnabledCollectors: []string{"slow"},
+			Collectors:        map[string]config.CollectorConfig{"slow": {Timeout: 1}},
+		}, log.MockLogger{}, nil, nil)
+		mc.RegisterCollector(slow, true)
+
+		start := time.Now()
+		err := mc.CollectAllMetrics()
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("collector runs once per its interval", func(t *testing.T) {
+		hourly := &fakeCollector{name: "hourly"}
+		mc := NewMetricsCollector(&config.Config{
+			EnabledCollectors: []string{"hourly"},
+			Collectors:        map[string]config.CollectorConfig{"hourly": {PollInterval: 3600}},
+		}, log.MockLogger{}, nil, nil)
+		mc.RegisterCollector(hourly, true)
+
+		require.NoError(t, mc.CollectAllMetrics())
+		require.NoError(t, mc.CollectAllMetrics())
+		assert.Equal(t, 1, hourly.runs)
+	})
+}
+
+func TestMetricsCollector_LabeledMetrics(t *testing.T) {
+	mc := NewMetricsCollector(&config.Config{EnabledCollectors: []string{"mounts"}}, log.MockLogger{}, nil, nil)
+	root := map[string]string{"mount": "/"}
+	boot := map[string]string{"mount": "/boot"}
+	mc.RegisterCollector(&fakeCollector{name: "mounts", metrics: map[string]collector.Metric{
+		collector.SeriesKey("DiskFree", root): {Labels: root, Type: common.GaugeMetricType, Value: 10},
+		collector.SeriesKey("DiskFree", boot): {Labels: boot, Type: common.GaugeMetricType, Value: 2},
+	}}, true)
+	require.NoError(t, mc.CollectAllMetrics())
+
+	batch, err := mc.snapshotBatch()
+	require.NoError(t, err)
+	free := make(map[string]float64)
+	for _, metric := range batch.Metrics {
+		if metric.ID == "DiskFree" {
+			free[metric.Labels["mount"]] = *metric.Value
+		}
+	}
+	assert.Equal(t, map[string]float64{"/": 10, "/boot": 2}, free)
+}
+
+func TestMetricsCollector_Init_ProcessCollector(t *testing.T) {
+	hasProcessCollector := func(mc *MetricsCollector) bool {
+		for _, rc := range mc.collectors {
+			if rc.collector.Name() == collector.ProcessName {
+				return true
+			}
+		}
+		return false
+	}
+
+	mc := NewMetricsCollector(&config.Config{}, log.MockLogger{}, nil, nil)
+	require.NoError(t, mc.Init())
+	assert.False(t, hasProcessCollector(mc), "no processes configured")
+
+	mc = NewMetricsCollector(&config.Config{
+		Processes: []config.ProcessTarget{{Name: "nginx"}},
+	}, log.MockLogger{}, nil, nil)
+	require.NoError(t, mc.Init())
+	assert.True(t, hasProcessCollector(mc))
+
+	mc = NewMetricsCollector(&config.Config{
+		Processes: []config.ProcessTarget{{Cmdline: "("}},
+	}, log.MockLogger{}, nil, nil)
+	require.ErrorContains(t, mc.Init(), "invalid cmdline regexp")
+}
+
+func TestMetricsCollector_ExecCollector(t *testing.T) {
+	mc := NewMetricsCollector(&config.Config{
+		EnabledCollectors: []string{"exec.orders"},
+		Exec:              []config.ExecCommand{{Name: "orders", Command: []string{"sh", "-c", "exit 1"}}},
+	}, log.MockLogger{}, nil, nil)
+	require.NoError(t, mc.Init())
+
+	// сбой команды не прерывает сбор, а попадает в метрики агента
+	require.NoError(t, mc.CollectAllMetrics())
+	failures := collector.SeriesKey("AgentExecErrors", map[string]string{"command": "orders"})
+	assert.Equal(t, "1", mc.currentMetricState[failures].value)
+	assert.Zero(t, mc.collectErrors.Load())
+
+	mc = NewMetricsCollector(&config.Config{
+		Exec: []config.ExecCommand{{Name: "orders"}},
+	}, log.MockLogger{}, nil, nil)
+	require.ErrorContains(t, mc.Init(), "exec command 'orders' is empty")
+}
+
+func TestMetricsCollector_AddMetrics(t *testing.T) {
+	mc := NewMetricsCollector(&config.Config{RateLimit: 2}, log.MockLogger{}, &mockHTTP{}, nil)
+	shop := map[string]string{"shop": "eu"}
+	orders := collector.SeriesKey("Orders", shop)
+
+	require.NoError(t, mc.AddMetrics([]*dto.MetricDTO{
+		{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(2)), Labels: shop},
+		{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1)), Labels: shop},
+		{ID: "Temp", MType: common.GaugeMetricType, Value: testutils.Pointer(1.5)},
+	}))
+	require.NoError(t, mc.AddMetrics([]*dto.MetricDTO{
+		{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(4)), Labels: shop},
+		{ID: "Temp", MType: common.GaugeMetricType, Value: testutils.Pointer(2.5)},
+	}))
+	ordersItem := MetricItem{labels: shop, value: "7", metricType: common.CounterMetricType}
+	assert.Equal(t, ordersItem, mc.currentMetricState[orders])
+	assert.Equal(t, MetricItem{value: "2.5", metricType: common.GaugeMetricType}, mc.currentMetricState["Temp"])
+
+	// пачка с некорректной метрикой отклоняется целиком
+	err := mc.AddMetrics([]*dto.MetricDTO{
+		{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1)), Labels: shop},
+		{ID: "Latency", MType: common.HistogramMetricType},
+	})
+	require.ErrorContains(t, err, "unsupported type")
+	assert.Equal(t, "7", mc.currentMetricState[orders].value)
+
+	// счётчики суммируются только между отправками
+	require.NoError(t, mc.SendMetrics())
+	assert.Equal(t, "0", mc.currentMetricState[orders].value)
+	assert.Equal(t, "2.5", mc.currentMetricState["Temp"].value)
+}
+
+func TestMetricsCollector_AddMetrics_Rejected(t *testing.T) {
+	gauge := func(name string, labels map[string]string) *dto.MetricDTO {
+		return &dto.MetricDTO{ID: name, MType: common.GaugeMetricType, Value: testutils.Pointer(1.0), Labels: labels}
+	}
+	counter := func(name string) *dto.MetricDTO {
+		return &dto.MetricDTO{ID: name, MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1))}
+	}
+
+	tests := []struct {
+		name    string
+		wantErr string
+		batch   []*dto.MetricDTO
+	}{
+		{name: "poll count", batch: []*dto.MetricDTO{counter(pollCountMetricName)}, wantErr: "reserved"},
+		{name: "self-metric", batch: []*dto.MetricDTO{counter(sendErrorsMetricName)}, wantErr: "reserved"},
+		{name: "collected metric", batch: []*dto.MetricDTO{gauge("Alloc", nil)}, wantErr: "reserved"},
+		{
+			name:    "collected labeled metric",
+			batch:   []*dto.MetricDTO{gauge("AgentExecUp", map[string]string{"command": "other"})},
+			wantErr: "reserved",
+		},
+		{name: "gauge over counter", batch: []*dto.MetricDTO{gauge("Orders", nil)}, wantErr: "is counter, not gauge"},
+		{
+			name:    "both types in batch",
+			batch:   []*dto.MetricDTO{gauge("Temp", nil), counter("Temp")},
+			wantErr: "pushed as both",
+		},
+		{
+			name:    "too many series",
+			batch:   []*dto.MetricDTO{gauge("Temp", map[string]string{"id": "1"}), gauge("Temp", map[string]string{"id": "2"})},
+			wantErr: "limit of 2 pushed series",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := NewMetricsCollector(&config.Config{}, log.MockLogger{}, nil, nil)
+			mc.maxPushedSeries = 2
+			mc.mergeMetrics(&registeredCollector{}, map[string]collector.Metric{
+				"Alloc":                         collector.Gauge(1),
+				`AgentExecUp{command="orders"}`: collector.Gauge(1),
+			})
+			require.NoError(t, mc.AddMetrics([]*dto.MetricDTO{counter("Orders")}))
+
+			err := mc.AddMetrics(tt.batch)
+			require.ErrorContains(t, err, tt.wantErr)
+			// пачка отклоняется целиком
+			assert.Equal(t, "1", mc.currentMetricState["Orders"].value)
+			assert.Len(t, mc.pushedSeries, 1)
+		})
+	}
+}
+
+func TestMetricsCollector_AddMetrics_Expire(t *testing.T) {
+	mc := NewMetricsCollector(&config.Config{}, log.MockLogger{}, &mockHTTP{}, nil)
+	mc.maxPushedSeries = 2
+	require.NoError(t, mc.AddMetrics([]*dto.MetricDTO{
+		{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(5))},
+		{ID: "Temp", MType: common.GaugeMetricType, Value: testutils.Pointer(2.5)},
+	}))
+	require.ErrorContains(t, mc.AddMetrics([]*dto.MetricDTO{
+		{ID: "Humidity", MType: common.GaugeMetricType, Value: testutils.Pointer(0.5)},
+	}), "limit of 2 pushed series")
+
+	// давно не обновлявшиеся серии удаляются, неотправленный прирост счётчика сохраняется
+	later := time.Now().Add(pushedSeriesTTL)
+	mc.expirePushedSeries(later)
+	assert.NotContains(t, mc.currentMetricState, "Temp")
+	assert.Equal(t, "5", mc.currentMetricState["Orders"].value)
+
+	require.NoError(t, mc.SendMetrics())
+	mc.expirePushedSeries(later)
+	assert.NotContains(t, mc.currentMetricState, "Orders")
+	assert.Empty(t, mc.pushedSeries)
+
+	// место под новые серии освобождается
+	require.NoError(t, mc.AddMetrics([]*dto.MetricDTO{
+		{ID: "Humidity", MType: common.GaugeMetricType, Value: testutils.Pointer(0.5)},
+	}))
+}
+
+func TestMetricsCollector_SettleCounters(t *testing.T) {
+	mc := NewMetricsCollector(&config.Config{RateLimit: 2}, log.MockLogger{}, &mockHTTP{}, nil)
+	require.NoError(t, mc.AddMetrics([]*dto.MetricDTO{
+		{ID: "Orders", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(5))},
+	}))
+
+	require.NoError(t, mc.sendMetricsViaWorkers(context.Background()))
+	assert.Equal(t, "0", mc.currentMetricState["Orders"].value)
+
+	// прирост, пришедший во время отправки, остаётся до следующего отчёта
+	mc.currentMetricState["Orders"] = MetricItem{value: "8", metricType: common.CounterMetricType}
+	mc.settleCounter("Orders", 5)
+	assert.Equal(t, "3", mc.currentMetricState["Orders"].value)
+}
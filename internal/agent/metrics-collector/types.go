@@ -5,6 +5,7 @@ import (
 )
 
 type MetricItem struct {
+	labels     map[string]string
 	value      string
 	metricType common.MetricType
 }
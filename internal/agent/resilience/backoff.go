@@ -0,0 +1,79 @@
+package resilience
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Backoff exponential backoff with jitter.
+type Backoff struct {
+	jitter func() float64
+	base   time.Duration
+	max    time.Duration
+}
+
+// NewBackoff creates backoff growing from base up to max.
+func NewBackoff(base, maxDelay time.Duration) *Backoff {
+	return &Backoff{jitter: rand.Float64, base: base, max: maxDelay}
+}
+
+// Delay returns delay after given number of consecutive failures. Delay is random in
+// [d/2, d], where d doubles with every failure, so agents do not retry in lockstep.
+func (b *Backoff) Delay(failures int) time.Duration {
+	if failures < 1 {
+		return 0
+	}
+	d := b.base
+	for i := 1; i < failures && d < b.max; i++ {
+		d *= 2
+	}
+	d = min(d, b.max)
+	half := d / 2
+	return half + time.Duration(b.jitter()*float64(d-half))
+}
+
+// Policy retry policy of job, job is not run until backoff after its last failure passes.
+type Policy struct {
+	retryAt  time.Time
+	backoff  *Backoff
+	failures int
+	mu       sync.Mutex
+}
+
+// NewPolicy creates policy with given backoff.
+func NewPolicy(backoff *Backoff) *Policy {
+	return &Policy{backoff: backoff}
+}
+
+// Ready reports whether job may run at now.
+func (p *Policy) Ready(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !now.Before(p.retryAt)
+}
+
+// Failure records failed run and returns delay before the next one.
+func (p *Policy) Failure(now time.Time) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures++
+	delay := p.backoff.Delay(p.failures)
+	p.retryAt = now.Add(delay)
+	return delay
+}
+
+// Success resets backoff.
+func (p *Policy) Success() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures = 0
+	p.retryAt = time.Time{}
+}
+
+// Failures returns number of consecutive failures.
+func (p *Policy) Failures() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failures
+}
@@ -0,0 +1,101 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State of circuit breaker.
+type State int
+
+const (
+	StateClosed   State = iota // calls pass through
+	StateHalfOpen              // single trial call checks whether server is back
+	StateOpen                  // calls fail fast
+)
+
+// ErrOpen returned instead of calling through open breaker.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Breaker opens after threshold consecutive failures and fails calls fast during cooldown,
+// then lets single trial call through. Successful trial closes breaker.
+type Breaker struct {
+	now       func() time.Time
+	openedAt  time.Time
+	cooldown  time.Duration
+	threshold int
+	failures  int
+	state     State
+	mu        sync.Mutex
+}
+
+// NewBreaker creates closed breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		now:       time.Now,
+		cooldown:  cooldown,
+		threshold: max(threshold, 1),
+	}
+}
+
+// Do calls fn unless breaker is open. Permanent errors say nothing about the callee
+// and do not change breaker state.
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.record(err)
+	return err
+}
+
+// State returns current breaker state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		return nil
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		return nil
+	default:
+		// trial call is in flight
+		return ErrOpen
+	}
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if IsPermanent(err) {
+		// permanent error says nothing about callee health, next call makes the trial
+		if b.state == StateHalfOpen {
+			b.state = StateOpen
+		}
+		return
+	}
+	if err == nil {
+		b.failures = 0
+		b.state = StateClosed
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
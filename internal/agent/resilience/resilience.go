@@ -0,0 +1,32 @@
+// Package resilience is error policy of agent jobs: error classification, backoff and circuit breaker.
+package resilience
+
+import "errors"
+
+// permanentError error retrying does not fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as permanent, e.g. broken config or data which never gets sent.
+// Errors not marked are transient.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether any error in err's chain is marked as permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,112 @@
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermanent(t *testing.T) {
+	base := errors.New("broken key")
+	wrapped := fmt.Errorf("send: %w", Permanent(base))
+
+	assert.True(t, IsPermanent(wrapped))
+	assert.ErrorIs(t, wrapped, base)
+	assert.False(t, IsPermanent(base))
+	assert.NoError(t, Permanent(nil))
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	tests := []struct {
+		name     string
+		jitter   float64
+		failures int
+		want     time.Duration
+	}{
+		{name: "no failures", failures: 0, want: 0},
+		{name: "first failure without jitter", failures: 1, jitter: 1, want: time.Second},
+		{name: "delay doubles", failures: 3, jitter: 1, want: 4 * time.Second},
+		{name: "delay is capped", failures: 30, jitter: 1, want: time.Minute},
+		{name: "jitter halves delay at most", failures: 3, jitter: 0, want: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff(time.Second, time.Minute)
+			b.jitter = func() float64 { return tt.jitter }
+			assert.Equal(t, tt.want, b.Delay(tt.failures))
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	now := time.Now()
+	b := NewBackoff(time.Second, time.Minute)
+	b.jitter = func() float64 { return 1 }
+	p := NewPolicy(b)
+
+	require.True(t, p.Ready(now))
+	assert.Equal(t, time.Second, p.Failure(now))
+	assert.False(t, p.Ready(now.Add(500*time.Millisecond)))
+	assert.True(t, p.Ready(now.Add(time.Second)))
+
+	assert.Equal(t, 2*time.Second, p.Failure(now))
+	assert.Equal(t, 2, p.Failures())
+
+	p.Success()
+	assert.Zero(t, p.Failures())
+	assert.True(t, p.Ready(now))
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+	calls := 0
+	fail := func() error { calls++; return failure }
+	succeed := func() error { calls++; return nil }
+
+	require.ErrorIs(t, b.Do(fail), failure)
+	assert.Equal(t, StateClosed, b.State())
+	require.ErrorIs(t, b.Do(fail), failure)
+	assert.Equal(t, StateOpen, b.State())
+
+	// во время паузы вызовы не доходят до сервера
+	require.ErrorIs(t, b.Do(succeed), ErrOpen)
+	assert.Equal(t, 2, calls)
+
+	// неудачная пробная попытка снова открывает выключатель
+	now = now.Add(time.Minute)
+	require.ErrorIs(t, b.Do(fail), failure)
+	assert.Equal(t, StateOpen, b.State())
+	require.ErrorIs(t, b.Do(succeed), ErrOpen)
+
+	// постоянная ошибка не закрывает выключатель
+	now = now.Add(time.Minute)
+	require.Error(t, b.Do(func() error { return Permanent(errors.New("bad data")) }))
+	assert.Equal(t, StateOpen, b.State())
+
+	require.NoError(t, b.Do(succeed))
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_SingleTrial(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+	require.Error(t, b.Do(func() error { return errors.New("timeout") }))
+
+	now = now.Add(time.Minute)
+	err := b.Do(func() error {
+		assert.Equal(t, StateHalfOpen, b.State())
+		// параллельный вызов во время пробной попытки отклоняется
+		assert.ErrorIs(t, b.Do(func() error { return nil }), ErrOpen)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StateClosed, b.State())
+}
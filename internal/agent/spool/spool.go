@@ -0,0 +1,287 @@
+// Package spool is disk-backed write-ahead queue of metric batches agent failed to deliver.
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/agent/resilience"
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+const (
+	batchFileExt = ".json"
+	tmpFileExt   = ".tmp"
+)
+
+// ErrTooLarge returned when single batch does not fit into queue size limit.
+var ErrTooLarge = errors.New("batch exceeds spool size limit")
+
+// Batch queued metrics batch. ID is kept, so replayed batch is recognized by server as
+// already applied if it was delivered before.
+type Batch struct {
+	CreatedAt time.Time        `json:"created_at"`
+	ID        string           `json:"id"`
+	Metrics   []*dto.MetricDTO `json:"metrics"`
+}
+
+// segment batch file on disk.
+type segment struct {
+	path string
+	seq  uint64
+	size int64
+}
+
+// Queue stores every batch in its own file, named by sequence number, so batches are
+// replayed in the order they were pushed, also after agent restart.
+type Queue struct {
+	now      func() time.Time
+	dir      string
+	segments []segment
+	maxSize  int64
+	maxAge   time.Duration
+	size     int64
+	nextSeq  uint64
+	dropped  uint64
+	mu       sync.Mutex
+	replayMu sync.Mutex // one batch is never sent by concurrent replays
+}
+
+// Open opens queue in dir, creating dir if needed. Batches left by previous run are kept.
+// Zero maxSize or maxAge disables corresponding limit.
+func Open(dir string, maxSize int64, maxAge time.Duration) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool dir: %w", err)
+	}
+
+	q := &Queue{
+		now:     time.Now,
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		// batch was not completely written before crash
+		if strings.HasSuffix(name, tmpFileExt) {
+			if rmErr := os.Remove(path); rmErr != nil {
+				return nil, fmt.Errorf("failed to remove incomplete batch: %w", rmErr)
+			}
+			continue
+		}
+		seq, parseErr := strconv.ParseUint(strings.TrimSuffix(name, batchFileExt), 10, 64)
+		if !strings.HasSuffix(name, batchFileExt) || parseErr != nil {
+			continue
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return nil, fmt.Errorf("failed to stat batch file: %w", infoErr)
+		}
+		q.segments = append(q.segments, segment{path: path, seq: seq, size: info.Size()})
+		q.size += info.Size()
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].seq < q.segments[j].seq })
+	if len(q.segments) > 0 {
+		q.nextSeq = q.segments[len(q.segments)-1].seq + 1
+	}
+
+	return q, nil
+}
+
+// Push appends batch to the end of queue. Oldest batches are dropped if queue would
+// exceed size limit.
+func (q *Queue) Push(batch *Batch) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	size := int64(len(data))
+	if q.maxSize > 0 && size > q.maxSize {
+		return ErrTooLarge
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.maxSize > 0 && len(q.segments) > 0 && q.size+size > q.maxSize {
+		if err = q.dropOldest(); err != nil {
+			return err
+		}
+	}
+
+	seg := segment{
+		path: filepath.Join(q.dir, fmt.Sprintf("%020d%s", q.nextSeq, batchFileExt)),
+		seq:  q.nextSeq,
+		size: size,
+	}
+	if err = writeFile(seg.path, data); err != nil {
+		return err
+	}
+	q.nextSeq++
+	q.segments = append(q.segments, seg)
+	q.size += size
+
+	return nil
+}
+
+// Replay passes queued batches to send oldest first, batch is removed from queue once
+// send succeeds. Replay stops on first send error and returns it, remaining batches are
+// kept for the next replay. Batches rejected with permanent error or older than age limit
+// are dropped. Queue is not locked while batch is sent, so Push is not blocked by slow server.
+func (q *Queue) Replay(send func(batch *Batch) error) (int, error) {
+	q.replayMu.Lock()
+	defer q.replayMu.Unlock()
+
+	sent := 0
+	for {
+		seq, batch, err := q.head()
+		if err != nil || batch == nil {
+			return sent, err
+		}
+
+		sendErr := send(batch)
+		if sendErr != nil && !resilience.IsPermanent(sendErr) {
+			return sent, fmt.Errorf("failed to replay batch '%s': %w", batch.ID, sendErr)
+		}
+		// batch with permanent error never gets delivered, keeping it would block whole queue
+		if err = q.remove(seq, sendErr != nil); err != nil {
+			return sent, err
+		}
+		if sendErr == nil {
+			sent++
+		}
+	}
+}
+
+// head returns oldest deliverable batch, nil if queue is empty. Unreadable and expired
+// batches are dropped.
+func (q *Queue) head() (uint64, *Batch, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.segments) > 0 {
+		seg := q.segments[0]
+		batch, err := readFile(seg.path)
+		if err != nil {
+			// unreadable batch never gets delivered, keeping it would block whole queue
+			if dropErr := q.dropOldest(); dropErr != nil {
+				return 0, nil, dropErr
+			}
+			continue
+		}
+		if q.maxAge > 0 && q.now().Sub(batch.CreatedAt) > q.maxAge {
+			if err = q.dropOldest(); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		return seg.seq, batch, nil
+	}
+	return 0, nil, nil
+}
+
+// remove removes replayed batch unless Push has already dropped it while batch was sent.
+func (q *Queue) remove(seq uint64, dropped bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.segments) == 0 || q.segments[0].seq != seq {
+		return nil
+	}
+	if dropped {
+		return q.dropOldest()
+	}
+	return q.removeOldest()
+}
+
+// Len returns number of queued batches.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.segments)
+}
+
+// Size returns total size of queued batches in bytes.
+func (q *Queue) Size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Dropped returns number of batches dropped due to size or age limits or rejected by server since Open.
+func (q *Queue) Dropped() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.dropped
+}
+
+func (q *Queue) dropOldest() error {
+	if err := q.removeOldest(); err != nil {
+		return err
+	}
+	q.dropped++
+	return nil
+}
+
+func (q *Queue) removeOldest() error {
+	seg := q.segments[0]
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove batch file: %w", err)
+	}
+	q.segments = q.segments[1:]
+	q.size -= seg.size
+	return nil
+}
+
+// writeFile writes batch to temporary file first, so crash never leaves partial batch in queue.
+func writeFile(path string, data []byte) error {
+	tmpPath := path + tmpFileExt
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create batch file: %w", err)
+	}
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write batch file: %w", err)
+	}
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to sync batch file: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to close batch file: %w", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to commit batch file: %w", err)
+	}
+	return nil
+}
+
+func readFile(path string) (*Batch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	batch := new(Batch)
+	if err = json.Unmarshal(data, batch); err != nil {
+		return nil, fmt.Errorf("failed to decode batch file: %w", err)
+	}
+	return batch, nil
+}
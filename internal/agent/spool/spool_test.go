@@ -0,0 +1,170 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/agent/resilience"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBatch(id string, createdAt time.Time) *Batch {
+	return &Batch{
+		CreatedAt: createdAt,
+		ID:        id,
+		Metrics: []*dto.MetricDTO{
+			{ID: "PollCount", MType: "counter", Delta: testutils.Pointer(int64(1))},
+		},
+	}
+}
+
+func replayIDs(t *testing.T, q *Queue) []string {
+	t.Helper()
+	ids := make([]string, 0)
+	_, err := q.Replay(func(batch *Batch) error {
+		ids = append(ids, batch.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	return ids
+}
+
+func TestQueue_ReplayInOrder(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	q, err := Open(dir, 0, 0)
+	require.NoError(t, err)
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, q.Push(newBatch(id, now)))
+	}
+	assert.Equal(t, 3, q.Len())
+
+	// сбой доставки останавливает воспроизведение, остаток сохраняется
+	failure := errors.New("server is down")
+	sent, err := q.Replay(func(batch *Batch) error {
+		if batch.ID == "b" {
+			return failure
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, failure)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 2, q.Len())
+
+	assert.Equal(t, []string{"b", "c"}, replayIDs(t, q))
+	assert.Equal(t, 0, q.Len())
+	assert.Zero(t, q.Size())
+}
+
+func TestQueue_ReplayDropsRejected(t *testing.T) {
+	q, err := Open(t.TempDir(), 0, 0)
+	require.NoError(t, err)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, q.Push(newBatch(id, time.Now())))
+	}
+
+	// отклонённая сервером пачка не блокирует очередь
+	sent, err := q.Replay(func(batch *Batch) error {
+		if batch.ID == "a" {
+			return resilience.Permanent(errors.New("status code 422"))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, uint64(1), q.Dropped())
+}
+
+func TestQueue_PushDuringReplay(t *testing.T) {
+	q, err := Open(t.TempDir(), 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, q.Push(newBatch("a", time.Now())))
+
+	// очередь не заблокирована, пока пачка отправляется
+	sent, err := q.Replay(func(batch *Batch) error {
+		if batch.ID == "a" {
+			require.NoError(t, q.Push(newBatch("b", time.Now())))
+			assert.Equal(t, 2, q.Len())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestQueue_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	q, err := Open(dir, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, q.Push(newBatch("a", now)))
+	require.NoError(t, q.Push(newBatch("b", now)))
+
+	// незавершённая запись и посторонние файлы игнорируются
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000002.json.tmp"), []byte("{"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("notes"), 0o600))
+
+	reopened, err := Open(dir, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+	assert.NoFileExists(t, filepath.Join(dir, "00000000000000000002.json.tmp"))
+
+	require.NoError(t, reopened.Push(newBatch("c", now)))
+	assert.Equal(t, []string{"a", "b", "c"}, replayIDs(t, reopened))
+}
+
+func TestQueue_Limits(t *testing.T) {
+	now := time.Now()
+	batchSize := func(t *testing.T) int64 {
+		t.Helper()
+		q, err := Open(t.TempDir(), 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, q.Push(newBatch("a", now)))
+		return q.Size()
+	}(t)
+
+	t.Run("oldest batches are dropped when size limit is reached", func(t *testing.T) {
+		q, err := Open(t.TempDir(), 2*batchSize, 0)
+		require.NoError(t, err)
+		for _, id := range []string{"a", "b", "c"} {
+			require.NoError(t, q.Push(newBatch(id, now)))
+		}
+		assert.Equal(t, uint64(1), q.Dropped())
+		assert.Equal(t, []string{"b", "c"}, replayIDs(t, q))
+	})
+
+	t.Run("batch larger than limit is rejected", func(t *testing.T) {
+		q, err := Open(t.TempDir(), batchSize-1, 0)
+		require.NoError(t, err)
+		require.ErrorIs(t, q.Push(newBatch("a", now)), ErrTooLarge)
+		assert.Equal(t, 0, q.Len())
+	})
+
+	t.Run("expired batches are dropped on replay", func(t *testing.T) {
+		q, err := Open(t.TempDir(), 0, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, q.Push(newBatch("old", now.Add(-2*time.Hour))))
+		require.NoError(t, q.Push(newBatch("fresh", now)))
+		assert.Equal(t, []string{"fresh"}, replayIDs(t, q))
+		assert.Equal(t, uint64(1), q.Dropped())
+	})
+
+	t.Run("corrupted batch is dropped on replay", func(t *testing.T) {
+		dir := t.TempDir()
+		q, err := Open(dir, 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, q.Push(newBatch("broken", now)))
+		require.NoError(t, q.Push(newBatch("ok", now)))
+		require.NoError(t, os.WriteFile(q.segments[0].path, []byte("{"), 0o600))
+		assert.Equal(t, []string{"ok"}, replayIDs(t, q))
+	})
+}
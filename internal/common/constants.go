@@ -8,3 +8,12 @@ const ErrDtoParse = "unable to parse dto"
 const ContentType = "Content-Type"
 const AcceptEncoding = "Accept-Encoding"
 const HashSHA256 = "HashSHA256"
+
+// BatchIDHeader HTTP header with client generated ID of metrics batch, retried batch keeps its ID.
+const BatchIDHeader = "X-Metcol-Batch-ID"
+
+// BatchIDMetadataKey gRPC metadata key with client generated ID of metrics batch.
+const BatchIDMetadataKey = "x-metcol-batch-id"
+
+// MaxBatchIDLength limits length of batch ID.
+const MaxBatchIDLength = 255
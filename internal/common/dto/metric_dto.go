@@ -4,14 +4,33 @@ package dto
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/Kopleman/metcol/internal/common"
 )
 
 // GetValueRequest dto for fetching metric data.
 type GetValueRequest struct {
-	ID    string            `json:"id"`   // metric name.
-	MType common.MetricType `json:"type"` // metric type - gauge or counter.
+	Labels map[string]string `json:"labels,omitempty"` // metric labels.
+	ID     string            `json:"id"`               // metric name.
+	MType  common.MetricType `json:"type"`             // metric type - gauge or counter.
+}
+
+// LabelsKey builds canonical representation of label set, e.g. `host="a",service="b"`.
+// Empty label set produces empty string.
+func LabelsKey(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := slices.Sorted(maps.Keys(labels))
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+strconv.Quote(labels[k]))
+	}
+	return strings.Join(pairs, ",")
 }
 
 func parseType(metricTypeAsString string) (common.MetricType, error) {
@@ -51,12 +70,90 @@ func (gr *GetValueRequest) UnmarshalJSON(data []byte) (err error) {
 	return nil
 }
 
+// HistogramDTO histogram data contract.
+type HistogramDTO struct {
+	Bounds []float64 `json:"bounds"` // upper bounds of buckets in ascending order
+	Counts []uint64  `json:"counts"` // observations per bucket, last one is +Inf bucket
+	Sum    float64   `json:"sum"`    // sum of all observations
+	Count  uint64    `json:"count"`  // total number of observations
+}
+
+// QuantileDTO quantile of summary.
+type QuantileDTO struct {
+	Quantile float64 `json:"quantile"` // quantile rank from 0 to 1
+	Value    float64 `json:"value"`    // observed value at the rank
+}
+
+// SummaryDTO summary data contract.
+type SummaryDTO struct {
+	Quantiles []QuantileDTO `json:"quantiles"` // quantiles calculated by client, ascending by rank
+	Sum       float64       `json:"sum"`       // sum of all observations
+	Count     uint64        `json:"count"`     // total number of observations
+}
+
 // MetricDTO metric data contract.
 type MetricDTO struct {
-	Delta *int64            `json:"delta,omitempty"` // value for counter type
-	Value *float64          `json:"value,omitempty"` // value for gauge type
-	ID    string            `json:"id"`              // metric name
-	MType common.MetricType `json:"type"`            // metric type
+	Delta     *int64            `json:"delta,omitempty"`     // value for counter type
+	Value     *float64          `json:"value,omitempty"`     // value for gauge type
+	Histogram *HistogramDTO     `json:"histogram,omitempty"` // value for histogram type
+	Summary   *SummaryDTO       `json:"summary,omitempty"`   // value for summary type
+	Labels    map[string]string `json:"labels,omitempty"`    // metric labels, part of metric identity
+	ID        string            `json:"id"`                  // metric name
+	MType     common.MetricType `json:"type"`                // metric type
+	Agent     string            `json:"agent,omitempty"`     // authenticated agent made last update
+	Namespace string            `json:"namespace,omitempty"` // tenant namespace, part of metric identity
+}
+
+// FullName returns metric name with its label set, e.g. `Alloc{host="a"}`.
+func (m *MetricDTO) FullName() string {
+	labelsKey := LabelsKey(m.Labels)
+	if labelsKey == "" {
+		return m.ID
+	}
+	return m.ID + "{" + labelsKey + "}"
+}
+
+// SameIdentity checks that both metrics share namespace, name, type and label set.
+func (m *MetricDTO) SameIdentity(other *MetricDTO) bool {
+	return m.Namespace == other.Namespace &&
+		m.MType == other.MType &&
+		m.ID == other.ID &&
+		maps.Equal(m.Labels, other.Labels)
+}
+
+// Clone returns deep copy of metric.
+func (m *MetricDTO) Clone() *MetricDTO {
+	c := &MetricDTO{
+		ID:        m.ID,
+		MType:     m.MType,
+		Labels:    maps.Clone(m.Labels),
+		Agent:     m.Agent,
+		Namespace: m.Namespace,
+	}
+	if m.Delta != nil {
+		delta := *m.Delta
+		c.Delta = &delta
+	}
+	if m.Value != nil {
+		value := *m.Value
+		c.Value = &value
+	}
+	if m.Histogram != nil {
+		c.Histogram = &HistogramDTO{
+			Bounds: slices.Clone(m.Histogram.Bounds),
+			Counts: slices.Clone(m.Histogram.Counts),
+			Sum:    m.Histogram.Sum,
+			Count:  m.Histogram.Count,
+		}
+	}
+	if m.Summary != nil {
+		c.Summary = &SummaryDTO{
+			Quantiles: slices.Clone(m.Summary.Quantiles),
+			Sum:       m.Summary.Sum,
+			Count:     m.Summary.Count,
+		}
+	}
+	return c
 }
 
 // MarshalJSON interface implementation.
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+)
+
+// Aggregation function applied to samples of each time bucket.
+type Aggregation string
+
+const (
+	AggregationAvg  Aggregation = "avg"  // average value
+	AggregationMin  Aggregation = "min"  // minimal value
+	AggregationMax  Aggregation = "max"  // maximal value
+	AggregationSum  Aggregation = "sum"  // sum of values
+	AggregationRate Aggregation = "rate" // per-second increase, counters only
+)
+
+// QueryRequest dto for range query over metric history.
+type QueryRequest struct {
+	From        time.Time         `json:"from"`             // range start, inclusive
+	To          time.Time         `json:"to"`               // range end, inclusive
+	Labels      map[string]string `json:"labels,omitempty"` // metric labels
+	ID          string            `json:"id"`               // metric name
+	MType       common.MetricType `json:"type"`             // metric type
+	Aggregation Aggregation       `json:"aggregation"`      // aggregation applied to each bucket
+	Step        time.Duration     `json:"step"`             // bucket width
+}
+
+// PointDTO aggregated value of single time bucket.
+type PointDTO struct {
+	Timestamp time.Time `json:"timestamp"` // bucket start
+	Value     float64   `json:"value"`     // aggregated value
+}
+
+// SeriesDTO result of range query.
+type SeriesDTO struct {
+	Labels      map[string]string `json:"labels,omitempty"` // metric labels
+	ID          string            `json:"id"`               // metric name
+	MType       common.MetricType `json:"type"`             // metric type
+	Aggregation Aggregation       `json:"aggregation"`      // applied aggregation
+	Points      []*PointDTO       `json:"points"`           // buckets with data
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+// SampleDTO single timestamped state of metric series.
+type SampleDTO struct {
+	Delta     *int64        `json:"delta,omitempty"`     // counter value at the moment
+	Value     *float64      `json:"value,omitempty"`     // gauge value at the moment
+	Histogram *HistogramDTO `json:"histogram,omitempty"` // histogram state at the moment
+	Summary   *SummaryDTO   `json:"summary,omitempty"`   // summary state at the moment
+	Timestamp time.Time     `json:"timestamp"`           // when update was accepted
+	Agent     string        `json:"agent,omitempty"`     // authenticated agent made update
+}
+
+// NewSampleDTO makes sample from current metric state.
+func NewSampleDTO(metric *MetricDTO, ts time.Time) *SampleDTO {
+	return &SampleDTO{
+		Delta:     metric.Delta,
+		Value:     metric.Value,
+		Histogram: metric.Histogram,
+		Summary:   metric.Summary,
+		Timestamp: ts,
+		Agent:     metric.Agent,
+	}
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Kopleman/metcol/internal/common"
+)
+
+// WatchFilter selects metric updates delivered to subscriber.
+type WatchFilter struct {
+	Prefix    string              // metric name prefix, empty matches any name
+	Types     []common.MetricType // accepted types, empty matches any type
+	Allowed   []string            // name prefixes subscriber may read, empty allows any name
+	Namespace string              // namespace of subscriber, only its metrics are delivered
+}
+
+// Match checks metric against filter.
+func (f WatchFilter) Match(m *MetricDTO) bool {
+	if m.Namespace != f.Namespace {
+		return false
+	}
+	if !strings.HasPrefix(m.ID, f.Prefix) {
+		return false
+	}
+	if len(f.Allowed) > 0 && !slices.ContainsFunc(f.Allowed, func(p string) bool {
+		return strings.HasPrefix(m.ID, p)
+	}) {
+		return false
+	}
+	return len(f.Types) == 0 || slices.Contains(f.Types, m.MType)
+}
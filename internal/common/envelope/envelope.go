@@ -0,0 +1,205 @@
+// Package envelope implements hybrid payload encryption: data is sealed with random AES-256-GCM key,
+// the key itself is wrapped with RSA-OAEP(SHA-256). Payload size is not limited by RSA modulus.
+//
+// Sealed payload layout:
+//
+//	magic "MCE" | version (1 byte) | key id length (1 byte) | key id |
+//	wrapped key length (uint16, big endian) | wrapped key | nonce | ciphertext
+//
+// Key id tells receiver which private key to use, so several keys can be in use during rotation.
+// Version 1 payloads have no key id fields.
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	version1      byte = 1
+	version2      byte = 2
+	aesKeySize         = 32
+	keyLengthSize      = 2
+	maxKeyIDSize       = 255
+	// KeyIDHeader PEM header carrying key id.
+	KeyIDHeader = "Key-Id"
+)
+
+var magic = []byte("MCE")
+
+var ErrMalformed = errors.New("malformed envelope")
+
+// PublicKey RSA public key with optional id of matching private key.
+type PublicKey struct {
+	Key *rsa.PublicKey
+	ID  string
+}
+
+type header struct {
+	keyID      string
+	wrappedKey []byte
+	rest       []byte
+}
+
+// IsSealed checks whether data starts with envelope header.
+func IsSealed(data []byte) bool {
+	return len(data) > len(magic) && bytes.HasPrefix(data, magic)
+}
+
+// Seal encrypts data for owner of private key matching pub.
+func Seal(pub *PublicKey, data []byte) ([]byte, error) {
+	if len(pub.ID) > maxKeyIDSize {
+		return nil, fmt.Errorf("key id is longer than %d bytes", maxKeyIDSize)
+	}
+
+	aesKey := make([]byte, aesKeySize)
+	if _, err := rand.Read(aesKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub.Key, aesKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := make([]byte, 0, len(magic)+2+len(pub.ID)+keyLengthSize+len(wrappedKey)+len(nonce))
+	header = append(header, magic...)
+	header = append(header, version2, byte(len(pub.ID)))
+	header = append(header, pub.ID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrappedKey))) //nolint:gosec // RSA output is far below 64k
+	header = append(header, wrappedKey...)
+	header = append(header, nonce...)
+
+	// header is authenticated too, so wrapped key can not be swapped
+	return gcm.Seal(header, nonce, data, header), nil
+}
+
+// KeyID returns id of key payload was sealed for, empty for payloads without id.
+func KeyID(sealed []byte) (string, error) {
+	h, err := parseHeader(sealed)
+	if err != nil {
+		return "", err
+	}
+	return h.keyID, nil
+}
+
+// Open decrypts payload produced by Seal.
+func Open(priv *rsa.PrivateKey, sealed []byte) ([]byte, error) {
+	h, err := parseHeader(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	aesKey, err := rsa.DecryptOAEP(sha256.New(), nil, priv, h.wrappedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	gcm, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.rest) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: truncated nonce", ErrMalformed)
+	}
+	nonce := h.rest[:gcm.NonceSize()]
+	ciphertext := h.rest[gcm.NonceSize():]
+	aad := sealed[:len(sealed)-len(ciphertext)]
+
+	data, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return data, nil
+}
+
+func parseHeader(sealed []byte) (*header, error) {
+	if !IsSealed(sealed) {
+		return nil, fmt.Errorf("%w: missing header", ErrMalformed)
+	}
+	rest := sealed[len(magic):]
+	version := rest[0]
+	rest = rest[1:]
+
+	h := &header{}
+	switch version {
+	case version1:
+	case version2:
+		if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+			return nil, fmt.Errorf("%w: truncated key id", ErrMalformed)
+		}
+		idLen := int(rest[0])
+		h.keyID = string(rest[1 : 1+idLen])
+		rest = rest[1+idLen:]
+	default:
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrMalformed, version)
+	}
+
+	if len(rest) < keyLengthSize {
+		return nil, fmt.Errorf("%w: truncated key length", ErrMalformed)
+	}
+	keyLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[keyLengthSize:]
+	if len(rest) < keyLen {
+		return nil, fmt.Errorf("%w: truncated wrapped key", ErrMalformed)
+	}
+	h.wrappedKey = rest[:keyLen]
+	h.rest = rest[keyLen:]
+
+	return h, nil
+}
+
+// LoadPublicKey reads PEM encoded PKIX RSA public key, key id is taken from Key-Id PEM header.
+func LoadPublicKey(path string) (*PublicKey, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key file: %w", err)
+	}
+
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return nil, errors.New("failed to parse public key PEM block")
+	}
+
+	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("failed to parse public key bytes")
+	}
+
+	rsaKey, ok := pubKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not RSA public key")
+	}
+	return &PublicKey{Key: rsaKey, ID: block.Headers[KeyIDHeader]}, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init gcm: %w", err)
+	}
+	return gcm, nil
+}
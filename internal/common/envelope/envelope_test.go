@@ -0,0 +1,161 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func TestSealOpen(t *testing.T) {
+	key := newKey(t)
+
+	tests := []struct {
+		name  string
+		keyID string
+		data  []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "small", data: []byte(`{"id":"foo","type":"gauge","value":1}`)},
+		{name: "larger than rsa limit", data: bytes.Repeat([]byte("metric"), 10_000)},
+		{name: "with key id", keyID: "20261017120000", data: []byte("payload")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := Seal(&PublicKey{Key: &key.PublicKey, ID: tt.keyID}, tt.data)
+			require.NoError(t, err)
+			assert.True(t, IsSealed(sealed))
+
+			keyID, err := KeyID(sealed)
+			require.NoError(t, err)
+			assert.Equal(t, tt.keyID, keyID)
+
+			opened, err := Open(key, sealed)
+			require.NoError(t, err)
+			assert.Equal(t, tt.data, opened)
+		})
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	key := newKey(t)
+	sealed, err := Seal(&PublicKey{Key: &key.PublicKey}, []byte("payload"))
+	require.NoError(t, err)
+
+	tamper := func(pos int) []byte {
+		out := bytes.Clone(sealed)
+		out[pos] ^= 0xff
+		return out
+	}
+	wrongVersion := bytes.Clone(sealed)
+	wrongVersion[len(magic)] = 3
+
+	tests := []struct {
+		name      string
+		key       *rsa.PrivateKey
+		data      []byte
+		malformed bool
+	}{
+		{name: "not sealed", key: key, data: []byte("plain"), malformed: true},
+		{name: "unsupported version", key: key, data: wrongVersion, malformed: true},
+		{name: "truncated key id", key: key, data: []byte("MCE\x02\x05ab"), malformed: true},
+		{name: "truncated key length", key: key, data: sealed[:len(magic)+3], malformed: true},
+		{name: "truncated wrapped key", key: key, data: sealed[:len(magic)+10], malformed: true},
+		{name: "tampered ciphertext", key: key, data: tamper(len(sealed) - 1)},
+		{name: "tampered wrapped key", key: key, data: tamper(len(magic) + 10)},
+		{name: "wrong key", key: newKey(t), data: sealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(tt.key, tt.data)
+			require.Error(t, err)
+			assert.Equal(t, tt.malformed, errors.Is(err, ErrMalformed))
+		})
+	}
+}
+
+func TestSeal_KeyIDTooLong(t *testing.T) {
+	key := newKey(t)
+	_, err := Seal(&PublicKey{Key: &key.PublicKey, ID: strings.Repeat("a", 256)}, []byte("payload"))
+	assert.ErrorContains(t, err, "key id is longer")
+}
+
+func TestOpen_Version1(t *testing.T) {
+	key := newKey(t)
+
+	// version 1 has no key id fields
+	aesKey := make([]byte, aesKeySize)
+	_, err := rand.Read(aesKey)
+	require.NoError(t, err)
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, aesKey, nil)
+	require.NoError(t, err)
+	gcm, err := newGCM(aesKey)
+	require.NoError(t, err)
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := append([]byte{}, magic...)
+	legacy = append(legacy, version1)
+	legacy = binary.BigEndian.AppendUint16(legacy, uint16(len(wrappedKey)))
+	legacy = append(legacy, wrappedKey...)
+	legacy = append(legacy, nonce...)
+	legacy = gcm.Seal(legacy, nonce, []byte("payload"), legacy)
+
+	keyID, err := KeyID(legacy)
+	require.NoError(t, err)
+	assert.Empty(t, keyID)
+
+	opened, err := Open(key, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), opened)
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	key := newKey(t)
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	validPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(validPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0o600))
+	withIDPath := filepath.Join(dir, "public-with-id.pem")
+	require.NoError(t, os.WriteFile(withIDPath, pem.EncodeToMemory(&pem.Block{
+		Type:    "PUBLIC KEY",
+		Headers: map[string]string{KeyIDHeader: "k1"},
+		Bytes:   pubBytes,
+	}), 0o600))
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("invalid"), 0o600))
+
+	loaded, err := LoadPublicKey(validPath)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(loaded.Key))
+	assert.Empty(t, loaded.ID)
+
+	loaded, err = LoadPublicKey(withIDPath)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(loaded.Key))
+	assert.Equal(t, "k1", loaded.ID)
+
+	_, err = LoadPublicKey(invalidPath)
+	assert.ErrorContains(t, err, "failed to parse public key PEM block")
+
+	_, err = LoadPublicKey(filepath.Join(dir, "missing.pem"))
+	assert.ErrorContains(t, err, "unable to read public key file")
+}
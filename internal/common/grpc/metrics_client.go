@@ -2,30 +2,89 @@ package grpc
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/envelope"
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"github.com/Kopleman/metcol/internal/common/utils"
 	pb "github.com/Kopleman/metcol/proto/metrics"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 type MetricsClient struct {
-	client pb.MetricsServiceClient
-	conn   *grpc.ClientConn
-	key    []byte
+	client    pb.MetricsServiceClient
+	conn      *grpc.ClientConn
+	publicKey *envelope.PublicKey
+	stream    *metricsStream // open StreamMetrics call, guarded by streamMu
+	key       []byte
+	streamMu  sync.Mutex
 }
 
-func NewMetricsClient(address string, key string) (*MetricsClient, error) {
+// metricsStream long-lived StreamMetrics call, batches are sent one at a time.
+type metricsStream struct {
+	call   grpc.BidiStreamingClient[pb.StreamMetricsRequest, pb.StreamMetricsAck]
+	cancel context.CancelFunc
+	stamp  signature.Stamp // timestamp and nonce of stream, part of every batch hash
+	seq    uint64          // seq of last sent batch
+}
+
+type encryptedRequest interface {
+	proto.Message
+	SetEncrypted(v []byte)
+}
+
+// callMetadata attaches agent token and namespace to every call.
+type callMetadata struct {
+	token     string
+	namespace string
+}
+
+func (c callMetadata) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	md := make(map[string]string, 2)
+	if c.token != "" {
+		md["authorization"] = "Bearer " + c.token
+	}
+	if c.namespace != "" {
+		md[identity.NamespaceMetadataKey] = c.namespace
+	}
+	return md, nil
+}
+
+func (c callMetadata) RequireTransportSecurity() bool {
+	return false
+}
+
+// NewMetricsClient dials server, tlsConfig nil means plaintext connection.
+// If publicKey is set, update requests are sent sealed with envelope encryption.
+// Non-empty token is sent as bearer token and non-empty namespace selects tenant
+// namespace with every call.
+func NewMetricsClient(
+	address string,
+	key string,
+	token string,
+	namespace string,
+	tlsConfig *tls.Config,
+	publicKey *envelope.PublicKey,
+) (*MetricsClient, error) {
+	transportCreds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCreds = credentials.NewTLS(tlsConfig)
+	}
 	dialOpts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(transportCreds),
 		grpc.WithDefaultServiceConfig(`{
 			"loadBalancingPolicy": "round_robin",
 			"methodConfig": [{
@@ -52,6 +111,10 @@ func NewMetricsClient(address string, key string) (*MetricsClient, error) {
 		}),
 	}
 
+	if token != "" || namespace != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(callMetadata{token: token, namespace: namespace}))
+	}
+
 	conn, err := grpc.NewClient(
 		address,
 		dialOpts...,
@@ -62,13 +125,38 @@ func NewMetricsClient(address string, key string) (*MetricsClient, error) {
 
 	client := pb.NewMetricsServiceClient(conn)
 	return &MetricsClient{
-		client: client,
-		conn:   conn,
-		key:    []byte(key),
+		client:    client,
+		conn:      conn,
+		publicKey: publicKey,
+		key:       []byte(key),
 	}, nil
 }
 
+// sealRequest replaces request fields with their encrypted form.
+func (c *MetricsClient) sealRequest(req encryptedRequest) error {
+	if c.publicKey == nil {
+		return nil
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	sealed, err := envelope.Seal(c.publicKey, data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt request: %w", err)
+	}
+
+	proto.Reset(req)
+	req.SetEncrypted(sealed)
+	return nil
+}
+
 func (c *MetricsClient) Close() error {
+	c.streamMu.Lock()
+	c.closeStream()
+	c.streamMu.Unlock()
+
 	err := c.conn.Close()
 	if err != nil {
 		return fmt.Errorf("failed to close connection: %w", err)
@@ -93,11 +181,18 @@ func (c *MetricsClient) addHashToContext(ctx context.Context, req interface{}) (
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	h := hmac.New(sha256.New, c.key)
-	h.Write(reqBytes)
-	hash := hex.EncodeToString(h.Sum(nil))
+	stamp, err := signature.NewStamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stamp request: %w", err)
+	}
+	hash := hex.EncodeToString(signature.Sum(c.key, stamp, reqBytes))
 
-	return metadata.AppendToOutgoingContext(ctx, "HashSHA256", hash), nil
+	return metadata.AppendToOutgoingContext(
+		ctx,
+		"HashSHA256", hash,
+		signature.TimestampMetadataKey, stamp.Timestamp(),
+		signature.NonceMetadataKey, stamp.Nonce,
+	), nil
 }
 
 func (c *MetricsClient) GetMetric(ctx context.Context, id string, metricType pb.MetricType) (*pb.Metric, error) {
@@ -121,6 +216,9 @@ func (c *MetricsClient) GetMetric(ctx context.Context, id string, metricType pb.
 func (c *MetricsClient) UpdateMetric(ctx context.Context, metric *pb.Metric) (*pb.Metric, error) {
 	req := &pb.UpdateMetricRequest{}
 	req.SetMetric(metric)
+	if err := c.sealRequest(req); err != nil {
+		return nil, err
+	}
 
 	ctx, err := c.addHashToContext(ctx, req)
 	if err != nil {
@@ -136,8 +234,25 @@ func (c *MetricsClient) UpdateMetric(ctx context.Context, metric *pb.Metric) (*p
 }
 
 func (c *MetricsClient) UpdateMetrics(ctx context.Context, metrics []*pb.Metric) ([]*pb.Metric, error) {
+	return c.UpdateMetricsBatch(ctx, "", metrics)
+}
+
+// UpdateMetricsBatch sends metrics batch identified by batchID, server applies batch with
+// the same batchID only once. Empty batchID sends plain update.
+func (c *MetricsClient) UpdateMetricsBatch(
+	ctx context.Context,
+	batchID string,
+	metrics []*pb.Metric,
+) ([]*pb.Metric, error) {
+	if batchID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, common.BatchIDMetadataKey, batchID)
+	}
+
 	req := &pb.UpdateMetricsRequest{}
 	req.SetMetrics(metrics)
+	if err := c.sealRequest(req); err != nil {
+		return nil, err
+	}
 
 	ctx, err := c.addHashToContext(ctx, req)
 	if err != nil {
@@ -152,6 +267,123 @@ func (c *MetricsClient) UpdateMetrics(ctx context.Context, metrics []*pb.Metric)
 	return resp.GetMetrics(), nil
 }
 
+// StreamMetricsBatch sends metrics batch identified by batchID via long-lived StreamMetrics call
+// and waits for its ack. Stream is opened on first call and reopened after failure, batch sent
+// to stream closed by server is resent once via new stream. Batch acknowledged with error is
+// returned as error, caller resends it with the same batchID and server applies it only once.
+func (c *MetricsClient) StreamMetricsBatch(ctx context.Context, batchID string, metrics []*pb.Metric) error {
+	req := &pb.StreamMetricsRequest{}
+	req.SetBatchId(batchID)
+	req.SetMetrics(metrics)
+	if err := c.sealRequest(req); err != nil {
+		return err
+	}
+
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+
+	reused := c.stream != nil
+	ack, err := c.sendStreamBatch(ctx, req)
+	if err != nil && reused && batchID != "" && ctx.Err() == nil {
+		// server closes idle and aged connections, batch is resent via new stream
+		c.closeStream()
+		ack, err = c.sendStreamBatch(ctx, req)
+	}
+	if err != nil {
+		c.closeStream()
+		return err
+	}
+	if ack.GetError() != "" {
+		return fmt.Errorf("metrics batch '%s' is not stored: %s", batchID, ack.GetError())
+	}
+
+	return nil
+}
+
+// sendStreamBatch sends batch with next seq of stream and receives its ack.
+func (c *MetricsClient) sendStreamBatch(
+	ctx context.Context,
+	req *pb.StreamMetricsRequest,
+) (*pb.StreamMetricsAck, error) {
+	if c.stream == nil {
+		stream, err := c.openStream()
+		if err != nil {
+			return nil, err
+		}
+		c.stream = stream
+	}
+	stop := context.AfterFunc(ctx, c.stream.cancel)
+	defer stop()
+
+	c.stream.seq++
+	req.SetSeq(c.stream.seq)
+	if len(c.key) > 0 {
+		hash, err := utils.StampedMessageHash(c.key, c.stream.stamp, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign metrics batch: %w", err)
+		}
+		req.SetHash(hash)
+	}
+
+	if err := c.stream.call.Send(req); err != nil {
+		return nil, fmt.Errorf("failed to send metrics batch: %w", err)
+	}
+	ack, err := c.stream.call.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive metrics batch ack: %w", err)
+	}
+	if ack.GetSeq() != req.GetSeq() {
+		return nil, fmt.Errorf("received ack of seq %d, expected seq %d", ack.GetSeq(), req.GetSeq())
+	}
+	if len(c.key) > 0 {
+		hash, err := utils.MessageHash(c.key, ack)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check ack hash: %w", err)
+		}
+		if hash != ack.GetHash() {
+			return nil, fmt.Errorf("invalid hash of ack of seq %d", ack.GetSeq())
+		}
+	}
+
+	return ack, nil
+}
+
+// openStream starts StreamMetrics call, its timestamp and nonce are sent once in call metadata.
+func (c *MetricsClient) openStream() (*metricsStream, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stamp signature.Stamp
+	if len(c.key) > 0 {
+		var err error
+		if stamp, err = signature.NewStamp(); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to stamp metrics stream: %w", err)
+		}
+		ctx = metadata.AppendToOutgoingContext(
+			ctx,
+			signature.TimestampMetadataKey, stamp.Timestamp(),
+			signature.NonceMetadataKey, stamp.Nonce,
+		)
+	}
+
+	call, err := c.client.StreamMetrics(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open metrics stream: %w", err)
+	}
+
+	return &metricsStream{call: call, cancel: cancel, stamp: stamp}, nil
+}
+
+func (c *MetricsClient) closeStream() {
+	if c.stream == nil {
+		return
+	}
+	_ = c.stream.call.CloseSend()
+	c.stream.cancel()
+	c.stream = nil
+}
+
 func (c *MetricsClient) GetAllMetrics(ctx context.Context) ([]*pb.Metric, error) {
 	req := &pb.GetAllMetricsRequest{}
 
@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"github.com/Kopleman/metcol/internal/common/utils"
+	pb "github.com/Kopleman/metcol/proto/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// streamServer принимает пакеты StreamMetrics и проверяет их подпись
+type streamServer struct {
+	pb.UnimplementedMetricsServiceServer
+	failBatch  string // batch acknowledged with error once
+	batches    []*pb.StreamMetricsRequest
+	key        []byte
+	closeAfter int // stream is closed after this many batches
+	streams    int
+	mu         sync.Mutex
+}
+
+func (s *streamServer) StreamMetrics(
+	stream grpc.BidiStreamingServer[pb.StreamMetricsRequest, pb.StreamMetricsAck],
+) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	var timestamp, nonce string
+	if values := md.Get(signature.TimestampMetadataKey); len(values) > 0 {
+		timestamp = values[0]
+	}
+	if values := md.Get(signature.NonceMetadataKey); len(values) > 0 {
+		nonce = values[0]
+	}
+	stamp, err := signature.ParseStamp(timestamp, nonce)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s.mu.Lock()
+	s.streams++
+	s.mu.Unlock()
+
+	for received := 1; ; received++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err //nolint:wrapcheck // test server
+		}
+		hash, err := utils.StampedMessageHash(s.key, stamp, req)
+		if err != nil || hash != req.GetHash() {
+			return status.Error(codes.InvalidArgument, "invalid hash")
+		}
+
+		ack := &pb.StreamMetricsAck{}
+		ack.SetSeq(req.GetSeq())
+		s.mu.Lock()
+		s.batches = append(s.batches, req)
+		if req.GetBatchId() == s.failBatch {
+			ack.SetError("store failed")
+			s.failBatch = ""
+		}
+		s.mu.Unlock()
+		if err = utils.SignMessage(s.key, ack); err != nil {
+			return err //nolint:wrapcheck // test server
+		}
+		if err = stream.Send(ack); err != nil {
+			return err //nolint:wrapcheck // test server
+		}
+
+		if received == s.closeAfter {
+			return nil
+		}
+	}
+}
+
+func newStreamClient(t *testing.T, srv *streamServer, key string) *MetricsClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pb.RegisterMetricsServiceServer(server, srv)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	client, err := NewMetricsClient(lis.Addr().String(), key, "", "", nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestMetricsClient_StreamMetricsBatch(t *testing.T) {
+	srv := &streamServer{key: []byte("secret"), failBatch: "batch-2", closeAfter: 3}
+	client := newStreamClient(t, srv, "secret")
+	ctx := context.Background()
+
+	metric := &pb.Metric{}
+	metric.SetId("foo")
+	metric.SetType(pb.MetricType_GAUGE)
+	metric.SetValue(1.5)
+	batch := []*pb.Metric{metric}
+
+	require.NoError(t, client.StreamMetricsBatch(ctx, "batch-1", batch))
+	// batch acknowledged with error is returned, caller resends it via the same stream
+	require.Error(t, client.StreamMetricsBatch(ctx, "batch-2", batch))
+	require.NoError(t, client.StreamMetricsBatch(ctx, "batch-2", batch))
+	// server closed stream after third batch, batch is resent via new stream
+	require.NoError(t, client.StreamMetricsBatch(ctx, "batch-3", batch))
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, 2, srv.streams)
+	type sent struct {
+		batchID string
+		seq     uint64
+	}
+	got := make([]sent, 0, len(srv.batches))
+	for _, req := range srv.batches {
+		require.Len(t, req.GetMetrics(), 1)
+		got = append(got, sent{batchID: req.GetBatchId(), seq: req.GetSeq()})
+	}
+	assert.Equal(t, []sent{
+		{batchID: "batch-1", seq: 1},
+		{batchID: "batch-2", seq: 2},
+		{batchID: "batch-2", seq: 3},
+		{batchID: "batch-3", seq: 1},
+	}, got)
+}
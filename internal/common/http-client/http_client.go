@@ -4,9 +4,7 @@ package httpclient
 import (
 	"bytes"
 	"compress/gzip"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -14,7 +12,9 @@ import (
 	"net/http"
 
 	"github.com/Kopleman/metcol/internal/agent/config"
+	"github.com/Kopleman/metcol/internal/agent/resilience"
 	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/identity"
 	"github.com/Kopleman/metcol/internal/common/log"
 )
 
@@ -39,6 +39,16 @@ func (c *HTTPClient) getOutboundIP() (net.IP, error) {
 
 // Post perform post request to dest url.
 func (c *HTTPClient) Post(url, contentType string, bodyBytes []byte) ([]byte, error) {
+	return c.post(url, contentType, "", bodyBytes)
+}
+
+// PostBatch performs post request of metrics batch. Server applies batch with the same
+// batchID only once, so batch may be safely sent again.
+func (c *HTTPClient) PostBatch(url, contentType, batchID string, bodyBytes []byte) ([]byte, error) {
+	return c.post(url, contentType, batchID, bodyBytes)
+}
+
+func (c *HTTPClient) post(url, contentType, batchID string, bodyBytes []byte) ([]byte, error) {
 	body := bytes.NewBuffer(bodyBytes)
 	finalURL := c.BaseURL + url
 	var respBody []byte
@@ -55,23 +65,43 @@ func (c *HTTPClient) Post(url, contentType string, bodyBytes []byte) ([]byte, er
 		req.Header.Set("X-Real-IP", ip.String())
 	}
 
-	bodyHash := c.calcHashForBody(bodyBytes)
-	if bodyHash != "" {
-		req.Header.Set(common.HashSHA256, bodyHash)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set(identity.NamespaceHeader, c.namespace)
+	}
+
+	if batchID != "" {
+		req.Header.Set(common.BatchIDHeader, batchID)
 	}
 
 	res, respErr := c.client.Do(req)
 	if respErr != nil {
 		return nil, fmt.Errorf("failed to send post req to '%s': %w", finalURL, respErr)
 	}
+	defer func() {
+		if bodyParseErr := res.Body.Close(); bodyParseErr != nil {
+			c.logger.Error(bodyParseErr)
+		}
+	}()
 
 	if res.StatusCode >= http.StatusBadRequest {
-		return nil, fmt.Errorf("failed to send post req to '%s': status code %d", finalURL, res.StatusCode)
+		statusErr := fmt.Errorf("failed to send post req to '%s': status code %d", finalURL, res.StatusCode)
+		if malformed(res.StatusCode) {
+			return nil, resilience.Permanent(statusErr)
+		}
+		if res.StatusCode < http.StatusInternalServerError {
+			// wrong key, stale replay stamp, revoked token or role are fixed by operator, request is kept for retry
+			c.logger.Errorf("server refused request to '%s' with status %d, check agent key, clock and token",
+				finalURL, res.StatusCode)
+		}
+		return nil, statusErr
 	}
 
 	gz, gzipErr := gzip.NewReader(res.Body)
 	if gzipErr != nil {
-		return nil, fmt.Errorf("failed to decompress response: %w", err)
+		return nil, fmt.Errorf("failed to decompress response: %w", gzipErr)
 	}
 	defer func() {
 		if gzErr := gz.Close(); gzErr != nil {
@@ -79,12 +109,6 @@ func (c *HTTPClient) Post(url, contentType string, bodyBytes []byte) ([]byte, er
 		}
 	}()
 
-	defer func() {
-		if bodyParseErr := res.Body.Close(); bodyParseErr != nil {
-			c.logger.Error(bodyParseErr)
-		}
-	}()
-
 	respBody, err = io.ReadAll(gz)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse response body: %w", err)
@@ -93,43 +117,66 @@ func (c *HTTPClient) Post(url, contentType string, bodyBytes []byte) ([]byte, er
 	return respBody, nil
 }
 
-func (c *HTTPClient) calcHashForBody(bodyBytes []byte) string {
-	if len(c.key) == 0 {
-		return ""
+// malformed reports whether server can never accept request payload, so sending it again
+// gives the same response.
+func malformed(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+		return true
+	default:
+		return false
 	}
-	if len(bodyBytes) == 0 {
-		return ""
-	}
-
-	h := hmac.New(sha256.New, c.key)
-	h.Write(bodyBytes)
-	hash := h.Sum(nil)
-	hashString := base64.StdEncoding.EncodeToString(hash)
-
-	return hashString
 }
 
 type HTTPClient struct {
 	logger     log.Logger
 	client     *http.Client
-	outboundIP net.IP
 	BaseURL    string
-	key        []byte
+	token      string
+	namespace  string
+	outboundIP net.IP
 }
 
 const defaultRetryCount = 3
 
-func NewHTTPClient(cfg *config.Config, logger log.Logger) *HTTPClient {
-	baseURL := `http://` + cfg.EndPoint.String()
+type options struct {
+	tlsConfig *tls.Config
+}
+
+// Option configures HTTPClient.
+type Option func(*options)
+
+// WithTLSConfig makes client use https with given config.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+func NewHTTPClient(cfg *config.Config, logger log.Logger, opts ...Option) *HTTPClient {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	scheme := `http://`
+	base := &http.Transport{}
+	if o.tlsConfig != nil {
+		scheme = `https://`
+		base.TLSClientConfig = o.tlsConfig
+	}
+	baseURL := scheme + cfg.EndPoint.String()
 
-	transport := NewRetryableTransport(logger, defaultRetryCount)
+	// every retry attempt is signed anew
+	transport := NewRetryableTransport(logger, defaultRetryCount, newSigningTransport([]byte(cfg.Key), base))
 
 	return &HTTPClient{
 		BaseURL: baseURL,
 		client: &http.Client{
 			Transport: transport,
 		},
-		logger: logger,
-		key:    []byte(cfg.Key),
+		logger:    logger,
+		token:     cfg.Token,
+		namespace: cfg.Namespace,
 	}
 }
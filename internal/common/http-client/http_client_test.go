@@ -4,8 +4,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
+	"crypto/tls"
 	"encoding/base64"
 	"errors"
 	"io"
@@ -15,9 +14,12 @@ import (
 	"testing"
 
 	"github.com/Kopleman/metcol/internal/agent/config"
+	"github.com/Kopleman/metcol/internal/agent/resilience"
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/flags"
+	"github.com/Kopleman/metcol/internal/common/identity"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/common/signature"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -29,11 +31,15 @@ func TestHTTPClient_Post(t *testing.T) {
 	tests := []struct {
 		name          string
 		key           string
+		token         string
+		namespace     string
+		batchID       string
 		mockResponse  *http.Response
 		mockError     error
 		expectedError string
 		expectedHash  bool
 		checkHeaders  bool
+		wantPermanent bool
 	}{
 		{
 			name: "successful request",
@@ -50,11 +56,45 @@ func TestHTTPClient_Post(t *testing.T) {
 			expectedHash: true,
 			checkHeaders: true,
 		},
+		{
+			name:         "request with bearer token",
+			token:        "agent-token",
+			mockResponse: &http.Response{StatusCode: http.StatusOK, Body: gzipBody("test body")},
+			checkHeaders: true,
+		},
+		{
+			name:         "request with namespace",
+			namespace:    "team-a",
+			mockResponse: &http.Response{StatusCode: http.StatusOK, Body: gzipBody("test body")},
+			checkHeaders: true,
+		},
+		{
+			name:         "batch request",
+			batchID:      "batch-1",
+			mockResponse: &http.Response{StatusCode: http.StatusOK, Body: gzipBody("test body")},
+			checkHeaders: true,
+		},
 		{
 			name:          "server error response",
-			mockResponse:  &http.Response{StatusCode: http.StatusInternalServerError},
+			mockResponse:  &http.Response{StatusCode: http.StatusInternalServerError, Body: http.NoBody},
 			expectedError: "status code 500",
 		},
+		{
+			name:          "refused request",
+			mockResponse:  &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody},
+			expectedError: "status code 401",
+		},
+		{
+			name:          "malformed request",
+			mockResponse:  &http.Response{StatusCode: http.StatusUnprocessableEntity, Body: http.NoBody},
+			expectedError: "status code 422",
+			wantPermanent: true,
+		},
+		{
+			name:          "rate limited request",
+			mockResponse:  &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody},
+			expectedError: "status code 429",
+		},
 		{
 			name:          "network error",
 			mockError:     errors.New("connection failed"),
@@ -75,11 +115,13 @@ func TestHTTPClient_Post(t *testing.T) {
 
 			// Configure client
 			cfg := &config.Config{
-				EndPoint: &flags.NetAddress{Host: "test-server", Port: "80"},
-				Key:      tt.key,
+				EndPoint:  &flags.NetAddress{Host: "test-server", Port: "80"},
+				Key:       tt.key,
+				Token:     tt.token,
+				Namespace: tt.namespace,
 			}
 			client := NewHTTPClient(cfg, mockLogger)
-			client.client.Transport = mockRT
+			client.client.Transport = newSigningTransport([]byte(tt.key), mockRT)
 
 			// Expected request validations
 			mockRT.On("RoundTrip", mock.AnythingOfType("*http.Request")).
@@ -87,12 +129,19 @@ func TestHTTPClient_Post(t *testing.T) {
 				Once()
 
 			// Execute
-			resp, err := client.Post("/test", "application/json", testBody)
+			var resp []byte
+			var err error
+			if tt.batchID != "" {
+				resp, err = client.PostBatch("/test", "application/json", tt.batchID, testBody)
+			} else {
+				resp, err = client.Post("/test", "application/json", testBody)
+			}
 
 			// Assertions
 			if tt.expectedError != "" {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Equal(t, tt.wantPermanent, resilience.IsPermanent(err))
 				return
 			}
 			require.NoError(t, err)
@@ -101,11 +150,24 @@ func TestHTTPClient_Post(t *testing.T) {
 				req := mockRT.Calls[0].Arguments[0].(*http.Request) //nolint:all // tests
 				assert.Equal(t, "application/json", req.Header.Get(common.ContentType))
 				assert.Equal(t, "gzip", req.Header.Get(common.AcceptEncoding))
+				if tt.token != "" {
+					assert.Equal(t, "Bearer "+tt.token, req.Header.Get("Authorization"))
+				} else {
+					assert.Empty(t, req.Header.Get("Authorization"))
+				}
+				assert.Equal(t, tt.namespace, req.Header.Get(identity.NamespaceHeader))
+				assert.Equal(t, tt.batchID, req.Header.Get(common.BatchIDHeader))
 			}
 
 			if tt.expectedHash {
 				req := mockRT.Calls[0].Arguments[0].(*http.Request) //nolint:all // tests
-				expectedHash := calculateHash(testBody, []byte(tt.key))
+				stamp, err := signature.ParseStamp(
+					req.Header.Get(signature.TimestampHeader),
+					req.Header.Get(signature.NonceHeader),
+				)
+				require.NoError(t, err)
+				require.False(t, stamp.Empty(), "signed request carries timestamp and nonce")
+				expectedHash := calculateHash(stamp, testBody, []byte(tt.key))
 				assert.Equal(t, expectedHash, req.Header.Get(common.HashSHA256))
 			}
 
@@ -116,34 +178,6 @@ func TestHTTPClient_Post(t *testing.T) {
 	}
 }
 
-func TestHTTPClient_CalcHashForBody(t *testing.T) {
-	secret := []byte("secret-key")
-	testBody := []byte("test-body")
-
-	t.Run("with key and body", func(t *testing.T) {
-		client := &HTTPClient{key: secret}
-		hash := client.calcHashForBody(testBody)
-
-		mac := hmac.New(sha256.New, secret)
-		mac.Write(testBody)
-		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-
-		assert.Equal(t, expected, hash)
-	})
-
-	t.Run("empty key", func(t *testing.T) {
-		client := &HTTPClient{key: []byte{}}
-		hash := client.calcHashForBody(testBody)
-		assert.Empty(t, hash)
-	})
-
-	t.Run("empty body", func(t *testing.T) {
-		client := &HTTPClient{key: secret}
-		hash := client.calcHashForBody([]byte{})
-		assert.Empty(t, hash)
-	})
-}
-
 func TestNewHTTPClient(t *testing.T) {
 	cfg := &config.Config{
 		EndPoint: &flags.NetAddress{Host: "example.com", Port: "8080"},
@@ -155,7 +189,25 @@ func TestNewHTTPClient(t *testing.T) {
 
 	assert.Equal(t, "http://example.com:8080", client.BaseURL)
 	assert.NotNil(t, client.client.Transport)
-	assert.Equal(t, []byte("test-key"), client.key)
+	transport, ok := client.client.Transport.(*retryableTransport)
+	require.True(t, ok)
+	signing, ok := transport.transport.(*signingTransport)
+	require.True(t, ok)
+	assert.Equal(t, []byte("test-key"), signing.key)
+}
+
+func TestNewHTTPClient_WithTLSConfig(t *testing.T) {
+	cfg := &config.Config{
+		EndPoint: &flags.NetAddress{Host: "example.com", Port: "8443"},
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	client := NewHTTPClient(cfg, new(log.MockLogger), WithTLSConfig(tlsConfig))
+
+	assert.Equal(t, "https://example.com:8443", client.BaseURL)
+	transport, ok := client.client.Transport.(*retryableTransport)
+	require.True(t, ok)
+	assert.Same(t, tlsConfig, transport.transport.(*http.Transport).TLSClientConfig)
 }
 
 func gzipBody(data string) io.ReadCloser {
@@ -166,14 +218,12 @@ func gzipBody(data string) io.ReadCloser {
 	return io.NopCloser(&buf)
 }
 
-func calculateHash(body []byte, key []byte) string {
+func calculateHash(stamp signature.Stamp, body []byte, key []byte) string {
 	if len(key) == 0 || len(body) == 0 {
 		return ""
 	}
 
-	mac := hmac.New(sha256.New, key)
-	mac.Write(body)
-	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return base64.StdEncoding.EncodeToString(signature.Sum(key, stamp, body))
 }
 
 func TestGetOutboundIP(t *testing.T) {
@@ -78,9 +78,12 @@ type retryableTransport struct {
 }
 
 // NewRetryableTransport creates instance of http transport with retries under the hood.
-func NewRetryableTransport(logger log.Logger, retryCount int) http.RoundTripper {
+func NewRetryableTransport(logger log.Logger, retryCount int, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = &http.Transport{}
+	}
 	transport := &retryableTransport{
-		transport:  &http.Transport{},
+		transport:  base,
 		retryCount: retryCount,
 		logger:     logger,
 	}
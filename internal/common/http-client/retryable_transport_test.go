@@ -211,7 +211,7 @@ func TestNewRetryableTransport(t *testing.T) {
 	logger := new(log.MockLogger)
 	retryCount := 3
 
-	transport := NewRetryableTransport(logger, retryCount).(*retryableTransport) //nolint:all //tests
+	transport := NewRetryableTransport(logger, retryCount, nil).(*retryableTransport) //nolint:all //tests
 
 	assert.NotNil(t, transport.transport)
 	assert.Equal(t, retryCount, transport.retryCount)
@@ -0,0 +1,68 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/signature"
+)
+
+// signingTransport signs every attempt of request with fresh timestamp and nonce. It sits
+// under retryableTransport, so retry is not rejected by server as replay; retried batch
+// is recognized by its batch ID instead.
+type signingTransport struct {
+	transport http.RoundTripper
+	key       []byte
+}
+
+func newSigningTransport(key []byte, transport http.RoundTripper) http.RoundTripper {
+	if len(key) == 0 {
+		return transport
+	}
+	return &signingTransport{transport: transport, key: key}
+}
+
+// RoundTrip interface implementation, signs copy of request.
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return t.transport.RoundTrip(req) //nolint:wrapcheck // transport error passed as is
+	}
+	bodyBytes, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+
+	bodyHash, stamp, err := t.calcHashForBody(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if bodyHash != "" {
+		signed.Header.Set(common.HashSHA256, bodyHash)
+		signed.Header.Set(signature.TimestampHeader, stamp.Timestamp())
+		signed.Header.Set(signature.NonceHeader, stamp.Nonce)
+	}
+
+	return t.transport.RoundTrip(signed) //nolint:wrapcheck // transport error passed as is
+}
+
+// calcHashForBody signs body together with fresh timestamp and nonce.
+func (t *signingTransport) calcHashForBody(bodyBytes []byte) (string, signature.Stamp, error) {
+	if len(bodyBytes) == 0 {
+		return "", signature.Stamp{}, nil
+	}
+
+	stamp, err := signature.NewStamp()
+	if err != nil {
+		return "", signature.Stamp{}, fmt.Errorf("failed to stamp request: %w", err)
+	}
+	hash := signature.Sum(t.key, stamp, bodyBytes)
+
+	return base64.StdEncoding.EncodeToString(hash), stamp, nil
+}
@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningTransport_CalcHashForBody(t *testing.T) {
+	secret := []byte("secret-key")
+	testBody := []byte("test-body")
+
+	t.Run("with key and body", func(t *testing.T) {
+		transport := &signingTransport{key: secret}
+		hash, stamp, err := transport.calcHashForBody(testBody)
+		require.NoError(t, err)
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(stamp.Timestamp() + "\n" + stamp.Nonce + "\n"))
+		mac.Write(testBody)
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+		assert.Equal(t, expected, hash)
+		assert.WithinDuration(t, time.Now(), stamp.Time, time.Minute)
+
+		// каждый запрос получает собственный nonce
+		_, other, err := transport.calcHashForBody(testBody)
+		require.NoError(t, err)
+		assert.NotEqual(t, stamp.Nonce, other.Nonce)
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		base := new(MockRoundTripper)
+		assert.Same(t, base, newSigningTransport([]byte{}, base))
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		transport := &signingTransport{key: secret}
+		hash, _, err := transport.calcHashForBody([]byte{})
+		require.NoError(t, err)
+		assert.Empty(t, hash)
+	})
+}
+
+func TestSigningTransport_Retry(t *testing.T) {
+	secret := []byte("secret-key")
+	body := []byte("test-body")
+	mockRT := new(MockRoundTripper)
+
+	var signed []*http.Request
+	mockRT.On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Run(func(args mock.Arguments) {
+			req := args.Get(0).(*http.Request) //nolint:all // tests
+			got, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, body, got)
+			signed = append(signed, req)
+		}).
+		Return(&http.Response{StatusCode: http.StatusInternalServerError}, errors.New("connection error")).
+		Once()
+	mockRT.On("RoundTrip", mock.AnythingOfType("*http.Request")).
+		Run(func(args mock.Arguments) {
+			signed = append(signed, args.Get(0).(*http.Request)) //nolint:all // tests
+		}).
+		Return(&http.Response{StatusCode: http.StatusOK}, nil).
+		Once()
+
+	transport := NewRetryableTransport(new(log.MockLogger), 1, newSigningTransport(secret, mockRT))
+	req, err := http.NewRequest(http.MethodPost, "http://test.com", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	req.Header.Set(common.BatchIDHeader, "batch-1")
+
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, signed, 2)
+
+	// повторная попытка подписывается заново, batch ID сохраняется
+	assert.NotEqual(t, signed[0].Header.Get(signature.NonceHeader), signed[1].Header.Get(signature.NonceHeader))
+	for _, r := range signed {
+		stamp, err := signature.ParseStamp(r.Header.Get(signature.TimestampHeader), r.Header.Get(signature.NonceHeader))
+		require.NoError(t, err)
+		expected := base64.StdEncoding.EncodeToString(signature.Sum(secret, stamp, body))
+		assert.Equal(t, expected, r.Header.Get(common.HashSHA256))
+		assert.Equal(t, "batch-1", r.Header.Get(common.BatchIDHeader))
+	}
+	assert.Empty(t, req.Header.Get(common.HashSHA256), "original request is not modified")
+	mockRT.AssertExpectations(t)
+}
@@ -0,0 +1,17 @@
+// Package identity carries authenticated agent identity and metrics namespace through request context.
+package identity
+
+import "context"
+
+type agentKey struct{}
+
+// WithAgent returns context carrying agent identity.
+func WithAgent(ctx context.Context, agent string) context.Context {
+	return context.WithValue(ctx, agentKey{}, agent)
+}
+
+// AgentFromContext returns agent identity, if request was authenticated.
+func AgentFromContext(ctx context.Context) (string, bool) {
+	agent, ok := ctx.Value(agentKey{}).(string)
+	return agent, ok && agent != ""
+}
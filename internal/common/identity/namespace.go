@@ -0,0 +1,37 @@
+package identity
+
+import (
+	"context"
+	"regexp"
+)
+
+const (
+	// NamespaceHeader HTTP header selecting metrics namespace.
+	NamespaceHeader = "X-Metcol-Namespace"
+	// NamespaceMetadataKey gRPC metadata key selecting metrics namespace.
+	NamespaceMetadataKey = "x-metcol-namespace"
+)
+
+// DefaultNamespace holds metrics of requests without namespace.
+const DefaultNamespace = ""
+
+var namespacePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,62}$`)
+
+type namespaceKey struct{}
+
+// ValidNamespace checks namespace name: up to 63 letters, digits, '_', '.' or '-',
+// starting with letter or digit. Default namespace is valid as well.
+func ValidNamespace(namespace string) bool {
+	return namespace == DefaultNamespace || namespacePattern.MatchString(namespace)
+}
+
+// WithNamespace returns context carrying metrics namespace of request.
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// NamespaceFromContext returns metrics namespace of request, DefaultNamespace if none was set.
+func NamespaceFromContext(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceKey{}).(string)
+	return namespace
+}
@@ -0,0 +1,83 @@
+// Package signature produces HMAC-SHA256 request signatures bound to timestamp and nonce,
+// so captured request can not be replayed later.
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// TimestampHeader HTTP header with request signing time, unix milliseconds.
+	TimestampHeader = "X-Metcol-Timestamp"
+	// NonceHeader HTTP header with request unique nonce.
+	NonceHeader = "X-Metcol-Nonce"
+	// TimestampMetadataKey gRPC metadata key with call signing time, unix milliseconds.
+	TimestampMetadataKey = "x-metcol-timestamp"
+	// NonceMetadataKey gRPC metadata key with call unique nonce.
+	NonceMetadataKey = "x-metcol-nonce"
+)
+
+const nonceSize = 16
+
+// ErrMalformedStamp is returned when timestamp or nonce of request can not be parsed.
+var ErrMalformedStamp = errors.New("malformed request stamp")
+
+// Stamp makes signature of request unique: timestamp limits its lifetime, nonce
+// lets server detect repeated delivery.
+type Stamp struct {
+	Time  time.Time
+	Nonce string
+}
+
+// NewStamp creates stamp with current time and random nonce.
+func NewStamp() (Stamp, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return Stamp{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return Stamp{Time: time.Now(), Nonce: hex.EncodeToString(nonce)}, nil
+}
+
+// ParseStamp parses timestamp and nonce received with request. Both empty values
+// give empty stamp, i.e. request signed by body only.
+func ParseStamp(timestamp, nonce string) (Stamp, error) {
+	if timestamp == "" && nonce == "" {
+		return Stamp{}, nil
+	}
+	if timestamp == "" || nonce == "" {
+		return Stamp{}, fmt.Errorf("%w: timestamp and nonce must be sent together", ErrMalformedStamp)
+	}
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Stamp{}, fmt.Errorf("%w: timestamp '%s'", ErrMalformedStamp, timestamp)
+	}
+	return Stamp{Time: time.UnixMilli(millis), Nonce: nonce}, nil
+}
+
+// Empty reports whether request was sent without stamp.
+func (s Stamp) Empty() bool {
+	return s.Nonce == ""
+}
+
+// Timestamp formats stamp time for header or metadata.
+func (s Stamp) Timestamp() string {
+	return strconv.FormatInt(s.Time.UnixMilli(), 10)
+}
+
+// Sum calculates HMAC-SHA256 of body prefixed with timestamp and nonce of stamp.
+// Empty stamp gives HMAC of body alone, as signed by agents without replay protection.
+func Sum(key []byte, stamp Stamp, body []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	if !stamp.Empty() {
+		h.Write([]byte(stamp.Timestamp() + "\n" + stamp.Nonce + "\n"))
+	}
+	h.Write(body)
+	return h.Sum(nil)
+}
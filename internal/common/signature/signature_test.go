@@ -0,0 +1,71 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStamp(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		nonce     string
+		want      Stamp
+		wantErr   bool
+	}{
+		{name: "no stamp", want: Stamp{}},
+		{name: "valid stamp", timestamp: "1700000000123", nonce: "abc", want: Stamp{Time: time.UnixMilli(1700000000123), Nonce: "abc"}},
+		{name: "missing nonce", timestamp: "1700000000123", wantErr: true},
+		{name: "missing timestamp", nonce: "abc", wantErr: true},
+		{name: "malformed timestamp", timestamp: "yesterday", nonce: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stamp, err := ParseStamp(tt.timestamp, tt.nonce)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrMalformedStamp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stamp)
+		})
+	}
+}
+
+func TestNewStamp(t *testing.T) {
+	first, err := NewStamp()
+	require.NoError(t, err)
+	second, err := NewStamp()
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.Nonce, second.Nonce)
+	assert.WithinDuration(t, time.Now(), first.Time, time.Minute)
+
+	// штамп переживает передачу через заголовки
+	parsed, err := ParseStamp(first.Timestamp(), first.Nonce)
+	require.NoError(t, err)
+	assert.Equal(t, first.Timestamp(), parsed.Timestamp())
+	assert.Equal(t, first.Nonce, parsed.Nonce)
+}
+
+func TestSum(t *testing.T) {
+	key := []byte("secret")
+	body := []byte("body")
+
+	// без штампа подпись совпадает с подписью старых агентов
+	h := hmac.New(sha256.New, key)
+	h.Write(body)
+	assert.Equal(t, h.Sum(nil), Sum(key, Stamp{}, body))
+
+	stamp := Stamp{Time: time.UnixMilli(1700000000000), Nonce: "n1"}
+	signed := Sum(key, stamp, body)
+	assert.NotEqual(t, Sum(key, Stamp{}, body), signed)
+	assert.NotEqual(t, Sum(key, Stamp{Time: stamp.Time, Nonce: "n2"}, body), signed)
+	assert.NotEqual(t, Sum(key, Stamp{Time: stamp.Time.Add(time.Second), Nonce: "n1"}, body), signed)
+}
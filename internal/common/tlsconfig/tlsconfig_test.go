@@ -0,0 +1,181 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPKI struct {
+	caPath, serverCert, serverKey, clientCert, clientKey string
+}
+
+func writePEM(t *testing.T, path, blockType string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0o600))
+}
+
+func issue(
+	t *testing.T,
+	dir, name string,
+	tmpl *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	writePEM(t, certPath, "CERTIFICATE", der)
+	writePEM(t, keyPath, "EC PRIVATE KEY", keyDER)
+	return cert, key, certPath, keyPath
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	notAfter := time.Now().Add(time.Hour)
+
+	ca, caKey, caPath, _ := issue(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "metcol-ca"},
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	_, _, serverCert, serverKey := issue(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "metcol-server"},
+		NotAfter:     notAfter,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	_, _, clientCert, clientKey := issue(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "agent-42"},
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	return testPKI{
+		caPath:     caPath,
+		serverCert: serverCert,
+		serverKey:  serverKey,
+		clientCert: clientCert,
+		clientKey:  clientKey,
+	}
+}
+
+func TestNewServerConfig(t *testing.T) {
+	pki := newTestPKI(t)
+
+	cfg, err := NewServerConfig(pki.serverCert, pki.serverKey, "")
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+
+	cfg, err = NewServerConfig(pki.serverCert, pki.serverKey, pki.caPath)
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+
+	_, err = NewServerConfig("missing.crt", pki.serverKey, "")
+	require.Error(t, err)
+
+	_, err = NewServerConfig(pki.serverCert, pki.serverKey, pki.serverKey)
+	require.Error(t, err, "key file holds no certificates")
+}
+
+func TestMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	serverCfg, err := NewServerConfig(pki.serverCert, pki.serverKey, pki.caPath)
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, PeerCommonName(r.TLS))
+	}))
+	ts.TLS = serverCfg
+	ts.StartTLS()
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		certPath string
+		keyPath  string
+		wantCN   string
+		wantErr  bool
+	}{
+		{
+			name:     "client with certificate",
+			certPath: pki.clientCert,
+			keyPath:  pki.clientKey,
+			wantCN:   "agent-42",
+		},
+		{
+			name:    "client without certificate is rejected",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCfg, cfgErr := NewClientConfig(pki.caPath, tt.certPath, tt.keyPath)
+			require.NoError(t, cfgErr)
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+
+			resp, reqErr := client.Get(ts.URL)
+			if tt.wantErr {
+				require.Error(t, reqErr)
+				return
+			}
+			require.NoError(t, reqErr)
+			defer resp.Body.Close() //nolint:all // test
+			body, readErr := io.ReadAll(resp.Body)
+			require.NoError(t, readErr)
+			assert.Equal(t, tt.wantCN, string(body))
+		})
+	}
+}
+
+func TestNewClientConfig_UnknownServerCA(t *testing.T) {
+	pki := newTestPKI(t)
+	other := newTestPKI(t)
+	serverCfg, err := NewServerConfig(pki.serverCert, pki.serverKey, "")
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = serverCfg
+	ts.StartTLS()
+	defer ts.Close()
+
+	clientCfg, err := NewClientConfig(other.caPath, "", "")
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+	_, err = client.Get(ts.URL) //nolint:bodyclose // request fails
+	require.Error(t, err)
+}
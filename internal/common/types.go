@@ -4,21 +4,27 @@ package common
 type MetricType string
 
 const (
-	CounterMetricType MetricType = "counter" // counter type.
-	GaugeMetricType   MetricType = "gauge"   // gauge type.
-	UnknownMetricType MetricType = "unknown" // unknown type
+	CounterMetricType   MetricType = "counter"   // counter type.
+	GaugeMetricType     MetricType = "gauge"     // gauge type.
+	HistogramMetricType MetricType = "histogram" // histogram type.
+	SummaryMetricType   MetricType = "summary"   // summary type.
+	UnknownMetricType   MetricType = "unknown"   // unknown type
 )
 
 var (
 	metricTypeName = map[MetricType]string{
-		CounterMetricType: "counter",
-		GaugeMetricType:   "gauge",
-		UnknownMetricType: "unknown",
+		CounterMetricType:   "counter",
+		GaugeMetricType:     "gauge",
+		HistogramMetricType: "histogram",
+		SummaryMetricType:   "summary",
+		UnknownMetricType:   "unknown",
 	}
 	metricTypeValue = map[string]MetricType{
-		"counter": CounterMetricType,
-		"gauge":   GaugeMetricType,
-		"unknown": UnknownMetricType,
+		"counter":   CounterMetricType,
+		"gauge":     GaugeMetricType,
+		"histogram": HistogramMetricType,
+		"summary":   SummaryMetricType,
+		"unknown":   UnknownMetricType,
 	}
 )
 
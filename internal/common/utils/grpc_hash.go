@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"google.golang.org/protobuf/proto"
+)
+
+// HashedMessage proto message carrying own HMAC, used in streams where metadata is per-stream only.
+type HashedMessage interface {
+	proto.Message
+	GetHash() string
+	SetHash(v string)
+}
+
+// MessageHash calculates HMAC-SHA256 of deterministically marshaled message with empty hash field.
+func MessageHash(key []byte, msg HashedMessage) (string, error) {
+	return StampedMessageHash(key, signature.Stamp{}, msg)
+}
+
+// StampedMessageHash calculates message hash bound to timestamp and nonce of stream it is sent in,
+// so message can not be replayed in another stream.
+func StampedMessageHash(key []byte, stamp signature.Stamp, msg HashedMessage) (string, error) {
+	unsigned, ok := proto.Clone(msg).(HashedMessage)
+	if !ok {
+		return "", errors.New("cloned message lost hash accessors")
+	}
+	unsigned.SetHash("")
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	return hex.EncodeToString(signature.Sum(key, stamp, data)), nil
+}
+
+// SignMessage sets hash field of message.
+func SignMessage(key []byte, msg HashedMessage) error {
+	hash, err := MessageHash(key, msg)
+	if err != nil {
+		return err
+	}
+	msg.SetHash(hash)
+	return nil
+}
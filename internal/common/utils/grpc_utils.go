@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
 	pb "github.com/Kopleman/metcol/proto/metrics"
@@ -12,6 +14,10 @@ func ConvertProtoMetricType(t pb.MetricType) common.MetricType {
 		return common.GaugeMetricType
 	case pb.MetricType_COUNTER:
 		return common.CounterMetricType
+	case pb.MetricType_HISTOGRAM:
+		return common.HistogramMetricType
+	case pb.MetricType_SUMMARY:
+		return common.SummaryMetricType
 	default:
 		return common.UnknownMetricType
 	}
@@ -29,11 +35,63 @@ func ConvertProtoMetricToDTO(m *pb.Metric) *dto.MetricDTO {
 	} else if m.GetType() == pb.MetricType_COUNTER {
 		delta := m.GetDelta()
 		metric.Delta = &delta
+	} else if m.GetType() == pb.MetricType_HISTOGRAM && m.HasHistogram() {
+		metric.Histogram = ConvertProtoHistogramToDTO(m.GetHistogram())
+	} else if m.GetType() == pb.MetricType_SUMMARY && m.HasSummary() {
+		metric.Summary = ConvertProtoSummaryToDTO(m.GetSummary())
+	}
+	if len(m.GetLabels()) > 0 {
+		metric.Labels = m.GetLabels()
 	}
 
 	return metric
 }
 
+func ConvertProtoHistogramToDTO(h *pb.Histogram) *dto.HistogramDTO {
+	return &dto.HistogramDTO{
+		Bounds: h.GetBounds(),
+		Counts: h.GetCounts(),
+		Sum:    h.GetSum(),
+		Count:  h.GetCount(),
+	}
+}
+
+func ConvertDTOHistogramToProto(h *dto.HistogramDTO) *pb.Histogram {
+	histogram := &pb.Histogram{}
+	histogram.SetBounds(h.Bounds)
+	histogram.SetCounts(h.Counts)
+	histogram.SetSum(h.Sum)
+	histogram.SetCount(h.Count)
+	return histogram
+}
+
+func ConvertProtoSummaryToDTO(s *pb.Summary) *dto.SummaryDTO {
+	quantiles := make([]dto.QuantileDTO, 0, len(s.GetQuantiles()))
+	for _, q := range s.GetQuantiles() {
+		quantiles = append(quantiles, dto.QuantileDTO{Quantile: q.GetQuantile(), Value: q.GetValue()})
+	}
+	return &dto.SummaryDTO{
+		Quantiles: quantiles,
+		Sum:       s.GetSum(),
+		Count:     s.GetCount(),
+	}
+}
+
+func ConvertDTOSummaryToProto(s *dto.SummaryDTO) *pb.Summary {
+	quantiles := make([]*pb.Quantile, 0, len(s.Quantiles))
+	for _, q := range s.Quantiles {
+		quantile := &pb.Quantile{}
+		quantile.SetQuantile(q.Quantile)
+		quantile.SetValue(q.Value)
+		quantiles = append(quantiles, quantile)
+	}
+	summary := &pb.Summary{}
+	summary.SetQuantiles(quantiles)
+	summary.SetSum(s.Sum)
+	summary.SetCount(s.Count)
+	return summary
+}
+
 func ConvertDTOToProtoMetric(m *dto.MetricDTO) *pb.Metric {
 	metric := &pb.Metric{}
 	metric.SetId(m.ID)
@@ -45,6 +103,15 @@ func ConvertDTOToProtoMetric(m *dto.MetricDTO) *pb.Metric {
 	if m.Delta != nil {
 		metric.SetDelta(*m.Delta)
 	}
+	if m.Histogram != nil {
+		metric.SetHistogram(ConvertDTOHistogramToProto(m.Histogram))
+	}
+	if m.Summary != nil {
+		metric.SetSummary(ConvertDTOSummaryToProto(m.Summary))
+	}
+	if len(m.Labels) > 0 {
+		metric.SetLabels(m.Labels)
+	}
 
 	return metric
 }
@@ -55,7 +122,78 @@ func ConvertDTOMetricType(t string) pb.MetricType {
 		return pb.MetricType_GAUGE
 	case common.CounterMetricType.String():
 		return pb.MetricType_COUNTER
+	case common.HistogramMetricType.String():
+		return pb.MetricType_HISTOGRAM
+	case common.SummaryMetricType.String():
+		return pb.MetricType_SUMMARY
 	default:
 		return pb.MetricType_UNKNOWN
 	}
 }
+
+func ConvertProtoAggregation(a pb.Aggregation) dto.Aggregation {
+	switch a {
+	case pb.Aggregation_AGGREGATION_AVG:
+		return dto.AggregationAvg
+	case pb.Aggregation_AGGREGATION_MIN:
+		return dto.AggregationMin
+	case pb.Aggregation_AGGREGATION_MAX:
+		return dto.AggregationMax
+	case pb.Aggregation_AGGREGATION_SUM:
+		return dto.AggregationSum
+	case pb.Aggregation_AGGREGATION_RATE:
+		return dto.AggregationRate
+	default:
+		return ""
+	}
+}
+
+func ConvertDTOAggregation(a dto.Aggregation) pb.Aggregation {
+	switch a {
+	case dto.AggregationAvg:
+		return pb.Aggregation_AGGREGATION_AVG
+	case dto.AggregationMin:
+		return pb.Aggregation_AGGREGATION_MIN
+	case dto.AggregationMax:
+		return pb.Aggregation_AGGREGATION_MAX
+	case dto.AggregationSum:
+		return pb.Aggregation_AGGREGATION_SUM
+	case dto.AggregationRate:
+		return pb.Aggregation_AGGREGATION_RATE
+	default:
+		return pb.Aggregation_AGGREGATION_UNKNOWN
+	}
+}
+
+func ConvertProtoQueryToDTO(q *pb.QueryMetricRequest) *dto.QueryRequest {
+	query := &dto.QueryRequest{
+		From:        time.UnixMilli(q.GetFrom()),
+		To:          time.UnixMilli(q.GetTo()),
+		ID:          q.GetId(),
+		MType:       ConvertProtoMetricType(q.GetType()),
+		Aggregation: ConvertProtoAggregation(q.GetAggregation()),
+		Step:        time.Duration(q.GetStep()) * time.Millisecond,
+	}
+	if len(q.GetLabels()) > 0 {
+		query.Labels = q.GetLabels()
+	}
+	return query
+}
+
+func ConvertDTOSeriesToProto(s *dto.SeriesDTO) *pb.QueryMetricResponse {
+	points := make([]*pb.Point, 0, len(s.Points))
+	for _, p := range s.Points {
+		point := &pb.Point{}
+		point.SetTimestamp(p.Timestamp.UnixMilli())
+		point.SetValue(p.Value)
+		points = append(points, point)
+	}
+
+	resp := &pb.QueryMetricResponse{}
+	resp.SetId(s.ID)
+	resp.SetType(ConvertDTOMetricType(s.MType.String()))
+	resp.SetLabels(s.Labels)
+	resp.SetAggregation(ConvertDTOAggregation(s.Aggregation))
+	resp.SetPoints(points)
+	return resp
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
@@ -25,6 +26,16 @@ func TestConvertProtoMetricType(t *testing.T) {
 			input:    pb.MetricType_COUNTER,
 			expected: common.CounterMetricType,
 		},
+		{
+			name:     "Convert HISTOGRAM",
+			input:    pb.MetricType_HISTOGRAM,
+			expected: common.HistogramMetricType,
+		},
+		{
+			name:     "Convert SUMMARY",
+			input:    pb.MetricType_SUMMARY,
+			expected: common.SummaryMetricType,
+		},
 		{
 			name:     "Convert UNKNOWN",
 			input:    pb.MetricType_UNKNOWN,
@@ -76,6 +87,57 @@ func TestConvertProtoMetricToDTO(t *testing.T) {
 				Delta: func() *int64 { v := int64(100); return &v }(),
 			},
 		},
+		{
+			name: "Convert HISTOGRAM metric",
+			input: func() *pb.Metric {
+				h := &pb.Histogram{}
+				h.SetBounds([]float64{0.1, 1})
+				h.SetCounts([]uint64{2, 1, 0})
+				h.SetSum(0.7)
+				h.SetCount(3)
+				m := &pb.Metric{}
+				m.SetId("test_histogram")
+				m.SetType(pb.MetricType_HISTOGRAM)
+				m.SetHistogram(h)
+				return m
+			}(),
+			expected: &dto.MetricDTO{
+				ID:    "test_histogram",
+				MType: common.HistogramMetricType,
+				Histogram: &dto.HistogramDTO{
+					Bounds: []float64{0.1, 1},
+					Counts: []uint64{2, 1, 0},
+					Sum:    0.7,
+					Count:  3,
+				},
+			},
+		},
+		{
+			name: "Convert SUMMARY metric",
+			input: func() *pb.Metric {
+				q := &pb.Quantile{}
+				q.SetQuantile(0.5)
+				q.SetValue(0.2)
+				s := &pb.Summary{}
+				s.SetQuantiles([]*pb.Quantile{q})
+				s.SetSum(0.7)
+				s.SetCount(3)
+				m := &pb.Metric{}
+				m.SetId("test_summary")
+				m.SetType(pb.MetricType_SUMMARY)
+				m.SetSummary(s)
+				return m
+			}(),
+			expected: &dto.MetricDTO{
+				ID:    "test_summary",
+				MType: common.SummaryMetricType,
+				Summary: &dto.SummaryDTO{
+					Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 0.2}},
+					Sum:       0.7,
+					Count:     3,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -148,6 +210,16 @@ func TestConvertDTOMetricType(t *testing.T) {
 			input:    common.CounterMetricType.String(),
 			expected: pb.MetricType_COUNTER,
 		},
+		{
+			name:     "Convert HISTOGRAM string",
+			input:    common.HistogramMetricType.String(),
+			expected: pb.MetricType_HISTOGRAM,
+		},
+		{
+			name:     "Convert SUMMARY string",
+			input:    common.SummaryMetricType.String(),
+			expected: pb.MetricType_SUMMARY,
+		},
 		{
 			name:     "Convert unknown string",
 			input:    "unknown",
@@ -162,3 +234,24 @@ func TestConvertDTOMetricType(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertProtoQueryToDTO(t *testing.T) {
+	req := &pb.QueryMetricRequest{}
+	req.SetId("foo")
+	req.SetType(pb.MetricType_COUNTER)
+	req.SetLabels(map[string]string{"host": "a"})
+	req.SetFrom(1767225600000)
+	req.SetTo(1767225720000)
+	req.SetStep(60000)
+	req.SetAggregation(pb.Aggregation_AGGREGATION_RATE)
+
+	query := ConvertProtoQueryToDTO(req)
+
+	assert.Equal(t, "foo", query.ID)
+	assert.Equal(t, common.CounterMetricType, query.MType)
+	assert.Equal(t, map[string]string{"host": "a"}, query.Labels)
+	assert.Equal(t, int64(1767225600), query.From.Unix())
+	assert.Equal(t, int64(1767225720), query.To.Unix())
+	assert.Equal(t, time.Minute, query.Step)
+	assert.Equal(t, dto.AggregationRate, query.Aggregation)
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+)
+
+// ErrForbidden is returned when authenticated principal is not allowed to perform action.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrInvalidNamespace is returned when requested namespace name is malformed.
+var ErrInvalidNamespace = errors.New("invalid namespace")
+
+// Role of credential. Roles are hierarchical: writer may read, admin may do everything.
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleWriter Role = "writer"
+	RoleAdmin  Role = "admin"
+)
+
+// Action requested by caller.
+type Action int
+
+const (
+	ActionRead Action = iota
+	ActionWrite
+	ActionAdmin
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionRead:
+		return "read"
+	case ActionWrite:
+		return "write"
+	case ActionAdmin:
+		return "admin"
+	default:
+		return fmt.Sprintf("action(%d)", int(a))
+	}
+}
+
+// ParseRole parses role name. Empty name is treated as writer, so token files
+// created before roles were introduced keep working.
+func ParseRole(name string) (Role, error) {
+	switch Role(strings.ToLower(name)) {
+	case "", RoleWriter:
+		return RoleWriter, nil
+	case RoleReader:
+		return RoleReader, nil
+	case RoleAdmin:
+		return RoleAdmin, nil
+	default:
+		return "", fmt.Errorf("unknown role '%s'", name)
+	}
+}
+
+func (r Role) level() int {
+	switch r {
+	case RoleReader:
+		return 1
+	case RoleWriter:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Allows checks whether role permits action.
+func (r Role) Allows(action Action) bool {
+	switch action {
+	case ActionRead:
+		return r.level() >= RoleReader.level()
+	case ActionWrite:
+		return r.level() >= RoleWriter.level()
+	default:
+		return r.level() >= RoleAdmin.level()
+	}
+}
+
+// Principal is authenticated credential with its permissions.
+type Principal struct {
+	Agent         string
+	Role          Role
+	ReadPrefixes  []string // metric name prefixes allowed to read, empty allows any name
+	WritePrefixes []string // metric name prefixes allowed to write, empty allows any name
+	Namespace     string   // namespace credential is bound to, empty allows choosing any namespace
+}
+
+// CanRead checks whether principal may read metric.
+func (p *Principal) CanRead(name string) bool {
+	return p.Role.Allows(ActionRead) && matchPrefix(p.ReadPrefixes, name)
+}
+
+// CanWrite checks whether principal may write metric.
+func (p *Principal) CanWrite(name string) bool {
+	return p.Role.Allows(ActionWrite) && matchPrefix(p.WritePrefixes, name)
+}
+
+func matchPrefix(prefixes []string, name string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns context carrying authenticated principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns principal, if request was authenticated.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authorize checks that request principal may perform action on every named metric.
+// Requests without principal (authentication disabled) are allowed.
+func Authorize(ctx context.Context, action Action, names ...string) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if !p.Role.Allows(action) {
+		return fmt.Errorf("%w: role '%s' may not %s", ErrForbidden, p.Role, action)
+	}
+
+	for _, name := range names {
+		switch action {
+		case ActionRead:
+			if !p.CanRead(name) {
+				return fmt.Errorf("%w: metric '%s' is not readable", ErrForbidden, name)
+			}
+		case ActionWrite:
+			if !p.CanWrite(name) {
+				return fmt.Errorf("%w: metric '%s' is not writable", ErrForbidden, name)
+			}
+		default:
+		}
+	}
+	return nil
+}
+
+// ResolveNamespace picks namespace of request. Principal bound to namespace may only
+// work in it, others (and unauthenticated requests) use requested one.
+func ResolveNamespace(ctx context.Context, requested string) (string, error) {
+	if !identity.ValidNamespace(requested) {
+		return "", fmt.Errorf("%w: '%s'", ErrInvalidNamespace, requested)
+	}
+
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Namespace == "" {
+		return requested, nil
+	}
+	if requested != "" && requested != p.Namespace {
+		return "", fmt.Errorf("%w: namespace '%s' is not accessible", ErrForbidden, requested)
+	}
+	return p.Namespace, nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Role
+		wantErr bool
+	}{
+		{name: "", want: RoleWriter},
+		{name: "reader", want: RoleReader},
+		{name: "WRITER", want: RoleWriter},
+		{name: "admin", want: RoleAdmin},
+		{name: "root", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, err := ParseRole(tt.name)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, role)
+		})
+	}
+}
+
+func TestRole_Allows(t *testing.T) {
+	tests := []struct {
+		role  Role
+		read  bool
+		write bool
+		admin bool
+	}{
+		{role: RoleReader, read: true},
+		{role: RoleWriter, read: true, write: true},
+		{role: RoleAdmin, read: true, write: true, admin: true},
+		{role: Role("unknown")},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			assert.Equal(t, tt.read, tt.role.Allows(ActionRead))
+			assert.Equal(t, tt.write, tt.role.Allows(ActionWrite))
+			assert.Equal(t, tt.admin, tt.role.Allows(ActionAdmin))
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	reader := &Principal{Agent: "grafana", Role: RoleReader, ReadPrefixes: []string{"http_", "db_"}}
+	writer := &Principal{Agent: "host-1", Role: RoleWriter, WritePrefixes: []string{"host_1_"}}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		action    Action
+		names     []string
+		wantErr   bool
+	}{
+		{name: "no principal", action: ActionAdmin, names: []string{"any"}},
+		{name: "reader reads allowed prefix", principal: reader, action: ActionRead, names: []string{"http_requests", "db_calls"}},
+		{name: "reader reads other prefix", principal: reader, action: ActionRead, names: []string{"http_requests", "cpu"}, wantErr: true},
+		{name: "reader writes", principal: reader, action: ActionWrite, names: []string{"http_requests"}, wantErr: true},
+		{name: "writer reads anything", principal: writer, action: ActionRead, names: []string{"cpu"}},
+		{name: "writer writes own prefix", principal: writer, action: ActionWrite, names: []string{"host_1_cpu"}},
+		{name: "writer writes foreign prefix", principal: writer, action: ActionWrite, names: []string{"host_2_cpu"}, wantErr: true},
+		{name: "writer admin", principal: writer, action: ActionAdmin, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = WithPrincipal(ctx, tt.principal)
+			}
+
+			err := Authorize(ctx, tt.action, tt.names...)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrForbidden)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestResolveNamespace(t *testing.T) {
+	bound := &Principal{Agent: "team-a-host", Role: RoleWriter, Namespace: "team-a"}
+	free := &Principal{Agent: "ops", Role: RoleAdmin}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		requested string
+		want      string
+		wantErr   error
+	}{
+		{name: "no principal default", want: ""},
+		{name: "no principal requested", requested: "team-b", want: "team-b"},
+		{name: "free principal requested", principal: free, requested: "team-b", want: "team-b"},
+		{name: "bound principal without header", principal: bound, want: "team-a"},
+		{name: "bound principal own namespace", principal: bound, requested: "team-a", want: "team-a"},
+		{name: "bound principal foreign namespace", principal: bound, requested: "team-b", wantErr: ErrForbidden},
+		{name: "invalid name", requested: "../etc", wantErr: ErrInvalidNamespace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = WithPrincipal(ctx, tt.principal)
+			}
+
+			namespace, err := ResolveNamespace(ctx, tt.requested)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, namespace)
+		})
+	}
+}
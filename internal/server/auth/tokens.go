@@ -0,0 +1,170 @@
+// Package auth authenticates agents by per-agent bearer tokens.
+//
+// Tokens are kept in JSON file:
+//
+//	{"tokens": [
+//	  {"agent": "host-1", "token_sha256": "<hex sha256 of token>"},
+//	  {"agent": "host-2", "token": "<plain token>", "revoked": true},
+//	  {"agent": "grafana", "token": "<plain token>", "role": "reader", "read_prefixes": ["http_"]},
+//	  {"agent": "team-a-1", "token": "<plain token>", "namespace": "team-a"}
+//	]}
+//
+// Role is one of reader, writer (default) or admin. Prefix lists restrict metric names
+// credential may read or write, empty list allows any name. Namespace binds credential
+// to single tenant namespace, otherwise namespace is taken from request.
+//
+// Single agent is revoked by marking (or removing) its entry and reloading the file.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/common/log"
+)
+
+const bearerPrefix = "Bearer "
+
+type tokenHash [sha256.Size]byte
+
+type tokenEntry struct {
+	Agent       string `json:"agent"`
+	Token       string `json:"token,omitempty"`        // plain token
+	TokenSHA256 string `json:"token_sha256,omitempty"` // hex encoded sha256 of token
+	Revoked     bool   `json:"revoked,omitempty"`
+
+	Role          string   `json:"role,omitempty"`
+	ReadPrefixes  []string `json:"read_prefixes,omitempty"`
+	WritePrefixes []string `json:"write_prefixes,omitempty"`
+	Namespace     string   `json:"namespace,omitempty"`
+}
+
+type tokensFile struct {
+	Tokens []tokenEntry `json:"tokens"`
+}
+
+// TokenStore keeps hashes of active agent tokens.
+type TokenStore struct {
+	logger log.Logger
+	tokens map[tokenHash]*Principal
+	path   string
+	mu     sync.RWMutex
+}
+
+func NewTokenStore(logger log.Logger) *TokenStore {
+	return &TokenStore{
+		logger: logger,
+		tokens: make(map[tokenHash]*Principal),
+	}
+}
+
+// Load replaces tokens with ones from file. Current tokens are kept on error.
+func (s *TokenStore) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read tokens file: %w", err)
+	}
+
+	var file tokensFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("unable to parse tokens file: %w", err)
+	}
+
+	tokens := make(map[tokenHash]*Principal, len(file.Tokens))
+	for i, entry := range file.Tokens {
+		hash, parseErr := entry.hash()
+		if parseErr != nil {
+			return fmt.Errorf("invalid token entry #%d: %w", i, parseErr)
+		}
+		role, parseErr := ParseRole(entry.Role)
+		if parseErr != nil {
+			return fmt.Errorf("invalid token entry #%d: %w", i, parseErr)
+		}
+		if !identity.ValidNamespace(entry.Namespace) {
+			return fmt.Errorf("invalid token entry #%d: %w '%s'", i, ErrInvalidNamespace, entry.Namespace)
+		}
+		if entry.Revoked {
+			continue
+		}
+		if _, ok := tokens[hash]; ok {
+			return fmt.Errorf("invalid token entry #%d: duplicate token", i)
+		}
+		tokens[hash] = &Principal{
+			Agent:         entry.Agent,
+			Role:          role,
+			ReadPrefixes:  entry.ReadPrefixes,
+			WritePrefixes: entry.WritePrefixes,
+			Namespace:     entry.Namespace,
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = path
+	s.tokens = tokens
+
+	return nil
+}
+
+// Reload re-reads file used in Load.
+func (s *TokenStore) Reload() error {
+	s.mu.RLock()
+	path := s.path
+	s.mu.RUnlock()
+
+	if err := s.Load(path); err != nil {
+		return fmt.Errorf("failed to reload tokens: %w", err)
+	}
+	s.logger.Infof("agent tokens reloaded from '%s'", path)
+	return nil
+}
+
+// Authenticate returns principal owning token.
+func (s *TokenStore) Authenticate(token string) (*Principal, bool) {
+	if token == "" {
+		return nil, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	principal, ok := s.tokens[sha256.Sum256([]byte(token))]
+	return principal, ok
+}
+
+func (e *tokenEntry) hash() (tokenHash, error) {
+	var hash tokenHash
+	if e.Agent == "" {
+		return hash, errors.New("agent is required")
+	}
+
+	switch {
+	case e.Token != "" && e.TokenSHA256 != "":
+		return hash, errors.New("token and token_sha256 are mutually exclusive")
+	case e.Token != "":
+		return sha256.Sum256([]byte(e.Token)), nil
+	case e.TokenSHA256 != "":
+		decoded, err := hex.DecodeString(e.TokenSHA256)
+		if err != nil || len(decoded) != sha256.Size {
+			return hash, errors.New("token_sha256 must be hex encoded sha256")
+		}
+		copy(hash[:], decoded)
+		return hash, nil
+	default:
+		return hash, errors.New("token or token_sha256 is required")
+	}
+}
+
+// BearerToken extracts token from Authorization header value.
+func BearerToken(header string) (string, bool) {
+	if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(bearerPrefix):]), true
+}
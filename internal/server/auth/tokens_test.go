@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTokens(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestTokenStore_Load(t *testing.T) {
+	hash := sha256.Sum256([]byte("hashed-token"))
+	hashHex := hex.EncodeToString(hash[:])
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+		valid   map[string]string
+		invalid []string
+	}{
+		{
+			name: "plain and hashed tokens",
+			content: `{"tokens": [
+				{"agent": "host-1", "token": "plain-token"},
+				{"agent": "host-2", "token_sha256": "` + hashHex + `"},
+				{"agent": "host-3", "token": "revoked-token", "revoked": true}
+			]}`,
+			valid:   map[string]string{"plain-token": "host-1", "hashed-token": "host-2"},
+			invalid: []string{"revoked-token", "unknown", "", hashHex},
+		},
+		{
+			name:    "several tokens of one agent",
+			content: `{"tokens": [{"agent": "host-1", "token": "old"}, {"agent": "host-1", "token": "new"}]}`,
+			valid:   map[string]string{"old": "host-1", "new": "host-1"},
+		},
+		{
+			name:    "missing agent",
+			content: `{"tokens": [{"token": "plain-token"}]}`,
+			wantErr: "invalid token entry #0: agent is required",
+		},
+		{
+			name:    "missing token",
+			content: `{"tokens": [{"agent": "host-1"}]}`,
+			wantErr: "token or token_sha256 is required",
+		},
+		{
+			name:    "both token kinds",
+			content: `{"tokens": [{"agent": "host-1", "token": "a", "token_sha256": "` + hashHex + `"}]}`,
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:    "invalid hash",
+			content: `{"tokens": [{"agent": "host-1", "token_sha256": "abc"}]}`,
+			wantErr: "token_sha256 must be hex encoded sha256",
+		},
+		{
+			name:    "duplicate token",
+			content: `{"tokens": [{"agent": "host-1", "token": "a"}, {"agent": "host-2", "token": "a"}]}`,
+			wantErr: "invalid token entry #1: duplicate token",
+		},
+		{
+			name:    "unknown role",
+			content: `{"tokens": [{"agent": "host-1", "token": "a", "role": "root"}]}`,
+			wantErr: "invalid token entry #0: unknown role 'root'",
+		},
+		{
+			name:    "invalid json",
+			content: `{"tokens": `,
+			wantErr: "unable to parse tokens file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tokens.json")
+			writeTokens(t, path, tt.content)
+
+			store := NewTokenStore(log.MockLogger{})
+			err := store.Load(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			for token, wantAgent := range tt.valid {
+				principal, ok := store.Authenticate(token)
+				require.True(t, ok, token)
+				assert.Equal(t, wantAgent, principal.Agent)
+				assert.Equal(t, RoleWriter, principal.Role)
+			}
+			for _, token := range tt.invalid {
+				_, ok := store.Authenticate(token)
+				assert.False(t, ok, token)
+			}
+		})
+	}
+}
+
+func TestTokenStore_LoadRoles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	writeTokens(t, path, `{"tokens": [
+		{"agent": "grafana", "token": "r", "role": "reader", "read_prefixes": ["http_"]},
+		{"agent": "ops", "token": "a", "role": "Admin"},
+		{"agent": "host-1", "token": "w", "write_prefixes": ["host_1_"]}
+	]}`)
+
+	store := NewTokenStore(log.MockLogger{})
+	require.NoError(t, store.Load(path))
+
+	reader, ok := store.Authenticate("r")
+	require.True(t, ok)
+	assert.Equal(t, &Principal{Agent: "grafana", Role: RoleReader, ReadPrefixes: []string{"http_"}}, reader)
+
+	admin, ok := store.Authenticate("a")
+	require.True(t, ok)
+	assert.Equal(t, RoleAdmin, admin.Role)
+
+	writer, ok := store.Authenticate("w")
+	require.True(t, ok)
+	assert.Equal(t, &Principal{Agent: "host-1", Role: RoleWriter, WritePrefixes: []string{"host_1_"}}, writer)
+}
+
+func TestTokenStore_LoadNamespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	writeTokens(t, path, `{"tokens": [{"agent": "host-1", "token": "w", "namespace": "team-a"}]}`)
+
+	store := NewTokenStore(log.MockLogger{})
+	require.NoError(t, store.Load(path))
+
+	principal, ok := store.Authenticate("w")
+	require.True(t, ok)
+	assert.Equal(t, "team-a", principal.Namespace)
+
+	// некорректное имя пространства не должно приниматься
+	writeTokens(t, path, `{"tokens": [{"agent": "host-1", "token": "w", "namespace": "team a"}]}`)
+	require.Error(t, store.Load(path))
+}
+
+func TestTokenStore_LoadMissingFile(t *testing.T) {
+	store := NewTokenStore(log.MockLogger{})
+	assert.ErrorContains(t, store.Load(filepath.Join(t.TempDir(), "missing.json")), "unable to read tokens file")
+}
+
+func TestTokenStore_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	writeTokens(t, path, `{"tokens": [{"agent": "host-1", "token": "t1"}, {"agent": "host-2", "token": "t2"}]}`)
+
+	store := NewTokenStore(log.MockLogger{})
+	require.NoError(t, store.Load(path))
+
+	// отзыв одного агента не затрагивает остальных
+	writeTokens(t, path, `{"tokens": [{"agent": "host-1", "token": "t1", "revoked": true}, {"agent": "host-2", "token": "t2"}]}`)
+	require.NoError(t, store.Reload())
+	_, ok := store.Authenticate("t1")
+	assert.False(t, ok)
+	principal, ok := store.Authenticate("t2")
+	require.True(t, ok)
+	assert.Equal(t, "host-2", principal.Agent)
+
+	// при ошибке остаются прежние токены
+	writeTokens(t, path, `broken`)
+	require.Error(t, store.Reload())
+	_, ok = store.Authenticate("t2")
+	assert.True(t, ok)
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{header: "Bearer abc", want: "abc", ok: true},
+		{header: "bearer abc ", want: "abc", ok: true},
+		{header: "Bearer ", ok: false},
+		{header: "Basic abc", ok: false},
+		{header: "", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			token, ok := BearerToken(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, token)
+		})
+	}
+}
@@ -10,23 +10,89 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Kopleman/metcol/internal/common/envelope"
 	"github.com/Kopleman/metcol/internal/common/log"
 )
 
+const privateKeyExt = ".pem"
+
 func (bd *BodyDecryptor) DecryptBodyBytes(body []byte) ([]byte, error) {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
+
 	if bd.privateKey == nil {
 		return nil, nil
 	}
-	decrypted, err := rsa.DecryptOAEP(sha256.New(), nil, bd.privateKey, body, nil)
+	if envelope.IsSealed(body) {
+		return bd.openEnvelope(body)
+	}
+
+	// legacy agents encrypt small bodies with RSA-OAEP directly and send no key id
+	var err error
+	for _, key := range bd.candidates("") {
+		var decrypted []byte
+		decrypted, err = rsa.DecryptOAEP(sha256.New(), nil, key, body, nil)
+		if err == nil {
+			return decrypted, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to decrypt body: %w", err)
+}
+
+func (bd *BodyDecryptor) openEnvelope(body []byte) ([]byte, error) {
+	keyID, err := envelope.KeyID(body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt body: %w", err)
+		return nil, fmt.Errorf("failed to open envelope: %w", err)
+	}
+	keys := bd.candidates(keyID)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("failed to open envelope: unknown key id '%s'", keyID)
+	}
+
+	for _, key := range keys {
+		var decrypted []byte
+		decrypted, err = envelope.Open(key, body)
+		if err == nil {
+			return decrypted, nil
+		}
 	}
-	return decrypted, nil
+	return nil, fmt.Errorf("failed to open envelope: %w", err)
+}
+
+// candidates returns keys to try for payload, all keys starting with default one if key id is unknown.
+func (bd *BodyDecryptor) candidates(keyID string) []*rsa.PrivateKey {
+	if keyID != "" {
+		if key, ok := bd.keys[keyID]; ok {
+			return []*rsa.PrivateKey{key}
+		}
+		return nil
+	}
+
+	keys := make([]*rsa.PrivateKey, 0, len(bd.keys)+1)
+	keys = append(keys, bd.privateKey)
+	for _, key := range bd.keys {
+		if key != bd.privateKey {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// HasPrivateKey reports whether encrypted payloads can be decrypted.
+func (bd *BodyDecryptor) HasPrivateKey() bool {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
+
+	return bd.privateKey != nil
 }
 
 func (bd *BodyDecryptor) DecryptBody(body io.Reader) (io.Reader, error) {
-	if bd.privateKey == nil {
+	if !bd.HasPrivateKey() {
 		return body, nil
 	}
 	bodyBytes, err := io.ReadAll(body)
@@ -42,34 +108,106 @@ func (bd *BodyDecryptor) DecryptBody(body io.Reader) (io.Reader, error) {
 	return bytes.NewReader(decryptedBytes), nil
 }
 
+// LoadPrivateKey loads private key file or directory of *.pem key files.
+// Key id is taken from Key-Id PEM header or file name without extension.
+// With directory, key from most recently modified file is used for payloads without key id,
+// greatest id breaks the tie.
 func (bd *BodyDecryptor) LoadPrivateKey(privateKeyPath string) error {
 	if privateKeyPath == "" {
 		return nil
 	}
 
-	keyBytes, err := os.ReadFile(privateKeyPath)
+	info, err := os.Stat(privateKeyPath)
 	if err != nil {
 		return fmt.Errorf("unable to read private key file: %w", err)
 	}
 
+	paths := []string{privateKeyPath}
+	if info.IsDir() {
+		if paths, err = filepath.Glob(filepath.Join(privateKeyPath, "*"+privateKeyExt)); err != nil {
+			return fmt.Errorf("unable to list private keys dir: %w", err)
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("no private keys found in '%s'", privateKeyPath)
+		}
+	}
+
+	keys := make(map[string]*rsa.PrivateKey, len(paths))
+	var defaultID string
+	var defaultModTime time.Time
+	for _, path := range paths {
+		keyID, key, loadErr := loadPrivateKeyFile(path)
+		if loadErr != nil {
+			return loadErr
+		}
+		if _, ok := keys[keyID]; ok {
+			return fmt.Errorf("duplicate private key id '%s' in '%s'", keyID, path)
+		}
+		keys[keyID] = key
+
+		keyInfo, statErr := os.Stat(path)
+		if statErr != nil {
+			return fmt.Errorf("unable to read private key file: %w", statErr)
+		}
+		// ids are arbitrary, e.g. "key-9" sorts after "key-10", so newest file is picked
+		modTime := keyInfo.ModTime()
+		if defaultID == "" || modTime.After(defaultModTime) || (modTime.Equal(defaultModTime) && keyID > defaultID) {
+			defaultID, defaultModTime = keyID, modTime
+		}
+	}
+
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	bd.path = privateKeyPath
+	bd.keys = keys
+	bd.privateKey = keys[defaultID]
+
+	return nil
+}
+
+// Reload re-reads keys from path used in LoadPrivateKey. Current keys are kept on error.
+func (bd *BodyDecryptor) Reload() error {
+	bd.mu.RLock()
+	path := bd.path
+	bd.mu.RUnlock()
+
+	if err := bd.LoadPrivateKey(path); err != nil {
+		return fmt.Errorf("failed to reload private keys: %w", err)
+	}
+	bd.logger.Infof("private keys reloaded from '%s'", path)
+	return nil
+}
+
+func loadPrivateKeyFile(path string) (string, *rsa.PrivateKey, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read private key file: %w", err)
+	}
+
 	block, _ := pem.Decode(keyBytes)
 	if block == nil {
-		return errors.New("failed to parse private key PEM block")
+		return "", nil, errors.New("failed to parse private key PEM block")
 	}
 
 	privKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse private key: %w", err)
+		return "", nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
-	bd.privateKey = privKey
+	keyID := block.Headers[envelope.KeyIDHeader]
+	if keyID == "" {
+		keyID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
 
-	return nil
+	return keyID, privKey, nil
 }
 
 type BodyDecryptor struct {
 	logger     log.Logger
 	privateKey *rsa.PrivateKey
+	keys       map[string]*rsa.PrivateKey
+	path       string
+	mu         sync.RWMutex
 }
 
 func NewBodyDecryptor(logger log.Logger) *BodyDecryptor {
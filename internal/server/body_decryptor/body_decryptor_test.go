@@ -10,9 +10,12 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 	"testing/iotest"
+	"time"
 
+	"github.com/Kopleman/metcol/internal/common/envelope"
 	"github.com/Kopleman/metcol/internal/common/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -94,6 +97,27 @@ func TestDecryptBodyBytes_Success(t *testing.T) {
 	assert.Equal(t, plaintext, decrypted)
 }
 
+func TestDecryptBodyBytes_Envelope(t *testing.T) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// больше, чем помещается в один блок RSA-OAEP
+	plaintext := bytes.Repeat([]byte(`{"id":"foo","type":"gauge","value":1},`), 100)
+	sealed, err := envelope.Seal(&envelope.PublicKey{Key: &privKey.PublicKey}, plaintext)
+	require.NoError(t, err)
+
+	bd := NewBodyDecryptor(log.MockLogger{})
+	bd.privateKey = privKey
+
+	decrypted, err := bd.DecryptBodyBytes(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	sealed[len(sealed)-1] ^= 0xff
+	_, err = bd.DecryptBodyBytes(sealed)
+	assert.ErrorContains(t, err, "failed to open envelope")
+}
+
 func TestDecryptBodyBytes_InvalidCiphertext(t *testing.T) {
 	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	require.NoError(t, err)
@@ -151,3 +175,154 @@ func TestDecryptBody_ReadError(t *testing.T) {
 	_, err = bd.DecryptBody(errReader)
 	assert.ErrorContains(t, err, "failed to read body bytes")
 }
+
+func writeKey(t *testing.T, path string, key *rsa.PrivateKey, headers map[string]string) {
+	t.Helper()
+	block := &pem.Block{
+		Type:    "RSA PRIVATE KEY",
+		Headers: headers,
+		Bytes:   x509.MarshalPKCS1PrivateKey(key),
+	}
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+}
+
+func TestLoadPrivateKey_Dir(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKey(t, filepath.Join(dir, "2026a.pem"), oldKey, nil)
+	writeKey(t, filepath.Join(dir, "renamed.pem"), newKey, map[string]string{envelope.KeyIDHeader: "2026b"})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600))
+	modTime := time.Now()
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "2026a.pem"), modTime, modTime))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "renamed.pem"), modTime, modTime))
+
+	bd := NewBodyDecryptor(log.MockLogger{})
+	require.NoError(t, bd.LoadPrivateKey(dir))
+	assert.Len(t, bd.keys, 2)
+	// при одинаковом времени изменения по умолчанию используется ключ с наибольшим id
+	assert.Equal(t, newKey.D, bd.privateKey.D)
+
+	tests := []struct {
+		pub     *envelope.PublicKey
+		name    string
+		wantErr string
+	}{
+		{name: "old key id", pub: &envelope.PublicKey{Key: &oldKey.PublicKey, ID: "2026a"}},
+		{name: "new key id", pub: &envelope.PublicKey{Key: &newKey.PublicKey, ID: "2026b"}},
+		{name: "without key id", pub: &envelope.PublicKey{Key: &oldKey.PublicKey}},
+		{
+			name:    "unknown key id",
+			pub:     &envelope.PublicKey{Key: &oldKey.PublicKey, ID: "2025"},
+			wantErr: "unknown key id '2025'",
+		},
+		{
+			name:    "key id of other key",
+			pub:     &envelope.PublicKey{Key: &oldKey.PublicKey, ID: "2026b"},
+			wantErr: "failed to unwrap data key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, sealErr := envelope.Seal(tt.pub, []byte("payload"))
+			require.NoError(t, sealErr)
+
+			decrypted, decryptErr := bd.DecryptBodyBytes(sealed)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, decryptErr, tt.wantErr)
+				return
+			}
+			require.NoError(t, decryptErr)
+			assert.Equal(t, []byte("payload"), decrypted)
+		})
+	}
+
+	// legacy RSA-OAEP payload decrypted with any loaded key
+	legacy, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &oldKey.PublicKey, []byte("legacy"), nil)
+	require.NoError(t, err)
+	decrypted, err := bd.DecryptBodyBytes(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("legacy"), decrypted)
+}
+
+func TestLoadPrivateKey_DirDefaultKey(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKey(t, filepath.Join(dir, "key-9.pem"), oldKey, nil)
+	writeKey(t, filepath.Join(dir, "key-10.pem"), newKey, nil)
+	modTime := time.Now()
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "key-9.pem"), modTime.Add(-time.Hour), modTime.Add(-time.Hour)))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "key-10.pem"), modTime, modTime))
+
+	bd := NewBodyDecryptor(log.MockLogger{})
+	require.NoError(t, bd.LoadPrivateKey(dir))
+	// по умолчанию используется последний изменённый ключ, а не наибольший id
+	assert.Equal(t, newKey.D, bd.privateKey.D)
+}
+
+func TestLoadPrivateKey_DirErrors(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	emptyDir := t.TempDir()
+	duplicateDir := t.TempDir()
+	writeKey(t, filepath.Join(duplicateDir, "a.pem"), key, map[string]string{envelope.KeyIDHeader: "k1"})
+	writeKey(t, filepath.Join(duplicateDir, "b.pem"), key, map[string]string{envelope.KeyIDHeader: "k1"})
+	invalidDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(invalidDir, "bad.pem"), []byte("invalid"), 0o600))
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr string
+	}{
+		{name: "empty dir", dir: emptyDir, wantErr: "no private keys found"},
+		{name: "duplicate key id", dir: duplicateDir, wantErr: "duplicate private key id 'k1'"},
+		{name: "invalid key", dir: invalidDir, wantErr: "failed to parse private key PEM block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bd := NewBodyDecryptor(log.MockLogger{})
+			assert.ErrorContains(t, bd.LoadPrivateKey(tt.dir), tt.wantErr)
+			assert.False(t, bd.HasPrivateKey())
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKey(t, filepath.Join(dir, "k1.pem"), oldKey, nil)
+
+	bd := NewBodyDecryptor(log.MockLogger{})
+	require.NoError(t, bd.LoadPrivateKey(dir))
+
+	sealed, err := envelope.Seal(&envelope.PublicKey{Key: &newKey.PublicKey, ID: "k2"}, []byte("payload"))
+	require.NoError(t, err)
+	_, err = bd.DecryptBodyBytes(sealed)
+	require.ErrorContains(t, err, "unknown key id 'k2'")
+
+	writeKey(t, filepath.Join(dir, "k2.pem"), newKey, nil)
+	require.NoError(t, bd.Reload())
+	decrypted, err := bd.DecryptBodyBytes(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), decrypted)
+
+	// broken key keeps previously loaded keys
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "k3.pem"), []byte("invalid"), 0o600))
+	require.Error(t, bd.Reload())
+	decrypted, err = bd.DecryptBodyBytes(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), decrypted)
+}
@@ -4,6 +4,7 @@ package config
 import (
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/Kopleman/metcol/internal/common/flags"
 	"github.com/Kopleman/metcol/internal/common/utils"
@@ -16,11 +17,18 @@ const defaultRestoreVal bool = true
 const defaultCPUProfilePath string = "./profiles/cpuprofile.pprof"
 const defaultMemProfilePath string = "./profiles/memprofile.pprof"
 const defaultAddress string = "localhost:8080"
+const defaultHistoryRetention int64 = 86400
+const defaultHistorySize int64 = 1000
+const defaultStatsDFlushInterval int64 = 1
+const defaultReplayWindow int64 = 300
+const defaultNonceCacheSize int64 = 100000
+const defaultBatchRetention int64 = 3600
 
 // Config contains all settled via envs or flags params.
 type Config struct {
 	NetAddr             *flags.NetAddress // server address
 	GRPCAddr            *flags.NetAddress // server address
+	StatsDAddr          *flags.NetAddress // UDP address for StatsD listener
 	FileStoragePath     string            // path to file for mem-store dump
 	DataBaseDSN         string            // DSN of postgres DSN
 	Key                 string            // hash key for sign received data
@@ -28,24 +36,47 @@ type Config struct {
 	ProfilerMemFilePath string            // where to store mem profile
 	PrivateKeyPath      string            // path to private key
 	TrustedSubnet       string            // CIDR for filtering requests
+	TLSCertPath         string            // server certificate, enables TLS for HTTP and gRPC
+	TLSKeyPath          string            // server certificate private key
+	TLSClientCAPath     string            // CA for client certificates, enables mutual TLS
+	AgentTokensPath     string            // per-agent bearer tokens file, enables token auth
+	InfluxCounters      []string          // influx fields and measurements which integers are counters
 	StoreInterval       int64             // how often dump memo store to file
 	ProfilerCollectTime int64             // how long to collect data after start-up
+	HistoryRetention    int64             // how long to keep metric samples, in seconds
+	HistorySize         int64             // how many samples per metric memo-store keeps
+	StatsDFlushInterval int64             // how often aggregated StatsD metrics are stored, in seconds
+	ReplayWindow        int64             // allowed clock skew of signed requests in seconds, 0 disables replay protection
+	NonceCacheSize      int64             // how many nonces of signed requests are remembered
+	BatchRetention      int64             // how long applied batch IDs are remembered, in seconds
 	Restore             bool              // restore memo-store from file
 }
 
 type configFromSource struct {
-	Restore             *bool  `json:"restore" env:"RESTORE"`
-	EndPoint            string `json:"address" env:"ADDRESS"`
-	GRPCEndPoint        string `json:"grpc_address" env:"GRPC_ADDRESS"`
-	FileStoragePath     string `json:"file_storage_path" env:"FILE_STORAGE_PATH"`
-	DataBaseDSN         string `json:"database_dsn" env:"DATABASE_DSN"`
-	Key                 string `json:"key" env:"KEY"`
-	ProfilerCPUFilePath string `json:"profiler_cpu_file_path" env:"PROFILER_CPU_FILE_PATH"`
-	ProfilerMemFilePath string `json:"profiler_mem_file_path" env:"PROFILER_MEM_FILE_PATH"`
-	PrivateKeyPath      string `json:"crypto_key" env:"PRIVATE_KEY_PATH"`
-	TrustedSubnet       string `json:"trusted_subnet" env:"TRUSTED_SUBNET"`
-	StoreInterval       int64  `json:"store_interval" env:"STORE_INTERVAL"`
-	ProfilerCollectTime int64  `json:"profiler_collect_time" env:"PROFILER_COLLECT_TIME"`
+	Restore             *bool    `json:"restore" env:"RESTORE"`
+	EndPoint            string   `json:"address" env:"ADDRESS"`
+	GRPCEndPoint        string   `json:"grpc_address" env:"GRPC_ADDRESS"`
+	StatsDEndPoint      string   `json:"statsd_address" env:"STATSD_ADDRESS"`
+	FileStoragePath     string   `json:"file_storage_path" env:"FILE_STORAGE_PATH"`
+	DataBaseDSN         string   `json:"database_dsn" env:"DATABASE_DSN"`
+	Key                 string   `json:"key" env:"KEY"`
+	ProfilerCPUFilePath string   `json:"profiler_cpu_file_path" env:"PROFILER_CPU_FILE_PATH"`
+	ProfilerMemFilePath string   `json:"profiler_mem_file_path" env:"PROFILER_MEM_FILE_PATH"`
+	PrivateKeyPath      string   `json:"crypto_key" env:"PRIVATE_KEY_PATH"`
+	TrustedSubnet       string   `json:"trusted_subnet" env:"TRUSTED_SUBNET"`
+	TLSCertPath         string   `json:"tls_cert" env:"TLS_CERT"`
+	TLSKeyPath          string   `json:"tls_key" env:"TLS_KEY"`
+	TLSClientCAPath     string   `json:"tls_client_ca" env:"TLS_CLIENT_CA"`
+	AgentTokensPath     string   `json:"agent_tokens" env:"AGENT_TOKENS_PATH"`
+	InfluxCounters      []string `json:"influx_counters" env:"INFLUX_COUNTERS" envSeparator:","`
+	StoreInterval       int64    `json:"store_interval" env:"STORE_INTERVAL"`
+	ProfilerCollectTime int64    `json:"profiler_collect_time" env:"PROFILER_COLLECT_TIME"`
+	HistoryRetention    int64    `json:"history_retention" env:"HISTORY_RETENTION"`
+	HistorySize         int64    `json:"history_size" env:"HISTORY_SIZE"`
+	StatsDFlushInterval int64    `json:"statsd_flush_interval" env:"STATSD_FLUSH_INTERVAL"`
+	ReplayWindow        int64    `json:"replay_window" env:"REPLAY_WINDOW"`
+	NonceCacheSize      int64    `json:"nonce_cache_size" env:"NONCE_CACHE_SIZE"`
+	BatchRetention      int64    `json:"batch_retention" env:"BATCH_RETENTION"`
 }
 
 func applyConfigFromSource(source *configFromSource, config *Config) error {
@@ -56,11 +87,23 @@ func applyConfigFromSource(source *configFromSource, config *Config) error {
 	}
 
 	if source.GRPCEndPoint != "" {
+		if config.GRPCAddr == nil {
+			config.GRPCAddr = new(flags.NetAddress)
+		}
 		if err := config.GRPCAddr.Set(source.GRPCEndPoint); err != nil {
 			return fmt.Errorf("failed to set grpc endpoint address: %w", err)
 		}
 	}
 
+	if source.StatsDEndPoint != "" {
+		if config.StatsDAddr == nil {
+			config.StatsDAddr = new(flags.NetAddress)
+		}
+		if err := config.StatsDAddr.Set(source.StatsDEndPoint); err != nil {
+			return fmt.Errorf("failed to set statsd endpoint address: %w", err)
+		}
+	}
+
 	if source.StoreInterval > 0 {
 		config.StoreInterval = source.StoreInterval
 	}
@@ -93,6 +136,58 @@ func applyConfigFromSource(source *configFromSource, config *Config) error {
 		config.ProfilerMemFilePath = source.ProfilerMemFilePath
 	}
 
+	if source.PrivateKeyPath != "" {
+		config.PrivateKeyPath = source.PrivateKeyPath
+	}
+
+	if source.TrustedSubnet != "" {
+		config.TrustedSubnet = source.TrustedSubnet
+	}
+
+	if source.TLSCertPath != "" {
+		config.TLSCertPath = source.TLSCertPath
+	}
+
+	if source.TLSKeyPath != "" {
+		config.TLSKeyPath = source.TLSKeyPath
+	}
+
+	if source.AgentTokensPath != "" {
+		config.AgentTokensPath = source.AgentTokensPath
+	}
+
+	if source.TLSClientCAPath != "" {
+		config.TLSClientCAPath = source.TLSClientCAPath
+	}
+
+	if source.HistoryRetention > 0 {
+		config.HistoryRetention = source.HistoryRetention
+	}
+
+	if source.HistorySize > 0 {
+		config.HistorySize = source.HistorySize
+	}
+
+	if source.StatsDFlushInterval > 0 {
+		config.StatsDFlushInterval = source.StatsDFlushInterval
+	}
+
+	if source.ReplayWindow > 0 {
+		config.ReplayWindow = source.ReplayWindow
+	}
+
+	if source.NonceCacheSize > 0 {
+		config.NonceCacheSize = source.NonceCacheSize
+	}
+
+	if source.BatchRetention > 0 {
+		config.BatchRetention = source.BatchRetention
+	}
+
+	if len(source.InfluxCounters) > 0 {
+		config.InfluxCounters = source.InfluxCounters
+	}
+
 	return nil
 }
 
@@ -130,6 +225,11 @@ func applyConfigFromEnv(config *Config) error {
 	return nil
 }
 
+// TLSEnabled reports whether server listens with TLS.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertPath != ""
+}
+
 // ParseServerConfig produce config for server via parsing env and flags(envs preferred).
 func ParseServerConfig() (*Config, error) {
 	cfgFromFlags := new(configFromSource)
@@ -140,6 +240,7 @@ func ParseServerConfig() (*Config, error) {
 	config.NetAddr = netAddr
 	config.ProfilerCPUFilePath = defaultCPUProfilePath
 	config.ProfilerMemFilePath = defaultMemProfilePath
+	config.StatsDFlushInterval = defaultStatsDFlushInterval
 
 	flag.StringVar(&cfgFromFlags.EndPoint, "a", defaultAddress, "address and port of collector-server")
 
@@ -153,10 +254,34 @@ func ParseServerConfig() (*Config, error) {
 
 	flag.StringVar(&config.Key, "k", "", "cypher key")
 
-	flag.StringVar(&config.PrivateKeyPath, "crypto-key", "", "cypher key")
+	flag.StringVar(&config.PrivateKeyPath, "crypto-key", "", "path to private key or directory of rotated keys")
 
 	flag.StringVar(&config.ProfilerCPUFilePath, "t", "", "profiler cpu filename")
 
+	flag.StringVar(&config.TLSCertPath, "tls-cert", "", "path to server TLS certificate")
+
+	flag.StringVar(&config.TLSKeyPath, "tls-key", "", "path to server TLS private key")
+
+	flag.StringVar(&config.TLSClientCAPath, "tls-client-ca", "", "path to CA verifying agent certificates")
+
+	flag.StringVar(&config.AgentTokensPath, "agent-tokens", "", "path to per-agent bearer tokens file")
+
+	flag.Int64Var(&config.HistoryRetention, "history-retention", defaultHistoryRetention, "metric history retention in seconds")
+
+	flag.Int64Var(&config.HistorySize, "history-size", defaultHistorySize, "metric samples kept per series in memo-store")
+
+	flag.Int64Var(&config.ReplayWindow, "replay-window", defaultReplayWindow, "allowed clock skew of signed requests in seconds, 0 disables replay protection")
+
+	flag.Int64Var(&config.NonceCacheSize, "nonce-cache-size", defaultNonceCacheSize, "how many nonces of signed requests are remembered")
+
+	flag.Int64Var(&config.BatchRetention, "batch-retention", defaultBatchRetention, "how long applied batch IDs are remembered in seconds")
+
+	flag.Func("influx-counters", "comma separated influx fields (<measurement>_<field>) and measurements "+
+		"which integers are counters", func(value string) error {
+		config.InfluxCounters = strings.Split(value, ",")
+		return nil
+	})
+
 	pathToConfig := flag.String("c", "", "CIDR for filtering requests")
 
 	flag.Parse()
@@ -10,14 +10,25 @@ import (
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
 	"github.com/Kopleman/metcol/internal/server/metrics"
 	"github.com/Kopleman/metcol/internal/server/sterrors"
 	"github.com/go-chi/chi/v5"
 )
 
 type MetricsForGetValue interface {
-	GetValueAsString(ctx context.Context, metricType common.MetricType, name string) (string, error)
-	GetMetricAsDTO(ctx context.Context, metricType common.MetricType, name string) (*dto.MetricDTO, error)
+	GetValueAsString(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (string, error)
+	GetMetricAsDTO(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (*dto.MetricDTO, error)
 }
 
 // GetValueController instance of controller.
@@ -40,9 +51,11 @@ func NewGetValueController(logger log.Logger, metricsService MetricsForGetValue)
 //	@Produce		plain
 //	@Param			metricType	path		string	true	"Metric type"
 //	@Param			metricName	path		string	true	"Metric name"
+//	@Param			labels		query		string	false	"Metric labels as key=value query params"
 //	@Success		200		{string}			"OK"
 //	@Failure		400		"Bad request"
 //	@Failure		404		"Not found"
+//	@Failure		403		"Forbidden"
 //	@Failure		500		"Internal Server Error"
 //	@Router			/value/{metricType}/{metricName} [get]
 func (ctrl *GetValueController) GetValue() func(http.ResponseWriter, *http.Request) {
@@ -61,11 +74,17 @@ func (ctrl *GetValueController) GetValue() func(http.ResponseWriter, *http.Reque
 			return
 		}
 
+		labels := labelsFromQuery(req)
+
 		ctrl.logger.Infof("getValue called with metricType='%s', metricName='%s' at %s", metricType, metricName)
 
-		value, err := ctrl.metricsService.GetValueAsString(ctx, metricType, metricName)
+		value, err := ctrl.metricsService.GetValueAsString(ctx, metricType, metricName, labels)
 
 		if err != nil {
+			if errors.Is(err, auth.ErrForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
 			if errors.Is(err, sterrors.ErrNotFound) {
 				http.Error(w, err.Error(), http.StatusNotFound)
 				return
@@ -95,6 +114,7 @@ func (ctrl *GetValueController) GetValue() func(http.ResponseWriter, *http.Reque
 //	@Success		200				{object}	dto.MetricDTO
 //	@Failure		400		"Bad request"
 //	@Failure		404		"Not found"
+//	@Failure		403		"Forbidden"
 //	@Failure		500		"Internal Server Error"
 //	@Router			/value [post]
 func (ctrl *GetValueController) GetValueAsDTO() func(http.ResponseWriter, *http.Request) {
@@ -112,8 +132,12 @@ func (ctrl *GetValueController) GetValueAsDTO() func(http.ResponseWriter, *http.
 			metricNameField, reqDto.ID,
 		)
 
-		value, err := ctrl.metricsService.GetMetricAsDTO(ctx, reqDto.MType, reqDto.ID)
+		value, err := ctrl.metricsService.GetMetricAsDTO(ctx, reqDto.MType, reqDto.ID, reqDto.Labels)
 		if err != nil {
+			if errors.Is(err, auth.ErrForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
 			if errors.Is(err, sterrors.ErrNotFound) {
 				http.Error(w, err.Error(), http.StatusNotFound)
 				return
@@ -132,3 +156,16 @@ func (ctrl *GetValueController) GetValueAsDTO() func(http.ResponseWriter, *http.
 		}
 	}
 }
+
+// labelsFromQuery collects metric labels from url query params, e.g. /value/gauge/alloc?host=a.
+func labelsFromQuery(req *http.Request) map[string]string {
+	query := req.URL.Query()
+	if len(query) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(query))
+	for key := range query {
+		labels[key] = query.Get(key)
+	}
+	return labels
+}
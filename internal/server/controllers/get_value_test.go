@@ -21,23 +21,35 @@ import (
 )
 
 type MockMetricsService struct {
-	GetValueAsStringFn func(ctx context.Context, metricType common.MetricType, name string) (string, error)
-	GetMetricAsDTOFn   func(ctx context.Context, metricType common.MetricType, name string) (*dto.MetricDTO, error)
+	GetValueAsStringFn func(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (string, error)
+	GetMetricAsDTOFn func(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (*dto.MetricDTO, error)
 }
 
 func (m *MockMetricsService) GetValueAsString(
 	ctx context.Context, metricType common.MetricType,
 	name string,
+	labels map[string]string,
 ) (string, error) {
-	return m.GetValueAsStringFn(ctx, metricType, name)
+	return m.GetValueAsStringFn(ctx, metricType, name, labels)
 }
 
 func (m *MockMetricsService) GetMetricAsDTO(
 	ctx context.Context,
 	metricType common.MetricType,
 	name string,
+	labels map[string]string,
 ) (*dto.MetricDTO, error) {
-	return m.GetMetricAsDTOFn(ctx, metricType, name)
+	return m.GetMetricAsDTOFn(ctx, metricType, name, labels)
 }
 
 func TestGetValueController_GetValue(t *testing.T) {
@@ -52,7 +64,12 @@ func TestGetValueController_GetValue(t *testing.T) {
 			name: "invalid metric type",
 			url:  "/value/invalid_type/metric1",
 			mockSetup: func(ms *MockMetricsService) {
-				ms.GetValueAsStringFn = func(ctx context.Context, metricType common.MetricType, name string) (string, error) {
+				ms.GetValueAsStringFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (string, error) {
 					return "", errors.New("not used")
 				}
 			},
@@ -63,7 +80,12 @@ func TestGetValueController_GetValue(t *testing.T) {
 			name: "empty metric name",
 			url:  "/value/gauge/",
 			mockSetup: func(ms *MockMetricsService) {
-				ms.GetValueAsStringFn = func(ctx context.Context, metricType common.MetricType, name string) (string, error) {
+				ms.GetValueAsStringFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (string, error) {
 					return "", nil
 				}
 			},
@@ -74,7 +96,12 @@ func TestGetValueController_GetValue(t *testing.T) {
 			name: "metric not found",
 			url:  "/value/gauge/nonexistent",
 			mockSetup: func(ms *MockMetricsService) {
-				ms.GetValueAsStringFn = func(ctx context.Context, metricType common.MetricType, name string) (string, error) {
+				ms.GetValueAsStringFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (string, error) {
 					return "", sterrors.ErrNotFound
 				}
 			},
@@ -85,7 +112,12 @@ func TestGetValueController_GetValue(t *testing.T) {
 			name: "internal server error",
 			url:  "/value/counter/metric1",
 			mockSetup: func(ms *MockMetricsService) {
-				ms.GetValueAsStringFn = func(ctx context.Context, metricType common.MetricType, name string) (string, error) {
+				ms.GetValueAsStringFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (string, error) {
 					return "", errors.New("some error")
 				}
 			},
@@ -96,13 +128,37 @@ func TestGetValueController_GetValue(t *testing.T) {
 			name: "success case",
 			url:  "/value/gauge/metric1",
 			mockSetup: func(ms *MockMetricsService) {
-				ms.GetValueAsStringFn = func(ctx context.Context, metricType common.MetricType, name string) (string, error) {
+				ms.GetValueAsStringFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (string, error) {
 					return "123.45", nil
 				}
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   "123.45",
 		},
+		{
+			name: "success case with labels",
+			url:  "/value/gauge/metric1?host=a",
+			mockSetup: func(ms *MockMetricsService) {
+				ms.GetValueAsStringFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (string, error) {
+					if labels["host"] != "a" {
+						return "", sterrors.ErrNotFound
+					}
+					return "1", nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -146,7 +202,12 @@ func TestGetValueController_GetValueAsDTO(t *testing.T) {
 			name:        "metric not found",
 			requestBody: `{"id":"metric1", "type":"gauge"}`,
 			mockSetup: func(ms *MockMetricsService) {
-				ms.GetMetricAsDTOFn = func(ctx context.Context, metricType common.MetricType, name string) (*dto.MetricDTO, error) {
+				ms.GetMetricAsDTOFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (*dto.MetricDTO, error) {
 					return nil, sterrors.ErrNotFound
 				}
 			},
@@ -157,7 +218,12 @@ func TestGetValueController_GetValueAsDTO(t *testing.T) {
 			name:        "success case",
 			requestBody: `{"id":"metric1", "type":"gauge"}`,
 			mockSetup: func(ms *MockMetricsService) {
-				ms.GetMetricAsDTOFn = func(ctx context.Context, metricType common.MetricType, name string) (*dto.MetricDTO, error) {
+				ms.GetMetricAsDTOFn = func(
+					ctx context.Context,
+					metricType common.MetricType,
+					name string,
+					labels map[string]string,
+				) (*dto.MetricDTO, error) {
 					return &dto.MetricDTO{
 						ID:    "metric1",
 						MType: "gauge",
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/Kopleman/metcol/internal/server/influx"
+	"github.com/Kopleman/metcol/internal/server/metrics"
+)
+
+// InfluxWriteError body of partial write response, same shape InfluxDB uses.
+type InfluxWriteError struct {
+	Error string `json:"error"`
+}
+
+// LineProtocolParser converts InfluxDB line protocol into metrics.
+type LineProtocolParser interface {
+	Parse(r io.Reader) ([]*dto.MetricDTO, []*influx.LineError, error)
+}
+
+// InfluxController instance of controller.
+type InfluxController struct {
+	logger         log.Logger            // logger
+	metricsService MetricsForBatchUpdate // metrics service
+	bd             BodyDecryptor         // decrypts body via private key
+	parser         LineProtocolParser    // line protocol parser
+}
+
+// NewInfluxController creates instance of controller.
+func NewInfluxController(
+	logger log.Logger,
+	metricsService MetricsForBatchUpdate,
+	bd BodyDecryptor,
+	parser LineProtocolParser,
+) *InfluxController {
+	return &InfluxController{
+		logger:         logger,
+		metricsService: metricsService,
+		bd:             bd,
+		parser:         parser,
+	}
+}
+
+// Write accepts InfluxDB line protocol
+//
+//	@Summary		influxdb line protocol receiver
+//	@Description	stores valid lines and reports broken ones per line
+//	@Tags			metrics
+//	@Accept			plain
+//	@Produce		json
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	InfluxWriteError
+//	@Failure		403		"Forbidden"
+//	@Failure		500		"Internal Server Error"
+//	@Router			/write [post]
+func (ctrl *InfluxController) Write() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		decryptedBody, err := ctrl.bd.DecryptBody(req.Body)
+		if err != nil {
+			http.Error(w, "unable to decrypt body", http.StatusBadRequest)
+			return
+		}
+
+		metricsBatch, lineErrors, err := ctrl.parser.Parse(decryptedBody)
+		if err != nil {
+			ctrl.logger.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctrl.logger.Infow(
+			"influx write called",
+			"amount", len(metricsBatch),
+			"brokenLines", len(lineErrors),
+		)
+
+		if len(metricsBatch) > 0 {
+			if err = ctrl.metricsService.SetMetrics(ctx, metricsBatch); err != nil {
+				if errors.Is(err, auth.ErrForbidden) {
+					http.Error(w, err.Error(), http.StatusForbidden)
+					return
+				}
+				if errors.Is(err, metrics.ErrValueParse) {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				ctrl.logger.Error(err)
+				http.Error(w, common.Err500Message, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if len(lineErrors) > 0 {
+			messages := make([]string, 0, len(lineErrors))
+			for _, lineErr := range lineErrors {
+				messages = append(messages, lineErr.Error())
+			}
+			w.Header().Set(common.ContentType, "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			resp := InfluxWriteError{Error: "partial write: " + strings.Join(messages, "\n")}
+			if err = json.NewEncoder(w).Encode(resp); err != nil {
+				ctrl.logger.Error(err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/influx"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInfluxWrite(t *testing.T) {
+	expectedBatch := []*dto.MetricDTO{
+		{ID: "cpu_usage", MType: "gauge", Value: testutils.Pointer(0.5)},
+	}
+
+	tests := []struct {
+		mockError      error
+		name           string
+		body           string
+		expectedBody   string
+		expectedStatus int
+		expectSet      bool
+	}{
+		{
+			name:           "success",
+			body:           "cpu usage=0.5",
+			expectedStatus: http.StatusNoContent,
+			expectSet:      true,
+		},
+		{
+			name:           "partial write stores valid lines",
+			body:           "cpu usage=0.5\ncpu",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "partial write: line 2:",
+			expectSet:      true,
+		},
+		{
+			name:           "all lines broken",
+			body:           "cpu\nmem",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "line 1:",
+		},
+		{
+			name:           "internal server error",
+			body:           "cpu usage=0.5",
+			mockError:      errors.New("some error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectSet:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMetricsService)
+			mockDecrypter := new(MockBodyDecryptor)
+			ctrl := NewInfluxController(new(log.MockLogger), mockService, mockDecrypter, influx.NewParser(nil))
+
+			r := httptest.NewRequest(http.MethodPost, "/write", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			mockDecrypter.On("DecryptBody", mock.Anything).Return(bytes.NewBufferString(tt.body), nil).Once()
+			if tt.expectSet {
+				mockService.On("SetMetrics", mock.Anything, expectedBatch).Return(tt.mockError).Once()
+			}
+
+			ctrl.Write()(w, r)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/prometheus"
+)
+
+type MetricsForExport interface {
+	ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error)
+}
+
+// PrometheusController instance of controller.
+type PrometheusController struct {
+	logger         log.Logger       // logger
+	metricsService MetricsForExport // metrics service
+}
+
+// NewPrometheusController creates instance of controller.
+func NewPrometheusController(logger log.Logger, metricsService MetricsForExport) *PrometheusController {
+	return &PrometheusController{logger, metricsService}
+}
+
+// Metrics renders all stored metrics for prometheus scraping
+//
+//	@Summary		prometheus exposition
+//	@Description	renders all stored metrics in prometheus text exposition format
+//	@Tags			metrics
+//	@Produce		plain
+//	@Success		200		{string}			"OK"
+//	@Failure		500		"Internal Server Error"
+//	@Router			/metrics [get]
+func (ctrl *PrometheusController) Metrics() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		allMetrics, err := ctrl.metricsService.ExportMetrics(ctx)
+		if err != nil {
+			ctrl.logger.Error(err)
+			http.Error(w, common.Err500Message, http.StatusInternalServerError)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err = prometheus.WriteText(&buf, allMetrics); err != nil {
+			ctrl.logger.Error(err)
+			http.Error(w, common.Err500Message, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(common.ContentType, prometheus.ContentType)
+		w.WriteHeader(http.StatusOK)
+		if _, err = w.Write(buf.Bytes()); err != nil {
+			ctrl.logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/controllers"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockExportService struct {
+	ExportMetricsFn func(ctx context.Context) ([]*dto.MetricDTO, error)
+}
+
+func (m *MockExportService) ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error) {
+	return m.ExportMetricsFn(ctx)
+}
+
+func TestPrometheusController_Metrics(t *testing.T) {
+	tests := []struct {
+		exportFn       func(ctx context.Context) ([]*dto.MetricDTO, error)
+		name           string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "internal server error",
+			exportFn: func(ctx context.Context) ([]*dto.MetricDTO, error) {
+				return nil, errors.New("some error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "something went wrong\n",
+		},
+		{
+			name: "success case",
+			exportFn: func(ctx context.Context) ([]*dto.MetricDTO, error) {
+				return []*dto.MetricDTO{
+					{ID: "PollCount", MType: "counter", Delta: testutils.Pointer(int64(1))},
+				}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "# HELP PollCount_total counter metric \"PollCount\" collected by metcol.\n" +
+				"# TYPE PollCount_total counter\n" +
+				"PollCount_total 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody)
+			w := httptest.NewRecorder()
+
+			ms := &MockExportService{ExportMetricsFn: tt.exportFn}
+			ctrl := controllers.NewPrometheusController(&log.MockLogger{}, ms)
+
+			handler := ctrl.Metrics()
+			handler(w, r)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get(common.ContentType))
+			}
+		})
+	}
+}
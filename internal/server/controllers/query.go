@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/Kopleman/metcol/internal/server/metrics"
+)
+
+const defaultQueryRange = time.Hour
+
+// reservedQueryParams are not treated as metric labels.
+var reservedQueryParams = map[string]struct{}{
+	"name": {}, "type": {}, "from": {}, "to": {}, "step": {}, "agg": {},
+}
+
+type MetricsForQuery interface {
+	QueryRange(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error)
+}
+
+// QueryController instance of controller.
+type QueryController struct {
+	logger         log.Logger      // logger
+	metricsService MetricsForQuery // metrics service
+}
+
+// NewQueryController creates instance of controller.
+func NewQueryController(logger log.Logger, metricsService MetricsForQuery) *QueryController {
+	return &QueryController{logger, metricsService}
+}
+
+// parseQueryTime accepts RFC3339 or unix seconds.
+func parseQueryTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if unixSeconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unixSeconds, 0), nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unable to parse time '%s'", metrics.ErrInvalidQuery, value)
+	}
+	return parsed, nil
+}
+
+// parseQueryStep accepts go duration (e.g. 30s, 1m) or seconds.
+func parseQueryStep(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	step, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: unable to parse step '%s'", metrics.ErrInvalidQuery, value)
+	}
+	return step, nil
+}
+
+func parseQueryRequest(params url.Values) (*dto.QueryRequest, error) {
+	metricType, err := metrics.ParseMetricType(strings.ToLower(params.Get("type")))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", metrics.ErrInvalidQuery, err)
+	}
+	to, err := parseQueryTime(params.Get("to"), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	from, err := parseQueryTime(params.Get("from"), to.Add(-defaultQueryRange))
+	if err != nil {
+		return nil, err
+	}
+	step, err := parseQueryStep(params.Get("step"))
+	if err != nil {
+		return nil, err
+	}
+	aggregation := dto.Aggregation(strings.ToLower(params.Get("agg")))
+	if aggregation == "" {
+		aggregation = dto.AggregationAvg
+	}
+
+	var labels map[string]string
+	for key := range params {
+		if _, reserved := reservedQueryParams[key]; reserved {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[key] = params.Get(key)
+	}
+
+	return &dto.QueryRequest{
+		From:        from,
+		To:          to,
+		Labels:      labels,
+		ID:          params.Get("name"),
+		MType:       metricType,
+		Aggregation: aggregation,
+		Step:        step,
+	}, nil
+}
+
+// Query fetch aggregated metric history
+//
+//	@Summary		fetch aggregated metric history
+//	@Description	fetch metric history split by step with aggregation applied to each bucket
+//	@Tags			metrics
+//	@Produce		json
+//	@Param			name	query		string	true	"Metric name"
+//	@Param			type	query		string	true	"Metric type"
+//	@Param			from	query		string	false	"Range start, RFC3339 or unix seconds, default to-1h"
+//	@Param			to		query		string	false	"Range end, RFC3339 or unix seconds, default now"
+//	@Param			step	query		string	false	"Bucket width, e.g. 1m or seconds, default whole range"
+//	@Param			agg		query		string	false	"Aggregation: avg, min, max, sum, rate(counters only)"
+//	@Success		200		{object}	dto.SeriesDTO
+//	@Failure		400		"Bad request"
+//	@Failure		403		"Forbidden"
+//	@Failure		500		"Internal Server Error"
+//	@Router			/query [get]
+func (ctrl *QueryController) Query() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		query, err := parseQueryRequest(req.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctrl.logger.Infow(
+			"range query called",
+			metricTypeField, query.MType,
+			metricNameField, query.ID,
+			"aggregation", query.Aggregation,
+		)
+
+		series, err := ctrl.metricsService.QueryRange(ctx, query)
+		if err != nil {
+			if errors.Is(err, auth.ErrForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if errors.Is(err, metrics.ErrInvalidQuery) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			ctrl.logger.Error(err)
+			http.Error(w, common.Err500Message, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(common.ContentType, "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err = json.NewEncoder(w).Encode(series); err != nil {
+			http.Error(w, common.Err500Message, http.StatusInternalServerError)
+			return
+		}
+	}
+}
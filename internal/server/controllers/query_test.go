@@ -0,0 +1,114 @@
+package controllers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/controllers"
+	"github.com/Kopleman/metcol/internal/server/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockQueryService struct {
+	QueryRangeFn func(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error)
+}
+
+func (m *MockQueryService) QueryRange(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error) {
+	return m.QueryRangeFn(ctx, q)
+}
+
+func TestQueryController_Query(t *testing.T) {
+	tests := []struct {
+		queryRangeFn   func(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error)
+		name           string
+		url            string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "invalid metric type",
+			url:            "/query?name=foo&type=bar",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid query: unknown metric type\n",
+		},
+		{
+			name:           "invalid step",
+			url:            "/query?name=foo&type=gauge&step=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid query: unable to parse step 'abc'\n",
+		},
+		{
+			name: "invalid query from service",
+			url:  "/query?name=foo&type=gauge&agg=rate",
+			queryRangeFn: func(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error) {
+				return nil, metrics.ErrInvalidQuery
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid query\n",
+		},
+		{
+			name: "internal server error",
+			url:  "/query?name=foo&type=gauge",
+			queryRangeFn: func(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error) {
+				return nil, errors.New("some error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "something went wrong\n",
+		},
+		{
+			name: "mixed case name",
+			url:  "/query?name=HeapAlloc&type=gauge",
+			queryRangeFn: func(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error) {
+				// имя метрики сравнивается с учётом регистра, как в gRPC
+				if q.ID != "HeapAlloc" {
+					return nil, errors.New("unexpected query")
+				}
+				return &dto.SeriesDTO{ID: q.ID, MType: q.MType, Aggregation: q.Aggregation}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"HeapAlloc","type":"gauge","aggregation":"avg","points":null}` + "\n",
+		},
+		{
+			name: "success case",
+			url:  "/query?name=foo&type=counter&from=1767225600&to=1767225720&step=1m&agg=rate&host=a",
+			queryRangeFn: func(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error) {
+				if q.Step != time.Minute || q.From.Unix() != 1767225600 || q.To.Unix() != 1767225720 ||
+					q.Aggregation != dto.AggregationRate || q.Labels["host"] != "a" || len(q.Labels) != 1 {
+					return nil, errors.New("unexpected query")
+				}
+				return &dto.SeriesDTO{
+					ID:          q.ID,
+					MType:       q.MType,
+					Labels:      q.Labels,
+					Aggregation: q.Aggregation,
+					Points:      []*dto.PointDTO{{Timestamp: q.From.UTC(), Value: 0.5}},
+				}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"labels":{"host":"a"},"id":"foo","type":"counter","aggregation":"rate",` +
+				`"points":[{"timestamp":"2026-01-01T00:00:00Z","value":0.5}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, http.NoBody)
+			w := httptest.NewRecorder()
+
+			ms := &MockQueryService{QueryRangeFn: tt.queryRangeFn}
+			ctrl := controllers.NewQueryController(&log.MockLogger{}, ms)
+
+			handler := ctrl.Query()
+			handler(w, r)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
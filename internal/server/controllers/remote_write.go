@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/Kopleman/metcol/internal/server/metrics"
+	"github.com/Kopleman/metcol/internal/server/prometheus"
+	"github.com/Kopleman/metcol/proto/prompb"
+)
+
+type MetricsForBatchUpdate interface {
+	SetMetrics(ctx context.Context, metrics []*dto.MetricDTO) error
+}
+
+type RemoteWriteConverter interface {
+	Convert(namespace string, req *prompb.WriteRequest, store func([]*dto.MetricDTO) error) error
+}
+
+// RemoteWriteController instance of controller.
+type RemoteWriteController struct {
+	logger         log.Logger            // logger
+	metricsService MetricsForBatchUpdate // metrics service
+	bd             BodyDecryptor         // decrypts body via private key
+	converter      RemoteWriteConverter  // maps prometheus series to metrics
+}
+
+// NewRemoteWriteController creates instance of controller.
+func NewRemoteWriteController(
+	logger log.Logger,
+	metricsService MetricsForBatchUpdate,
+	bd BodyDecryptor,
+	converter RemoteWriteConverter,
+) *RemoteWriteController {
+	return &RemoteWriteController{
+		logger:         logger,
+		metricsService: metricsService,
+		bd:             bd,
+		converter:      converter,
+	}
+}
+
+// Write accepts prometheus remote-write request
+//
+//	@Summary		prometheus remote-write receiver
+//	@Description	accepts snappy-compressed protobuf WriteRequest and stores its samples
+//	@Tags			metrics
+//	@Accept			application/x-protobuf
+//	@Success		204		"No Content"
+//	@Failure		400		"Bad request"
+//	@Failure		403		"Forbidden"
+//	@Failure		500		"Internal Server Error"
+//	@Router			/api/v1/write [post]
+func (ctrl *RemoteWriteController) Write() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		decryptedBody, err := ctrl.bd.DecryptBody(req.Body)
+		if err != nil {
+			http.Error(w, "unable to decrypt body", http.StatusBadRequest)
+			return
+		}
+
+		writeRequest, err := prometheus.DecodeWriteRequest(decryptedBody)
+		if err != nil {
+			ctrl.logger.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		namespace := identity.NamespaceFromContext(ctx)
+		err = ctrl.converter.Convert(namespace, writeRequest, func(metricsBatch []*dto.MetricDTO) error {
+			ctrl.logger.Infow(
+				"remote-write called",
+				"series", len(writeRequest.GetTimeseries()),
+				"amount", len(metricsBatch),
+			)
+			if len(metricsBatch) == 0 {
+				return nil
+			}
+			if setErr := ctrl.metricsService.SetMetrics(ctx, metricsBatch); setErr != nil {
+				return fmt.Errorf("unable to store remote-write metrics: %w", setErr)
+			}
+			return nil
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, prometheus.ErrRemoteWriteParse):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, auth.ErrForbidden):
+				http.Error(w, err.Error(), http.StatusForbidden)
+			case errors.Is(err, metrics.ErrValueParse):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				ctrl.logger.Error(err)
+				http.Error(w, common.Err500Message, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
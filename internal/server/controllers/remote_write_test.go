@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/prometheus"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/Kopleman/metcol/proto/prompb"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func newRemoteWriteBody(t *testing.T) []byte {
+	t.Helper()
+	nameLabel := &prompb.Label{}
+	nameLabel.SetName("__name__")
+	nameLabel.SetValue("temperature")
+	sample := &prompb.Sample{}
+	sample.SetValue(21.5)
+	series := &prompb.TimeSeries{}
+	series.SetLabels([]*prompb.Label{nameLabel})
+	series.SetSamples([]*prompb.Sample{sample})
+	req := &prompb.WriteRequest{}
+	req.SetTimeseries([]*prompb.TimeSeries{series})
+
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+	return snappy.Encode(nil, data)
+}
+
+func TestRemoteWrite(t *testing.T) {
+	validBody := newRemoteWriteBody(t)
+	expectedBatch := []*dto.MetricDTO{
+		{ID: "temperature", MType: "gauge", Value: testutils.Pointer(21.5)},
+	}
+
+	tests := []struct {
+		mockError      error
+		name           string
+		body           []byte
+		expectedStatus int
+		expectSet      bool
+	}{
+		{
+			name:           "success",
+			body:           validBody,
+			expectedStatus: http.StatusNoContent,
+			expectSet:      true,
+		},
+		{
+			name:           "not snappy body",
+			body:           []byte("plain text"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "internal server error",
+			body:           validBody,
+			mockError:      errors.New("some error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectSet:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMetricsService)
+			mockDecrypter := new(MockBodyDecryptor)
+			ctrl := NewRemoteWriteController(
+				new(log.MockLogger),
+				mockService,
+				mockDecrypter,
+				prometheus.NewRemoteWriteConverter(),
+			)
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			mockDecrypter.On("DecryptBody", mock.Anything).Return(bytes.NewReader(tt.body), nil).Once()
+			if tt.expectSet {
+				mockService.On("SetMetrics", mock.Anything, expectedBatch).Return(tt.mockError).Once()
+			}
+
+			ctrl.Write()(w, r)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
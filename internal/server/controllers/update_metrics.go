@@ -12,7 +12,9 @@ import (
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
 	"github.com/Kopleman/metcol/internal/server/metrics"
 	"github.com/go-chi/chi/v5"
 )
@@ -21,6 +23,7 @@ type MetricsForUpdate interface {
 	SetMetric(ctx context.Context, metricType common.MetricType, name string, value string) error
 	SetMetricByDto(ctx context.Context, metricDto *dto.MetricDTO) error
 	SetMetrics(ctx context.Context, metrics []*dto.MetricDTO) error
+	SetMetricsBatch(ctx context.Context, batchID string, metrics []*dto.MetricDTO) ([]*dto.MetricDTO, error)
 }
 
 type BodyDecryptor interface {
@@ -59,6 +62,7 @@ func NewUpdateMetricsController(
 //	@Success		200		{string}			"OK"
 //	@Failure		400		"Bad request"
 //	@Failure		404		"Not found"
+//	@Failure		403		"Forbidden"
 //	@Failure		500		"Internal Server Error"
 //	@Router			/update/{metricType}/{metricName}/{metricValue} [post]
 func (ctrl *UpdateMetricsController) UpdateOrSet() func(http.ResponseWriter, *http.Request) {
@@ -92,6 +96,10 @@ func (ctrl *UpdateMetricsController) UpdateOrSet() func(http.ResponseWriter, *ht
 
 		err = ctrl.metricsService.SetMetric(ctx, metricType, metricName, metricValue)
 
+		if errors.Is(err, auth.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if errors.Is(err, metrics.ErrValueParse) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -117,6 +125,7 @@ func (ctrl *UpdateMetricsController) UpdateOrSet() func(http.ResponseWriter, *ht
 //	@Param			data			body	dto.MetricDTO	true	"Body params"
 //	@Success		200				{object}	dto.MetricDTO
 //	@Failure		400		"Bad request"
+//	@Failure		403		"Forbidden"
 //	@Failure		500		"Internal Server Error"
 //	@Router			/update [post]
 func (ctrl *UpdateMetricsController) UpdateOrSetViaDTO() func(http.ResponseWriter, *http.Request) {
@@ -145,6 +154,10 @@ func (ctrl *UpdateMetricsController) UpdateOrSetViaDTO() func(http.ResponseWrite
 
 		err := ctrl.metricsService.SetMetricByDto(ctx, metricDto)
 
+		if errors.Is(err, auth.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if errors.Is(err, metrics.ErrValueParse) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -195,13 +208,21 @@ func (ctrl *UpdateMetricsController) parseUpdateBody(req *http.Request) ([]*dto.
 //	@Accept			json
 //	@Produce		json
 //	@Param			data			body	[]dto.MetricDTO	true	"Body params"
+//	@Param			X-Metcol-Batch-ID	header	string	false	"Batch ID, retried batch is not applied twice"
 //	@Success		200				{array}	dto.MetricDTO
 //	@Failure		400		"Bad request"
+//	@Failure		403		"Forbidden"
 //	@Failure		500		"Internal Server Error"
 //	@Router			/update [post]
 func (ctrl *UpdateMetricsController) UpdateMetrics() func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
+		batchID := req.Header.Get(common.BatchIDHeader)
+		if len(batchID) > common.MaxBatchIDLength {
+			http.Error(w, "batch id is too long", http.StatusBadRequest)
+			return
+		}
+
 		metricsBatch, err := ctrl.parseUpdateBody(req)
 		if err != nil {
 			ctrl.logger.Error(err)
@@ -209,12 +230,26 @@ func (ctrl *UpdateMetricsController) UpdateMetrics() func(http.ResponseWriter, *
 			return
 		}
 
+		agent, _ := identity.AgentFromContext(ctx)
 		ctrl.logger.Infow(
 			"metrics update called",
 			"amount", len(metricsBatch),
+			"agent", agent,
+			"batch", batchID,
 		)
 
-		setError := ctrl.metricsService.SetMetrics(ctx, metricsBatch)
+		result := metricsBatch
+		var setError error
+		if batchID != "" {
+			result, setError = ctrl.metricsService.SetMetricsBatch(ctx, batchID, metricsBatch)
+		} else {
+			setError = ctrl.metricsService.SetMetrics(ctx, metricsBatch)
+		}
+
+		if errors.Is(setError, auth.ErrForbidden) {
+			http.Error(w, setError.Error(), http.StatusForbidden)
+			return
+		}
 
 		if setError != nil {
 			msg := common.Err500Message
@@ -228,7 +263,7 @@ func (ctrl *UpdateMetricsController) UpdateMetrics() func(http.ResponseWriter, *
 
 		w.Header().Set(common.ContentType, "application/json")
 		w.WriteHeader(http.StatusOK)
-		if err = json.NewEncoder(w).Encode(metricsBatch); err != nil {
+		if err = json.NewEncoder(w).Encode(result); err != nil {
 			http.Error(w, common.Err500Message, http.StatusBadRequest)
 			return
 		}
@@ -14,6 +14,7 @@ import (
 	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/common/log"
 	"github.com/Kopleman/metcol/internal/server/metrics"
+	"github.com/Kopleman/metcol/internal/testutils"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -44,6 +45,16 @@ func (m *MockMetricsService) SetMetrics(ctx context.Context, metrics []*dto.Metr
 	return args.Error(0) //nolint:wrapcheck // mocked-err
 }
 
+func (m *MockMetricsService) SetMetricsBatch(
+	ctx context.Context,
+	batchID string,
+	metrics []*dto.MetricDTO,
+) ([]*dto.MetricDTO, error) {
+	args := m.Called(ctx, batchID, metrics)
+	result, _ := args.Get(0).([]*dto.MetricDTO)
+	return result, args.Error(1) //nolint:wrapcheck // mocked-err
+}
+
 type MockBodyDecryptor struct {
 	mock.Mock
 }
@@ -274,6 +285,41 @@ func TestUpdateMetrics(t *testing.T) {
 	}
 }
 
+func TestUpdateMetrics_Batch(t *testing.T) {
+	body := `[{"id":"PollCount","type":"counter","delta":1}]`
+	// при повторе сервис возвращает результат первой доставки
+	original := []*dto.MetricDTO{{ID: "PollCount", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1))}}
+
+	decryptor := new(MockBodyDecryptor)
+	decryptor.On("DecryptBody", mock.Anything).Return(strings.NewReader(body), nil)
+	service := new(MockMetricsService)
+	service.On("SetMetricsBatch", mock.Anything, "batch-1", mock.Anything).Return(original, nil).Once()
+
+	ctrl := NewUpdateMetricsController(log.MockLogger{}, service, decryptor)
+
+	req := httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))
+	req.Header.Set(common.BatchIDHeader, "batch-1")
+	w := httptest.NewRecorder()
+	ctrl.UpdateMetrics()(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close() //nolint:all // tests
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result []*dto.MetricDTO
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, original, result)
+	service.AssertNotCalled(t, "SetMetrics", mock.Anything, mock.Anything)
+
+	// слишком длинный ID отклоняется до разбора тела
+	ctrl = NewUpdateMetricsController(log.MockLogger{}, service, new(MockBodyDecryptor))
+	req = httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(body))
+	req.Header.Set(common.BatchIDHeader, strings.Repeat("x", common.MaxBatchIDLength+1))
+	w = httptest.NewRecorder()
+	ctrl.UpdateMetrics()(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestParseUpdateBody(t *testing.T) {
 	tests := []struct {
 		mockDecryptErr error
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/metrics"
+)
+
+const watchHeartbeatInterval = 15 * time.Second
+
+type MetricsForWatch interface {
+	Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func())
+}
+
+// WatchController instance of controller.
+type WatchController struct {
+	logger         log.Logger      // logger
+	metricsService MetricsForWatch // metrics service
+}
+
+// NewWatchController creates instance of controller.
+func NewWatchController(logger log.Logger, metricsService MetricsForWatch) *WatchController {
+	return &WatchController{
+		logger:         logger,
+		metricsService: metricsService,
+	}
+}
+
+// Watch streams metric changes as server-sent events
+//
+//	@Summary		live metric updates
+//	@Description	pushes every accepted metric change as `metric` event, filtered by name prefix and types
+//	@Tags			metrics
+//	@Produce		text/event-stream
+//	@Param			prefix	query		string	false	"Metric name prefix"
+//	@Param			type	query		[]string	false	"Metric types"
+//	@Success		200		{object}	dto.MetricDTO
+//	@Failure		400		"Bad request"
+//	@Failure		500		"Internal Server Error"
+//	@Router			/watch [get]
+func (ctrl *WatchController) Watch() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		filter := dto.WatchFilter{Prefix: req.URL.Query().Get("prefix")}
+		for _, rawType := range req.URL.Query()["type"] {
+			metricType, err := metrics.ParseMetricType(rawType)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			filter.Types = append(filter.Types, metricType)
+		}
+
+		rc := http.NewResponseController(w)
+		updates, cancel := ctrl.metricsService.Watch(req.Context(), filter)
+		defer cancel()
+
+		w.Header().Set(common.ContentType, "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			ctrl.logger.Error(fmt.Errorf("streaming unsupported: %w", err))
+			return
+		}
+
+		heartbeat := time.NewTicker(watchHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		ctx := req.Context()
+		for {
+			var err error
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				_, err = fmt.Fprint(w, ": ping\n\n")
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				err = writeMetricEvent(w, update)
+			}
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				ctrl.logger.Error(fmt.Errorf("failed to write metric event: %w", err))
+				return
+			}
+		}
+	}
+}
+
+func writeMetricEvent(w http.ResponseWriter, metric *dto.MetricDTO) error {
+	data, err := json.Marshal(metric)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metric: %w", err)
+	}
+	if _, err = fmt.Fprintf(w, "event: metric\ndata: %s\n\n", data); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeWatcher struct {
+	updates chan *dto.MetricDTO
+	filter  dto.WatchFilter
+}
+
+func (f *fakeWatcher) Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func()) {
+	f.filter = filter
+	return f.updates, func() {}
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedBody   string
+		expectedFilter dto.WatchFilter
+		expectedStatus int
+	}{
+		{
+			name:           "streams updates",
+			query:          "?prefix=foo&type=gauge&type=counter",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "event: metric\ndata: {\"value\":1.5,\"id\":\"foo\",\"type\":\"gauge\"}\n\n",
+			expectedFilter: dto.WatchFilter{
+				Prefix: "foo",
+				Types:  []common.MetricType{common.GaugeMetricType, common.CounterMetricType},
+			},
+		},
+		{
+			name:           "unknown type",
+			query:          "?type=foo",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unknown metric type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := &fakeWatcher{updates: make(chan *dto.MetricDTO, 1)}
+			watcher.updates <- &dto.MetricDTO{ID: "foo", MType: common.GaugeMetricType, Value: testutils.Pointer(1.5)}
+			close(watcher.updates)
+			ctrl := NewWatchController(new(log.MockLogger), watcher)
+
+			r := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/watch"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			ctrl.Watch()(w, r)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get(common.ContentType))
+				assert.Equal(t, tt.expectedFilter, watcher.filter)
+			}
+		})
+	}
+}
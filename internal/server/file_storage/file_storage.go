@@ -16,7 +16,7 @@ import (
 )
 
 type MetricService interface {
-	ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error)
+	DumpMetrics(ctx context.Context) ([]*dto.MetricDTO, error)
 	ImportMetrics(ctx context.Context, metricsToImport []*dto.MetricDTO) error
 }
 
@@ -30,13 +30,13 @@ type FileStorage struct {
 	decoder       *json.Decoder  // decoder
 }
 
-// ExportMetrics export metrics to file specified in config.
+// ExportMetrics export metrics of all namespaces to file specified in config.
 func (fs *FileStorage) ExportMetrics() error {
 	ctx := context.Background()
 	if err := fs.file.Truncate(0); err != nil {
 		return fmt.Errorf("could not truncate file store: %w", err)
 	}
-	metricsAsDTO, err := fs.metricService.ExportMetrics(ctx)
+	metricsAsDTO, err := fs.metricService.DumpMetrics(ctx)
 	if err != nil {
 		return fmt.Errorf("could not export metrics: %w", err)
 	}
@@ -20,7 +20,7 @@ type MockMetricService struct {
 	mock.Mock
 }
 
-func (m *MockMetricService) ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error) {
+func (m *MockMetricService) DumpMetrics(ctx context.Context) ([]*dto.MetricDTO, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]*dto.MetricDTO), args.Error(1) //nolint:all // tests
 }
@@ -42,7 +42,7 @@ func TestFileStorage_ExportMetrics(t *testing.T) {
 		}
 
 		mockService := new(MockMetricService)
-		mockService.On("ExportMetrics", mock.Anything).Return(metrics, nil)
+		mockService.On("DumpMetrics", mock.Anything).Return(metrics, nil)
 
 		cfg := &config.Config{FileStoragePath: tmpFile.Name()}
 		fs := NewFileStorage(cfg, nil, mockService)
@@ -63,7 +63,7 @@ func TestFileStorage_ExportMetrics(t *testing.T) {
 
 	t.Run("export error", func(t *testing.T) {
 		mockService := new(MockMetricService)
-		mockService.On("ExportMetrics", mock.Anything).Return([]*dto.MetricDTO{}, errors.New("export error"))
+		mockService.On("DumpMetrics", mock.Anything).Return([]*dto.MetricDTO{}, errors.New("export error"))
 
 		cfg := &config.Config{FileStoragePath: "/dev/null"}
 		fs := NewFileStorage(cfg, nil, mockService)
@@ -154,7 +154,7 @@ func TestFileStorage_Close(t *testing.T) {
 		defer os.Remove(tmpFile.Name()) //nolint:all // tests
 
 		mockService := new(MockMetricService)
-		mockService.On("ExportMetrics", mock.Anything).Return([]*dto.MetricDTO{}, nil)
+		mockService.On("DumpMetrics", mock.Anything).Return([]*dto.MetricDTO{}, nil)
 
 		mockLogger := new(log.MockLogger)
 		cfg := &config.Config{FileStoragePath: tmpFile.Name()}
@@ -2,13 +2,20 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/common/log"
 	"github.com/Kopleman/metcol/internal/common/utils"
+	"github.com/Kopleman/metcol/internal/server/metrics"
 	pb "github.com/Kopleman/metcol/proto/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type MetricsService struct {
@@ -20,11 +27,24 @@ type MetricsService struct {
 type Metrics interface {
 	SetMetric(ctx context.Context, metricType common.MetricType, name string, value string) error
 	SetMetricByDto(ctx context.Context, metricDto *dto.MetricDTO) error
-	GetValueAsString(ctx context.Context, metricType common.MetricType, name string) (string, error)
-	GetMetricAsDTO(ctx context.Context, metricType common.MetricType, name string) (*dto.MetricDTO, error)
+	GetValueAsString(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (string, error)
+	GetMetricAsDTO(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (*dto.MetricDTO, error)
 	GetAllValuesAsString(ctx context.Context) (map[string]string, error)
 	SetMetrics(ctx context.Context, metrics []*dto.MetricDTO) error
+	SetMetricsBatch(ctx context.Context, batchID string, metrics []*dto.MetricDTO) ([]*dto.MetricDTO, error)
 	ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error)
+	QueryRange(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error)
+	Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func())
 }
 
 func NewMetricsService(logger log.Logger, metricsService Metrics) *MetricsService {
@@ -39,7 +59,7 @@ func (s *MetricsService) GetMetric(
 	req *pb.GetMetricRequest,
 ) (*pb.GetMetricResponse, error) {
 	metricType := utils.ConvertProtoMetricType(req.GetType())
-	metric, err := s.metricsService.GetMetricAsDTO(ctx, metricType, req.GetId())
+	metric, err := s.metricsService.GetMetricAsDTO(ctx, metricType, req.GetId(), req.GetLabels())
 	if err != nil {
 		s.logger.Error(err)
 		return nil, fmt.Errorf("unable to get metric: %w", err)
@@ -75,14 +95,36 @@ func (s *MetricsService) UpdateMetrics(
 		metrics = append(metrics, utils.ConvertProtoMetricToDTO(m))
 	}
 
-	err := s.metricsService.SetMetrics(ctx, metrics)
-	if err != nil {
-		s.logger.Error(err)
-		return nil, fmt.Errorf("unable to update metrics: %w", err)
+	var batchID string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(common.BatchIDMetadataKey); len(values) > 0 {
+		batchID = values[0]
+	}
+	if len(batchID) > common.MaxBatchIDLength {
+		return nil, status.Error(codes.InvalidArgument, "batch id is too long")
 	}
 
 	resp := &pb.UpdateMetricsResponse{}
-	resp.SetMetrics(req.GetMetrics())
+	if batchID == "" {
+		if err := s.metricsService.SetMetrics(ctx, metrics); err != nil {
+			s.logger.Error(err)
+			return nil, fmt.Errorf("unable to update metrics: %w", err)
+		}
+		resp.SetMetrics(req.GetMetrics())
+		return resp, nil
+	}
+
+	// retried batch gets result of its first delivery
+	result, err := s.metricsService.SetMetricsBatch(ctx, batchID, metrics)
+	if err != nil {
+		s.logger.Error(err)
+		return nil, fmt.Errorf("unable to update metrics batch '%s': %w", batchID, err)
+	}
+	respMetrics := make([]*pb.Metric, 0, len(result))
+	for _, m := range result {
+		respMetrics = append(respMetrics, utils.ConvertDTOToProtoMetric(m))
+	}
+	resp.SetMetrics(respMetrics)
 	return resp, nil
 }
 
@@ -105,3 +147,111 @@ func (s *MetricsService) GetAllMetrics(
 	resp.SetMetrics(metrics)
 	return resp, nil
 }
+
+func (s *MetricsService) QueryMetric(
+	ctx context.Context,
+	req *pb.QueryMetricRequest,
+) (*pb.QueryMetricResponse, error) {
+	series, err := s.metricsService.QueryRange(ctx, utils.ConvertProtoQueryToDTO(req))
+	if err != nil {
+		if errors.Is(err, metrics.ErrInvalidQuery) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		s.logger.Error(err)
+		return nil, fmt.Errorf("unable to query metric: %w", err)
+	}
+
+	return utils.ConvertDTOSeriesToProto(series), nil
+}
+
+// StreamMetrics stores every received batch and acknowledges it with same seq.
+// Seq must increase within stream, otherwise stream is closed. Failed batch is acknowledged with error
+// and does not close the stream, batch with batch id is applied only once, so client may resend it.
+func (s *MetricsService) StreamMetrics(stream grpc.BidiStreamingServer[pb.StreamMetricsRequest, pb.StreamMetricsAck]) error {
+	ctx := stream.Context()
+	var lastSeq uint64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to receive metrics batch: %w", err)
+		}
+		if req.GetSeq() <= lastSeq {
+			return status.Errorf(codes.InvalidArgument, "seq %d does not increase, last seq %d", req.GetSeq(), lastSeq)
+		}
+		lastSeq = req.GetSeq()
+
+		ack := &pb.StreamMetricsAck{}
+		ack.SetSeq(req.GetSeq())
+		accepted, err := s.storeStreamBatch(ctx, req)
+		if err != nil {
+			s.logger.Error(err)
+			ack.SetError(err.Error())
+		} else {
+			ack.SetAccepted(uint32(accepted)) //nolint:gosec // batch size fits uint32
+		}
+
+		if err = stream.Send(ack); err != nil {
+			return fmt.Errorf("unable to send ack: %w", err)
+		}
+	}
+}
+
+// storeStreamBatch stores metrics of stream batch, returns number of stored metrics.
+func (s *MetricsService) storeStreamBatch(ctx context.Context, req *pb.StreamMetricsRequest) (int, error) {
+	batch := make([]*dto.MetricDTO, 0, len(req.GetMetrics()))
+	for _, m := range req.GetMetrics() {
+		batch = append(batch, utils.ConvertProtoMetricToDTO(m))
+	}
+
+	batchID := req.GetBatchId()
+	if batchID == "" {
+		if err := s.metricsService.SetMetrics(ctx, batch); err != nil {
+			return 0, fmt.Errorf("unable to update metrics: %w", err)
+		}
+		return len(batch), nil
+	}
+	if len(batchID) > common.MaxBatchIDLength {
+		return 0, errors.New("batch id is too long")
+	}
+
+	// retried batch gets result of its first delivery
+	result, err := s.metricsService.SetMetricsBatch(ctx, batchID, batch)
+	if err != nil {
+		return 0, fmt.Errorf("unable to update metrics batch '%s': %w", batchID, err)
+	}
+	return len(result), nil
+}
+
+// WatchMetrics pushes every accepted metric change matching request filter until client leaves.
+func (s *MetricsService) WatchMetrics(
+	req *pb.WatchMetricsRequest,
+	stream grpc.ServerStreamingServer[pb.WatchMetricsResponse],
+) error {
+	filter := dto.WatchFilter{Prefix: req.GetPrefix()}
+	for _, t := range req.GetTypes() {
+		filter.Types = append(filter.Types, utils.ConvertProtoMetricType(t))
+	}
+
+	updates, cancel := s.metricsService.Watch(stream.Context(), filter)
+	defer cancel()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			resp := &pb.WatchMetricsResponse{}
+			resp.SetMetric(utils.ConvertDTOToProtoMetric(update))
+			if err := stream.Send(resp); err != nil {
+				return fmt.Errorf("unable to send metric update: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/common/utils"
+	bodydecryptor "github.com/Kopleman/metcol/internal/server/body_decryptor"
+	"github.com/Kopleman/metcol/internal/server/memstore"
+	"github.com/Kopleman/metcol/internal/server/metrics"
+	"github.com/Kopleman/metcol/internal/testutils"
+	pb "github.com/Kopleman/metcol/proto/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type streamMetrics struct {
+	Metrics
+	updates chan *dto.MetricDTO
+	filter  dto.WatchFilter
+	failOn  string
+	stored  []*dto.MetricDTO
+}
+
+func (m *streamMetrics) Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func()) {
+	m.filter = filter
+	return m.updates, func() {}
+}
+
+func (m *streamMetrics) SetMetrics(_ context.Context, metrics []*dto.MetricDTO) error {
+	for _, metric := range metrics {
+		if metric.ID == m.failOn {
+			return errors.New("store failed")
+		}
+	}
+	m.stored = append(m.stored, metrics...)
+	return nil
+}
+
+func newStreamBatch(t *testing.T, key []byte, seq uint64, ids ...string) *pb.StreamMetricsRequest {
+	t.Helper()
+	metrics := make([]*pb.Metric, 0, len(ids))
+	for _, id := range ids {
+		m := &pb.Metric{}
+		m.SetId(id)
+		m.SetType(pb.MetricType_GAUGE)
+		m.SetValue(1)
+		metrics = append(metrics, m)
+	}
+	req := &pb.StreamMetricsRequest{}
+	req.SetSeq(seq)
+	req.SetMetrics(metrics)
+	require.NoError(t, utils.SignMessage(key, req))
+	return req
+}
+
+func newTestClient(t *testing.T, svc Metrics, key []byte) pb.MetricsServiceClient {
+	t.Helper()
+	server := NewServer(
+		log.MockLogger{},
+		NewMetricsService(log.MockLogger{}, svc),
+		"",
+		string(key),
+		nil,
+		bodydecryptor.NewBodyDecryptor(log.MockLogger{}),
+		nil,
+		nil,
+	)
+
+	lis := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return pb.NewMetricsServiceClient(conn)
+}
+
+func TestMetricsService_StreamMetrics(t *testing.T) {
+	key := []byte("secret")
+	svc := &streamMetrics{failOn: "broken"}
+	client := newTestClient(t, svc, key)
+
+	stream, err := client.StreamMetrics(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Send(newStreamBatch(t, key, 1, "foo", "bar")))
+	ack, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), ack.GetSeq())
+	require.Equal(t, uint32(2), ack.GetAccepted())
+	require.Empty(t, ack.GetError())
+	expectedHash, err := utils.MessageHash(key, ack)
+	require.NoError(t, err)
+	require.Equal(t, expectedHash, ack.GetHash())
+
+	// failed batch is acknowledged with error, stream stays open
+	require.NoError(t, stream.Send(newStreamBatch(t, key, 2, "broken")))
+	ack, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), ack.GetSeq())
+	require.NotEmpty(t, ack.GetError())
+
+	require.NoError(t, stream.Send(newStreamBatch(t, key, 3, "baz")))
+	ack, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), ack.GetAccepted())
+
+	// tampered batch closes stream
+	tampered := newStreamBatch(t, key, 4, "qux")
+	tampered.SetSeq(5)
+	require.NoError(t, stream.Send(tampered))
+	_, err = stream.Recv()
+	require.Error(t, err)
+
+	require.NoError(t, stream.CloseSend())
+	require.Len(t, svc.stored, 3)
+}
+
+func TestMetricsService_StreamMetrics_Seq(t *testing.T) {
+	key := []byte("secret")
+	client := newTestClient(t, &streamMetrics{}, key)
+
+	stream, err := client.StreamMetrics(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Send(newStreamBatch(t, key, 2, "foo")))
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	// seq does not increase, stream is closed
+	require.NoError(t, stream.Send(newStreamBatch(t, key, 2, "bar")))
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMetricsService_StreamMetrics_Batch(t *testing.T) {
+	ctx := context.Background()
+	svc := metrics.NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+	client := newTestClient(t, svc, nil)
+
+	stream, err := client.StreamMetrics(ctx)
+	require.NoError(t, err)
+
+	counter := &pb.Metric{}
+	counter.SetId("foo")
+	counter.SetType(pb.MetricType_COUNTER)
+	counter.SetDelta(5)
+	for seq := range uint64(3) {
+		req := &pb.StreamMetricsRequest{}
+		req.SetSeq(seq + 1)
+		req.SetBatchId("batch-1")
+		req.SetMetrics([]*pb.Metric{counter})
+		require.NoError(t, stream.Send(req))
+
+		ack, recvErr := stream.Recv()
+		require.NoError(t, recvErr)
+		require.Empty(t, ack.GetError())
+		require.Equal(t, uint32(1), ack.GetAccepted())
+	}
+
+	req := &pb.StreamMetricsRequest{}
+	req.SetSeq(4)
+	req.SetBatchId(strings.Repeat("a", common.MaxBatchIDLength+1))
+	require.NoError(t, stream.Send(req))
+	ack, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotEmpty(t, ack.GetError())
+	require.NoError(t, stream.CloseSend())
+
+	stored, err := svc.GetMetricAsDTO(ctx, common.CounterMetricType, "foo", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), *stored.Delta)
+}
+
+func TestMetricsService_WatchMetrics(t *testing.T) {
+	key := []byte("secret")
+	svc := &streamMetrics{updates: make(chan *dto.MetricDTO, 1)}
+	svc.updates <- &dto.MetricDTO{ID: "foo", MType: common.GaugeMetricType, Value: testutils.Pointer(1.5)}
+	close(svc.updates)
+	client := newTestClient(t, svc, key)
+
+	req := &pb.WatchMetricsRequest{}
+	req.SetPrefix("fo")
+	req.SetTypes([]pb.MetricType{pb.MetricType_GAUGE})
+	require.NoError(t, utils.SignMessage(key, req))
+
+	stream, err := client.WatchMetrics(context.Background(), req)
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "foo", resp.GetMetric().GetId())
+	require.InDelta(t, 1.5, resp.GetMetric().GetValue(), 0)
+	expectedHash, err := utils.MessageHash(key, resp)
+	require.NoError(t, err)
+	require.Equal(t, expectedHash, resp.GetHash())
+
+	_, err = stream.Recv()
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, dto.WatchFilter{Prefix: "fo", Types: []common.MetricType{common.GaugeMetricType}}, svc.filter)
+}
+
+func TestMetricsService_UpdateMetrics_Batch(t *testing.T) {
+	ctx := context.Background()
+	svc := metrics.NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+	client := newTestClient(t, svc, nil)
+
+	counter := &pb.Metric{}
+	counter.SetId("foo")
+	counter.SetType(pb.MetricType_COUNTER)
+	counter.SetDelta(5)
+	req := &pb.UpdateMetricsRequest{}
+	req.SetMetrics([]*pb.Metric{counter})
+
+	batchCtx := metadata.AppendToOutgoingContext(ctx, common.BatchIDMetadataKey, "batch-1")
+	for range 3 {
+		resp, err := client.UpdateMetrics(batchCtx, req)
+		require.NoError(t, err)
+		require.Len(t, resp.GetMetrics(), 1)
+		require.Equal(t, int64(5), resp.GetMetrics()[0].GetDelta())
+	}
+
+	stored, err := svc.GetMetricAsDTO(ctx, common.CounterMetricType, "foo", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), *stored.Delta)
+
+	tooLong := metadata.AppendToOutgoingContext(ctx, common.BatchIDMetadataKey, strings.Repeat("a", common.MaxBatchIDLength+1))
+	_, err = client.UpdateMetrics(tooLong, req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
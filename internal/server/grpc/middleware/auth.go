@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationMetadataKey = "authorization"
+
+type TokenAuthenticator interface {
+	Authenticate(token string) (*auth.Principal, bool)
+}
+
+// Auth requires valid agent bearer token in "authorization" metadata and stores token owner as agent identity and principal.
+func Auth(tokens TokenAuthenticator) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := authenticate(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthStream stream version of Auth.
+func AuthStream(tokens TokenAuthenticator) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := authenticate(ss.Context(), tokens)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, tokens TokenAuthenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationMetadataKey)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "bearer token is required")
+	}
+
+	token, ok := auth.BearerToken(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "bearer token is required")
+	}
+
+	principal, ok := tokens.Authenticate(token)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return auth.WithPrincipal(identity.WithAgent(ctx, principal.Agent), principal), nil
+}
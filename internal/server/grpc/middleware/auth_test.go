@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// stubTokens сопоставляет токен агенту
+type stubTokens map[string]string
+
+func (s stubTokens) Authenticate(token string) (*auth.Principal, bool) {
+	agent, ok := s[token]
+	if !ok {
+		return nil, false
+	}
+	return &auth.Principal{Agent: agent, Role: auth.RoleWriter}, true
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		md        metadata.MD
+		wantAgent string
+		wantCode  codes.Code
+	}{
+		{name: "valid token", md: metadata.Pairs("authorization", "Bearer t1"), wantAgent: "host-1", wantCode: codes.OK},
+		{name: "unknown token", md: metadata.Pairs("authorization", "Bearer t2"), wantCode: codes.Unauthenticated},
+		{name: "not bearer scheme", md: metadata.Pairs("authorization", "t1"), wantCode: codes.Unauthenticated},
+		{name: "missing metadata", wantCode: codes.Unauthenticated},
+	}
+
+	tokens := stubTokens{"t1": "host-1"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			// Проверяем unary и stream варианты
+			_, err := Auth(tokens)(ctx, "test", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				agent, _ := identity.AgentFromContext(ctx)
+				require.Equal(t, tt.wantAgent, agent)
+				return nil, nil
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+
+			err = AuthStream(tokens)(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+				agent, _ := identity.AgentFromContext(ss.Context())
+				require.Equal(t, tt.wantAgent, agent)
+				return nil
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type PayloadDecryptor interface {
+	HasPrivateKey() bool
+	DecryptBodyBytes(body []byte) ([]byte, error)
+}
+
+// EncryptedMessage proto message able to carry its own fields in encrypted form.
+type EncryptedMessage interface {
+	proto.Message
+	GetEncrypted() []byte
+	SetEncrypted(v []byte)
+}
+
+// Decrypt replaces encrypted payload of request with decrypted fields.
+// Must be chained after Hash, so hash is checked over payload as it was sent.
+func Decrypt(decryptor PayloadDecryptor) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := openMessage(decryptor, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// DecryptStream stream version of Decrypt, applied to every received message.
+func DecryptStream(decryptor PayloadDecryptor) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &decryptedServerStream{ServerStream: ss, decryptor: decryptor})
+	}
+}
+
+type decryptedServerStream struct {
+	grpc.ServerStream
+	decryptor PayloadDecryptor
+}
+
+func (s *decryptedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err //nolint:wrapcheck // io.EOF must reach handler as is
+	}
+
+	return openMessage(s.decryptor, m)
+}
+
+func openMessage(decryptor PayloadDecryptor, m interface{}) error {
+	msg, ok := m.(EncryptedMessage)
+	if !ok || len(msg.GetEncrypted()) == 0 {
+		return nil
+	}
+
+	if !decryptor.HasPrivateKey() {
+		return status.Error(codes.FailedPrecondition, "encrypted payload is not accepted, server has no private key")
+	}
+
+	plain, err := decryptor.DecryptBodyBytes(msg.GetEncrypted())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "unable to decrypt payload")
+	}
+
+	msg.SetEncrypted(nil)
+	if err = (proto.UnmarshalOptions{Merge: true}).Unmarshal(plain, msg); err != nil {
+		return status.Error(codes.InvalidArgument, "unable to parse decrypted payload")
+	}
+
+	return nil
+}
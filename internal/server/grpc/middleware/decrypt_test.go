@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/envelope"
+	pb "github.com/Kopleman/metcol/proto/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// envelopeDecryptor расшифровывает payload закрытым ключом
+type envelopeDecryptor struct {
+	key *rsa.PrivateKey
+}
+
+func (d envelopeDecryptor) HasPrivateKey() bool {
+	return d.key != nil
+}
+
+func (d envelopeDecryptor) DecryptBodyBytes(body []byte) ([]byte, error) {
+	return envelope.Open(d.key, body) //nolint:wrapcheck // tests
+}
+
+func newSealedRequest(t *testing.T, pub *rsa.PublicKey) *pb.UpdateMetricsRequest {
+	t.Helper()
+	metric := &pb.Metric{}
+	metric.SetId("foo")
+	metric.SetType(pb.MetricType_COUNTER)
+	metric.SetDelta(5)
+	plain := &pb.UpdateMetricsRequest{}
+	plain.SetMetrics([]*pb.Metric{metric})
+
+	data, err := proto.Marshal(plain)
+	require.NoError(t, err)
+	sealed, err := envelope.Seal(&envelope.PublicKey{Key: pub}, data)
+	require.NoError(t, err)
+
+	req := &pb.UpdateMetricsRequest{}
+	req.SetEncrypted(sealed)
+	return req
+}
+
+func TestDecrypt(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	plain := &pb.UpdateMetricsRequest{}
+	plain.SetMetrics([]*pb.Metric{{}})
+
+	tests := []struct {
+		name      string
+		decryptor PayloadDecryptor
+		req       *pb.UpdateMetricsRequest
+		wantCode  codes.Code
+		wantID    string
+	}{
+		{
+			name:      "sealed request",
+			decryptor: envelopeDecryptor{key: key},
+			req:       newSealedRequest(t, &key.PublicKey),
+			wantCode:  codes.OK,
+			wantID:    "foo",
+		},
+		{
+			name:      "plain request passes through",
+			decryptor: envelopeDecryptor{},
+			req:       plain,
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "server without private key",
+			decryptor: envelopeDecryptor{},
+			req:       newSealedRequest(t, &key.PublicKey),
+			wantCode:  codes.FailedPrecondition,
+		},
+		{
+			name:      "wrong key",
+			decryptor: envelopeDecryptor{key: otherKey},
+			req:       newSealedRequest(t, &key.PublicKey),
+			wantCode:  codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *pb.UpdateMetricsRequest
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				got = req.(*pb.UpdateMetricsRequest)
+				return nil, nil
+			}
+
+			_, err := Decrypt(tt.decryptor)(context.Background(), tt.req, &grpc.UnaryServerInfo{}, handler)
+			require.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				require.Nil(t, got)
+				return
+			}
+			require.Empty(t, got.GetEncrypted())
+			require.Len(t, got.GetMetrics(), 1)
+			require.Equal(t, tt.wantID, got.GetMetrics()[0].GetId())
+		})
+	}
+}
+
+func TestDecryptStream(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	plain := &pb.StreamMetricsRequest{}
+	metric := &pb.Metric{}
+	metric.SetId("bar")
+	plain.SetSeq(7)
+	plain.SetMetrics([]*pb.Metric{metric})
+	data, err := proto.Marshal(plain)
+	require.NoError(t, err)
+	sealed, err := envelope.Seal(&envelope.PublicKey{Key: &key.PublicKey}, data)
+	require.NoError(t, err)
+	in := &pb.StreamMetricsRequest{}
+	in.SetEncrypted(sealed)
+
+	ss := &mockServerStream{ctx: context.Background(), in: in}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		got := &pb.StreamMetricsRequest{}
+		require.NoError(t, stream.RecvMsg(got))
+		require.Empty(t, got.GetEncrypted())
+		require.Equal(t, uint64(7), got.GetSeq())
+		require.Equal(t, "bar", got.GetMetrics()[0].GetId())
+		return nil
+	}
+
+	err = DecryptStream(envelopeDecryptor{key: key})(nil, ss, &grpc.StreamServerInfo{}, handler)
+	require.NoError(t, err)
+}
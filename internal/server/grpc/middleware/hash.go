@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Kopleman/metcol/internal/common/signature"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -16,7 +17,14 @@ import (
 
 const HashSHA256 = "HashSHA256"
 
-func Hash(key []byte) grpc.UnaryServerInterceptor {
+// ReplayGuard accepts every call stamp only once.
+type ReplayGuard interface {
+	Check(stamp signature.Stamp) error
+}
+
+// Hash validates call signature. With non-nil replayGuard calls must carry timestamp
+// and nonce metadata, and every of them is accepted only once.
+func Hash(key []byte, replayGuard ReplayGuard) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -39,6 +47,11 @@ func Hash(key []byte) grpc.UnaryServerInterceptor {
 
 		receivedHash := hashValues[0]
 
+		stamp, err := stampFromMetadata(md)
+		if err != nil {
+			return nil, err
+		}
+
 		reqI, ok := req.(interface{ Marshal() ([]byte, error) })
 		if !ok {
 			return nil, status.Error(codes.InvalidArgument, "request body not marshalling")
@@ -49,14 +62,16 @@ func Hash(key []byte) grpc.UnaryServerInterceptor {
 			return nil, status.Error(codes.Internal, "failed to marshal request")
 		}
 
-		h := hmac.New(sha256.New, key)
-		h.Write(reqBytes)
-		calculatedHash := hex.EncodeToString(h.Sum(nil))
+		calculatedHash := hex.EncodeToString(signature.Sum(key, stamp, reqBytes))
 
 		if receivedHash != calculatedHash {
 			return nil, status.Error(codes.InvalidArgument, "invalid hash")
 		}
 
+		if err = checkReplay(replayGuard, stamp); err != nil {
+			return nil, err
+		}
+
 		newCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs(HashSHA256, calculatedHash))
 
 		resp, err := handler(newCtx, req)
@@ -74,7 +89,7 @@ func Hash(key []byte) grpc.UnaryServerInterceptor {
 			return nil, fmt.Errorf("failed to marshal response: %w", err)
 		}
 
-		h = hmac.New(sha256.New, key)
+		h := hmac.New(sha256.New, key)
 		h.Write(respBytes)
 		respHash := hex.EncodeToString(h.Sum(nil))
 
@@ -85,3 +100,30 @@ func Hash(key []byte) grpc.UnaryServerInterceptor {
 		return resp, nil
 	}
 }
+
+// stampFromMetadata reads call timestamp and nonce, empty stamp if call was signed without them.
+func stampFromMetadata(md metadata.MD) (signature.Stamp, error) {
+	var timestamp, nonce string
+	if values := md.Get(signature.TimestampMetadataKey); len(values) > 0 {
+		timestamp = values[0]
+	}
+	if values := md.Get(signature.NonceMetadataKey); len(values) > 0 {
+		nonce = values[0]
+	}
+
+	stamp, err := signature.ParseStamp(timestamp, nonce)
+	if err != nil {
+		return signature.Stamp{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return stamp, nil
+}
+
+func checkReplay(replayGuard ReplayGuard, stamp signature.Stamp) error {
+	if replayGuard == nil {
+		return nil
+	}
+	if err := replayGuard.Check(stamp); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
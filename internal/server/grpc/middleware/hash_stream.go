@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"github.com/Kopleman/metcol/internal/common/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// HashStream checks HMAC of every received stream message and signs every sent one.
+// Stream metadata is sent once, so hash travels inside message itself (see utils.HashedMessage).
+// Timestamp and nonce of stream are part of every received message hash, with non-nil
+// replayGuard they are checked once, on first message.
+func HashStream(key []byte, replayGuard ReplayGuard) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if len(key) == 0 {
+			return handler(srv, ss)
+		}
+
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		stamp, err := stampFromMetadata(md)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &hashedServerStream{ServerStream: ss, replay: replayGuard, stamp: stamp, key: key})
+	}
+}
+
+type hashedServerStream struct {
+	grpc.ServerStream
+	replay  ReplayGuard
+	stamp   signature.Stamp
+	key     []byte
+	checked bool // stamp was accepted by replay guard
+}
+
+func (s *hashedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err //nolint:wrapcheck // io.EOF must reach handler as is
+	}
+
+	msg, ok := m.(utils.HashedMessage)
+	if !ok {
+		return status.Error(codes.InvalidArgument, "message does not carry hash")
+	}
+
+	receivedHash := msg.GetHash()
+	if receivedHash == "" {
+		return status.Error(codes.InvalidArgument, "hash not found")
+	}
+
+	calculatedHash, err := utils.StampedMessageHash(s.key, s.stamp, msg)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to marshal request")
+	}
+
+	if receivedHash != calculatedHash {
+		return status.Error(codes.InvalidArgument, "invalid hash")
+	}
+
+	if !s.checked {
+		if err = checkReplay(s.replay, s.stamp); err != nil {
+			return err
+		}
+		s.checked = true
+	}
+
+	return nil
+}
+
+func (s *hashedServerStream) SendMsg(m interface{}) error {
+	if msg, ok := m.(utils.HashedMessage); ok {
+		if err := utils.SignMessage(s.key, msg); err != nil {
+			return fmt.Errorf("failed to sign response: %w", err)
+		}
+	}
+
+	return s.ServerStream.SendMsg(m) //nolint:wrapcheck // transport error passed as is
+}
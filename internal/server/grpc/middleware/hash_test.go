@@ -91,7 +91,7 @@ func TestHash(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			interceptor := Hash(tt.key)
+			interceptor := Hash(tt.key, nil)
 
 			// Создаем контекст с метаданными
 			ctx := context.Background()
@@ -142,7 +142,7 @@ func TestHash_ResponseHash(t *testing.T) {
 	respData := []byte("response-data")
 	validHash := calculateHash(testKey, testData)
 
-	interceptor := Hash(testKey)
+	interceptor := Hash(testKey, nil)
 
 	// Создаем контекст с метаданными
 	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(common.HashSHA256, validHash))
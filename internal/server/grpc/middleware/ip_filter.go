@@ -17,30 +17,53 @@ func IPFilter(trustedCIDR string) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		p, ok := peer.FromContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.PermissionDenied, "peer information not available")
+		if err := checkPeer(ctx, trustedCIDR); err != nil {
+			return nil, err
 		}
 
-		clientIP, _, err := net.SplitHostPort(p.Addr.String())
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid client address")
-		}
+		return handler(ctx, req)
+	}
+}
 
-		_, ipnet, err := net.ParseCIDR(trustedCIDR)
-		if err != nil {
-			return nil, status.Error(codes.Internal, "invalid CIDR format")
+func IPFilterStream(trustedCIDR string) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := checkPeer(ss.Context(), trustedCIDR); err != nil {
+			return err
 		}
 
-		ip := net.ParseIP(clientIP)
-		if ip == nil {
-			return nil, status.Error(codes.InvalidArgument, "invalid IP address format")
-		}
+		return handler(srv, ss)
+	}
+}
 
-		if !ipnet.Contains(ip) {
-			return nil, status.Error(codes.PermissionDenied, "access denied")
-		}
+func checkPeer(ctx context.Context, trustedCIDR string) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.PermissionDenied, "peer information not available")
+	}
 
-		return handler(ctx, req)
+	clientIP, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid client address")
 	}
+
+	_, ipnet, err := net.ParseCIDR(trustedCIDR)
+	if err != nil {
+		return status.Error(codes.Internal, "invalid CIDR format")
+	}
+
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return status.Error(codes.InvalidArgument, "invalid IP address format")
+	}
+
+	if !ipnet.Contains(ip) {
+		return status.Error(codes.PermissionDenied, "access denied")
+	}
+
+	return nil
 }
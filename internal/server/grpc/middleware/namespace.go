@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Namespace resolves metrics namespace of call from credentials or "x-metcol-namespace" metadata.
+func Namespace() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := withNamespace(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// NamespaceStream stream version of Namespace.
+func NamespaceStream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := withNamespace(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func withNamespace(ctx context.Context) (context.Context, error) {
+	var requested string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(identity.NamespaceMetadataKey); len(values) > 0 {
+		requested = values[0]
+	}
+
+	namespace, err := auth.ResolveNamespace(ctx, requested)
+	if err != nil {
+		code := codes.InvalidArgument
+		if errors.Is(err, auth.ErrForbidden) {
+			code = codes.PermissionDenied
+		}
+		return nil, status.Error(code, err.Error())
+	}
+
+	return identity.WithNamespace(ctx, namespace), nil
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNamespace(t *testing.T) {
+	bound := &auth.Principal{Agent: "host-1", Role: auth.RoleWriter, Namespace: "team-a"}
+
+	tests := []struct {
+		name          string
+		principal     *auth.Principal
+		requested     string
+		wantNamespace string
+		wantCode      codes.Code
+	}{
+		{name: "default namespace", wantCode: codes.OK},
+		{name: "namespace from metadata", requested: "team-b", wantNamespace: "team-b", wantCode: codes.OK},
+		{name: "namespace from credentials", principal: bound, wantNamespace: "team-a", wantCode: codes.OK},
+		{name: "foreign namespace", principal: bound, requested: "team-b", wantCode: codes.PermissionDenied},
+		{name: "invalid namespace", requested: "team/b", wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.requested != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(identity.NamespaceMetadataKey, tt.requested))
+			}
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+
+			// Проверяем unary и stream варианты
+			var gotNamespace string
+			_, err := Namespace()(ctx, "test", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				gotNamespace = identity.NamespaceFromContext(ctx)
+				return nil, nil
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantNamespace, gotNamespace)
+
+			gotNamespace = ""
+			err = NamespaceStream()(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+				gotNamespace = identity.NamespaceFromContext(ss.Context())
+				return nil
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantNamespace, gotNamespace)
+		})
+	}
+}
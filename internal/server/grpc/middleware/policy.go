@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Kopleman/metcol/internal/server/auth"
+	pb "github.com/Kopleman/metcol/proto/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// methodActions maps service methods to action required by them. Unknown methods require admin role.
+var methodActions = map[string]auth.Action{
+	pb.MetricsService_GetMetric_FullMethodName:     auth.ActionRead,
+	pb.MetricsService_GetAllMetrics_FullMethodName: auth.ActionRead,
+	pb.MetricsService_QueryMetric_FullMethodName:   auth.ActionRead,
+	pb.MetricsService_WatchMetrics_FullMethodName:  auth.ActionRead,
+	pb.MetricsService_UpdateMetric_FullMethodName:  auth.ActionWrite,
+	pb.MetricsService_UpdateMetrics_FullMethodName: auth.ActionWrite,
+	pb.MetricsService_StreamMetrics_FullMethodName: auth.ActionWrite,
+}
+
+// Policy checks principal role against called method. Metric name prefixes are checked
+// by service itself, their violations are reported as codes.PermissionDenied.
+func Policy() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := authorizeMethod(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		return resp, forbiddenToStatus(err)
+	}
+}
+
+// PolicyStream stream version of Policy.
+func PolicyStream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := authorizeMethod(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return forbiddenToStatus(handler(srv, ss))
+	}
+}
+
+func authorizeMethod(ctx context.Context, fullMethod string) error {
+	action, ok := methodActions[fullMethod]
+	if !ok {
+		action = auth.ActionAdmin
+	}
+	if err := auth.Authorize(ctx, action); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+func forbiddenToStatus(err error) error {
+	if errors.Is(err, auth.ErrForbidden) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
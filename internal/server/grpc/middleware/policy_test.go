@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/server/auth"
+	pb "github.com/Kopleman/metcol/proto/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPolicy(t *testing.T) {
+	reader := &auth.Principal{Agent: "grafana", Role: auth.RoleReader}
+	writer := &auth.Principal{Agent: "host-1", Role: auth.RoleWriter}
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		method     string
+		handlerErr error
+		wantCode   codes.Code
+	}{
+		{name: "no principal", method: "/metrics.MetricsService/Unknown", wantCode: codes.OK},
+		{name: "reader reads", principal: reader, method: pb.MetricsService_GetAllMetrics_FullMethodName, wantCode: codes.OK},
+		{name: "reader writes", principal: reader, method: pb.MetricsService_UpdateMetrics_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "writer writes", principal: writer, method: pb.MetricsService_StreamMetrics_FullMethodName, wantCode: codes.OK},
+		{name: "writer unknown method", principal: writer, method: "/metrics.MetricsService/Unknown", wantCode: codes.PermissionDenied},
+		{
+			name:       "forbidden prefix from handler",
+			principal:  writer,
+			method:     pb.MetricsService_UpdateMetric_FullMethodName,
+			handlerErr: fmt.Errorf("unable to update metric: %w", auth.ErrForbidden),
+			wantCode:   codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+
+			// Проверяем unary и stream варианты
+			_, err := Policy()(ctx, "test", &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, tt.handlerErr
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+
+			err = PolicyStream()(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method}, func(srv interface{}, ss grpc.ServerStream) error {
+				return tt.handlerErr
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
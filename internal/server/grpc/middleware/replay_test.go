@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"github.com/Kopleman/metcol/internal/common/utils"
+	"github.com/Kopleman/metcol/internal/server/replay"
+	pb "github.com/Kopleman/metcol/proto/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// mockTransportStream позволяет grpc.SetHeader работать без реального соединения
+type mockTransportStream struct{}
+
+func (m *mockTransportStream) Method() string { return pb.MetricsService_UpdateMetrics_FullMethodName }
+
+func (m *mockTransportStream) SetHeader(metadata.MD) error { return nil }
+
+func (m *mockTransportStream) SendHeader(metadata.MD) error { return nil }
+
+func (m *mockTransportStream) SetTrailer(metadata.MD) error { return nil }
+
+func stampedMetadata(stamp signature.Stamp) metadata.MD {
+	md := metadata.MD{}
+	if !stamp.Empty() {
+		md.Set(signature.TimestampMetadataKey, stamp.Timestamp())
+		md.Set(signature.NonceMetadataKey, stamp.Nonce)
+	}
+	return md
+}
+
+func TestHash_Replay(t *testing.T) {
+	key := []byte("test-key")
+	data := []byte("test-data")
+	stamp, err := signature.NewStamp()
+	require.NoError(t, err)
+
+	newCtx := func(stamp signature.Stamp) context.Context {
+		md := stampedMetadata(stamp)
+		md.Set(common.HashSHA256, hex.EncodeToString(signature.Sum(key, stamp, data)))
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		return grpc.NewContextWithServerTransportStream(ctx, &mockTransportStream{})
+	}
+
+	tests := []struct {
+		name        string
+		replayGuard ReplayGuard
+		calls       []context.Context
+		wantCodes   []codes.Code
+	}{
+		{
+			name:        "stamped call is accepted once",
+			replayGuard: replay.NewGuard(time.Minute, 10),
+			calls:       []context.Context{newCtx(stamp), newCtx(stamp)},
+			wantCodes:   []codes.Code{codes.OK, codes.InvalidArgument},
+		},
+		{
+			name:        "stale call",
+			replayGuard: replay.NewGuard(time.Minute, 10),
+			calls:       []context.Context{newCtx(signature.Stamp{Time: time.Now().Add(-time.Hour), Nonce: "old"})},
+			wantCodes:   []codes.Code{codes.InvalidArgument},
+		},
+		{
+			name:        "call without stamp",
+			replayGuard: replay.NewGuard(time.Minute, 10),
+			calls:       []context.Context{newCtx(signature.Stamp{})},
+			wantCodes:   []codes.Code{codes.InvalidArgument},
+		},
+		{
+			name:      "call without stamp, replay protection disabled",
+			calls:     []context.Context{newCtx(signature.Stamp{})},
+			wantCodes: []codes.Code{codes.OK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := Hash(key, tt.replayGuard)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &mockProtoMessage{data: []byte("response-data")}, nil
+			}
+
+			for i, ctx := range tt.calls {
+				_, err := interceptor(ctx, &mockProtoMessage{data: data}, &grpc.UnaryServerInfo{}, handler)
+				require.Equal(t, tt.wantCodes[i], status.Code(err), "call %d", i)
+			}
+		})
+	}
+}
+
+func TestHashStream_Replay(t *testing.T) {
+	key := []byte("test-key")
+	guard := replay.NewGuard(time.Minute, 10)
+	stamp, err := signature.NewStamp()
+	require.NoError(t, err)
+
+	newRequest := func(stamp signature.Stamp) *pb.StreamMetricsRequest {
+		req := &pb.StreamMetricsRequest{}
+		req.SetSeq(1)
+		hash, hashErr := utils.StampedMessageHash(key, stamp, req)
+		require.NoError(t, hashErr)
+		req.SetHash(hash)
+		return req
+	}
+
+	openStream := func(streamStamp signature.Stamp, req *pb.StreamMetricsRequest) error {
+		ctx := metadata.NewIncomingContext(context.Background(), stampedMetadata(streamStamp))
+		ss := &mockServerStream{ctx: ctx, in: req}
+		return HashStream(key, guard)(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			return stream.RecvMsg(&pb.StreamMetricsRequest{})
+		})
+	}
+
+	require.NoError(t, openStream(stamp, newRequest(stamp)))
+
+	// тот же поток повторно не принимается
+	require.Equal(t, codes.InvalidArgument, status.Code(openStream(stamp, newRequest(stamp))))
+
+	// сообщение привязано к штампу потока и не проходит в другом потоке
+	other, err := signature.NewStamp()
+	require.NoError(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(openStream(other, newRequest(stamp))))
+	require.NoError(t, openStream(other, newRequest(other)))
+}
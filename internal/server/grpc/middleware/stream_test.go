@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/utils"
+	pb "github.com/Kopleman/metcol/proto/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// mockServerStream реализует grpc.ServerStream для тестов
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	in   proto.Message
+	sent []interface{}
+}
+
+func (m *mockServerStream) Context() context.Context {
+	return m.ctx
+}
+
+func (m *mockServerStream) RecvMsg(msg interface{}) error {
+	proto.Merge(msg.(proto.Message), m.in)
+	return nil
+}
+
+func (m *mockServerStream) SendMsg(msg interface{}) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestIPFilterStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		clientIP string
+		errCode  codes.Code
+		wantErr  bool
+	}{
+		{
+			name:     "valid IP in trusted subnet",
+			clientIP: "192.168.1.100",
+		},
+		{
+			name:     "IP not in trusted subnet",
+			clientIP: "10.0.0.1",
+			wantErr:  true,
+			errCode:  codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := IPFilterStream("192.168.1.0/24")
+			ss := &mockServerStream{
+				ctx: peer.NewContext(context.Background(), &peer.Peer{
+					Addr: &mockAddr{addr: tt.clientIP + ":12345"},
+				}),
+			}
+
+			called := false
+			err := interceptor(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+				called = true
+				return nil
+			})
+
+			if tt.wantErr {
+				require.Equal(t, tt.errCode, status.Code(err))
+				require.False(t, called)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, called)
+		})
+	}
+}
+
+func TestHashStream(t *testing.T) {
+	key := []byte("test-key")
+
+	newRequest := func(sign bool, hash string) *pb.StreamMetricsRequest {
+		req := &pb.StreamMetricsRequest{}
+		req.SetSeq(7)
+		if sign {
+			require.NoError(t, utils.SignMessage(key, req))
+		} else {
+			req.SetHash(hash)
+		}
+		return req
+	}
+
+	tests := []struct {
+		req     *pb.StreamMetricsRequest
+		name    string
+		errCode codes.Code
+		wantErr bool
+	}{
+		{
+			name: "valid hash",
+			req:  newRequest(true, ""),
+		},
+		{
+			name:    "invalid hash",
+			req:     newRequest(false, "deadbeef"),
+			wantErr: true,
+			errCode: codes.InvalidArgument,
+		},
+		{
+			name:    "missing hash",
+			req:     newRequest(false, ""),
+			wantErr: true,
+			errCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := &mockServerStream{ctx: context.Background(), in: tt.req}
+			interceptor := HashStream(key, nil)
+
+			err := interceptor(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+				req := &pb.StreamMetricsRequest{}
+				if recvErr := stream.RecvMsg(req); recvErr != nil {
+					return recvErr
+				}
+				ack := &pb.StreamMetricsAck{}
+				ack.SetSeq(req.GetSeq())
+				return stream.SendMsg(ack)
+			})
+
+			if tt.wantErr {
+				require.Equal(t, tt.errCode, status.Code(err))
+				require.Empty(t, ss.sent)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, ss.sent, 1)
+
+			// Подтверждение подписано тем же ключом
+			ack := ss.sent[0].(*pb.StreamMetricsAck)
+			expectedHash, hashErr := utils.MessageHash(key, ack)
+			require.NoError(t, hashErr)
+			require.Equal(t, expectedHash, ack.GetHash())
+		})
+	}
+}
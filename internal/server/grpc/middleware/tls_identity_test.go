@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestTLSIdentity(t *testing.T) {
+	verified := credentials.TLSInfo{State: tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "agent-42"}}}},
+	}}
+
+	tests := []struct {
+		authInfo  credentials.AuthInfo
+		name      string
+		wantAgent string
+	}{
+		{name: "verified client certificate", authInfo: verified, wantAgent: "agent-42"},
+		{name: "tls without client certificate", authInfo: credentials.TLSInfo{}},
+		{name: "plaintext connection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{
+				Addr:     &mockAddr{addr: "127.0.0.1:12345"},
+				AuthInfo: tt.authInfo,
+			})
+
+			// Проверяем unary и stream варианты
+			_, err := TLSIdentity()(ctx, "test", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				agent, _ := identity.AgentFromContext(ctx)
+				require.Equal(t, tt.wantAgent, agent)
+				return nil, nil
+			})
+			require.NoError(t, err)
+
+			err = TLSIdentityStream()(nil, &mockServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+				agent, _ := identity.AgentFromContext(ss.Context())
+				require.Equal(t, tt.wantAgent, agent)
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+}
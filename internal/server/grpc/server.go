@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"time"
@@ -9,7 +10,8 @@ import (
 	grpcmiddleware "github.com/Kopleman/metcol/internal/server/grpc/middleware"
 	pb "github.com/Kopleman/metcol/proto/metrics"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip"
 	_ "google.golang.org/grpc/encoding/proto"
 	"google.golang.org/grpc/keepalive"
 )
@@ -19,14 +21,44 @@ type Server struct {
 	logger log.Logger
 }
 
-func NewServer(logger log.Logger, metricsService *MetricsService, trustedCIDR string, key string) *Server {
-	encoding.RegisterCompressor(encoding.GetCompressor("gzip"))
+func NewServer(
+	logger log.Logger,
+	metricsService *MetricsService,
+	trustedCIDR string,
+	key string,
+	tlsConfig *tls.Config,
+	decryptor grpcmiddleware.PayloadDecryptor,
+	tokens grpcmiddleware.TokenAuthenticator,
+	replayGuard grpcmiddleware.ReplayGuard,
+) *Server {
+	unaryInterceptors := []grpc.UnaryServerInterceptor{grpcmiddleware.TLSIdentity()}
+	streamInterceptors := []grpc.StreamServerInterceptor{grpcmiddleware.TLSIdentityStream()}
+	if tokens != nil {
+		unaryInterceptors = append(unaryInterceptors, grpcmiddleware.Auth(tokens))
+		streamInterceptors = append(streamInterceptors, grpcmiddleware.AuthStream(tokens))
+		unaryInterceptors = append(unaryInterceptors, grpcmiddleware.Policy())
+		streamInterceptors = append(streamInterceptors, grpcmiddleware.PolicyStream())
+	}
+	if trustedCIDR != "" {
+		unaryInterceptors = append(unaryInterceptors, grpcmiddleware.IPFilter(trustedCIDR))
+		streamInterceptors = append(streamInterceptors, grpcmiddleware.IPFilterStream(trustedCIDR))
+	}
+	unaryInterceptors = append(
+		unaryInterceptors,
+		grpcmiddleware.Namespace(),
+		grpcmiddleware.Hash([]byte(key), replayGuard),
+		grpcmiddleware.Decrypt(decryptor),
+	)
+	streamInterceptors = append(
+		streamInterceptors,
+		grpcmiddleware.NamespaceStream(),
+		grpcmiddleware.HashStream([]byte(key), replayGuard),
+		grpcmiddleware.DecryptStream(decryptor),
+	)
 
 	serverOpts := []grpc.ServerOption{
-		grpc.ChainUnaryInterceptor(
-			grpcmiddleware.IPFilter(trustedCIDR),
-			grpcmiddleware.Hash([]byte(key)),
-		),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 		grpc.KeepaliveParams(keepalive.ServerParameters{
 			MaxConnectionIdle:     5 * time.Minute,
 			MaxConnectionAge:      10 * time.Minute,
@@ -39,6 +71,9 @@ func NewServer(logger log.Logger, metricsService *MetricsService, trustedCIDR st
 			PermitWithoutStream: true,
 		}),
 	}
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
 	server := grpc.NewServer(serverOpts...)
 	pb.RegisterMetricsServiceServer(server, metricsService)
 
@@ -0,0 +1,231 @@
+// Package influx parses InfluxDB line protocol into metrics.
+package influx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+var ErrLineParse = errors.New("unable to parse line")
+
+// LineError describes why particular line of request was rejected.
+type LineError struct {
+	Err  error
+	Line int // 1-based line number
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Parser converts line protocol into metrics. Telegraf reports integer fields as absolute values,
+// e.g. mem total, so they are gauges unless field or whole measurement is configured as counter.
+// Values of counter fields are increments.
+type Parser struct {
+	counters map[string]struct{}
+}
+
+// NewParser creates parser, counters lists metric names (`<measurement>_<field>`) and measurements
+// which integer fields are counters.
+func NewParser(counters []string) *Parser {
+	p := &Parser{counters: make(map[string]struct{}, len(counters))}
+	for _, name := range counters {
+		p.counters[name] = struct{}{}
+	}
+	return p
+}
+
+// Parse reads line protocol request. Valid lines are converted to metrics even if others are broken,
+// broken lines are reported via LineError list.
+func (p *Parser) Parse(r io.Reader) ([]*dto.MetricDTO, []*LineError, error) {
+	result := make([]*dto.MetricDTO, 0)
+	var lineErrors []*LineError
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lineMetrics, err := p.ParseLine(line)
+		if err != nil {
+			lineErrors = append(lineErrors, &LineError{Line: lineNum, Err: err})
+			continue
+		}
+		result = append(result, lineMetrics...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	return result, lineErrors, nil
+}
+
+const (
+	maxLineSize              = 1024 * 1024
+	sectionsWithoutTimestamp = 2
+	sectionsWithTimestamp    = 3
+)
+
+// ParseLine converts single `measurement[,tag=value...] field=value[,field=value...] [timestamp]` line.
+// Every numeric field becomes metric named `<measurement>_<field>` with tags as labels: integer
+// fields configured as counters are counters, other numeric fields are gauges, string and boolean
+// fields are skipped. Timestamp is validated only, samples are stored with receive time.
+func (p *Parser) ParseLine(line string) ([]*dto.MetricDTO, error) {
+	sections := splitUnescaped(line, ' ', true)
+	if len(sections) != sectionsWithoutTimestamp && len(sections) != sectionsWithTimestamp {
+		return nil, fmt.Errorf("%w: '%s': expected measurement, fields and optional timestamp", ErrLineParse, line)
+	}
+
+	if len(sections) == sectionsWithTimestamp {
+		if _, err := strconv.ParseInt(sections[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: '%s': bad timestamp: %w", ErrLineParse, line, err)
+		}
+	}
+
+	keyParts := splitUnescaped(sections[0], ',', false)
+	measurement := unescape(keyParts[0])
+	if measurement == "" {
+		return nil, fmt.Errorf("%w: '%s': missing measurement", ErrLineParse, line)
+	}
+
+	var labels map[string]string
+	for _, tag := range keyParts[1:] {
+		k, v, ok := cutUnescaped(tag, '=')
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("%w: '%s': bad tag '%s'", ErrLineParse, line, tag)
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[unescape(k)] = unescape(v)
+	}
+
+	_, measurementCounters := p.counters[measurement]
+	result := make([]*dto.MetricDTO, 0)
+	for _, field := range splitUnescaped(sections[1], ',', true) {
+		k, v, ok := cutUnescaped(field, '=')
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("%w: '%s': bad field '%s'", ErrLineParse, line, field)
+		}
+		name := measurement + "_" + unescape(k)
+		_, fieldCounter := p.counters[name]
+		metric, err := parseField(name, v, measurementCounters || fieldCounter)
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s': field '%s': %w", ErrLineParse, line, k, err)
+		}
+		if metric == nil {
+			continue
+		}
+		metric.Labels = labels
+		result = append(result, metric)
+	}
+
+	return result, nil
+}
+
+func parseField(name, raw string, counter bool) (*dto.MetricDTO, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		if len(raw) == 1 || !strings.HasSuffix(raw, `"`) {
+			return nil, errors.New("unterminated string")
+		}
+		return nil, nil //nolint:nilnil // string fields are skipped
+	case isBool(raw):
+		return nil, nil //nolint:nilnil // boolean fields are skipped
+	case strings.HasSuffix(raw, "i"):
+		delta, err := strconv.ParseInt(strings.TrimSuffix(raw, "i"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad integer: %w", err)
+		}
+		return integerMetric(name, delta, counter), nil
+	case strings.HasSuffix(raw, "u"):
+		delta, err := strconv.ParseInt(strings.TrimSuffix(raw, "u"), 10, 64)
+		if err != nil || delta < 0 {
+			return nil, fmt.Errorf("bad unsigned integer '%s'", raw)
+		}
+		return integerMetric(name, delta, counter), nil
+	default:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad float: %w", err)
+		}
+		return &dto.MetricDTO{ID: name, MType: common.GaugeMetricType, Value: &value}, nil
+	}
+}
+
+func integerMetric(name string, value int64, counter bool) *dto.MetricDTO {
+	if counter {
+		return &dto.MetricDTO{ID: name, MType: common.CounterMetricType, Delta: &value}
+	}
+	gauge := float64(value)
+	return &dto.MetricDTO{ID: name, MType: common.GaugeMetricType, Value: &gauge}
+}
+
+func isBool(raw string) bool {
+	switch raw {
+	case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+		return true
+	default:
+		return false
+	}
+}
+
+// splitUnescaped splits s by sep ignoring backslash-escaped separators and, if quoted set, separators in "...".
+func splitUnescaped(s string, sep byte, quoted bool) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quoted && s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func cutUnescaped(s string, sep byte) (before, after string, found bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
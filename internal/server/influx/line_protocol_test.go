@@ -0,0 +1,145 @@
+package influx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		counters []string
+		want     []*dto.MetricDTO
+		wantErr  bool
+	}{
+		{
+			name: "float field is gauge",
+			line: "cpu usage=0.5",
+			want: []*dto.MetricDTO{
+				{ID: "cpu_usage", MType: "gauge", Value: testutils.Pointer(0.5)},
+			},
+		},
+		{
+			name: "integer fields are gauges, tags become labels",
+			line: "mem,host=a total=8192i,available=4096u 1700000000000000000",
+			want: []*dto.MetricDTO{
+				{
+					ID:     "mem_total",
+					MType:  "gauge",
+					Labels: map[string]string{"host": "a"},
+					Value:  testutils.Pointer(8192.0),
+				},
+				{
+					ID:     "mem_available",
+					MType:  "gauge",
+					Labels: map[string]string{"host": "a"},
+					Value:  testutils.Pointer(4096.0),
+				},
+			},
+		},
+		{
+			name:     "configured measurement integers are counters",
+			line:     "net,host=a,iface=eth0 bytes_recv=100i,packets=3u,usage=0.5",
+			counters: []string{"net"},
+			want: []*dto.MetricDTO{
+				{
+					ID:     "net_bytes_recv",
+					MType:  "counter",
+					Labels: map[string]string{"host": "a", "iface": "eth0"},
+					Delta:  testutils.Pointer(int64(100)),
+				},
+				{
+					ID:     "net_packets",
+					MType:  "counter",
+					Labels: map[string]string{"host": "a", "iface": "eth0"},
+					Delta:  testutils.Pointer(int64(3)),
+				},
+				{
+					ID:     "net_usage",
+					MType:  "gauge",
+					Labels: map[string]string{"host": "a", "iface": "eth0"},
+					Value:  testutils.Pointer(0.5),
+				},
+			},
+		},
+		{
+			name:     "configured field is counter",
+			line:     "net bytes_recv=100i,drop_in=2i",
+			counters: []string{"net_bytes_recv"},
+			want: []*dto.MetricDTO{
+				{ID: "net_bytes_recv", MType: "counter", Delta: testutils.Pointer(int64(100))},
+				{ID: "net_drop_in", MType: "gauge", Value: testutils.Pointer(2.0)},
+			},
+		},
+		{
+			name: "string and boolean fields are skipped",
+			line: `system,host=a load1=1.5,uptime_format="1 day, 2:03",ok=true`,
+			want: []*dto.MetricDTO{
+				{
+					ID:     "system_load1",
+					MType:  "gauge",
+					Labels: map[string]string{"host": "a"},
+					Value:  testutils.Pointer(1.5),
+				},
+			},
+		},
+		{
+			name: "escaped characters",
+			line: `disk\ io,path=/mnt/my\ disk,kind=a\,b used=2`,
+			want: []*dto.MetricDTO{
+				{
+					ID:     "disk io_used",
+					MType:  "gauge",
+					Labels: map[string]string{"path": "/mnt/my disk", "kind": "a,b"},
+					Value:  testutils.Pointer(2.0),
+				},
+			},
+		},
+		{name: "missing fields", line: "cpu", wantErr: true},
+		{name: "bad timestamp", line: "cpu usage=1 abc", wantErr: true},
+		{name: "bad tag", line: "cpu,host usage=1", wantErr: true},
+		{name: "bad field value", line: "cpu usage=abc", wantErr: true},
+		{name: "bad integer", line: "cpu usage=1.5i", wantErr: true},
+		{name: "unterminated string", line: `cpu usage="abc`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParser(tt.counters).ParseLine(tt.line)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrLineParse)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	body := strings.Join([]string{
+		"# comment",
+		"cpu usage=0.5",
+		"broken",
+		"",
+		"mem used=10i",
+		"cpu usage=oops",
+	}, "\n")
+
+	got, lineErrors, err := NewParser([]string{"mem_used"}).Parse(strings.NewReader(body))
+	require.NoError(t, err)
+	assert.Equal(t, []*dto.MetricDTO{
+		{ID: "cpu_usage", MType: "gauge", Value: testutils.Pointer(0.5)},
+		{ID: "mem_used", MType: "counter", Delta: testutils.Pointer(int64(10))},
+	}, got)
+
+	require.Len(t, lineErrors, 2)
+	assert.Equal(t, 3, lineErrors[0].Line)
+	assert.Equal(t, 6, lineErrors[1].Line)
+	assert.ErrorIs(t, lineErrors[0], ErrLineParse)
+}
@@ -0,0 +1,72 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/server/sterrors"
+)
+
+// appliedBatch result of applied metrics batch.
+type appliedBatch struct {
+	appliedAt time.Time
+	result    []*dto.MetricDTO
+}
+
+func buildBatchKey(namespace string, batchID string) string {
+	return namespace + "/" + batchID
+}
+
+func cloneMetrics(metricsDTO []*dto.MetricDTO) []*dto.MetricDTO {
+	clones := make([]*dto.MetricDTO, 0, len(metricsDTO))
+	for _, metric := range metricsDTO {
+		clones = append(clones, metric.Clone())
+	}
+	return clones
+}
+
+func (s *Store) ReadBatch(_ context.Context, namespace string, batchID string) ([]*dto.MetricDTO, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch, ok := s.batches[buildBatchKey(namespace, batchID)]
+	if !ok {
+		return nil, sterrors.ErrNotFound
+	}
+	return cloneMetrics(batch.result), nil
+}
+
+func (s *Store) ApplyBatch(
+	_ context.Context,
+	namespace string,
+	batchID string,
+	metricsDTO []*dto.MetricDTO,
+	result []*dto.MetricDTO,
+	ts time.Time,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := buildBatchKey(namespace, batchID)
+	if _, ok := s.batches[key]; ok {
+		return sterrors.ErrAlreadyExists
+	}
+	for _, metric := range metricsDTO {
+		s.db[s.buildStoreKey(metric.Namespace, metric.ID, metric.MType, metric.Labels)] = metric
+	}
+	s.batches[key] = &appliedBatch{appliedAt: ts, result: cloneMetrics(result)}
+	return nil
+}
+
+func (s *Store) DeleteBatchesBefore(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, batch := range s.batches {
+		if batch.appliedAt.Before(before) {
+			delete(s.batches, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/server/sterrors"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Batches(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewStore(make(map[string]*dto.MetricDTO))
+	result := []*dto.MetricDTO{{ID: "foo", MType: "counter", Delta: testutils.Pointer(int64(1))}}
+
+	_, err := s.ReadBatch(ctx, "", "batch-1")
+	require.ErrorIs(t, err, sterrors.ErrNotFound)
+
+	metric := &dto.MetricDTO{ID: "foo", MType: "counter", Delta: testutils.Pointer(int64(1))}
+	require.NoError(t, s.ApplyBatch(ctx, "", "batch-1", []*dto.MetricDTO{metric}, result, start))
+	require.NoError(t, s.ApplyBatch(ctx, "team-a", "batch-2", nil, result, start.Add(time.Hour)))
+
+	stored, err := s.Read(ctx, "", "counter", "foo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *stored.Delta)
+
+	// изменение исходного результата не влияет на сохранённый
+	*result[0].Delta = 100
+	got, err := s.ReadBatch(ctx, "", "batch-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *got[0].Delta)
+
+	// повторное применение ничего не записывает
+	retried := &dto.MetricDTO{ID: "foo", MType: "counter", Delta: testutils.Pointer(int64(2))}
+	err = s.ApplyBatch(ctx, "", "batch-1", []*dto.MetricDTO{retried}, result, start)
+	require.ErrorIs(t, err, sterrors.ErrAlreadyExists)
+	got, err = s.ReadBatch(ctx, "", "batch-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *got[0].Delta)
+	stored, err = s.Read(ctx, "", "counter", "foo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *stored.Delta)
+
+	_, err = s.ReadBatch(ctx, "", "batch-2")
+	require.ErrorIs(t, err, sterrors.ErrNotFound, "batches are separated by namespace")
+
+	require.NoError(t, s.DeleteBatchesBefore(ctx, start.Add(time.Minute)))
+	_, err = s.ReadBatch(ctx, "", "batch-1")
+	require.ErrorIs(t, err, sterrors.ErrNotFound)
+	_, err = s.ReadBatch(ctx, "team-a", "batch-2")
+	require.NoError(t, err)
+}
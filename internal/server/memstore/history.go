@@ -0,0 +1,109 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+const defaultHistorySize = 1000
+
+// sampleRing fixed-size ring buffer of samples, oldest sample is overwritten first.
+type sampleRing struct {
+	samples []*dto.SampleDTO
+	start   int
+	size    int
+}
+
+func newSampleRing(capacity int) *sampleRing {
+	return &sampleRing{samples: make([]*dto.SampleDTO, capacity)}
+}
+
+func (r *sampleRing) push(sample *dto.SampleDTO) {
+	capacity := len(r.samples)
+	if r.size < capacity {
+		r.samples[(r.start+r.size)%capacity] = sample
+		r.size++
+		return
+	}
+	r.samples[r.start] = sample
+	r.start = (r.start + 1) % capacity
+}
+
+func (r *sampleRing) at(i int) *dto.SampleDTO {
+	return r.samples[(r.start+i)%len(r.samples)]
+}
+
+// dropBefore removes samples older than given time, samples are kept in order of appending.
+func (r *sampleRing) dropBefore(before time.Time) {
+	for r.size > 0 && r.at(0).Timestamp.Before(before) {
+		r.samples[r.start] = nil
+		r.start = (r.start + 1) % len(r.samples)
+		r.size--
+	}
+}
+
+func (r *sampleRing) between(from, to time.Time) []*dto.SampleDTO {
+	result := make([]*dto.SampleDTO, 0, r.size)
+	for i := range r.size {
+		sample := r.at(i)
+		if sample.Timestamp.Before(from) || sample.Timestamp.After(to) {
+			continue
+		}
+		result = append(result, sample)
+	}
+	return result
+}
+
+func (s *Store) AppendSamples(_ context.Context, metricsDTO []*dto.MetricDTO, ts time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, metric := range metricsDTO {
+		key := s.buildStoreKey(metric.Namespace, metric.ID, metric.MType, metric.Labels)
+		ring, ok := s.history[key]
+		if !ok {
+			ring = newSampleRing(s.historySize)
+			s.history[key] = ring
+		}
+		ring.push(dto.NewSampleDTO(metric, ts))
+	}
+
+	return nil
+}
+
+func (s *Store) ReadSamples(
+	_ context.Context,
+	namespace string,
+	mType common.MetricType,
+	name string,
+	labels map[string]string,
+	from, to time.Time,
+) ([]*dto.SampleDTO, error) {
+	key := s.buildStoreKey(namespace, name, mType, labels)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ring, ok := s.history[key]
+	if !ok {
+		return []*dto.SampleDTO{}, nil
+	}
+
+	return ring.between(from, to), nil
+}
+
+func (s *Store) DeleteSamplesBefore(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, ring := range s.history {
+		ring.dropBefore(before)
+		if ring.size == 0 {
+			delete(s.history, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_History(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		from        time.Time
+		to          time.Time
+		deleteOlder time.Time
+		name        string
+		want        []float64
+		historySize int
+		appended    int
+	}{
+		{
+			name:        "should return all samples in range",
+			historySize: 10,
+			appended:    3,
+			from:        start,
+			to:          start.Add(time.Hour),
+			want:        []float64{0, 1, 2},
+		},
+		{
+			name:        "should keep only last samples when ring is full",
+			historySize: 2,
+			appended:    5,
+			from:        start,
+			to:          start.Add(time.Hour),
+			want:        []float64{3, 4},
+		},
+		{
+			name:        "should filter samples by range",
+			historySize: 10,
+			appended:    5,
+			from:        start.Add(time.Minute),
+			to:          start.Add(3 * time.Minute),
+			want:        []float64{1, 2, 3},
+		},
+		{
+			name:        "should drop samples older than retention",
+			historySize: 10,
+			appended:    5,
+			from:        start,
+			to:          start.Add(time.Hour),
+			deleteOlder: start.Add(3 * time.Minute),
+			want:        []float64{3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewStore(make(map[string]*dto.MetricDTO), WithHistorySize(tt.historySize))
+			for i := range tt.appended {
+				metric := &dto.MetricDTO{
+					ID:     "foo",
+					MType:  "gauge",
+					Labels: map[string]string{"host": "a"},
+					Value:  testutils.Pointer(float64(i)),
+				}
+				err := s.AppendSamples(ctx, []*dto.MetricDTO{metric}, start.Add(time.Duration(i)*time.Minute))
+				require.NoError(t, err)
+			}
+			if !tt.deleteOlder.IsZero() {
+				require.NoError(t, s.DeleteSamplesBefore(ctx, tt.deleteOlder))
+			}
+
+			samples, err := s.ReadSamples(ctx, "", "gauge", "foo", map[string]string{"host": "a"}, tt.from, tt.to)
+			require.NoError(t, err)
+			got := make([]float64, 0, len(samples))
+			for _, sample := range samples {
+				got = append(got, *sample.Value)
+			}
+			assert.Equal(t, tt.want, got)
+
+			otherSeries, err := s.ReadSamples(ctx, "", "gauge", "foo", nil, tt.from, tt.to)
+			require.NoError(t, err)
+			assert.Empty(t, otherSeries)
+		})
+	}
+}
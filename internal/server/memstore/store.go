@@ -8,10 +8,23 @@ import (
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/server/sterrors"
+	"github.com/Kopleman/metcol/internal/server/store"
 )
 
-func (s *Store) buildStoreKey(name string, metricType common.MetricType) string {
-	return name + "-" + string(metricType)
+func (s *Store) buildStoreKey(
+	namespace string,
+	name string,
+	metricType common.MetricType,
+	labels map[string]string,
+) string {
+	key := name + "-" + string(metricType)
+	if labelsKey := dto.LabelsKey(labels); labelsKey != "" {
+		key += "{" + labelsKey + "}"
+	}
+	if namespace != "" {
+		key = namespace + "/" + key
+	}
+	return key
 }
 
 func (s *Store) existed(key string) bool {
@@ -20,7 +33,7 @@ func (s *Store) existed(key string) bool {
 }
 
 func (s *Store) Create(_ context.Context, value *dto.MetricDTO) error {
-	key := s.buildStoreKey(value.ID, value.MType)
+	key := s.buildStoreKey(value.Namespace, value.ID, value.MType, value.Labels)
 	if s.existed(key) {
 		return sterrors.ErrAlreadyExists
 	}
@@ -30,8 +43,14 @@ func (s *Store) Create(_ context.Context, value *dto.MetricDTO) error {
 	return nil
 }
 
-func (s *Store) Read(_ context.Context, mType common.MetricType, name string) (*dto.MetricDTO, error) {
-	key := s.buildStoreKey(name, mType)
+func (s *Store) Read(
+	_ context.Context,
+	namespace string,
+	mType common.MetricType,
+	name string,
+	labels map[string]string,
+) (*dto.MetricDTO, error) {
+	key := s.buildStoreKey(namespace, name, mType, labels)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	value, existed := s.db[key]
@@ -44,11 +63,11 @@ func (s *Store) Read(_ context.Context, mType common.MetricType, name string) (*
 }
 
 func (s *Store) Update(ctx context.Context, value *dto.MetricDTO) error {
-	if _, err := s.Read(ctx, value.MType, value.ID); err != nil {
+	if _, err := s.Read(ctx, value.Namespace, value.MType, value.ID, value.Labels); err != nil {
 		return err
 	}
 
-	key := s.buildStoreKey(value.ID, value.MType)
+	key := s.buildStoreKey(value.Namespace, value.ID, value.MType, value.Labels)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.db[key] = value
@@ -56,9 +75,15 @@ func (s *Store) Update(ctx context.Context, value *dto.MetricDTO) error {
 	return nil
 }
 
-func (s *Store) Delete(ctx context.Context, mType common.MetricType, name string) error {
-	key := s.buildStoreKey(name, mType)
-	if _, err := s.Read(ctx, mType, name); err != nil {
+func (s *Store) Delete(
+	ctx context.Context,
+	namespace string,
+	mType common.MetricType,
+	name string,
+	labels map[string]string,
+) error {
+	key := s.buildStoreKey(namespace, name, mType, labels)
+	if _, err := s.Read(ctx, namespace, mType, name, labels); err != nil {
 		return err
 	}
 
@@ -67,9 +92,12 @@ func (s *Store) Delete(ctx context.Context, mType common.MetricType, name string
 	return nil
 }
 
-func (s *Store) GetAll(_ context.Context) ([]*dto.MetricDTO, error) {
+func (s *Store) GetAll(_ context.Context, namespace string) ([]*dto.MetricDTO, error) {
 	exportData := make([]*dto.MetricDTO, 0, len(s.db))
 	for _, metricValue := range s.db {
+		if namespace != store.AllNamespaces && metricValue.Namespace != namespace {
+			continue
+		}
 		exportData = append(exportData, metricValue)
 	}
 	return exportData, nil
@@ -80,7 +108,7 @@ func (s *Store) BulkCreateOrUpdate(_ context.Context, metricsDTO []*dto.MetricDT
 	defer s.mu.Unlock()
 
 	for _, metric := range metricsDTO {
-		key := s.buildStoreKey(metric.ID, metric.MType)
+		key := s.buildStoreKey(metric.Namespace, metric.ID, metric.MType, metric.Labels)
 		s.db[key] = metric
 	}
 
@@ -88,13 +116,35 @@ func (s *Store) BulkCreateOrUpdate(_ context.Context, metricsDTO []*dto.MetricDT
 }
 
 type Store struct {
-	db map[string]*dto.MetricDTO
-	mu *sync.Mutex
+	db          map[string]*dto.MetricDTO
+	history     map[string]*sampleRing
+	batches     map[string]*appliedBatch
+	mu          *sync.Mutex
+	historySize int
 }
 
-func NewStore(db map[string]*dto.MetricDTO) *Store {
-	return &Store{
-		db: db,
-		mu: &sync.Mutex{},
+// Option configures memo-store.
+type Option func(*Store)
+
+// WithHistorySize sets how many samples are kept per metric series.
+func WithHistorySize(size int) Option {
+	return func(s *Store) {
+		if size > 0 {
+			s.historySize = size
+		}
+	}
+}
+
+func NewStore(db map[string]*dto.MetricDTO, opts ...Option) *Store {
+	s := &Store{
+		db:          db,
+		history:     make(map[string]*sampleRing),
+		batches:     make(map[string]*appliedBatch),
+		mu:          &sync.Mutex{},
+		historySize: defaultHistorySize,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/server/store"
 	"github.com/Kopleman/metcol/internal/testutils"
 	"github.com/stretchr/testify/assert"
 )
@@ -77,8 +78,9 @@ func TestStore_Read(t *testing.T) {
 		db map[string]*dto.MetricDTO
 	}
 	type args struct {
-		name  string
-		mType common.MetricType
+		labels map[string]string
+		name   string
+		mType  common.MetricType
 	}
 	tests := []struct {
 		want    *dto.MetricDTO
@@ -108,6 +110,48 @@ func TestStore_Read(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "should read labeled value from memstore",
+			fields: fields{
+				db: map[string]*dto.MetricDTO{
+					"foo-gauge": {
+						ID:    "foo",
+						MType: "gauge",
+						Value: testutils.Pointer(0.0),
+					},
+					`foo-gauge{host="a"}`: {
+						ID:     "foo",
+						MType:  "gauge",
+						Labels: map[string]string{"host": "a"},
+						Value:  testutils.Pointer(1.0),
+					},
+				},
+			},
+			args: args{name: "foo", mType: "gauge", labels: map[string]string{"host": "a"}},
+			want: &dto.MetricDTO{
+				ID:     "foo",
+				MType:  "gauge",
+				Labels: map[string]string{"host": "a"},
+				Value:  testutils.Pointer(1.0),
+			},
+			wantErr: false,
+		},
+		{
+			name: "should throw error if labels do not match",
+			fields: fields{
+				db: map[string]*dto.MetricDTO{
+					`foo-gauge{host="a"}`: {
+						ID:     "foo",
+						MType:  "gauge",
+						Labels: map[string]string{"host": "a"},
+						Value:  testutils.Pointer(1.0),
+					},
+				},
+			},
+			args:    args{name: "foo", mType: "gauge", labels: map[string]string{"host": "b"}},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "should throw error if value not presented",
 			fields: fields{
@@ -129,7 +173,7 @@ func TestStore_Read(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			s := NewStore(tt.fields.db)
-			got, err := s.Read(ctx, tt.args.mType, tt.args.name)
+			got, err := s.Read(ctx, "", tt.args.mType, tt.args.name, tt.args.labels)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Read() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -226,8 +270,9 @@ func TestStore_Delete(t *testing.T) {
 		db map[string]*dto.MetricDTO
 	}
 	type args struct {
-		name  string
-		mType common.MetricType
+		labels map[string]string
+		name   string
+		mType  common.MetricType
 	}
 	tests := []struct {
 		name    string
@@ -270,7 +315,7 @@ func TestStore_Delete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			s := NewStore(tt.fields.db)
-			err := s.Delete(ctx, tt.args.mType, tt.args.name)
+			err := s.Delete(ctx, "", tt.args.mType, tt.args.name, tt.args.labels)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -283,3 +328,29 @@ func TestStore_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_Namespaces(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(make(map[string]*dto.MetricDTO))
+
+	// одноимённые метрики разных пространств не конфликтуют
+	assert.NoError(t, s.Create(ctx, &dto.MetricDTO{ID: "foo", MType: "gauge", Value: testutils.Pointer(1.0)}))
+	assert.NoError(t, s.Create(ctx, &dto.MetricDTO{ID: "foo", MType: "gauge", Value: testutils.Pointer(2.0), Namespace: "team-a"}))
+	assert.Error(t, s.Create(ctx, &dto.MetricDTO{ID: "foo", MType: "gauge", Value: testutils.Pointer(3.0), Namespace: "team-a"}))
+
+	got, err := s.Read(ctx, "team-a", common.GaugeMetricType, "foo", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, *got.Value)
+
+	_, err = s.Read(ctx, "team-b", common.GaugeMetricType, "foo", nil)
+	assert.Error(t, err)
+
+	all, err := s.GetAll(ctx, "team-a")
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Equal(t, "team-a", all[0].Namespace)
+
+	all, err = s.GetAll(ctx, store.AllNamespaces)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+}
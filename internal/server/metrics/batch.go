@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/sterrors"
+)
+
+const batchCleanupInterval = time.Minute
+
+// SetMetricsBatch applies metrics batch at most once. Batch applied earlier under the same ID
+// is not applied again, its original result is returned instead. Empty batchID disables
+// deduplication.
+func (m *Metrics) SetMetricsBatch(
+	ctx context.Context,
+	batchID string,
+	metricDTOs []*dto.MetricDTO,
+) ([]*dto.MetricDTO, error) {
+	if batchID == "" {
+		if err := m.SetMetrics(ctx, metricDTOs); err != nil {
+			return nil, err
+		}
+		return metricDTOs, nil
+	}
+
+	namespace := identity.NamespaceFromContext(ctx)
+	result, err := m.store.ReadBatch(ctx, namespace, batchID)
+	if err == nil {
+		return result, nil
+	}
+	if !errors.Is(err, sterrors.ErrNotFound) {
+		return nil, fmt.Errorf("metrics.SetMetricsBatch read batch '%s': %w", batchID, err)
+	}
+
+	stampIdentity(ctx, metricDTOs...)
+	dtoForSet, err := m.prepareMetricDTOForSet(ctx, metricDTOs)
+	if err != nil {
+		return nil, fmt.Errorf("metrics.SetMetricsBatch prepare metric DTOs for set: %w", err)
+	}
+	stampIdentity(ctx, dtoForSet...)
+
+	// metrics and batch ID are written together, so batch is never applied twice even if
+	// retry arrives while original request is still being applied, e.g. by another server instance.
+	err = m.store.ApplyBatch(ctx, namespace, batchID, dtoForSet, metricDTOs, time.Now())
+	if errors.Is(err, sterrors.ErrAlreadyExists) {
+		result, err = m.store.ReadBatch(ctx, namespace, batchID)
+		if err != nil {
+			return nil, fmt.Errorf("metrics.SetMetricsBatch read applied batch '%s': %w", batchID, err)
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("metrics.SetMetricsBatch apply batch '%s': %w", batchID, err)
+	}
+
+	if err = m.appendSamples(ctx, dtoForSet...); err != nil {
+		return nil, fmt.Errorf("metrics.SetMetricsBatch: %w", err)
+	}
+
+	return metricDTOs, nil
+}
+
+// RunBatchRetentionJob periodically forgets batches applied longer than retention ago until
+// ctx is done. Failed cleanup is retried on next tick.
+func (m *Metrics) RunBatchRetentionJob(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(batchCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.store.DeleteBatchesBefore(ctx, time.Now().Add(-retention)); err != nil {
+				m.logger.Errorf("failed to cleanup applied batches: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/memstore"
+	"github.com/Kopleman/metcol/internal/server/sterrors"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_SetMetricsBatch(t *testing.T) {
+	newBatch := func() []*dto.MetricDTO {
+		return []*dto.MetricDTO{
+			{ID: "foo", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(5))},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		batchIDs  []string
+		wantDelta int64
+	}{
+		{
+			name:      "same batch is applied once",
+			batchIDs:  []string{"batch-1", "batch-1", "batch-1"},
+			wantDelta: 5,
+		},
+		{
+			name:      "different batches are applied",
+			batchIDs:  []string{"batch-1", "batch-2"},
+			wantDelta: 10,
+		},
+		{
+			name:      "batches without ID are always applied",
+			batchIDs:  []string{"", ""},
+			wantDelta: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+
+			var first []*dto.MetricDTO
+			for i, batchID := range tt.batchIDs {
+				result, err := m.SetMetricsBatch(ctx, batchID, newBatch())
+				require.NoError(t, err)
+				if i == 0 {
+					first = result
+					continue
+				}
+				if batchID == tt.batchIDs[0] && batchID != "" {
+					// повтор возвращает результат первого применения
+					assert.Equal(t, first, result)
+				}
+			}
+
+			metric, err := m.GetMetricAsDTO(ctx, common.CounterMetricType, "foo", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDelta, *metric.Delta)
+		})
+	}
+}
+
+func TestMetrics_SetMetricsBatch_Namespace(t *testing.T) {
+	m := NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+	batch := []*dto.MetricDTO{
+		{ID: "foo", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(5))},
+	}
+
+	for _, namespace := range []string{"team-a", "team-b", "team-a"} {
+		ctx := identity.WithNamespace(context.Background(), namespace)
+		_, err := m.SetMetricsBatch(ctx, "batch-1", []*dto.MetricDTO{batch[0].Clone()})
+		require.NoError(t, err)
+	}
+
+	for _, namespace := range []string{"team-a", "team-b"} {
+		ctx := identity.WithNamespace(context.Background(), namespace)
+		metric, err := m.GetMetricAsDTO(ctx, common.CounterMetricType, "foo", nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), *metric.Delta, namespace)
+	}
+}
+
+// racingStore misses batch on first read, as if it was applied by another server instance meanwhile.
+type racingStore struct {
+	*memstore.Store
+	missed bool
+}
+
+func (s *racingStore) ReadBatch(ctx context.Context, namespace string, batchID string) ([]*dto.MetricDTO, error) {
+	if !s.missed {
+		s.missed = true
+		return nil, sterrors.ErrNotFound
+	}
+	return s.Store.ReadBatch(ctx, namespace, batchID) //nolint:wrapcheck // test wrapper
+}
+
+func TestMetrics_SetMetricsBatch_AppliedConcurrently(t *testing.T) {
+	ctx := context.Background()
+	s := &racingStore{Store: memstore.NewStore(make(map[string]*dto.MetricDTO))}
+	original := []*dto.MetricDTO{{ID: "foo", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(5))}}
+	require.NoError(t, s.ApplyBatch(ctx, "", "batch-1", original, original, time.Now()))
+
+	m := NewMetrics(s, log.MockLogger{})
+	result, err := m.SetMetricsBatch(ctx, "batch-1", []*dto.MetricDTO{
+		{ID: "foo", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(5))},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, original, result)
+
+	metric, err := m.GetMetricAsDTO(ctx, common.CounterMetricType, "foo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *metric.Delta)
+}
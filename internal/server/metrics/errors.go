@@ -7,3 +7,7 @@ var ErrGaugeValueParse = errors.New("can not parse gauge value from store")
 var ErrValueParse = errors.New("can not parse input value")
 var ErrUnknownMetricType = errors.New("unknown metric type")
 var ErrStoreKeyParse = errors.New("unable to parse store key")
+var ErrHistogramValueParse = errors.New("can not parse histogram value from store")
+var ErrSummaryValueParse = errors.New("can not parse summary value from store")
+var ErrHistogramBoundsMismatch = errors.New("histogram bucket bounds mismatch")
+var ErrInvalidQuery = errors.New("invalid query")
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+func validateHistogram(h *dto.HistogramDTO) error {
+	if h == nil {
+		return fmt.Errorf("%w: field Histogram cannot be nil", ErrValueParse)
+	}
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf(
+			"%w: histogram must have %d bucket counts for %d bounds, got %d",
+			ErrValueParse, len(h.Bounds)+1, len(h.Bounds), len(h.Counts),
+		)
+	}
+	for i := 1; i < len(h.Bounds); i++ {
+		if h.Bounds[i] <= h.Bounds[i-1] {
+			return fmt.Errorf("%w: histogram bounds must be strictly ascending", ErrValueParse)
+		}
+	}
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total != h.Count {
+		return fmt.Errorf("%w: histogram count %d does not match sum of buckets %d", ErrValueParse, h.Count, total)
+	}
+
+	return nil
+}
+
+// mergeHistograms adds observations of incoming histogram to the existed one. Both must share the same bounds.
+func mergeHistograms(existed, incoming *dto.HistogramDTO) (*dto.HistogramDTO, error) {
+	if !slices.Equal(existed.Bounds, incoming.Bounds) {
+		return nil, fmt.Errorf(
+			"%w: %w: %v != %v", ErrValueParse, ErrHistogramBoundsMismatch, existed.Bounds, incoming.Bounds,
+		)
+	}
+
+	merged := &dto.HistogramDTO{
+		Bounds: slices.Clone(existed.Bounds),
+		Counts: make([]uint64, len(existed.Counts)),
+		Sum:    existed.Sum + incoming.Sum,
+		Count:  existed.Count + incoming.Count,
+	}
+	for i := range existed.Counts {
+		merged.Counts[i] = existed.Counts[i] + incoming.Counts[i]
+	}
+
+	return merged, nil
+}
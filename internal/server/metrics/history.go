@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+const historyCleanupInterval = time.Minute
+
+// appendSamples records accepted metric states into history store and notifies watchers.
+func (m *Metrics) appendSamples(ctx context.Context, metricDTOs ...*dto.MetricDTO) error {
+	if err := m.store.AppendSamples(ctx, metricDTOs, time.Now()); err != nil {
+		return fmt.Errorf("failed to append metric samples: %w", err)
+	}
+	m.watchers.publish(metricDTOs...)
+	return nil
+}
+
+// RunHistoryRetentionJob periodically removes samples older than retention until ctx is done.
+// Failed cleanup is retried on next tick.
+func (m *Metrics) RunHistoryRetentionJob(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(historyCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.store.DeleteSamplesBefore(ctx, time.Now().Add(-retention)); err != nil {
+				m.logger.Errorf("failed to cleanup metric history: %v", err)
+			}
+		}
+	}
+}
@@ -10,26 +10,37 @@ import (
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
 	"github.com/Kopleman/metcol/internal/common/log"
 	"github.com/Kopleman/metcol/internal/server/sterrors"
 	"github.com/Kopleman/metcol/internal/server/store"
 )
 
-func (m *Metrics) SetGauge(ctx context.Context, name string, value float64) (*float64, error) {
-	_, err := m.store.Read(ctx, common.GaugeMetricType, name)
+func (m *Metrics) SetGauge(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+	value float64,
+) (*float64, error) {
+	_, err := m.store.Read(ctx, identity.NamespaceFromContext(ctx), common.GaugeMetricType, name, labels)
 
 	metricDTO := &dto.MetricDTO{
-		Delta: nil,
-		Value: &value,
-		ID:    name,
-		MType: common.GaugeMetricType,
+		Delta:  nil,
+		Value:  &value,
+		Labels: labels,
+		ID:     name,
+		MType:  common.GaugeMetricType,
 	}
+	stampIdentity(ctx, metricDTO)
 	if err != nil {
 		if errors.Is(err, sterrors.ErrNotFound) {
 			storeErr := m.store.Create(ctx, metricDTO)
 			if storeErr != nil {
 				return nil, fmt.Errorf("failed to create gauge metric '%s': %w", name, storeErr)
 			}
+			if historyErr := m.appendSamples(ctx, metricDTO); historyErr != nil {
+				return nil, historyErr
+			}
 			return &value, nil
 		}
 
@@ -40,25 +51,38 @@ func (m *Metrics) SetGauge(ctx context.Context, name string, value float64) (*fl
 	if updateErr != nil {
 		return nil, fmt.Errorf("failed to update gauge metric '%s': %w", name, err)
 	}
+	if historyErr := m.appendSamples(ctx, metricDTO); historyErr != nil {
+		return nil, historyErr
+	}
 
 	return &value, nil
 }
 
-func (m *Metrics) SetCounter(ctx context.Context, name string, value int64) (*int64, error) {
-	existedCounter, err := m.store.Read(ctx, common.CounterMetricType, name)
+func (m *Metrics) SetCounter(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+	value int64,
+) (*int64, error) {
+	existedCounter, err := m.store.Read(ctx, identity.NamespaceFromContext(ctx), common.CounterMetricType, name, labels)
 
 	if err != nil {
 		if errors.Is(err, sterrors.ErrNotFound) {
 			metricDTO := &dto.MetricDTO{
-				Delta: &value,
-				Value: nil,
-				ID:    name,
-				MType: common.CounterMetricType,
+				Delta:  &value,
+				Value:  nil,
+				Labels: labels,
+				ID:     name,
+				MType:  common.CounterMetricType,
 			}
+			stampIdentity(ctx, metricDTO)
 			storeErr := m.store.Create(ctx, metricDTO)
 			if storeErr != nil {
 				return nil, fmt.Errorf("failed to create counter metric '%s': %w", name, err)
 			}
+			if historyErr := m.appendSamples(ctx, metricDTO); historyErr != nil {
+				return nil, historyErr
+			}
 			return &value, nil
 		}
 
@@ -71,14 +95,123 @@ func (m *Metrics) SetCounter(ctx context.Context, name string, value int64) (*in
 
 	newValue := *existedCounter.Delta + value
 	existedCounter.Delta = &newValue
+	stampIdentity(ctx, existedCounter)
 	updateErr := m.store.Update(ctx, existedCounter)
 	if updateErr != nil {
 		return nil, fmt.Errorf("failed to update counter metric '%s': %w", name, err)
 	}
+	if historyErr := m.appendSamples(ctx, existedCounter); historyErr != nil {
+		return nil, historyErr
+	}
 
 	return &newValue, nil
 }
 
+func (m *Metrics) SetHistogram(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+	value *dto.HistogramDTO,
+) (*dto.HistogramDTO, error) {
+	if err := validateHistogram(value); err != nil {
+		return nil, err
+	}
+
+	existedHistogram, err := m.store.Read(ctx, identity.NamespaceFromContext(ctx), common.HistogramMetricType, name, labels)
+	if err != nil {
+		if errors.Is(err, sterrors.ErrNotFound) {
+			metricDTO := &dto.MetricDTO{
+				Histogram: value,
+				Labels:    labels,
+				ID:        name,
+				MType:     common.HistogramMetricType,
+			}
+			stampIdentity(ctx, metricDTO)
+			storeErr := m.store.Create(ctx, metricDTO)
+			if storeErr != nil {
+				return nil, fmt.Errorf("failed to create histogram metric '%s': %w", name, storeErr)
+			}
+			if historyErr := m.appendSamples(ctx, metricDTO); historyErr != nil {
+				return nil, historyErr
+			}
+			return value, nil
+		}
+
+		return nil, fmt.Errorf("failed to read histogram metric '%s': %w", name, err)
+	}
+
+	if existedHistogram.Histogram == nil {
+		return nil, ErrHistogramValueParse
+	}
+
+	merged, mergeErr := mergeHistograms(existedHistogram.Histogram, value)
+	if mergeErr != nil {
+		return nil, fmt.Errorf("failed to merge histogram metric '%s': %w", name, mergeErr)
+	}
+	existedHistogram.Histogram = merged
+	stampIdentity(ctx, existedHistogram)
+	updateErr := m.store.Update(ctx, existedHistogram)
+	if updateErr != nil {
+		return nil, fmt.Errorf("failed to update histogram metric '%s': %w", name, updateErr)
+	}
+	if historyErr := m.appendSamples(ctx, existedHistogram); historyErr != nil {
+		return nil, historyErr
+	}
+
+	return merged, nil
+}
+
+func (m *Metrics) SetSummary(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+	value *dto.SummaryDTO,
+) (*dto.SummaryDTO, error) {
+	if err := validateSummary(value); err != nil {
+		return nil, err
+	}
+
+	existedSummary, err := m.store.Read(ctx, identity.NamespaceFromContext(ctx), common.SummaryMetricType, name, labels)
+	if err != nil {
+		if errors.Is(err, sterrors.ErrNotFound) {
+			metricDTO := &dto.MetricDTO{
+				Summary: value,
+				Labels:  labels,
+				ID:      name,
+				MType:   common.SummaryMetricType,
+			}
+			stampIdentity(ctx, metricDTO)
+			storeErr := m.store.Create(ctx, metricDTO)
+			if storeErr != nil {
+				return nil, fmt.Errorf("failed to create summary metric '%s': %w", name, storeErr)
+			}
+			if historyErr := m.appendSamples(ctx, metricDTO); historyErr != nil {
+				return nil, historyErr
+			}
+			return value, nil
+		}
+
+		return nil, fmt.Errorf("failed to read summary metric '%s': %w", name, err)
+	}
+
+	if existedSummary.Summary == nil {
+		return nil, ErrSummaryValueParse
+	}
+
+	merged := mergeSummaries(existedSummary.Summary, value)
+	existedSummary.Summary = merged
+	stampIdentity(ctx, existedSummary)
+	updateErr := m.store.Update(ctx, existedSummary)
+	if updateErr != nil {
+		return nil, fmt.Errorf("failed to update summary metric '%s': %w", name, updateErr)
+	}
+	if historyErr := m.appendSamples(ctx, existedSummary); historyErr != nil {
+		return nil, historyErr
+	}
+
+	return merged, nil
+}
+
 func (m *Metrics) SetMetric(ctx context.Context, metricType common.MetricType, name string, value string) error {
 	switch metricType {
 	case common.CounterMetricType:
@@ -86,30 +219,38 @@ func (m *Metrics) SetMetric(ctx context.Context, metricType common.MetricType, n
 		if err != nil {
 			return ErrValueParse
 		}
-		_, err = m.SetCounter(ctx, name, parsedValue)
+		_, err = m.SetCounter(ctx, name, nil, parsedValue)
 		return err
 	case common.GaugeMetricType:
 		parsedValue, err := strconv.ParseFloat(value, 64)
 		if err != nil {
 			return ErrValueParse
 		}
-		_, err = m.SetGauge(ctx, name, parsedValue)
+		_, err = m.SetGauge(ctx, name, nil, parsedValue)
 		return err
+	case common.HistogramMetricType, common.SummaryMetricType:
+		return fmt.Errorf("%w: %s can be set only via JSON", ErrValueParse, metricType)
 	default:
 		return ErrUnknownMetricType
 	}
 }
 
 func (m *Metrics) SetMetrics(ctx context.Context, metricDTOs []*dto.MetricDTO) error {
+	stampIdentity(ctx, metricDTOs...)
 	dtoForSet, err := m.prepareMetricDTOForSet(ctx, metricDTOs)
 	if err != nil {
 		return fmt.Errorf("metrics.SetMetrics prepare metric DTOs for set: %w", err)
 	}
+	stampIdentity(ctx, dtoForSet...)
 
 	if err = m.store.BulkCreateOrUpdate(ctx, dtoForSet); err != nil {
 		return fmt.Errorf("metrics.setMetric BulkCreateOrUpdate: %w", err)
 	}
 
+	if err = m.appendSamples(ctx, dtoForSet...); err != nil {
+		return fmt.Errorf("metrics.SetMetrics: %w", err)
+	}
+
 	return nil
 }
 
@@ -119,7 +260,7 @@ func (m *Metrics) SetMetricByDto(ctx context.Context, d *dto.MetricDTO) error {
 		if d.Delta == nil {
 			return ErrValueParse
 		}
-		newDelta, err := m.SetCounter(ctx, d.ID, *d.Delta)
+		newDelta, err := m.SetCounter(ctx, d.ID, d.Labels, *d.Delta)
 		if err != nil {
 			return err
 		}
@@ -129,17 +270,42 @@ func (m *Metrics) SetMetricByDto(ctx context.Context, d *dto.MetricDTO) error {
 		if d.Value == nil {
 			return ErrValueParse
 		}
-		newValue, err := m.SetGauge(ctx, d.ID, *d.Value)
+		newValue, err := m.SetGauge(ctx, d.ID, d.Labels, *d.Value)
 		if err != nil {
 			return err
 		}
 		d.Value = newValue
 		return nil
+	case common.HistogramMetricType:
+		newHistogram, err := m.SetHistogram(ctx, d.ID, d.Labels, d.Histogram)
+		if err != nil {
+			return err
+		}
+		d.Histogram = newHistogram
+		return nil
+	case common.SummaryMetricType:
+		newSummary, err := m.SetSummary(ctx, d.ID, d.Labels, d.Summary)
+		if err != nil {
+			return err
+		}
+		d.Summary = newSummary
+		return nil
 	default:
 		return ErrUnknownMetricType
 	}
 }
 
+// stampIdentity records authenticated agent from context as author of metrics update
+// and places metrics into request namespace.
+func stampIdentity(ctx context.Context, metricDTOs ...*dto.MetricDTO) {
+	agent, _ := identity.AgentFromContext(ctx)
+	namespace := identity.NamespaceFromContext(ctx)
+	for _, d := range metricDTOs {
+		d.Agent = agent
+		d.Namespace = namespace
+	}
+}
+
 func (m *Metrics) validateMetricDto(d *dto.MetricDTO) error {
 	switch d.MType {
 	case common.CounterMetricType:
@@ -152,6 +318,10 @@ func (m *Metrics) validateMetricDto(d *dto.MetricDTO) error {
 			return fmt.Errorf("%w: field Value cannot be nil", ErrValueParse)
 		}
 		return nil
+	case common.HistogramMetricType:
+		return validateHistogram(d.Histogram)
+	case common.SummaryMetricType:
+		return validateSummary(d.Summary)
 	default:
 		return ErrUnknownMetricType
 	}
@@ -167,7 +337,7 @@ func (m *Metrics) prepareMetricDTOForSet(ctx context.Context, metricDTOs []*dto.
 		// check that we did not meet this metric before.
 		indexOfPrepared := slices.IndexFunc(dtoForSet,
 			func(preparedDTO *dto.MetricDTO) bool {
-				return preparedDTO.MType == d.MType && preparedDTO.ID == d.ID
+				return preparedDTO.SameIdentity(d)
 			})
 
 		var existedMetric *dto.MetricDTO
@@ -176,7 +346,7 @@ func (m *Metrics) prepareMetricDTOForSet(ctx context.Context, metricDTOs []*dto.
 		}
 
 		if existedMetric == nil {
-			metricInStore, readErr := m.store.Read(ctx, common.CounterMetricType, d.ID)
+			metricInStore, readErr := m.store.Read(ctx, d.Namespace, d.MType, d.ID, d.Labels)
 			if readErr != nil {
 				if errors.Is(readErr, sterrors.ErrNotFound) {
 					dtoForSet = append(dtoForSet, d)
@@ -184,7 +354,8 @@ func (m *Metrics) prepareMetricDTOForSet(ctx context.Context, metricDTOs []*dto.
 				}
 				return nil, fmt.Errorf("metrics.prepareDataForSet read: %w", readErr)
 			}
-			existedMetric = metricInStore
+			// stored metric is merged in copy, store is changed only by write of prepared DTOs
+			existedMetric = metricInStore.Clone()
 			dtoForSet = append(dtoForSet, existedMetric)
 		}
 
@@ -198,6 +369,14 @@ func (m *Metrics) prepareMetricDTOForSet(ctx context.Context, metricDTOs []*dto.
 			existedMetric.Delta = &newValue
 		case common.GaugeMetricType:
 			existedMetric.Value = d.Value
+		case common.HistogramMetricType:
+			merged, mergeErr := mergeHistograms(existedMetric.Histogram, d.Histogram)
+			if mergeErr != nil {
+				return nil, fmt.Errorf("metrics.prepareDataForSet merge histogram: %w", mergeErr)
+			}
+			existedMetric.Histogram = merged
+		case common.SummaryMetricType:
+			existedMetric.Summary = mergeSummaries(existedMetric.Summary, d.Summary)
 		default:
 			return nil, ErrUnknownMetricType
 		}
@@ -206,8 +385,13 @@ func (m *Metrics) prepareMetricDTOForSet(ctx context.Context, metricDTOs []*dto.
 	return dtoForSet, nil
 }
 
-func (m *Metrics) GetValueAsString(ctx context.Context, metricType common.MetricType, name string) (string, error) {
-	value, err := m.store.Read(ctx, metricType, name)
+func (m *Metrics) GetValueAsString(
+	ctx context.Context,
+	metricType common.MetricType,
+	name string,
+	labels map[string]string,
+) (string, error) {
+	value, err := m.store.Read(ctx, identity.NamespaceFromContext(ctx), metricType, name, labels)
 	if err != nil {
 		return "", fmt.Errorf("failed to read metric '%s': %w", name, err)
 	}
@@ -218,8 +402,10 @@ func (m *Metrics) GetValueAsString(ctx context.Context, metricType common.Metric
 func (m *Metrics) GetMetricAsDTO(
 	ctx context.Context,
 	metricType common.MetricType,
-	name string) (*dto.MetricDTO, error) {
-	value, err := m.store.Read(ctx, metricType, name)
+	name string,
+	labels map[string]string,
+) (*dto.MetricDTO, error) {
+	value, err := m.store.Read(ctx, identity.NamespaceFromContext(ctx), metricType, name, labels)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read metric '%s': %w", name, err)
 	}
@@ -229,7 +415,7 @@ func (m *Metrics) GetMetricAsDTO(
 
 func (m *Metrics) GetAllValuesAsString(ctx context.Context) (map[string]string, error) {
 	dataToReturn := make(map[string]string)
-	allMetrics, err := m.store.GetAll(ctx)
+	allMetrics, err := m.store.GetAll(ctx, identity.NamespaceFromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read all metrics for output: %w", err)
 	}
@@ -239,7 +425,7 @@ func (m *Metrics) GetAllValuesAsString(ctx context.Context) (map[string]string,
 		if err != nil {
 			return dataToReturn, err
 		}
-		dataToReturn[metricValue.ID] = valueAsString
+		dataToReturn[metricValue.FullName()] = valueAsString
 	}
 
 	return dataToReturn, nil
@@ -257,22 +443,52 @@ func (m *Metrics) convertMetricValueToString(metricDTO *dto.MetricDTO) (string,
 			return "", ErrGaugeValueParse
 		}
 		return strconv.FormatFloat(*metricDTO.Value, 'f', -1, 64), nil
+	case common.HistogramMetricType:
+		if metricDTO.Histogram == nil {
+			return "", ErrHistogramValueParse
+		}
+		return fmt.Sprintf(
+			"count=%d sum=%s",
+			metricDTO.Histogram.Count,
+			strconv.FormatFloat(metricDTO.Histogram.Sum, 'f', -1, 64),
+		), nil
+	case common.SummaryMetricType:
+		if metricDTO.Summary == nil {
+			return "", ErrSummaryValueParse
+		}
+		return fmt.Sprintf(
+			"count=%d sum=%s",
+			metricDTO.Summary.Count,
+			strconv.FormatFloat(metricDTO.Summary.Sum, 'f', -1, 64),
+		), nil
 	default:
 		return "", ErrUnknownMetricType
 	}
 }
 
+// ExportMetrics returns all metrics of request namespace.
 func (m *Metrics) ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error) {
-	allMetrics, err := m.store.GetAll(ctx)
+	allMetrics, err := m.store.GetAll(ctx, identity.NamespaceFromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read all metrics for export: %w", err)
 	}
 	return allMetrics, nil
 }
 
+// DumpMetrics returns metrics of every namespace, e.g. for backup.
+func (m *Metrics) DumpMetrics(ctx context.Context) ([]*dto.MetricDTO, error) {
+	allMetrics, err := m.store.GetAll(ctx, store.AllNamespaces)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read all metrics for dump: %w", err)
+	}
+	return allMetrics, nil
+}
+
+// ImportMetrics restores metrics, each one into namespace it was dumped from.
 func (m *Metrics) ImportMetrics(ctx context.Context, metricsToImport []*dto.MetricDTO) error {
 	for _, metricToImport := range metricsToImport {
-		if err := m.SetMetricByDto(ctx, metricToImport); err != nil {
+		namespaceCtx := identity.WithNamespace(ctx, metricToImport.Namespace)
+		if err := m.SetMetricByDto(namespaceCtx, metricToImport); err != nil {
 			return fmt.Errorf("failed to import metric '%s': %w", metricToImport.ID, err)
 		}
 	}
@@ -285,16 +501,21 @@ func ParseMetricType(typeAsString string) (common.MetricType, error) {
 		return common.CounterMetricType, nil
 	case string(common.GaugeMetricType):
 		return common.GaugeMetricType, nil
+	case string(common.HistogramMetricType):
+		return common.HistogramMetricType, nil
+	case string(common.SummaryMetricType):
+		return common.SummaryMetricType, nil
 	default:
 		return common.UnknownMetricType, ErrUnknownMetricType
 	}
 }
 
 type Metrics struct {
-	store  store.Store
-	logger log.Logger
+	store    store.Store
+	logger   log.Logger
+	watchers *watchHub
 }
 
 func NewMetrics(s store.Store, logger log.Logger) *Metrics {
-	return &Metrics{store: s, logger: logger}
+	return &Metrics{store: s, logger: logger, watchers: newWatchHub()}
 }
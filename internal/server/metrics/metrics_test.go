@@ -6,10 +6,13 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
 	"github.com/Kopleman/metcol/internal/server/memstore"
+	"github.com/Kopleman/metcol/internal/server/sterrors"
 	"github.com/Kopleman/metcol/internal/testutils"
 	"github.com/stretchr/testify/assert"
 )
@@ -82,7 +85,7 @@ func TestMetrics_SetGauge(t *testing.T) {
 			m := &Metrics{
 				store: memstore.NewStore(tt.fields.db),
 			}
-			_, err := m.SetGauge(ctx, tt.args.name, tt.args.value)
+			_, err := m.SetGauge(ctx, tt.args.name, nil, tt.args.value)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SetGauge() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -175,7 +178,7 @@ func TestMetrics_SetCounter(t *testing.T) {
 				}
 			}
 
-			_, err := m.SetCounter(ctx, tt.args.name, tt.args.value)
+			_, err := m.SetCounter(ctx, tt.args.name, nil, tt.args.value)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SetCounter() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -270,7 +273,7 @@ func TestMetrics_GetValueAsString(t *testing.T) {
 			m := &Metrics{
 				store: memstore.NewStore(tt.fields.db),
 			}
-			got, err := m.GetValueAsString(ctx, tt.args.metricType, tt.args.name)
+			got, err := m.GetValueAsString(ctx, tt.args.metricType, tt.args.name, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetValueAsString() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -382,9 +385,15 @@ func TestMetrics_GetAllValuesAsString(t *testing.T) {
 						Delta: testutils.Pointer(int64(2)),
 						Value: nil,
 					},
+					`bar-counter{host="a"}`: {
+						ID:     "bar",
+						MType:  "counter",
+						Labels: map[string]string{"host": "a"},
+						Delta:  testutils.Pointer(int64(3)),
+					},
 				},
 			},
-			want:    map[string]string{"foo": "0.1", "bar": "2"},
+			want:    map[string]string{"foo": "0.1", "bar": "2", `bar{host="a"}`: "3"},
 			wantErr: false,
 		},
 		{
@@ -460,6 +469,37 @@ func TestMetrics_SetMetricByDto(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "merge summary metric",
+			fields: fields{db: map[string]*dto.MetricDTO{
+				"foo-summary": {
+					ID:      "foo",
+					MType:   "summary",
+					Summary: &dto.SummaryDTO{Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 1}}, Sum: 1, Count: 1},
+				},
+			}},
+			args: args{
+				metricDto: &dto.MetricDTO{
+					ID:      "foo",
+					MType:   "summary",
+					Summary: &dto.SummaryDTO{Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 2}}, Sum: 4, Count: 2},
+				},
+			},
+			expect: &dto.MetricDTO{
+				ID:      "foo",
+				MType:   "summary",
+				Summary: &dto.SummaryDTO{Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 2}}, Sum: 5, Count: 3},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "summary without value",
+			fields: fields{db: make(map[string]*dto.MetricDTO)},
+			args: args{
+				metricDto: &dto.MetricDTO{ID: "foo", MType: "summary"},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -483,6 +523,7 @@ func TestMetrics_GetMetricAsDTO(t *testing.T) {
 		db map[string]*dto.MetricDTO
 	}
 	type args struct {
+		labels     map[string]string
 		metricType common.MetricType
 		name       string
 	}
@@ -550,6 +591,34 @@ func TestMetrics_GetMetricAsDTO(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "get labeled gauge metric",
+			fields: fields{db: map[string]*dto.MetricDTO{
+				"foo-gauge": {
+					ID:    "foo",
+					MType: "gauge",
+					Value: testutils.Pointer(1.1),
+				},
+				`foo-gauge{host="a"}`: {
+					ID:     "foo",
+					MType:  "gauge",
+					Labels: map[string]string{"host": "a"},
+					Value:  testutils.Pointer(2.2),
+				},
+			}},
+			args: args{
+				metricType: "gauge",
+				name:       "foo",
+				labels:     map[string]string{"host": "a"},
+			},
+			want: &dto.MetricDTO{
+				ID:     "foo",
+				MType:  "gauge",
+				Labels: map[string]string{"host": "a"},
+				Value:  testutils.Pointer(2.2),
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -557,7 +626,7 @@ func TestMetrics_GetMetricAsDTO(t *testing.T) {
 			m := &Metrics{
 				store: memstore.NewStore(tt.fields.db),
 			}
-			got, err := m.GetMetricAsDTO(ctx, tt.args.metricType, tt.args.name)
+			got, err := m.GetMetricAsDTO(ctx, tt.args.metricType, tt.args.name, tt.args.labels)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetMetricAsDTO() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -919,6 +988,47 @@ func TestMetrics_SetMetricsWithMemo(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "set same counters with different labels",
+			fields: fields{db: make(map[string]*dto.MetricDTO)},
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:     "bar",
+						MType:  "counter",
+						Labels: map[string]string{"host": "a"},
+						Delta:  testutils.Pointer(int64(4)),
+					},
+					{
+						ID:     "bar",
+						MType:  "counter",
+						Labels: map[string]string{"host": "b"},
+						Delta:  testutils.Pointer(int64(1)),
+					},
+					{
+						ID:     "bar",
+						MType:  "counter",
+						Labels: map[string]string{"host": "a"},
+						Delta:  testutils.Pointer(int64(2)),
+					},
+				},
+			},
+			want: map[string]*dto.MetricDTO{
+				`bar-counter{host="a"}`: {
+					ID:     "bar",
+					MType:  "counter",
+					Labels: map[string]string{"host": "a"},
+					Delta:  testutils.Pointer(int64(6)),
+				},
+				`bar-counter{host="b"}`: {
+					ID:     "bar",
+					MType:  "counter",
+					Labels: map[string]string{"host": "b"},
+					Delta:  testutils.Pointer(int64(1)),
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name:   "set bad metric",
 			fields: fields{db: make(map[string]*dto.MetricDTO)},
@@ -935,6 +1045,203 @@ func TestMetrics_SetMetricsWithMemo(t *testing.T) {
 			want:    make(map[string]*dto.MetricDTO),
 			wantErr: true,
 		},
+		{
+			name: "merge histograms on existed",
+			fields: fields{
+				db: map[string]*dto.MetricDTO{
+					"latency-histogram": {
+						ID:    "latency",
+						MType: "histogram",
+						Histogram: &dto.HistogramDTO{
+							Bounds: []float64{0.1, 1},
+							Counts: []uint64{1, 0, 0},
+							Sum:    0.25,
+							Count:  1,
+						},
+					},
+				},
+			},
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "latency",
+						MType: "histogram",
+						Histogram: &dto.HistogramDTO{
+							Bounds: []float64{0.1, 1},
+							Counts: []uint64{0, 2, 1},
+							Sum:    2,
+							Count:  3,
+						},
+					},
+					{
+						ID:    "latency",
+						MType: "histogram",
+						Histogram: &dto.HistogramDTO{
+							Bounds: []float64{0.1, 1},
+							Counts: []uint64{1, 0, 0},
+							Sum:    0.25,
+							Count:  1,
+						},
+					},
+				},
+			},
+			want: map[string]*dto.MetricDTO{
+				"latency-histogram": {
+					ID:    "latency",
+					MType: "histogram",
+					Histogram: &dto.HistogramDTO{
+						Bounds: []float64{0.1, 1},
+						Counts: []uint64{2, 2, 1},
+						Sum:    2.5,
+						Count:  5,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "histogram bounds mismatch",
+			fields: fields{
+				db: map[string]*dto.MetricDTO{
+					"latency-histogram": {
+						ID:    "latency",
+						MType: "histogram",
+						Histogram: &dto.HistogramDTO{
+							Bounds: []float64{0.1},
+							Counts: []uint64{1, 0},
+							Count:  1,
+						},
+					},
+				},
+			},
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "latency",
+						MType: "histogram",
+						Histogram: &dto.HistogramDTO{
+							Bounds: []float64{0.5},
+							Counts: []uint64{1, 0},
+							Count:  1,
+						},
+					},
+				},
+			},
+			want: map[string]*dto.MetricDTO{
+				"latency-histogram": {
+					ID:    "latency",
+					MType: "histogram",
+					Histogram: &dto.HistogramDTO{
+						Bounds: []float64{0.1},
+						Counts: []uint64{1, 0},
+						Count:  1,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "set histogram with wrong counts",
+			fields: fields{db: make(map[string]*dto.MetricDTO)},
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "latency",
+						MType: "histogram",
+						Histogram: &dto.HistogramDTO{
+							Bounds: []float64{0.1, 1},
+							Counts: []uint64{1},
+							Count:  1,
+						},
+					},
+				},
+			},
+			want:    make(map[string]*dto.MetricDTO),
+			wantErr: true,
+		},
+		{
+			name: "merge summaries on existed",
+			fields: fields{
+				db: map[string]*dto.MetricDTO{
+					"latency-summary": {
+						ID:    "latency",
+						MType: "summary",
+						Summary: &dto.SummaryDTO{
+							Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 0.1}, {Quantile: 0.99, Value: 0.4}},
+							Sum:       0.25,
+							Count:     1,
+						},
+					},
+				},
+			},
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "latency",
+						MType: "summary",
+						Summary: &dto.SummaryDTO{
+							Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 0.2}, {Quantile: 0.99, Value: 0.8}},
+							Sum:       2,
+							Count:     3,
+						},
+					},
+					{
+						ID:    "latency",
+						MType: "summary",
+						Summary: &dto.SummaryDTO{
+							Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 0.3}, {Quantile: 0.99, Value: 0.9}},
+							Sum:       0.25,
+							Count:     1,
+						},
+					},
+				},
+			},
+			want: map[string]*dto.MetricDTO{
+				"latency-summary": {
+					ID:    "latency",
+					MType: "summary",
+					Summary: &dto.SummaryDTO{
+						Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 0.3}, {Quantile: 0.99, Value: 0.9}},
+						Sum:       2.5,
+						Count:     5,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "set summary with unordered quantiles",
+			fields: fields{db: make(map[string]*dto.MetricDTO)},
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "latency",
+						MType: "summary",
+						Summary: &dto.SummaryDTO{
+							Quantiles: []dto.QuantileDTO{{Quantile: 0.9, Value: 1}, {Quantile: 0.5, Value: 0.5}},
+							Count:     1,
+						},
+					},
+				},
+			},
+			want:    make(map[string]*dto.MetricDTO),
+			wantErr: true,
+		},
+		{
+			name:   "set summary with quantile out of range",
+			fields: fields{db: make(map[string]*dto.MetricDTO)},
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:      "latency",
+						MType:   "summary",
+						Summary: &dto.SummaryDTO{Quantiles: []dto.QuantileDTO{{Quantile: 1.5, Value: 1}}},
+					},
+				},
+			},
+			want:    make(map[string]*dto.MetricDTO),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -951,3 +1258,120 @@ func TestMetrics_SetMetricsWithMemo(t *testing.T) {
 		})
 	}
 }
+
+func TestMetrics_History(t *testing.T) {
+	ctx := context.Background()
+	m := &Metrics{
+		store: memstore.NewStore(make(map[string]*dto.MetricDTO)),
+	}
+	from := time.Now()
+
+	assert.NoError(t, m.SetMetricByDto(ctx, &dto.MetricDTO{
+		ID: "foo", MType: "counter", Delta: testutils.Pointer(int64(2)),
+	}))
+	assert.NoError(t, m.SetMetric(ctx, "counter", "foo", "3"))
+	assert.NoError(t, m.SetMetrics(ctx, []*dto.MetricDTO{
+		{ID: "foo", MType: "counter", Delta: testutils.Pointer(int64(5))},
+	}))
+
+	samples, err := m.store.ReadSamples(ctx, "", common.CounterMetricType, "foo", nil, from, time.Now())
+	assert.NoError(t, err)
+	got := make([]int64, 0, len(samples))
+	for _, sample := range samples {
+		got = append(got, *sample.Delta)
+	}
+	assert.Equal(t, []int64{2, 5, 10}, got)
+}
+
+func TestMetrics_RecordsAgent(t *testing.T) {
+	m := &Metrics{
+		store: memstore.NewStore(make(map[string]*dto.MetricDTO)),
+	}
+	from := time.Now()
+	host1 := identity.WithAgent(context.Background(), "host-1")
+	host2 := identity.WithAgent(context.Background(), "host-2")
+
+	assert.NoError(t, m.SetMetricByDto(host1, &dto.MetricDTO{
+		ID: "foo", MType: "counter", Delta: testutils.Pointer(int64(2)),
+	}))
+	assert.NoError(t, m.SetMetrics(host2, []*dto.MetricDTO{
+		{ID: "foo", MType: "counter", Delta: testutils.Pointer(int64(5))},
+		{ID: "bar", MType: "gauge", Value: testutils.Pointer(1.5)},
+	}))
+	assert.NoError(t, m.SetMetric(context.Background(), "gauge", "baz", "1"))
+
+	// последнее обновление определяет агента метрики
+	foo, err := m.GetMetricAsDTO(context.Background(), common.CounterMetricType, "foo", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "host-2", foo.Agent)
+	bar, err := m.GetMetricAsDTO(context.Background(), common.GaugeMetricType, "bar", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "host-2", bar.Agent)
+	baz, err := m.GetMetricAsDTO(context.Background(), common.GaugeMetricType, "baz", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, baz.Agent)
+
+	samples, err := m.store.ReadSamples(context.Background(), "", common.CounterMetricType, "foo", nil, from, time.Now())
+	assert.NoError(t, err)
+	agents := make([]string, 0, len(samples))
+	for _, sample := range samples {
+		agents = append(agents, sample.Agent)
+	}
+	assert.Equal(t, []string{"host-1", "host-2"}, agents)
+}
+
+func TestMetrics_Namespaces(t *testing.T) {
+	m := &Metrics{
+		store: memstore.NewStore(make(map[string]*dto.MetricDTO)),
+	}
+	teamA := identity.WithNamespace(context.Background(), "team-a")
+	teamB := identity.WithNamespace(context.Background(), "team-b")
+
+	// одноимённые метрики разных команд не перетирают друг друга
+	assert.NoError(t, m.SetMetric(teamA, "gauge", "Alloc", "1"))
+	assert.NoError(t, m.SetMetric(teamB, "gauge", "Alloc", "2"))
+	assert.NoError(t, m.SetMetrics(teamA, []*dto.MetricDTO{
+		{ID: "PollCount", MType: "counter", Delta: testutils.Pointer(int64(1))},
+		{ID: "PollCount", MType: "counter", Delta: testutils.Pointer(int64(2))},
+	}))
+	assert.NoError(t, m.SetMetricByDto(teamB, &dto.MetricDTO{
+		ID: "PollCount", MType: "counter", Delta: testutils.Pointer(int64(10)),
+	}))
+
+	value, err := m.GetValueAsString(teamA, common.GaugeMetricType, "Alloc", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+	value, err = m.GetValueAsString(teamB, common.GaugeMetricType, "Alloc", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", value)
+	value, err = m.GetValueAsString(teamA, common.CounterMetricType, "PollCount", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", value)
+
+	_, err = m.GetValueAsString(context.Background(), common.GaugeMetricType, "Alloc", nil)
+	assert.ErrorIs(t, err, sterrors.ErrNotFound)
+
+	all, err := m.GetAllValuesAsString(teamB)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Alloc": "2", "PollCount": "10"}, all)
+
+	exported, err := m.ExportMetrics(teamA)
+	assert.NoError(t, err)
+	assert.Len(t, exported, 2)
+	for _, metric := range exported {
+		assert.Equal(t, "team-a", metric.Namespace)
+	}
+
+	// дамп содержит все пространства имён и восстанавливается в исходные
+	dump, err := m.DumpMetrics(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, dump, 4)
+
+	restored := &Metrics{
+		store: memstore.NewStore(make(map[string]*dto.MetricDTO)),
+	}
+	assert.NoError(t, restored.ImportMetrics(context.Background(), dump))
+	value, err = restored.GetValueAsString(teamB, common.CounterMetricType, "PollCount", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", value)
+}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
+)
+
+// maxQueryPoints limits amount of buckets in a single range query.
+const maxQueryPoints = 11000
+
+// bucket accumulates samples values that fall into one step.
+type bucket struct {
+	min   float64
+	max   float64
+	sum   float64
+	count int
+}
+
+func (b *bucket) add(v float64) {
+	if b.count == 0 || v < b.min {
+		b.min = v
+	}
+	if b.count == 0 || v > b.max {
+		b.max = v
+	}
+	b.sum += v
+	b.count++
+}
+
+func validateQuery(q *dto.QueryRequest) error {
+	if q.ID == "" {
+		return fmt.Errorf("%w: empty metric name", ErrInvalidQuery)
+	}
+	switch q.MType {
+	case common.GaugeMetricType, common.CounterMetricType:
+	case common.HistogramMetricType, common.SummaryMetricType:
+		return fmt.Errorf("%w: range queries over %ss are not supported", ErrInvalidQuery, q.MType)
+	default:
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, ErrUnknownMetricType)
+	}
+	switch q.Aggregation {
+	case dto.AggregationAvg, dto.AggregationMin, dto.AggregationMax, dto.AggregationSum:
+	case dto.AggregationRate:
+		if q.MType != common.CounterMetricType {
+			return fmt.Errorf("%w: rate is supported only for counters", ErrInvalidQuery)
+		}
+	default:
+		return fmt.Errorf("%w: unknown aggregation '%s'", ErrInvalidQuery, q.Aggregation)
+	}
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w: 'from' must be before 'to'", ErrInvalidQuery)
+	}
+	if q.Step < 0 {
+		return fmt.Errorf("%w: negative step", ErrInvalidQuery)
+	}
+	if q.Step > 0 && q.To.Sub(q.From)/q.Step > maxQueryPoints {
+		return fmt.Errorf("%w: too many points, increase step", ErrInvalidQuery)
+	}
+	return nil
+}
+
+func sampleValue(mType common.MetricType, sample *dto.SampleDTO) (float64, bool) {
+	switch mType {
+	case common.GaugeMetricType:
+		if sample.Value == nil {
+			return 0, false
+		}
+		return *sample.Value, true
+	case common.CounterMetricType:
+		if sample.Delta == nil {
+			return 0, false
+		}
+		return float64(*sample.Delta), true
+	default:
+		return 0, false
+	}
+}
+
+// QueryRange returns metric history split into buckets of q.Step width, with q.Aggregation applied to each one.
+// Zero step produces a single bucket for whole range. Buckets without samples are omitted.
+func (m *Metrics) QueryRange(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error) {
+	if err := validateQuery(q); err != nil {
+		return nil, err
+	}
+	step := q.Step
+	if step == 0 {
+		step = q.To.Sub(q.From) + 1
+	}
+
+	samples, err := m.store.ReadSamples(ctx, identity.NamespaceFromContext(ctx), q.MType, q.ID, q.Labels, q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read samples of '%s': %w", q.ID, err)
+	}
+
+	buckets := make(map[int]*bucket)
+	order := make([]int, 0)
+	var prev float64
+	hasPrev := false
+	for _, sample := range samples {
+		v, ok := sampleValue(q.MType, sample)
+		if !ok {
+			continue
+		}
+		if q.Aggregation == dto.AggregationRate {
+			// rate works with increase between neighbour samples, the first one is only a baseline.
+			current := v
+			if !hasPrev {
+				prev, hasPrev = current, true
+				continue
+			}
+			v = current - prev
+			if v < 0 {
+				// counter was reset, it restarted from zero.
+				v = current
+			}
+			prev = current
+		}
+
+		idx := int(sample.Timestamp.Sub(q.From) / step)
+		b, ok := buckets[idx]
+		if !ok {
+			b = &bucket{}
+			buckets[idx] = b
+			order = append(order, idx)
+		}
+		b.add(v)
+	}
+
+	points := make([]*dto.PointDTO, 0, len(order))
+	for _, idx := range order {
+		b := buckets[idx]
+		point := &dto.PointDTO{Timestamp: q.From.Add(time.Duration(idx) * step)}
+		switch q.Aggregation {
+		case dto.AggregationAvg:
+			point.Value = b.sum / float64(b.count)
+		case dto.AggregationMin:
+			point.Value = b.min
+		case dto.AggregationMax:
+			point.Value = b.max
+		case dto.AggregationSum:
+			point.Value = b.sum
+		case dto.AggregationRate:
+			point.Value = b.sum / math.Max(step.Seconds(), 1)
+		}
+		points = append(points, point)
+	}
+
+	return &dto.SeriesDTO{
+		Labels:      q.Labels,
+		ID:          q.ID,
+		MType:       q.MType,
+		Aggregation: q.Aggregation,
+		Points:      points,
+	}, nil
+}
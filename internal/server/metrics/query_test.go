@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/server/memstore"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_QueryRange(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	gauges := []float64{1, 3, 2, 6}
+	counters := []int64{10, 20, 5, 15}
+
+	tests := []struct {
+		query   *dto.QueryRequest
+		name    string
+		want    []*dto.PointDTO
+		wantErr bool
+	}{
+		{
+			name: "avg of gauge per 2 minutes",
+			query: &dto.QueryRequest{
+				From: start, To: start.Add(4 * time.Minute), Step: 2 * time.Minute,
+				ID: "foo", MType: common.GaugeMetricType, Aggregation: dto.AggregationAvg,
+			},
+			want: []*dto.PointDTO{
+				{Timestamp: start, Value: 2},
+				{Timestamp: start.Add(2 * time.Minute), Value: 4},
+			},
+		},
+		{
+			name: "min of gauge per 2 minutes",
+			query: &dto.QueryRequest{
+				From: start, To: start.Add(4 * time.Minute), Step: 2 * time.Minute,
+				ID: "foo", MType: common.GaugeMetricType, Aggregation: dto.AggregationMin,
+			},
+			want: []*dto.PointDTO{
+				{Timestamp: start, Value: 1},
+				{Timestamp: start.Add(2 * time.Minute), Value: 2},
+			},
+		},
+		{
+			name: "max of gauge for whole range",
+			query: &dto.QueryRequest{
+				From: start, To: start.Add(4 * time.Minute),
+				ID: "foo", MType: common.GaugeMetricType, Aggregation: dto.AggregationMax,
+			},
+			want: []*dto.PointDTO{{Timestamp: start, Value: 6}},
+		},
+		{
+			name: "sum of gauge per minute within range",
+			query: &dto.QueryRequest{
+				From: start.Add(time.Minute), To: start.Add(2 * time.Minute), Step: time.Minute,
+				ID: "foo", MType: common.GaugeMetricType, Aggregation: dto.AggregationSum,
+			},
+			want: []*dto.PointDTO{
+				{Timestamp: start.Add(time.Minute), Value: 3},
+				{Timestamp: start.Add(2 * time.Minute), Value: 2},
+			},
+		},
+		{
+			name: "rate of counter with reset",
+			query: &dto.QueryRequest{
+				From: start, To: start.Add(4 * time.Minute), Step: 2 * time.Minute,
+				ID: "bar", MType: common.CounterMetricType, Aggregation: dto.AggregationRate,
+			},
+			want: []*dto.PointDTO{
+				{Timestamp: start, Value: 10.0 / 120},
+				{Timestamp: start.Add(2 * time.Minute), Value: 15.0 / 120},
+			},
+		},
+		{
+			name: "rate is not allowed for gauge",
+			query: &dto.QueryRequest{
+				From: start, To: start.Add(4 * time.Minute),
+				ID: "foo", MType: common.GaugeMetricType, Aggregation: dto.AggregationRate,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown aggregation",
+			query: &dto.QueryRequest{
+				From: start, To: start.Add(4 * time.Minute),
+				ID: "foo", MType: common.GaugeMetricType, Aggregation: "median",
+			},
+			wantErr: true,
+		},
+		{
+			name: "inverted range",
+			query: &dto.QueryRequest{
+				From: start.Add(4 * time.Minute), To: start,
+				ID: "foo", MType: common.GaugeMetricType, Aggregation: dto.AggregationAvg,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := memstore.NewStore(make(map[string]*dto.MetricDTO))
+			for i := range gauges {
+				ts := start.Add(time.Duration(i) * time.Minute)
+				require.NoError(t, s.AppendSamples(ctx, []*dto.MetricDTO{
+					{ID: "foo", MType: common.GaugeMetricType, Value: testutils.Pointer(gauges[i])},
+					{ID: "bar", MType: common.CounterMetricType, Delta: testutils.Pointer(counters[i])},
+				}, ts))
+			}
+			m := &Metrics{store: s}
+
+			got, err := m.QueryRange(ctx, tt.query)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidQuery)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Points)
+		})
+	}
+}
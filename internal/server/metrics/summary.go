@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+func validateSummary(s *dto.SummaryDTO) error {
+	if s == nil {
+		return fmt.Errorf("%w: field Summary cannot be nil", ErrValueParse)
+	}
+	for i, q := range s.Quantiles {
+		if q.Quantile < 0 || q.Quantile > 1 {
+			return fmt.Errorf("%w: summary quantile %v is out of [0, 1]", ErrValueParse, q.Quantile)
+		}
+		if i > 0 && q.Quantile <= s.Quantiles[i-1].Quantile {
+			return fmt.Errorf("%w: summary quantiles must be strictly ascending", ErrValueParse)
+		}
+	}
+
+	return nil
+}
+
+// mergeSummaries adds observations of incoming summary to the existed one. Quantiles are calculated by client
+// over its own window and can not be combined, so incoming ones replace existed.
+func mergeSummaries(existed, incoming *dto.SummaryDTO) *dto.SummaryDTO {
+	return &dto.SummaryDTO{
+		Quantiles: slices.Clone(incoming.Quantiles),
+		Sum:       existed.Sum + incoming.Sum,
+		Count:     existed.Count + incoming.Count,
+	}
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
+)
+
+const watchBufferSize = 256
+
+type watcher struct {
+	ch     chan *dto.MetricDTO
+	filter dto.WatchFilter
+}
+
+// watchHub fans out accepted metric changes to subscribers without ever blocking ingestion.
+type watchHub struct {
+	watchers map[*watcher]struct{}
+	mu       sync.RWMutex
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watchers: make(map[*watcher]struct{})}
+}
+
+func (h *watchHub) subscribe(filter dto.WatchFilter) (<-chan *dto.MetricDTO, func()) {
+	w := &watcher{ch: make(chan *dto.MetricDTO, watchBufferSize), filter: filter}
+
+	h.mu.Lock()
+	h.watchers[w] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.watchers, w)
+			close(w.ch)
+			h.mu.Unlock()
+		})
+	}
+
+	return w.ch, cancel
+}
+
+// publish delivers metrics to matching subscribers. When subscriber buffer is full
+// the oldest pending update is dropped, so slow consumer sees latest values.
+func (h *watchHub) publish(metricDTOs ...*dto.MetricDTO) {
+	if h == nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for w := range h.watchers {
+		for _, metric := range metricDTOs {
+			if !w.filter.Match(metric) {
+				continue
+			}
+			update := metric.Clone()
+			select {
+			case w.ch <- update:
+				continue
+			default:
+			}
+			select {
+			case <-w.ch:
+			default:
+			}
+			select {
+			case w.ch <- update:
+			default:
+			}
+		}
+	}
+}
+
+// Watch subscribes to every metric change of request namespace accepted by the service. Returned cancel func
+// releases subscription and closes the channel.
+func (m *Metrics) Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func()) {
+	filter.Namespace = identity.NamespaceFromContext(ctx)
+	return m.watchers.subscribe(filter)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/memstore"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Watch(t *testing.T) {
+	ctx := context.Background()
+	m := NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+
+	all, cancelAll := m.Watch(ctx, dto.WatchFilter{})
+	defer cancelAll()
+	counters, cancelCounters := m.Watch(ctx, dto.WatchFilter{Prefix: "req", Types: []common.MetricType{common.CounterMetricType}})
+
+	_, err := m.SetGauge(ctx, "requests_gauge", nil, 1.5)
+	require.NoError(t, err)
+	require.NoError(t, m.SetMetrics(ctx, []*dto.MetricDTO{
+		{ID: "requests", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(2))},
+		{ID: "other", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1))},
+	}))
+	_, err = m.SetCounter(ctx, "requests", nil, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, "requests_gauge", (<-all).ID)
+	assert.Len(t, all, 3)
+
+	first := <-counters
+	assert.Equal(t, "requests", first.ID)
+	assert.Equal(t, int64(2), *first.Delta)
+	second := <-counters
+	assert.Equal(t, int64(5), *second.Delta, "subscriber gets accepted state, not increment")
+	assert.Empty(t, counters)
+
+	cancelCounters()
+	_, ok := <-counters
+	assert.False(t, ok, "channel is closed after cancel")
+}
+
+func TestMetrics_Watch_SlowConsumer(t *testing.T) {
+	ctx := context.Background()
+	m := NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+
+	updates, cancel := m.Watch(ctx, dto.WatchFilter{})
+	defer cancel()
+
+	// nobody reads updates, ingestion must not block
+	total := watchBufferSize + 10
+	for i := 1; i <= total; i++ {
+		_, err := m.SetGauge(ctx, "foo", nil, float64(i))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, updates, watchBufferSize)
+	var last *dto.MetricDTO
+	for len(updates) > 0 {
+		last = <-updates
+	}
+	assert.Equal(t, float64(total), *last.Value, "oldest updates are dropped first")
+}
+
+func TestMetrics_Watch_Namespace(t *testing.T) {
+	ctx := context.Background()
+	m := NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+
+	teamA := identity.WithNamespace(ctx, "team-a")
+	updates, cancel := m.Watch(teamA, dto.WatchFilter{})
+	defer cancel()
+
+	require.NoError(t, m.SetMetrics(identity.WithNamespace(ctx, "team-b"), []*dto.MetricDTO{
+		{ID: "requests", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1))},
+	}))
+	require.NoError(t, m.SetMetrics(teamA, []*dto.MetricDTO{
+		{ID: "requests", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(2))},
+	}))
+
+	got := <-updates
+	assert.Equal(t, "team-a", got.Namespace)
+	assert.Equal(t, int64(2), *got.Delta)
+	assert.Empty(t, updates, "updates of other namespaces are not delivered")
+}
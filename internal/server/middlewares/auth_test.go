@@ -0,0 +1,127 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubTokens сопоставляет токен агенту
+type stubTokens map[string]string
+
+func (s stubTokens) Authenticate(token string) (*auth.Principal, bool) {
+	agent, ok := s[token]
+	if !ok {
+		return nil, false
+	}
+	return &auth.Principal{Agent: agent, Role: auth.RoleWriter}, true
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name           string
+		authorization  string
+		wantAgent      string
+		expectedStatus int
+	}{
+		{
+			name:           "valid token",
+			authorization:  "Bearer t1",
+			wantAgent:      "host-1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown token",
+			authorization:  "Bearer t2",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing header",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "not bearer scheme",
+			authorization:  "Basic t1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAgent string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAgent, _ = identity.AgentFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+			Auth(stubTokens{"t1": "host-1"})(handler).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.wantAgent, gotAgent)
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		principal      *auth.Principal
+		name           string
+		action         auth.Action
+		expectedStatus int
+	}{
+		{name: "no principal", action: auth.ActionAdmin, expectedStatus: http.StatusOK},
+		{
+			name:           "reader reads",
+			principal:      &auth.Principal{Role: auth.RoleReader},
+			action:         auth.ActionRead,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "reader writes",
+			principal:      &auth.Principal{Role: auth.RoleReader},
+			action:         auth.ActionWrite,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "writer writes",
+			principal:      &auth.Principal{Role: auth.RoleWriter},
+			action:         auth.ActionWrite,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "writer profiles",
+			principal:      &auth.Principal{Role: auth.RoleWriter},
+			action:         auth.ActionAdmin,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			RequireRole(tt.action)(handler).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
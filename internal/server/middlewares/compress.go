@@ -40,6 +40,17 @@ func (c *compressWriter) WriteHeader(statusCode int) {
 	c.w.WriteHeader(statusCode)
 }
 
+// FlushError sends compressed data written so far, used by http.ResponseController for streaming responses.
+func (c *compressWriter) FlushError() error {
+	if err := c.zw.Flush(); err != nil {
+		return fmt.Errorf("flush gzip wrapper error: %w", err)
+	}
+	if err := http.NewResponseController(c.w).Flush(); err != nil {
+		return fmt.Errorf("flush response error: %w", err)
+	}
+	return nil
+}
+
 func (c *compressWriter) Close() error {
 	if err := c.zw.Close(); err != nil {
 		return fmt.Errorf("close gzip wrapper wrap error: %w", err)
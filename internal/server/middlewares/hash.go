@@ -12,6 +12,7 @@ import (
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/common/signature"
 )
 
 type hashWriter struct {
@@ -39,6 +40,11 @@ func (hw *hashWriter) Write(p []byte) (int, error) {
 	return n, nil
 }
 
+// Unwrap lets http.ResponseController reach underlying writer.
+func (hw *hashWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
 func (hw *hashWriter) calcHashForBody(bodyBytes []byte) string {
 	if len(hw.key) == 0 {
 		return ""
@@ -55,34 +61,39 @@ func (hw *hashWriter) calcHashForBody(bodyBytes []byte) string {
 	return hashString
 }
 
-func Hash(l log.Logger, keyString string) func(next http.Handler) http.Handler {
-	hw := NewHashMiddleware(l, keyString)
+// ReplayGuard accepts every request stamp only once.
+type ReplayGuard interface {
+	Check(stamp signature.Stamp) error
+}
+
+// Hash validates request signature. With non-nil replayGuard signed requests must carry
+// timestamp and nonce, and every of them is accepted only once. Unsigned requests are passed
+// through, routes which must be signed use RequireHash.
+func Hash(l log.Logger, keyString string, replayGuard ReplayGuard) func(next http.Handler) http.Handler {
+	hw := NewHashMiddleware(l, keyString, replayGuard)
 	return hw.Handler
 }
 
-func NewHashMiddleware(l log.Logger, keyString string) *HashMiddleware {
+func NewHashMiddleware(l log.Logger, keyString string, replayGuard ReplayGuard) *HashMiddleware {
 	return &HashMiddleware{
 		logger: l,
+		replay: replayGuard,
 		key:    []byte(keyString),
 	}
 }
 
 type HashMiddleware struct {
 	logger log.Logger
+	replay ReplayGuard
 	key    []byte
 }
 
-func (hw *HashMiddleware) validateHash(bodyBytes []byte, hashString string) (bool, error) {
-	if hashString == "" {
-		return true, nil
-	}
+func (hw *HashMiddleware) validateHash(stamp signature.Stamp, bodyBytes []byte, hashString string) (bool, error) {
 	encrypted, err := base64.StdEncoding.DecodeString(hashString)
 	if err != nil {
 		return false, fmt.Errorf("failed to decode string: %w", err)
 	}
-	h := hmac.New(sha256.New, hw.key)
-	h.Write(bodyBytes)
-	reqHash := h.Sum(nil)
+	reqHash := signature.Sum(hw.key, stamp, bodyBytes)
 
 	return hmac.Equal(reqHash, encrypted), nil
 }
@@ -102,7 +113,19 @@ func (hw *HashMiddleware) Handler(next http.Handler) http.Handler {
 			return
 		}
 
-		valid, validateErr := hw.validateHash(bodyBytes, hash)
+		if hash == "" {
+			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			next.ServeHTTP(hashW, r)
+			return
+		}
+
+		stamp, err := signature.ParseStamp(r.Header.Get(signature.TimestampHeader), r.Header.Get(signature.NonceHeader))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		valid, validateErr := hw.validateHash(stamp, bodyBytes, hash)
 		if validateErr != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -112,8 +135,30 @@ func (hw *HashMiddleware) Handler(next http.Handler) http.Handler {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
+
+		// nonce is remembered only after signature check, so forged requests can not flood cache
+		if hw.replay != nil {
+			if err = hw.replay.Check(stamp); err != nil {
+				hw.logger.Warnf("rejected request: %v", err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
 		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 
 		next.ServeHTTP(hashW, r)
 	})
 }
+
+// RequireHash rejects unsigned requests when key is set. Signature itself is validated by Hash.
+func RequireHash(keyString string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if keyString != "" && r.Header.Get(common.HashSHA256) == "" {
+				http.Error(w, "hash header not found", http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
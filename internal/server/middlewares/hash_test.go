@@ -0,0 +1,145 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"github.com/Kopleman/metcol/internal/server/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSignedRequest(t *testing.T, key []byte, stamp signature.Stamp, body []byte) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(body))
+	req.Header.Set(common.HashSHA256, base64.StdEncoding.EncodeToString(signature.Sum(key, stamp, body)))
+	if !stamp.Empty() {
+		req.Header.Set(signature.TimestampHeader, stamp.Timestamp())
+		req.Header.Set(signature.NonceHeader, stamp.Nonce)
+	}
+	return req
+}
+
+func TestHash_Replay(t *testing.T) {
+	key := []byte("secret")
+	body := []byte(`[{"id":"PollCount","type":"counter","delta":1}]`)
+	stamp, err := signature.NewStamp()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		replayGuard    ReplayGuard
+		requests       []*http.Request
+		expectedStatus []int
+	}{
+		{
+			name:           "unsigned request",
+			replayGuard:    replay.NewGuard(time.Minute, 10),
+			requests:       []*http.Request{httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(body))},
+			expectedStatus: []int{http.StatusOK},
+		},
+		{
+			name:        "stamped request is accepted once",
+			replayGuard: replay.NewGuard(time.Minute, 10),
+			requests: []*http.Request{
+				newSignedRequest(t, key, stamp, body),
+				newSignedRequest(t, key, stamp, body),
+			},
+			expectedStatus: []int{http.StatusOK, http.StatusBadRequest},
+		},
+		{
+			name:        "stale request",
+			replayGuard: replay.NewGuard(time.Minute, 10),
+			requests: []*http.Request{
+				newSignedRequest(t, key, signature.Stamp{Time: time.Now().Add(-time.Hour), Nonce: "old"}, body),
+			},
+			expectedStatus: []int{http.StatusBadRequest},
+		},
+		{
+			name:           "request without stamp",
+			replayGuard:    replay.NewGuard(time.Minute, 10),
+			requests:       []*http.Request{newSignedRequest(t, key, signature.Stamp{}, body)},
+			expectedStatus: []int{http.StatusBadRequest},
+		},
+		{
+			name:           "request without stamp, replay protection disabled",
+			requests:       []*http.Request{newSignedRequest(t, key, signature.Stamp{}, body)},
+			expectedStatus: []int{http.StatusOK},
+		},
+		{
+			name:        "stamp does not match signature",
+			replayGuard: replay.NewGuard(time.Minute, 10),
+			requests: func() []*http.Request {
+				req := newSignedRequest(t, key, stamp, body)
+				req.Header.Set(signature.NonceHeader, "forged")
+				return []*http.Request{req}
+			}(),
+			expectedStatus: []int{http.StatusBadRequest},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			mw := Hash(log.MockLogger{}, string(key), tt.replayGuard)(handler)
+
+			for i, req := range tt.requests {
+				rr := httptest.NewRecorder()
+				mw.ServeHTTP(rr, req)
+				assert.Equal(t, tt.expectedStatus[i], rr.Code, "request %d", i)
+			}
+		})
+	}
+}
+
+func TestRequireHash(t *testing.T) {
+	key := []byte("secret")
+	body := []byte(`[{"id":"PollCount","type":"counter","delta":1}]`)
+	stamp, err := signature.NewStamp()
+	require.NoError(t, err)
+
+	tests := []struct {
+		req            *http.Request
+		name           string
+		key            string
+		expectedStatus int
+	}{
+		{
+			name:           "unsigned request",
+			key:            string(key),
+			req:            httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(body)),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "signed request",
+			key:            string(key),
+			req:            newSignedRequest(t, key, stamp, body),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unsigned request, key is not set",
+			req:            httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(body)),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			rr := httptest.NewRecorder()
+			RequireHash(tt.key)(handler).ServeHTTP(rr, tt.req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/auth"
+)
+
+// Namespace resolves metrics namespace of request from credentials or X-Metcol-Namespace header.
+func Namespace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace, err := auth.ResolveNamespace(r.Context(), r.Header.Get(identity.NamespaceHeader))
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, auth.ErrForbidden) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(identity.WithNamespace(r.Context(), namespace)))
+	})
+}
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	bound := &auth.Principal{Agent: "host-1", Role: auth.RoleWriter, Namespace: "team-a"}
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		header         string
+		wantNamespace  string
+		expectedStatus int
+	}{
+		{name: "default namespace", expectedStatus: http.StatusOK},
+		{name: "namespace from header", header: "team-b", wantNamespace: "team-b", expectedStatus: http.StatusOK},
+		{name: "namespace from credentials", principal: bound, wantNamespace: "team-a", expectedStatus: http.StatusOK},
+		{name: "foreign namespace", principal: bound, header: "team-b", expectedStatus: http.StatusForbidden},
+		{name: "invalid namespace", header: "team/b", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotNamespace string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotNamespace = identity.NamespaceFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set(identity.NamespaceHeader, tt.header)
+			}
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			Namespace(handler).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.wantNamespace, gotNamespace)
+		})
+	}
+}
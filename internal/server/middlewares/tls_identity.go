@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/Kopleman/metcol/internal/common/identity"
+	"github.com/Kopleman/metcol/internal/common/tlsconfig"
+)
+
+// TLSIdentity stores CN of verified client certificate as agent identity.
+func TLSIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cn := tlsconfig.PeerCommonName(r.TLS); cn != "" {
+			r = r.WithContext(identity.WithAgent(r.Context(), cn))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,69 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: applied_batches.sql
+
+package pgxstore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const CreateAppliedBatch = `-- name: CreateAppliedBatch :execrows
+INSERT INTO applied_batches (namespace, batch_id, result, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (namespace, batch_id) DO NOTHING
+`
+
+type CreateAppliedBatchParams struct {
+	Namespace string           `db:"namespace" json:"namespace"`
+	BatchID   string           `db:"batch_id" json:"batch_id"`
+	Result    []byte           `db:"result" json:"result"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) CreateAppliedBatch(ctx context.Context, arg CreateAppliedBatchParams) (int64, error) {
+	result, err := q.db.Exec(ctx, CreateAppliedBatch,
+		arg.Namespace,
+		arg.BatchID,
+		arg.Result,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const DeleteAppliedBatchesBefore = `-- name: DeleteAppliedBatchesBefore :exec
+DELETE FROM applied_batches WHERE created_at < $1
+`
+
+func (q *Queries) DeleteAppliedBatchesBefore(ctx context.Context, createdAt pgtype.Timestamp) error {
+	_, err := q.db.Exec(ctx, DeleteAppliedBatchesBefore, createdAt)
+	return err
+}
+
+const GetAppliedBatch = `-- name: GetAppliedBatch :one
+SELECT namespace, batch_id, result, created_at FROM applied_batches
+WHERE namespace = $1 AND batch_id = $2
+`
+
+type GetAppliedBatchParams struct {
+	Namespace string `db:"namespace" json:"namespace"`
+	BatchID   string `db:"batch_id" json:"batch_id"`
+}
+
+func (q *Queries) GetAppliedBatch(ctx context.Context, arg GetAppliedBatchParams) (*AppliedBatch, error) {
+	row := q.db.QueryRow(ctx, GetAppliedBatch, arg.Namespace, arg.BatchID)
+	var i AppliedBatch
+	err := row.Scan(
+		&i.Namespace,
+		&i.BatchID,
+		&i.Result,
+		&i.CreatedAt,
+	)
+	return &i, err
+}
@@ -0,0 +1,85 @@
+package pgxstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/server/sterrors"
+	"github.com/jackc/pgx/v5"
+)
+
+func (p *PGXStore) ReadBatch(ctx context.Context, namespace string, batchID string) ([]*dto.MetricDTO, error) {
+	batch, err := p.GetAppliedBatch(ctx, GetAppliedBatchParams{
+		Namespace: namespace,
+		BatchID:   batchID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, sterrors.ErrNotFound
+		}
+		return nil, fmt.Errorf("could not get batch '%s' from db: %w", batchID, err)
+	}
+
+	var result []*dto.MetricDTO
+	if err = json.Unmarshal(batch.Result, &result); err != nil {
+		return nil, fmt.Errorf("could not decode result of batch '%s': %w", batchID, err)
+	}
+	return result, nil
+}
+
+func (p *PGXStore) ApplyBatch(
+	ctx context.Context,
+	namespace string,
+	batchID string,
+	metricsDTO []*dto.MetricDTO,
+	result []*dto.MetricDTO,
+	ts time.Time,
+) error {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("could not encode result of batch '%s': %w", batchID, err)
+	}
+
+	tx, err := p.startTx(ctx, &pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("could not apply batch '%s': %w", batchID, err)
+	}
+	defer tx.Rollback(ctx) //nolint:all // its safe
+	txStore := &PGXStore{Queries: p.WithTx(tx), db: p.db, logger: p.logger, activeTX: tx}
+
+	// batch row is inserted first, concurrent insert of the same batch waits for this transaction
+	inserted, err := txStore.CreateAppliedBatch(ctx, CreateAppliedBatchParams{
+		Namespace: namespace,
+		BatchID:   batchID,
+		Result:    encoded,
+		CreatedAt: toPGXTimestamp(ts),
+	})
+	if err != nil {
+		return fmt.Errorf("could not save batch '%s': %w", batchID, err)
+	}
+	if inserted == 0 {
+		return sterrors.ErrAlreadyExists
+	}
+
+	for _, metric := range metricsDTO {
+		if err = txStore.CreateOrUpdate(ctx, metric); err != nil {
+			return fmt.Errorf("could not apply batch '%s': %w", batchID, err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("could not commit batch '%s': %w", batchID, err)
+	}
+	return nil
+}
+
+func (p *PGXStore) DeleteBatchesBefore(ctx context.Context, before time.Time) error {
+	if err := p.DeleteAppliedBatchesBefore(ctx, toPGXTimestamp(before)); err != nil {
+		return fmt.Errorf("could not delete batches before %s: %w", before, err)
+	}
+	return nil
+}
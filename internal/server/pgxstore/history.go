@@ -0,0 +1,101 @@
+package pgxstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func toPGXTimestamp(t time.Time) pgtype.Timestamp {
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
+
+func (p *PGXStore) AppendSamples(ctx context.Context, metricsDTO []*dto.MetricDTO, ts time.Time) error {
+	for _, metric := range metricsDTO {
+		mType, err := p.commonMetricTypeToPGXMType(metric.MType)
+		if err != nil {
+			return fmt.Errorf("pgxstore.AppendSamples type conversion: %w", err)
+		}
+		histogram, err := p.encodeHistogram(metric)
+		if err != nil {
+			return fmt.Errorf("pgxstore.AppendSamples: %w", err)
+		}
+		summary, err := p.encodeSummary(metric)
+		if err != nil {
+			return fmt.Errorf("pgxstore.AppendSamples: %w", err)
+		}
+		labels, err := p.encodeLabels(metric.Labels)
+		if err != nil {
+			return fmt.Errorf("pgxstore.AppendSamples: %w", err)
+		}
+
+		if err = p.CreateMetricSample(ctx, CreateMetricSampleParams{
+			Value:     metric.Value,
+			Delta:     metric.Delta,
+			CreatedAt: toPGXTimestamp(ts),
+			Name:      metric.ID,
+			Type:      mType,
+			Labels:    labels,
+			Histogram: histogram,
+			Summary:   summary,
+			Agent:     agentParam(metric.Agent),
+			Namespace: metric.Namespace,
+		}); err != nil {
+			return fmt.Errorf("pgxstore.AppendSamples could not create sample for '%s': %w", metric.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PGXStore) ReadSamples(
+	ctx context.Context,
+	namespace string,
+	mType common.MetricType,
+	name string,
+	labels map[string]string,
+	from, to time.Time,
+) ([]*dto.SampleDTO, error) {
+	PGXType, err := p.commonMetricTypeToPGXMType(mType)
+	if err != nil {
+		return nil, fmt.Errorf("could not get metric type for '%s': %w", mType, err)
+	}
+	encodedLabels, err := p.encodeLabels(labels)
+	if err != nil {
+		return nil, fmt.Errorf("could not read samples of '%s': %w", name, err)
+	}
+
+	items, err := p.GetMetricSamples(ctx, GetMetricSamplesParams{
+		FromTime:  toPGXTimestamp(from),
+		ToTime:    toPGXTimestamp(to),
+		Type:      PGXType,
+		Name:      name,
+		Namespace: namespace,
+		Labels:    encodedLabels,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get samples of '%s' from db: %w", name, err)
+	}
+
+	samples := make([]*dto.SampleDTO, 0, len(items))
+	for _, item := range items {
+		sample, dtoErr := item.ToDTO()
+		if dtoErr != nil {
+			return nil, fmt.Errorf("could not convert sample to dto: %w", dtoErr)
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+func (p *PGXStore) DeleteSamplesBefore(ctx context.Context, before time.Time) error {
+	if err := p.DeleteMetricSamplesBefore(ctx, toPGXTimestamp(before)); err != nil {
+		return fmt.Errorf("could not delete samples before %s: %w", before, err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package pgxstore
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -18,6 +19,10 @@ func (m *Metric) convertMetricType() (common.MetricType, error) {
 		return common.GaugeMetricType, nil
 	case MetricTypeCounter:
 		return common.CounterMetricType, nil
+	case MetricTypeHistogram:
+		return common.HistogramMetricType, nil
+	case MetricTypeSummary:
+		return common.SummaryMetricType, nil
 	default:
 		return common.UnknownMetricType, fmt.Errorf("unknown metric type: %v", m.Type)
 	}
@@ -33,11 +38,82 @@ func (m *Metric) ToDTO() (*dto.MetricDTO, error) {
 	}
 
 	metricDto := dto.MetricDTO{
-		ID:    m.Name,
-		MType: mType,
-		Delta: m.Delta,
-		Value: m.Value,
+		ID:        m.Name,
+		MType:     mType,
+		Delta:     m.Delta,
+		Value:     m.Value,
+		Namespace: m.Namespace,
+	}
+	if m.Agent != nil {
+		metricDto.Agent = *m.Agent
+	}
+
+	if m.Histogram != nil {
+		histogram := new(dto.HistogramDTO)
+		if err = json.Unmarshal(m.Histogram, histogram); err != nil {
+			return nil, fmt.Errorf("dto converting error: could not decode histogram: %w", err)
+		}
+		metricDto.Histogram = histogram
+	}
+
+	if m.Summary != nil {
+		summary := new(dto.SummaryDTO)
+		if err = json.Unmarshal(m.Summary, summary); err != nil {
+			return nil, fmt.Errorf("dto converting error: could not decode summary: %w", err)
+		}
+		metricDto.Summary = summary
+	}
+
+	if len(m.Labels) > 0 {
+		labels := make(map[string]string)
+		if err = json.Unmarshal(m.Labels, &labels); err != nil {
+			return nil, fmt.Errorf("dto converting error: could not decode labels: %w", err)
+		}
+		if len(labels) > 0 {
+			metricDto.Labels = labels
+		}
 	}
 
 	return &metricDto, nil
 }
+
+func (s *MetricSample) ToDTO() (*dto.SampleDTO, error) {
+	if s == nil {
+		return nil, errors.New("nil sample")
+	}
+
+	sample := dto.SampleDTO{
+		Delta:     s.Delta,
+		Value:     s.Value,
+		Timestamp: s.CreatedAt.Time,
+	}
+	if s.Agent != nil {
+		sample.Agent = *s.Agent
+	}
+
+	if s.Histogram != nil {
+		histogram := new(dto.HistogramDTO)
+		if err := json.Unmarshal(s.Histogram, histogram); err != nil {
+			return nil, fmt.Errorf("sample converting error: could not decode histogram: %w", err)
+		}
+		sample.Histogram = histogram
+	}
+
+	if s.Summary != nil {
+		summary := new(dto.SummaryDTO)
+		if err := json.Unmarshal(s.Summary, summary); err != nil {
+			return nil, fmt.Errorf("sample converting error: could not decode summary: %w", err)
+		}
+		sample.Summary = summary
+	}
+
+	return &sample, nil
+}
+
+// agentParam maps empty agent identity to NULL.
+func agentParam(agent string) *string {
+	if agent == "" {
+		return nil
+	}
+	return &agent
+}
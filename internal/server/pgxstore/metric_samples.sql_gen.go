@@ -0,0 +1,110 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: metric_samples.sql
+
+package pgxstore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const CreateMetricSample = `-- name: CreateMetricSample :exec
+INSERT INTO metric_samples (name, type, labels, value, delta, histogram, created_at, agent, namespace, summary)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
+
+type CreateMetricSampleParams struct {
+	Value     *float64         `db:"value" json:"value"`
+	Delta     *int64           `db:"delta" json:"delta"`
+	Agent     *string          `db:"agent" json:"agent"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+	Name      string           `db:"name" json:"name"`
+	Type      MetricType       `db:"type" json:"type"`
+	Namespace string           `db:"namespace" json:"namespace"`
+	Labels    []byte           `db:"labels" json:"labels"`
+	Histogram []byte           `db:"histogram" json:"histogram"`
+	Summary   []byte           `db:"summary" json:"summary"`
+}
+
+func (q *Queries) CreateMetricSample(ctx context.Context, arg CreateMetricSampleParams) error {
+	_, err := q.db.Exec(ctx, CreateMetricSample,
+		arg.Name,
+		arg.Type,
+		arg.Labels,
+		arg.Value,
+		arg.Delta,
+		arg.Histogram,
+		arg.CreatedAt,
+		arg.Agent,
+		arg.Namespace,
+		arg.Summary,
+	)
+	return err
+}
+
+const DeleteMetricSamplesBefore = `-- name: DeleteMetricSamplesBefore :exec
+DELETE FROM metric_samples WHERE created_at < $1
+`
+
+func (q *Queries) DeleteMetricSamplesBefore(ctx context.Context, createdAt pgtype.Timestamp) error {
+	_, err := q.db.Exec(ctx, DeleteMetricSamplesBefore, createdAt)
+	return err
+}
+
+const GetMetricSamples = `-- name: GetMetricSamples :many
+SELECT id, name, type, labels, value, delta, histogram, created_at, agent, namespace, summary FROM metric_samples
+WHERE namespace = $1 AND type = $2 AND name = $3 AND labels = $4
+  AND created_at >= $5 AND created_at <= $6
+ORDER BY created_at ASC
+`
+
+type GetMetricSamplesParams struct {
+	FromTime  pgtype.Timestamp `db:"from_time" json:"from_time"`
+	ToTime    pgtype.Timestamp `db:"to_time" json:"to_time"`
+	Type      MetricType       `db:"type" json:"type"`
+	Name      string           `db:"name" json:"name"`
+	Namespace string           `db:"namespace" json:"namespace"`
+	Labels    []byte           `db:"labels" json:"labels"`
+}
+
+func (q *Queries) GetMetricSamples(ctx context.Context, arg GetMetricSamplesParams) ([]*MetricSample, error) {
+	rows, err := q.db.Query(ctx, GetMetricSamples,
+		arg.Namespace,
+		arg.Type,
+		arg.Name,
+		arg.Labels,
+		arg.FromTime,
+		arg.ToTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*MetricSample{}
+	for rows.Next() {
+		var i MetricSample
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.Labels,
+			&i.Value,
+			&i.Delta,
+			&i.Histogram,
+			&i.CreatedAt,
+			&i.Agent,
+			&i.Namespace,
+			&i.Summary,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
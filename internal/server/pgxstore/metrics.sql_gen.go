@@ -10,16 +10,21 @@ import (
 )
 
 const CreateMetric = `-- name: CreateMetric :one
-INSERT INTO metrics (name, type, value, delta, created_at)
-VALUES ($1, $2, $3, $4, now())
-    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at
+INSERT INTO metrics (name, type, value, delta, histogram, labels, agent, namespace, summary, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())
+    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary
 `
 
 type CreateMetricParams struct {
-	Value *float64   `db:"value" json:"value"`
-	Delta *int64     `db:"delta" json:"delta"`
-	Name  string     `db:"name" json:"name"`
-	Type  MetricType `db:"type" json:"type"`
+	Value     *float64   `db:"value" json:"value"`
+	Delta     *int64     `db:"delta" json:"delta"`
+	Agent     *string    `db:"agent" json:"agent"`
+	Name      string     `db:"name" json:"name"`
+	Type      MetricType `db:"type" json:"type"`
+	Namespace string     `db:"namespace" json:"namespace"`
+	Histogram []byte     `db:"histogram" json:"histogram"`
+	Summary   []byte     `db:"summary" json:"summary"`
+	Labels    []byte     `db:"labels" json:"labels"`
 }
 
 func (q *Queries) CreateMetric(ctx context.Context, arg CreateMetricParams) (*Metric, error) {
@@ -28,6 +33,11 @@ func (q *Queries) CreateMetric(ctx context.Context, arg CreateMetricParams) (*Me
 		arg.Type,
 		arg.Value,
 		arg.Delta,
+		arg.Histogram,
+		arg.Labels,
+		arg.Agent,
+		arg.Namespace,
+		arg.Summary,
 	)
 	var i Metric
 	err := row.Scan(
@@ -39,22 +49,32 @@ func (q *Queries) CreateMetric(ctx context.Context, arg CreateMetricParams) (*Me
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Histogram,
+		&i.Labels,
+		&i.Agent,
+		&i.Namespace,
+		&i.Summary,
 	)
 	return &i, err
 }
 
 const CreateOrUpdateMetric = `-- name: CreateOrUpdateMetric :one
-INSERT INTO metrics (name, type, value, delta, created_at)
-VALUES ($1, $2, $3, $4, now())
-    ON CONFLICT ON CONSTRAINT name_type_uniq DO UPDATE SET value=$3, delta=$4, updated_at=now()
-    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at
+INSERT INTO metrics (name, type, value, delta, histogram, labels, agent, namespace, summary, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())
+    ON CONFLICT ON CONSTRAINT name_type_uniq DO UPDATE SET value=$3, delta=$4, histogram=$5, agent=$7, summary=$9, updated_at=now()
+    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary
 `
 
 type CreateOrUpdateMetricParams struct {
-	Value *float64   `db:"value" json:"value"`
-	Delta *int64     `db:"delta" json:"delta"`
-	Name  string     `db:"name" json:"name"`
-	Type  MetricType `db:"type" json:"type"`
+	Value     *float64   `db:"value" json:"value"`
+	Delta     *int64     `db:"delta" json:"delta"`
+	Agent     *string    `db:"agent" json:"agent"`
+	Name      string     `db:"name" json:"name"`
+	Type      MetricType `db:"type" json:"type"`
+	Namespace string     `db:"namespace" json:"namespace"`
+	Histogram []byte     `db:"histogram" json:"histogram"`
+	Summary   []byte     `db:"summary" json:"summary"`
+	Labels    []byte     `db:"labels" json:"labels"`
 }
 
 func (q *Queries) CreateOrUpdateMetric(ctx context.Context, arg CreateOrUpdateMetricParams) (*Metric, error) {
@@ -63,6 +83,11 @@ func (q *Queries) CreateOrUpdateMetric(ctx context.Context, arg CreateOrUpdateMe
 		arg.Type,
 		arg.Value,
 		arg.Delta,
+		arg.Histogram,
+		arg.Labels,
+		arg.Agent,
+		arg.Namespace,
+		arg.Summary,
 	)
 	var i Metric
 	err := row.Scan(
@@ -74,28 +99,40 @@ func (q *Queries) CreateOrUpdateMetric(ctx context.Context, arg CreateOrUpdateMe
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Histogram,
+		&i.Labels,
+		&i.Agent,
+		&i.Namespace,
+		&i.Summary,
 	)
 	return &i, err
 }
 
 const ExistsMetric = `-- name: ExistsMetric :one
-SELECT EXISTS (SELECT id, name, type, value, delta, created_at, updated_at, deleted_at FROM metrics WHERE name=$1 AND type=$2)::boolean
+SELECT EXISTS (SELECT id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary FROM metrics WHERE name=$1 AND type=$2 AND labels=$3 AND namespace=$4)::boolean
 `
 
 type ExistsMetricParams struct {
-	Name string     `db:"name" json:"name"`
-	Type MetricType `db:"type" json:"type"`
+	Name      string     `db:"name" json:"name"`
+	Type      MetricType `db:"type" json:"type"`
+	Namespace string     `db:"namespace" json:"namespace"`
+	Labels    []byte     `db:"labels" json:"labels"`
 }
 
 func (q *Queries) ExistsMetric(ctx context.Context, arg ExistsMetricParams) (bool, error) {
-	row := q.db.QueryRow(ctx, ExistsMetric, arg.Name, arg.Type)
+	row := q.db.QueryRow(ctx, ExistsMetric,
+		arg.Name,
+		arg.Type,
+		arg.Labels,
+		arg.Namespace,
+	)
 	var column_1 bool
 	err := row.Scan(&column_1)
 	return column_1, err
 }
 
 const GetAllMetrics = `-- name: GetAllMetrics :many
-SELECT id, name, type, value, delta, created_at, updated_at, deleted_at FROM metrics ORDER BY name ASC
+SELECT id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary FROM metrics ORDER BY name ASC
 `
 
 func (q *Queries) GetAllMetrics(ctx context.Context) ([]*Metric, error) {
@@ -116,6 +153,11 @@ func (q *Queries) GetAllMetrics(ctx context.Context) ([]*Metric, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.Histogram,
+			&i.Labels,
+			&i.Agent,
+			&i.Namespace,
+			&i.Summary,
 		); err != nil {
 			return nil, err
 		}
@@ -128,16 +170,23 @@ func (q *Queries) GetAllMetrics(ctx context.Context) ([]*Metric, error) {
 }
 
 const GetMetric = `-- name: GetMetric :one
-SELECT id, name, type, value, delta, created_at, updated_at, deleted_at FROM metrics WHERE type=$1 AND name=$2 LIMIT 1
+SELECT id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary FROM metrics WHERE type=$1 AND name=$2 AND labels=$3 AND namespace=$4 LIMIT 1
 `
 
 type GetMetricParams struct {
-	Type MetricType `db:"type" json:"type"`
-	Name string     `db:"name" json:"name"`
+	Type      MetricType `db:"type" json:"type"`
+	Name      string     `db:"name" json:"name"`
+	Namespace string     `db:"namespace" json:"namespace"`
+	Labels    []byte     `db:"labels" json:"labels"`
 }
 
 func (q *Queries) GetMetric(ctx context.Context, arg GetMetricParams) (*Metric, error) {
-	row := q.db.QueryRow(ctx, GetMetric, arg.Type, arg.Name)
+	row := q.db.QueryRow(ctx, GetMetric,
+		arg.Type,
+		arg.Name,
+		arg.Labels,
+		arg.Namespace,
+	)
 	var i Metric
 	err := row.Scan(
 		&i.ID,
@@ -148,53 +197,116 @@ func (q *Queries) GetMetric(ctx context.Context, arg GetMetricParams) (*Metric,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Histogram,
+		&i.Labels,
+		&i.Agent,
+		&i.Namespace,
+		&i.Summary,
 	)
 	return &i, err
 }
 
+const GetNamespaceMetrics = `-- name: GetNamespaceMetrics :many
+SELECT id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary FROM metrics WHERE namespace=$1 ORDER BY name ASC
+`
+
+func (q *Queries) GetNamespaceMetrics(ctx context.Context, namespace string) ([]*Metric, error) {
+	rows, err := q.db.Query(ctx, GetNamespaceMetrics, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Metric{}
+	for rows.Next() {
+		var i Metric
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.Value,
+			&i.Delta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Histogram,
+			&i.Labels,
+			&i.Agent,
+			&i.Namespace,
+			&i.Summary,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const UpdateMetric = `-- name: UpdateMetric :exec
 UPDATE metrics
-SET value=$1, delta=$2, updated_at=now()
-WHERE type=$3 AND name=$4
+SET value=$1, delta=$2, histogram=$3, agent=$7, summary=$9, updated_at=now()
+WHERE type=$4 AND name=$5 AND labels=$6 AND namespace=$8
 `
 
 type UpdateMetricParams struct {
-	Value *float64   `db:"value" json:"value"`
-	Delta *int64     `db:"delta" json:"delta"`
-	Type  MetricType `db:"type" json:"type"`
-	Name  string     `db:"name" json:"name"`
+	Value     *float64   `db:"value" json:"value"`
+	Delta     *int64     `db:"delta" json:"delta"`
+	Agent     *string    `db:"agent" json:"agent"`
+	Type      MetricType `db:"type" json:"type"`
+	Name      string     `db:"name" json:"name"`
+	Namespace string     `db:"namespace" json:"namespace"`
+	Histogram []byte     `db:"histogram" json:"histogram"`
+	Summary   []byte     `db:"summary" json:"summary"`
+	Labels    []byte     `db:"labels" json:"labels"`
 }
 
 func (q *Queries) UpdateMetric(ctx context.Context, arg UpdateMetricParams) error {
 	_, err := q.db.Exec(ctx, UpdateMetric,
 		arg.Value,
 		arg.Delta,
+		arg.Histogram,
 		arg.Type,
 		arg.Name,
+		arg.Labels,
+		arg.Agent,
+		arg.Namespace,
+		arg.Summary,
 	)
 	return err
 }
 
 const UpdateMetricAndGet = `-- name: UpdateMetricAndGet :one
 UPDATE metrics
-SET value=$1, delta=$2, updated_at=now()
-WHERE type=$3 AND name=$4
-    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at
+SET value=$1, delta=$2, histogram=$3, agent=$7, summary=$9, updated_at=now()
+WHERE type=$4 AND name=$5 AND labels=$6 AND namespace=$8
+    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary
 `
 
 type UpdateMetricAndGetParams struct {
-	Value *float64   `db:"value" json:"value"`
-	Delta *int64     `db:"delta" json:"delta"`
-	Type  MetricType `db:"type" json:"type"`
-	Name  string     `db:"name" json:"name"`
+	Value     *float64   `db:"value" json:"value"`
+	Delta     *int64     `db:"delta" json:"delta"`
+	Agent     *string    `db:"agent" json:"agent"`
+	Type      MetricType `db:"type" json:"type"`
+	Name      string     `db:"name" json:"name"`
+	Namespace string     `db:"namespace" json:"namespace"`
+	Histogram []byte     `db:"histogram" json:"histogram"`
+	Summary   []byte     `db:"summary" json:"summary"`
+	Labels    []byte     `db:"labels" json:"labels"`
 }
 
 func (q *Queries) UpdateMetricAndGet(ctx context.Context, arg UpdateMetricAndGetParams) (*Metric, error) {
 	row := q.db.QueryRow(ctx, UpdateMetricAndGet,
 		arg.Value,
 		arg.Delta,
+		arg.Histogram,
 		arg.Type,
 		arg.Name,
+		arg.Labels,
+		arg.Agent,
+		arg.Namespace,
+		arg.Summary,
 	)
 	var i Metric
 	err := row.Scan(
@@ -206,6 +318,11 @@ func (q *Queries) UpdateMetricAndGet(ctx context.Context, arg UpdateMetricAndGet
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Histogram,
+		&i.Labels,
+		&i.Agent,
+		&i.Namespace,
+		&i.Summary,
 	)
 	return &i, err
 }
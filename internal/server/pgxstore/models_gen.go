@@ -14,8 +14,10 @@ import (
 type MetricType string
 
 const (
-	MetricTypeGauge   MetricType = "gauge"
-	MetricTypeCounter MetricType = "counter"
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeHistogram MetricType = "histogram"
+	MetricTypeSummary   MetricType = "summary"
 )
 
 func (e *MetricType) Scan(src interface{}) error {
@@ -56,7 +58,9 @@ func (ns NullMetricType) Value() (driver.Value, error) {
 func (e MetricType) Valid() bool {
 	switch e {
 	case MetricTypeGauge,
-		MetricTypeCounter:
+		MetricTypeCounter,
+		MetricTypeHistogram,
+		MetricTypeSummary:
 		return true
 	}
 	return false
@@ -66,16 +70,44 @@ func AllMetricTypeValues() []MetricType {
 	return []MetricType{
 		MetricTypeGauge,
 		MetricTypeCounter,
+		MetricTypeHistogram,
+		MetricTypeSummary,
 	}
 }
 
+type AppliedBatch struct {
+	Namespace string           `db:"namespace" json:"namespace"`
+	BatchID   string           `db:"batch_id" json:"batch_id"`
+	Result    []byte           `db:"result" json:"result"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
 type Metric struct {
 	Value     *float64         `db:"value" json:"value"`
 	Delta     *int64           `db:"delta" json:"delta"`
+	Agent     *string          `db:"agent" json:"agent"`
 	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamp `db:"updated_at" json:"updated_at"`
 	DeletedAt pgtype.Timestamp `db:"deleted_at" json:"deleted_at"`
 	Name      string           `db:"name" json:"name"`
 	Type      MetricType       `db:"type" json:"type"`
+	Namespace string           `db:"namespace" json:"namespace"`
+	Histogram []byte           `db:"histogram" json:"histogram"`
+	Summary   []byte           `db:"summary" json:"summary"`
+	Labels    []byte           `db:"labels" json:"labels"`
 	ID        pgtype.UUID      `db:"id" json:"id"`
 }
+
+type MetricSample struct {
+	Value     *float64         `db:"value" json:"value"`
+	Delta     *int64           `db:"delta" json:"delta"`
+	Agent     *string          `db:"agent" json:"agent"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+	Name      string           `db:"name" json:"name"`
+	Type      MetricType       `db:"type" json:"type"`
+	Namespace string           `db:"namespace" json:"namespace"`
+	Labels    []byte           `db:"labels" json:"labels"`
+	Histogram []byte           `db:"histogram" json:"histogram"`
+	Summary   []byte           `db:"summary" json:"summary"`
+	ID        int64            `db:"id" json:"id"`
+}
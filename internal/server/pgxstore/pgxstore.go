@@ -3,6 +3,7 @@ package pgxstore
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -72,8 +73,14 @@ func (p *PGXStore) WithTx(tx pgx.Tx) *Queries {
 	return p.Queries.WithTx(tx)
 }
 
-func (p *PGXStore) GetAll(ctx context.Context) ([]*dto.MetricDTO, error) {
-	items, err := p.GetAllMetrics(ctx)
+func (p *PGXStore) GetAll(ctx context.Context, namespace string) ([]*dto.MetricDTO, error) {
+	var items []*Metric
+	var err error
+	if namespace == store.AllNamespaces {
+		items, err = p.GetAllMetrics(ctx)
+	} else {
+		items, err = p.GetNamespaceMetrics(ctx, namespace)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not get metrics from db: %w", err)
 	}
@@ -94,20 +101,69 @@ func (p *PGXStore) commonMetricTypeToPGXMType(mType common.MetricType) (MetricTy
 		return MetricTypeCounter, nil
 	case common.GaugeMetricType:
 		return MetricTypeGauge, nil
+	case common.HistogramMetricType:
+		return MetricTypeHistogram, nil
+	case common.SummaryMetricType:
+		return MetricTypeSummary, nil
 	default:
 		return "", fmt.Errorf("unknown metric type: %v", mType)
 	}
 }
 
-func (p *PGXStore) Read(ctx context.Context, mType common.MetricType, name string) (*dto.MetricDTO, error) {
+func (p *PGXStore) encodeHistogram(metricDTO *dto.MetricDTO) ([]byte, error) {
+	if metricDTO.Histogram == nil {
+		return nil, nil
+	}
+	histogram, err := json.Marshal(metricDTO.Histogram)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode histogram for '%s': %w", metricDTO.ID, err)
+	}
+	return histogram, nil
+}
+
+func (p *PGXStore) encodeSummary(metricDTO *dto.MetricDTO) ([]byte, error) {
+	if metricDTO.Summary == nil {
+		return nil, nil
+	}
+	summary, err := json.Marshal(metricDTO.Summary)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode summary for '%s': %w", metricDTO.ID, err)
+	}
+	return summary, nil
+}
+
+func (p *PGXStore) encodeLabels(labels map[string]string) ([]byte, error) {
+	if len(labels) == 0 {
+		return []byte("{}"), nil
+	}
+	encoded, err := json.Marshal(labels)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode labels: %w", err)
+	}
+	return encoded, nil
+}
+
+func (p *PGXStore) Read(
+	ctx context.Context,
+	namespace string,
+	mType common.MetricType,
+	name string,
+	labels map[string]string,
+) (*dto.MetricDTO, error) {
 	PGXType, err := p.commonMetricTypeToPGXMType(mType)
 	if err != nil {
 		return nil, fmt.Errorf("could not get metric type for '%s': %w", mType, err)
 	}
+	encodedLabels, err := p.encodeLabels(labels)
+	if err != nil {
+		return nil, fmt.Errorf("could not read metric '%s': %w", name, err)
+	}
 
 	item, readErr := p.GetMetric(ctx, GetMetricParams{
-		Type: PGXType,
-		Name: name,
+		Type:      PGXType,
+		Name:      name,
+		Namespace: namespace,
+		Labels:    encodedLabels,
 	})
 	if readErr != nil {
 		if errors.Is(readErr, pgx.ErrNoRows) {
@@ -132,12 +188,29 @@ func (p *PGXStore) Create(ctx context.Context, metricDTO *dto.MetricDTO) error {
 	if err != nil {
 		return fmt.Errorf("could not get metric type for '%s': %w", metricDTO.MType, err)
 	}
+	histogram, err := p.encodeHistogram(metricDTO)
+	if err != nil {
+		return err
+	}
+	summary, err := p.encodeSummary(metricDTO)
+	if err != nil {
+		return err
+	}
+	labels, err := p.encodeLabels(metricDTO.Labels)
+	if err != nil {
+		return err
+	}
 
 	createParams := CreateMetricParams{
-		Name:  metricDTO.ID,
-		Type:  mType,
-		Delta: metricDTO.Delta,
-		Value: metricDTO.Value,
+		Name:      metricDTO.ID,
+		Type:      mType,
+		Delta:     metricDTO.Delta,
+		Value:     metricDTO.Value,
+		Histogram: histogram,
+		Summary:   summary,
+		Labels:    labels,
+		Agent:     agentParam(metricDTO.Agent),
+		Namespace: metricDTO.Namespace,
 	}
 	_, err = p.CreateMetric(ctx, createParams)
 	if err != nil {
@@ -156,12 +229,29 @@ func (p *PGXStore) Update(ctx context.Context, metricDTO *dto.MetricDTO) error {
 	if err != nil {
 		return fmt.Errorf("could not get metric type for '%s': %w", metricDTO.MType, err)
 	}
+	histogram, err := p.encodeHistogram(metricDTO)
+	if err != nil {
+		return err
+	}
+	summary, err := p.encodeSummary(metricDTO)
+	if err != nil {
+		return err
+	}
+	labels, err := p.encodeLabels(metricDTO.Labels)
+	if err != nil {
+		return err
+	}
 
 	err = p.UpdateMetric(ctx, UpdateMetricParams{
-		Name:  metricDTO.ID,
-		Type:  mType,
-		Delta: metricDTO.Delta,
-		Value: metricDTO.Value,
+		Name:      metricDTO.ID,
+		Type:      mType,
+		Delta:     metricDTO.Delta,
+		Value:     metricDTO.Value,
+		Histogram: histogram,
+		Summary:   summary,
+		Labels:    labels,
+		Agent:     agentParam(metricDTO.Agent),
+		Namespace: metricDTO.Namespace,
 	})
 	if err != nil {
 		return fmt.Errorf("could not update metric: %w", err)
@@ -175,12 +265,29 @@ func (p *PGXStore) CreateOrUpdate(ctx context.Context, metricDTO *dto.MetricDTO)
 	if err != nil {
 		return fmt.Errorf("pgxstore.CreateOrUpdate type conversion: %w", err)
 	}
+	histogram, err := p.encodeHistogram(metricDTO)
+	if err != nil {
+		return fmt.Errorf("pgxstore.CreateOrUpdate: %w", err)
+	}
+	summary, err := p.encodeSummary(metricDTO)
+	if err != nil {
+		return fmt.Errorf("pgxstore.CreateOrUpdate: %w", err)
+	}
+	labels, err := p.encodeLabels(metricDTO.Labels)
+	if err != nil {
+		return fmt.Errorf("pgxstore.CreateOrUpdate: %w", err)
+	}
 
 	_, err = p.CreateOrUpdateMetric(ctx, CreateOrUpdateMetricParams{
-		Name:  metricDTO.ID,
-		Type:  mType,
-		Delta: metricDTO.Delta,
-		Value: metricDTO.Value,
+		Name:      metricDTO.ID,
+		Type:      mType,
+		Delta:     metricDTO.Delta,
+		Value:     metricDTO.Value,
+		Histogram: histogram,
+		Summary:   summary,
+		Labels:    labels,
+		Agent:     agentParam(metricDTO.Agent),
+		Namespace: metricDTO.Namespace,
 	})
 
 	if err != nil {
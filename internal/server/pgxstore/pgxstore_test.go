@@ -59,6 +59,95 @@ func TestMetrics_BulkCreateOrUpdate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "set histogram metric",
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "latency",
+						MType: "histogram",
+						Histogram: &dto.HistogramDTO{
+							Bounds: []float64{0.1, 0.5},
+							Counts: []uint64{1, 2, 0},
+							Sum:    0.9,
+							Count:  3,
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "set summary metric",
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "latency",
+						MType: "summary",
+						Summary: &dto.SummaryDTO{
+							Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 0.2}, {Quantile: 0.99, Value: 0.6}},
+							Sum:       0.9,
+							Count:     3,
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "set labeled metrics",
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:     "foo",
+						MType:  "gauge",
+						Labels: map[string]string{"host": "a"},
+						Value:  testutils.Pointer(0.1),
+					},
+					{
+						ID:     "foo",
+						MType:  "gauge",
+						Labels: map[string]string{"host": "b"},
+						Value:  testutils.Pointer(0.2),
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "set metric with agent",
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:    "foo",
+						MType: "gauge",
+						Value: testutils.Pointer(0.1),
+						Agent: "host-1",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "set same metric in different namespaces",
+			args: args{
+				metrics: []*dto.MetricDTO{
+					{
+						ID:        "Alloc",
+						MType:     "gauge",
+						Value:     testutils.Pointer(0.1),
+						Namespace: "team-a",
+					},
+					{
+						ID:        "Alloc",
+						MType:     "gauge",
+						Value:     testutils.Pointer(0.2),
+						Namespace: "team-b",
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -75,8 +164,20 @@ func TestMetrics_BulkCreateOrUpdate(t *testing.T) {
 
 			for index, metric := range tt.args.metrics {
 				indexToStr := strconv.Itoa(index)
+				histogram, encodeErr := store.encodeHistogram(metric)
+				if encodeErr != nil {
+					t.Fatal(encodeErr)
+				}
+				summary, encodeErr := store.encodeSummary(metric)
+				if encodeErr != nil {
+					t.Fatal(encodeErr)
+				}
+				labels, encodeErr := store.encodeLabels(metric.Labels)
+				if encodeErr != nil {
+					t.Fatal(encodeErr)
+				}
 				rows := pgxmock.
-					NewRows([]string{"id", "name", "type", "value", "delta", "created_at", "updated_at", "deleted_at"}).
+					NewRows([]string{"id", "name", "type", "value", "delta", "created_at", "updated_at", "deleted_at", "histogram", "labels", "agent", "namespace", "summary"}).
 					AddRow(
 						"00000000-0000-0000-0000-00000000000"+indexToStr,
 						metric.ID,
@@ -86,9 +187,24 @@ func TestMetrics_BulkCreateOrUpdate(t *testing.T) {
 						time.Now(),
 						nil,
 						nil,
+						histogram,
+						labels,
+						agentParam(metric.Agent),
+						metric.Namespace,
+						summary,
 					)
 				mock.ExpectQuery(CreateOrUpdateMetric).
-					WithArgs(metric.ID, MetricType(metric.MType), metric.Value, metric.Delta).
+					WithArgs(
+						metric.ID,
+						MetricType(metric.MType),
+						metric.Value,
+						metric.Delta,
+						histogram,
+						labels,
+						agentParam(metric.Agent),
+						metric.Namespace,
+						summary,
+					).
 					WillReturnRows(rows)
 			}
 
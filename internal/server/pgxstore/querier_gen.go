@@ -6,14 +6,23 @@ package pgxstore
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	CreateAppliedBatch(ctx context.Context, arg CreateAppliedBatchParams) (int64, error)
 	CreateMetric(ctx context.Context, arg CreateMetricParams) (*Metric, error)
+	CreateMetricSample(ctx context.Context, arg CreateMetricSampleParams) error
 	CreateOrUpdateMetric(ctx context.Context, arg CreateOrUpdateMetricParams) (*Metric, error)
+	DeleteAppliedBatchesBefore(ctx context.Context, createdAt pgtype.Timestamp) error
+	DeleteMetricSamplesBefore(ctx context.Context, createdAt pgtype.Timestamp) error
 	ExistsMetric(ctx context.Context, arg ExistsMetricParams) (bool, error)
 	GetAllMetrics(ctx context.Context) ([]*Metric, error)
+	GetAppliedBatch(ctx context.Context, arg GetAppliedBatchParams) (*AppliedBatch, error)
 	GetMetric(ctx context.Context, arg GetMetricParams) (*Metric, error)
+	GetMetricSamples(ctx context.Context, arg GetMetricSamplesParams) ([]*MetricSample, error)
+	GetNamespaceMetrics(ctx context.Context, namespace string) ([]*Metric, error)
 	UpdateMetric(ctx context.Context, arg UpdateMetricParams) error
 	UpdateMetricAndGet(ctx context.Context, arg UpdateMetricAndGetParams) (*Metric, error)
 }
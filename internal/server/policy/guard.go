@@ -0,0 +1,179 @@
+// Package policy restricts metrics service access to permissions of request principal.
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/server/auth"
+)
+
+type Metrics interface {
+	SetMetric(ctx context.Context, metricType common.MetricType, name string, value string) error
+	SetMetricByDto(ctx context.Context, metricDto *dto.MetricDTO) error
+	GetValueAsString(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (string, error)
+	GetMetricAsDTO(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (*dto.MetricDTO, error)
+	GetAllValuesAsString(ctx context.Context) (map[string]string, error)
+	SetMetrics(ctx context.Context, metrics []*dto.MetricDTO) error
+	SetMetricsBatch(ctx context.Context, batchID string, metrics []*dto.MetricDTO) ([]*dto.MetricDTO, error)
+	QueryRange(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error)
+	ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error)
+	Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func())
+}
+
+// Guard wraps metrics service and checks every call against request principal.
+// Writes outside allowed prefixes are rejected with auth.ErrForbidden, listings
+// are narrowed down to readable metrics.
+type Guard struct {
+	metrics Metrics
+}
+
+func NewGuard(metrics Metrics) *Guard {
+	return &Guard{metrics: metrics}
+}
+
+func (g *Guard) SetMetric(ctx context.Context, metricType common.MetricType, name string, value string) error {
+	if err := auth.Authorize(ctx, auth.ActionWrite, name); err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+	return g.metrics.SetMetric(ctx, metricType, name, value) //nolint:wrapcheck // service errors are passed as is
+}
+
+func (g *Guard) SetMetricByDto(ctx context.Context, metricDto *dto.MetricDTO) error {
+	if err := auth.Authorize(ctx, auth.ActionWrite, metricDto.ID); err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+	return g.metrics.SetMetricByDto(ctx, metricDto) //nolint:wrapcheck // service errors are passed as is
+}
+
+func (g *Guard) SetMetrics(ctx context.Context, metrics []*dto.MetricDTO) error {
+	names := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		names = append(names, metric.ID)
+	}
+	if err := auth.Authorize(ctx, auth.ActionWrite, names...); err != nil {
+		return fmt.Errorf("policy: %w", err)
+	}
+	return g.metrics.SetMetrics(ctx, metrics) //nolint:wrapcheck // service errors are passed as is
+}
+
+func (g *Guard) SetMetricsBatch(
+	ctx context.Context,
+	batchID string,
+	metrics []*dto.MetricDTO,
+) ([]*dto.MetricDTO, error) {
+	names := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		names = append(names, metric.ID)
+	}
+	if err := auth.Authorize(ctx, auth.ActionWrite, names...); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	return g.metrics.SetMetricsBatch(ctx, batchID, metrics) //nolint:wrapcheck // service errors are passed as is
+}
+
+func (g *Guard) GetValueAsString(
+	ctx context.Context,
+	metricType common.MetricType,
+	name string,
+	labels map[string]string,
+) (string, error) {
+	if err := auth.Authorize(ctx, auth.ActionRead, name); err != nil {
+		return "", fmt.Errorf("policy: %w", err)
+	}
+	return g.metrics.GetValueAsString(ctx, metricType, name, labels) //nolint:wrapcheck // service errors are passed as is
+}
+
+func (g *Guard) GetMetricAsDTO(
+	ctx context.Context,
+	metricType common.MetricType,
+	name string,
+	labels map[string]string,
+) (*dto.MetricDTO, error) {
+	if err := auth.Authorize(ctx, auth.ActionRead, name); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	return g.metrics.GetMetricAsDTO(ctx, metricType, name, labels) //nolint:wrapcheck // service errors are passed as is
+}
+
+func (g *Guard) QueryRange(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error) {
+	if err := auth.Authorize(ctx, auth.ActionRead, q.ID); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	return g.metrics.QueryRange(ctx, q) //nolint:wrapcheck // service errors are passed as is
+}
+
+// GetAllValuesAsString returns only metrics readable by principal.
+func (g *Guard) GetAllValuesAsString(ctx context.Context) (map[string]string, error) {
+	if err := auth.Authorize(ctx, auth.ActionRead); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	values, err := g.metrics.GetAllValuesAsString(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // service errors are passed as is
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return values, nil
+	}
+	// keys look like "<name>{<labels>}", so name prefix is also key prefix
+	for fullName := range values {
+		if !principal.CanRead(fullName) {
+			delete(values, fullName)
+		}
+	}
+	return values, nil
+}
+
+// ExportMetrics returns only metrics readable by principal.
+func (g *Guard) ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error) {
+	if err := auth.Authorize(ctx, auth.ActionRead); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	metrics, err := g.metrics.ExportMetrics(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // service errors are passed as is
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return metrics, nil
+	}
+	allowed := metrics[:0]
+	for _, metric := range metrics {
+		if principal.CanRead(metric.ID) {
+			allowed = append(allowed, metric)
+		}
+	}
+	return allowed, nil
+}
+
+// Watch narrows subscription to metrics readable by principal. Principal without
+// read permission gets already closed channel.
+func (g *Guard) Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func()) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return g.metrics.Watch(ctx, filter)
+	}
+	if !principal.Role.Allows(auth.ActionRead) {
+		closed := make(chan *dto.MetricDTO)
+		close(closed)
+		return closed, func() {}
+	}
+
+	filter.Allowed = principal.ReadPrefixes
+	return g.metrics.Watch(ctx, filter)
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
+	"github.com/Kopleman/metcol/internal/server/memstore"
+	"github.com/Kopleman/metcol/internal/server/metrics"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGuard(t *testing.T) *Guard {
+	t.Helper()
+
+	m := metrics.NewMetrics(memstore.NewStore(make(map[string]*dto.MetricDTO)), log.MockLogger{})
+	require.NoError(t, m.SetMetrics(context.Background(), []*dto.MetricDTO{
+		{ID: "http_requests", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1))},
+		{ID: "db_calls", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(2))},
+		{ID: "cpu", MType: common.GaugeMetricType, Value: testutils.Pointer(0.5)},
+	}))
+	return NewGuard(m)
+}
+
+func TestGuard_Write(t *testing.T) {
+	g := newTestGuard(t)
+	writer := auth.WithPrincipal(context.Background(), &auth.Principal{
+		Agent:         "host-1",
+		Role:          auth.RoleWriter,
+		WritePrefixes: []string{"host_1_"},
+	})
+	reader := auth.WithPrincipal(context.Background(), &auth.Principal{Agent: "grafana", Role: auth.RoleReader})
+
+	require.NoError(t, g.SetMetric(writer, common.GaugeMetricType, "host_1_cpu", "1"))
+	assert.ErrorIs(t, g.SetMetric(writer, common.GaugeMetricType, "host_2_cpu", "1"), auth.ErrForbidden)
+	assert.ErrorIs(t, g.SetMetricByDto(reader, &dto.MetricDTO{
+		ID:    "host_1_cpu",
+		MType: common.GaugeMetricType,
+		Value: testutils.Pointer(1.0),
+	}), auth.ErrForbidden)
+
+	// батч отклоняется целиком, если хотя бы одна метрика вне разрешённых префиксов
+	err := g.SetMetrics(writer, []*dto.MetricDTO{
+		{ID: "host_1_mem", MType: common.GaugeMetricType, Value: testutils.Pointer(1.0)},
+		{ID: "cpu", MType: common.GaugeMetricType, Value: testutils.Pointer(1.0)},
+	})
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	_, err = g.GetMetricAsDTO(context.Background(), common.GaugeMetricType, "host_1_mem", nil)
+	assert.Error(t, err)
+
+	// без принципала (аутентификация выключена) ограничений нет
+	require.NoError(t, g.SetMetric(context.Background(), common.GaugeMetricType, "anything", "1"))
+}
+
+func TestGuard_Read(t *testing.T) {
+	g := newTestGuard(t)
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+		Agent:        "grafana",
+		Role:         auth.RoleReader,
+		ReadPrefixes: []string{"http_", "db_"},
+	})
+
+	value, err := g.GetValueAsString(ctx, common.CounterMetricType, "http_requests", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1", value)
+
+	_, err = g.GetMetricAsDTO(ctx, common.GaugeMetricType, "cpu", nil)
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+
+	_, err = g.QueryRange(ctx, &dto.QueryRequest{ID: "cpu", MType: common.GaugeMetricType})
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+
+	all, err := g.GetAllValuesAsString(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"http_requests": "1", "db_calls": "2"}, all)
+
+	exported, err := g.ExportMetrics(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, m := range exported {
+		names = append(names, m.ID)
+	}
+	assert.ElementsMatch(t, []string{"http_requests", "db_calls"}, names)
+}
+
+func TestGuard_Watch(t *testing.T) {
+	g := newTestGuard(t)
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+		Agent:        "grafana",
+		Role:         auth.RoleReader,
+		ReadPrefixes: []string{"http_"},
+	})
+	updates, cancel := g.Watch(ctx, dto.WatchFilter{})
+	defer cancel()
+
+	require.NoError(t, g.SetMetrics(context.Background(), []*dto.MetricDTO{
+		{ID: "cpu", MType: common.GaugeMetricType, Value: testutils.Pointer(1.0)},
+		{ID: "http_requests", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(1))},
+	}))
+	update := <-updates
+	assert.Equal(t, "http_requests", update.ID)
+
+	noRole, cancelNoRole := g.Watch(
+		auth.WithPrincipal(context.Background(), &auth.Principal{Agent: "unknown"}),
+		dto.WatchFilter{},
+	)
+	defer cancelNoRole()
+	_, ok := <-noRole
+	assert.False(t, ok)
+}
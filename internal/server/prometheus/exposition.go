@@ -0,0 +1,294 @@
+// Package prometheus converts metrics between internal DTO and prometheus formats.
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+// ContentType of text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const counterSuffix = "_total"
+
+// family groups series which share sanitized name and type, HELP and TYPE lines are written
+// once per family.
+type family struct {
+	name   string
+	mType  common.MetricType
+	series []*dto.MetricDTO
+}
+
+// SanitizeName makes valid prometheus metric name: [a-zA-Z_:][a-zA-Z0-9_:]*.
+func SanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+// SanitizeLabelName makes valid prometheus label name: [a-zA-Z_][a-zA-Z0-9_]*.
+func SanitizeLabelName(name string) string {
+	return sanitize(name, false)
+}
+
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+	var b strings.Builder
+	for i, r := range name {
+		valid := r == '_' ||
+			(r >= 'a' && r <= 'z') ||
+			(r >= 'A' && r <= 'Z') ||
+			(allowColon && r == ':') ||
+			(i > 0 && r >= '0' && r <= '9')
+		switch {
+		case valid:
+			b.WriteRune(r)
+		case i == 0 && r >= '0' && r <= '9':
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func familyName(metric *dto.MetricDTO) string {
+	name := SanitizeName(metric.ID)
+	if metric.MType == common.CounterMetricType && !strings.HasSuffix(name, counterSuffix) {
+		name += counterSuffix
+	}
+	return name
+}
+
+func typeName(mType common.MetricType) string {
+	switch mType {
+	case common.CounterMetricType:
+		return "counter"
+	case common.GaugeMetricType:
+		return "gauge"
+	case common.HistogramMetricType:
+		return "histogram"
+	case common.SummaryMetricType:
+		return "summary"
+	default:
+		return "untyped"
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// labelPairs renders label set in stable order. Label which name collides with reserved name
+// or with name of another label after sanitizing gets numeric suffix.
+func labelPairs(labels map[string]string, reserved string) []string {
+	pairs := make([]string, 0, len(labels)+1)
+	used := make(map[string]struct{}, len(labels)+1)
+	if reserved != "" {
+		used[reserved] = struct{}{}
+	}
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		name := uniqueName(SanitizeLabelName(k), used)
+		used[name] = struct{}{}
+		pairs = append(pairs, name+`="`+labelValueReplacer.Replace(labels[k])+`"`)
+	}
+	return pairs
+}
+
+func uniqueName(name string, used map[string]struct{}) string {
+	if _, ok := used[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := name + "_" + strconv.Itoa(i)
+		if _, ok := used[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// formatLabels renders label pairs, extra pair is appended last (used for histogram "le" and
+// summary "quantile").
+func formatLabels(pairs []string, extraName, extraValue string) string {
+	if len(pairs) == 0 && extraName == "" {
+		return ""
+	}
+	if extraName != "" {
+		pairs = append(slices.Clip(pairs), extraName+`="`+labelValueReplacer.Replace(extraValue)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// sampleNames returns names of all samples family writes.
+func (f *family) sampleNames() []string {
+	switch f.mType {
+	case common.HistogramMetricType:
+		return []string{f.name, f.name + "_bucket", f.name + "_sum", f.name + "_count"}
+	case common.SummaryMetricType:
+		return []string{f.name, f.name + "_sum", f.name + "_count"}
+	default:
+		return []string{f.name}
+	}
+}
+
+// resolveConflicts keeps sample names unique, so scrapers accept the page. Family which name is
+// taken by family of another type gets type suffix and is dropped if suffixed name is taken too.
+func resolveConflicts(families []*family) []*family {
+	taken := make(map[string]struct{})
+	isTaken := func(f *family) bool {
+		return slices.ContainsFunc(f.sampleNames(), func(name string) bool {
+			_, ok := taken[name]
+			return ok
+		})
+	}
+
+	resolved := make([]*family, 0, len(families))
+	for _, f := range families {
+		if isTaken(f) {
+			f.name += "_" + typeName(f.mType)
+			if isTaken(f) {
+				continue
+			}
+		}
+		for _, name := range f.sampleNames() {
+			taken[name] = struct{}{}
+		}
+		resolved = append(resolved, f)
+	}
+	return resolved
+}
+
+func groupFamilies(metrics []*dto.MetricDTO) []*family {
+	byKey := make(map[string]*family)
+	for _, metric := range metrics {
+		name := familyName(metric)
+		key := name + "-" + string(metric.MType)
+		f, ok := byKey[key]
+		if !ok {
+			f = &family{name: name, mType: metric.MType}
+			byKey[key] = f
+		}
+		f.series = append(f.series, metric)
+	}
+
+	families := slices.Collect(maps.Values(byKey))
+	slices.SortFunc(families, func(a, b *family) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.mType), string(b.mType))
+	})
+	families = resolveConflicts(families)
+	slices.SortFunc(families, func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+	for _, f := range families {
+		slices.SortFunc(f.series, func(a, b *dto.MetricDTO) int {
+			if c := strings.Compare(dto.LabelsKey(a.Labels), dto.LabelsKey(b.Labels)); c != 0 {
+				return c
+			}
+			return strings.Compare(a.ID, b.ID)
+		})
+	}
+	return families
+}
+
+func writeSeries(w *bufio.Writer, f *family, metric *dto.MetricDTO, pairs []string) {
+	switch f.mType {
+	case common.CounterMetricType:
+		if metric.Delta == nil {
+			return
+		}
+		fmt.Fprintf(w, "%s%s %d\n", f.name, formatLabels(pairs, "", ""), *metric.Delta)
+	case common.GaugeMetricType:
+		if metric.Value == nil {
+			return
+		}
+		fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(pairs, "", ""), formatFloat(*metric.Value))
+	case common.HistogramMetricType:
+		h := metric.Histogram
+		if h == nil {
+			return
+		}
+		// prometheus buckets are cumulative, stored counts are per bucket.
+		var cumulative uint64
+		for i, count := range h.Counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(h.Bounds) {
+				le = formatFloat(h.Bounds[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(pairs, "le", le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, formatLabels(pairs, "", ""), formatFloat(h.Sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, formatLabels(pairs, "", ""), h.Count)
+	case common.SummaryMetricType:
+		s := metric.Summary
+		if s == nil {
+			return
+		}
+		for _, q := range s.Quantiles {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(pairs, "quantile", formatFloat(q.Quantile)), formatFloat(q.Value))
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, formatLabels(pairs, "", ""), formatFloat(s.Sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, formatLabels(pairs, "", ""), s.Count)
+	default:
+		return
+	}
+}
+
+// WriteText renders metrics in prometheus text exposition format.
+func WriteText(out io.Writer, metrics []*dto.MetricDTO) error {
+	w := bufio.NewWriter(out)
+	for _, f := range groupFamilies(metrics) {
+		help := helpReplacer.Replace(fmt.Sprintf("%s metric %q collected by metcol.", f.mType, f.series[0].ID))
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.name, typeName(f.mType))
+		reserved := ""
+		switch f.mType {
+		case common.HistogramMetricType:
+			reserved = "le"
+		case common.SummaryMetricType:
+			reserved = "quantile"
+		default:
+		}
+		// different names may become the same after sanitizing, repeated series is skipped
+		written := make(map[string]struct{}, len(f.series))
+		for _, metric := range f.series {
+			pairs := labelPairs(metric.Labels, reserved)
+			key := strings.Join(pairs, ",")
+			if _, ok := written[key]; ok {
+				continue
+			}
+			written[key] = struct{}{}
+			writeSeries(w, f, metric, pairs)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write prometheus exposition: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package prometheus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		metrics []*dto.MetricDTO
+	}{
+		{
+			name:    "empty store",
+			metrics: []*dto.MetricDTO{},
+			want:    "",
+		},
+		{
+			name: "counter and gauges",
+			metrics: []*dto.MetricDTO{
+				{ID: "PollCount", MType: "counter", Delta: testutils.Pointer(int64(5))},
+				{ID: "Alloc", MType: "gauge", Value: testutils.Pointer(1.5), Labels: map[string]string{"host": "b"}},
+				{ID: "Alloc", MType: "gauge", Value: testutils.Pointer(2.0), Labels: map[string]string{"host": "a"}},
+			},
+			want: `# HELP Alloc gauge metric "Alloc" collected by metcol.
+# TYPE Alloc gauge
+Alloc{host="a"} 2
+Alloc{host="b"} 1.5
+# HELP PollCount_total counter metric "PollCount" collected by metcol.
+# TYPE PollCount_total counter
+PollCount_total 5
+`,
+		},
+		{
+			name: "names and label values are escaped",
+			metrics: []*dto.MetricDTO{
+				{
+					ID:     "1cpu.util-%",
+					MType:  "gauge",
+					Value:  testutils.Pointer(0.25),
+					Labels: map[string]string{"core-id": "a\"b\\c"},
+				},
+			},
+			want: `# HELP _1cpu_util__ gauge metric "1cpu.util-%" collected by metcol.
+# TYPE _1cpu_util__ gauge
+_1cpu_util__{core_id="a\"b\\c"} 0.25
+`,
+		},
+		{
+			name: "histogram with cumulative buckets",
+			metrics: []*dto.MetricDTO{
+				{
+					ID:    "latency",
+					MType: "histogram",
+					Histogram: &dto.HistogramDTO{
+						Bounds: []float64{0.1, 1},
+						Counts: []uint64{1, 2, 3},
+						Sum:    7.5,
+						Count:  6,
+					},
+				},
+			},
+			want: `# HELP latency histogram metric "latency" collected by metcol.
+# TYPE latency histogram
+latency_bucket{le="0.1"} 1
+latency_bucket{le="1"} 3
+latency_bucket{le="+Inf"} 6
+latency_sum 7.5
+latency_count 6
+`,
+		},
+		{
+			name: "gauge and histogram share name",
+			metrics: []*dto.MetricDTO{
+				{ID: "latency", MType: "gauge", Value: testutils.Pointer(0.5)},
+				{
+					ID:        "latency",
+					MType:     "histogram",
+					Histogram: &dto.HistogramDTO{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1},
+				},
+				{ID: "latency_count", MType: "gauge", Value: testutils.Pointer(3.0)},
+			},
+			want: `# HELP latency gauge metric "latency" collected by metcol.
+# TYPE latency gauge
+latency 0.5
+# HELP latency_count gauge metric "latency_count" collected by metcol.
+# TYPE latency_count gauge
+latency_count 3
+# HELP latency_histogram histogram metric "latency" collected by metcol.
+# TYPE latency_histogram histogram
+latency_histogram_bucket{le="1"} 1
+latency_histogram_bucket{le="+Inf"} 1
+latency_histogram_sum 0.5
+latency_histogram_count 1
+`,
+		},
+		{
+			name: "names collide after sanitizing",
+			metrics: []*dto.MetricDTO{
+				{ID: "cpu.util", MType: "gauge", Value: testutils.Pointer(1.0)},
+				{ID: "cpu_util", MType: "gauge", Value: testutils.Pointer(2.0)},
+				{ID: "cpu_util", MType: "gauge", Value: testutils.Pointer(3.0), Labels: map[string]string{"core": "0"}},
+				{
+					ID:     "cpu_util",
+					MType:  "gauge",
+					Value:  testutils.Pointer(4.0),
+					Labels: map[string]string{"core.id": "1", "core_id": "2"},
+				},
+			},
+			want: `# HELP cpu_util gauge metric "cpu.util" collected by metcol.
+# TYPE cpu_util gauge
+cpu_util 1
+cpu_util{core_id="1",core_id_2="2"} 4
+cpu_util{core="0"} 3
+`,
+		},
+		{
+			name: "histogram label named le",
+			metrics: []*dto.MetricDTO{
+				{
+					ID:        "latency",
+					MType:     "histogram",
+					Labels:    map[string]string{"le": "x"},
+					Histogram: &dto.HistogramDTO{Bounds: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1},
+				},
+			},
+			want: `# HELP latency histogram metric "latency" collected by metcol.
+# TYPE latency histogram
+latency_bucket{le_2="x",le="1"} 1
+latency_bucket{le_2="x",le="+Inf"} 1
+latency_sum{le_2="x"} 0.5
+latency_count{le_2="x"} 1
+`,
+		},
+		{
+			name: "summary with quantiles",
+			metrics: []*dto.MetricDTO{
+				{
+					ID:     "rpc_duration",
+					MType:  "summary",
+					Labels: map[string]string{"quantile": "x"},
+					Summary: &dto.SummaryDTO{
+						Quantiles: []dto.QuantileDTO{{Quantile: 0.5, Value: 0.2}, {Quantile: 0.99, Value: 1.5}},
+						Sum:       12.5,
+						Count:     40,
+					},
+				},
+			},
+			want: `# HELP rpc_duration summary metric "rpc_duration" collected by metcol.
+# TYPE rpc_duration summary
+rpc_duration{quantile_2="x",quantile="0.5"} 0.2
+rpc_duration{quantile_2="x",quantile="0.99"} 1.5
+rpc_duration_sum{quantile_2="x"} 12.5
+rpc_duration_count{quantile_2="x"} 40
+`,
+		},
+		{
+			name: "summary count name taken by gauge",
+			metrics: []*dto.MetricDTO{
+				{ID: "rpc_count", MType: "gauge", Value: testutils.Pointer(3.0)},
+				{ID: "rpc", MType: "summary", Summary: &dto.SummaryDTO{Sum: 1, Count: 2}},
+			},
+			want: `# HELP rpc summary metric "rpc" collected by metcol.
+# TYPE rpc summary
+rpc_sum 1
+rpc_count 2
+# HELP rpc_count_gauge gauge metric "rpc_count" collected by metcol.
+# TYPE rpc_count_gauge gauge
+rpc_count_gauge 3
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteText(&buf, tt.metrics))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/proto/prompb"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	metricNameLabel = "__name__"
+	// seriesTTL how long total of counter series not seen in requests is kept.
+	seriesTTL = time.Hour
+)
+
+var ErrRemoteWriteParse = errors.New("unable to parse remote-write request")
+
+// RemoteWriteConverter maps remote-write time series into metric DTOs.
+//
+// Prometheus counters are cumulative while metcol counters accumulate deltas,
+// so converter remembers last seen total of every counter series of namespace and emits increments.
+// First sample of series only sets baseline, so totals accumulated before server start are not
+// added again. Value drop is treated as counter reset. Series not seen for seriesTTL are
+// forgotten and start from new baseline.
+type RemoteWriteConverter struct {
+	now        func() time.Time
+	lastTotals map[string]counterTotal
+	lastSweep  time.Time
+	mu         sync.Mutex
+}
+
+// counterTotal last seen total of counter series.
+type counterTotal struct {
+	seenAt time.Time
+	total  float64
+}
+
+// counterSample counter metric waiting for increment of its total.
+type counterSample struct {
+	metric    *dto.MetricDTO
+	seriesKey string
+	total     float64
+}
+
+// NewRemoteWriteConverter creates instance of converter.
+func NewRemoteWriteConverter() *RemoteWriteConverter {
+	return &RemoteWriteConverter{now: time.Now, lastTotals: make(map[string]counterTotal)}
+}
+
+// DecodeWriteRequest reads snappy-compressed protobuf WriteRequest.
+func DecodeWriteRequest(body io.Reader) (*prompb.WriteRequest, error) {
+	compressed, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read body: %w", err)
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: snappy decode: %w", ErrRemoteWriteParse, err)
+	}
+	req := &prompb.WriteRequest{}
+	if err = proto.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("%w: protobuf unmarshal: %w", ErrRemoteWriteParse, err)
+	}
+	return req, nil
+}
+
+func familyTypes(req *prompb.WriteRequest) map[string]prompb.MetricMetadata_MetricType {
+	types := make(map[string]prompb.MetricMetadata_MetricType, len(req.GetMetadata()))
+	for _, md := range req.GetMetadata() {
+		types[md.GetMetricFamilyName()] = md.GetType()
+	}
+	return types
+}
+
+// seriesType resolves metcol type of series, counters are detected via metadata or "_total" suffix.
+func seriesType(name string, types map[string]prompb.MetricMetadata_MetricType) common.MetricType {
+	if t, ok := types[name]; ok {
+		if t == prompb.MetricMetadata_COUNTER {
+			return common.CounterMetricType
+		}
+		return common.GaugeMetricType
+	}
+	if t, ok := types[strings.TrimSuffix(name, counterSuffix)]; ok && t == prompb.MetricMetadata_COUNTER {
+		return common.CounterMetricType
+	}
+	if strings.HasSuffix(name, counterSuffix) {
+		return common.CounterMetricType
+	}
+	return common.GaugeMetricType
+}
+
+// counterIncrement converts cumulative total into increment since previous sample of series.
+// Totals of request are collected in pending and remembered via commit.
+func (c *RemoteWriteConverter) counterIncrement(pending map[string]float64, seriesKey string, total float64) int64 {
+	last, seen := pending[seriesKey]
+	if !seen {
+		var stored counterTotal
+		stored, seen = c.lastTotals[seriesKey]
+		last = stored.total
+	}
+	pending[seriesKey] = total
+	if !seen {
+		return 0
+	}
+	if total < last {
+		return int64(math.Round(total))
+	}
+	// rounding both totals keeps fractional counters from drifting.
+	return int64(math.Round(total) - math.Round(last))
+}
+
+// Convert maps every sample of request into metric DTO, NaN values (staleness markers) are skipped,
+// and passes them to store. Converter state is locked only while increments are computed, so slow
+// store does not block other requests. Counter totals are remembered before store and restored if
+// it fails, so failed request is converted to the same increments when retried.
+func (c *RemoteWriteConverter) Convert(
+	namespace string,
+	req *prompb.WriteRequest,
+	store func([]*dto.MetricDTO) error,
+) error {
+	types := familyTypes(req)
+
+	counters := make([]counterSample, 0)
+	result := make([]*dto.MetricDTO, 0, len(req.GetTimeseries()))
+	for _, ts := range req.GetTimeseries() {
+		var name string
+		labels := make(map[string]string, len(ts.GetLabels()))
+		for _, l := range ts.GetLabels() {
+			if l.GetName() == metricNameLabel {
+				name = l.GetValue()
+				continue
+			}
+			labels[l.GetName()] = l.GetValue()
+		}
+		if name == "" {
+			return fmt.Errorf("%w: series without %s label", ErrRemoteWriteParse, metricNameLabel)
+		}
+		if len(labels) == 0 {
+			labels = nil
+		}
+
+		mType := seriesType(name, types)
+		// namespaces can't contain '/', so equal series of different namespaces never share total.
+		seriesKey := namespace + "/" + name + "{" + dto.LabelsKey(labels) + "}"
+		samples := slices.Clone(ts.GetSamples())
+		slices.SortStableFunc(samples, func(a, b *prompb.Sample) int {
+			return int(a.GetTimestamp() - b.GetTimestamp())
+		})
+
+		for _, sample := range samples {
+			value := sample.GetValue()
+			if math.IsNaN(value) {
+				continue
+			}
+			metric := &dto.MetricDTO{Labels: labels, ID: name, MType: mType}
+			if mType == common.CounterMetricType {
+				counters = append(counters, counterSample{metric: metric, seriesKey: seriesKey, total: value})
+			} else {
+				metric.Value = &value
+			}
+			result = append(result, metric)
+		}
+	}
+
+	c.mu.Lock()
+	pending := make(map[string]float64)
+	for _, sample := range counters {
+		delta := c.counterIncrement(pending, sample.seriesKey, sample.total)
+		sample.metric.Delta = &delta
+	}
+	previous := c.commit(pending)
+	c.mu.Unlock()
+
+	if err := store(result); err != nil {
+		c.mu.Lock()
+		c.rollback(pending, previous)
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// commit remembers totals of request and forgets series not seen for seriesTTL.
+// Returns totals replaced by request.
+func (c *RemoteWriteConverter) commit(pending map[string]float64) map[string]counterTotal {
+	now := c.now()
+	previous := make(map[string]counterTotal, len(pending))
+	for seriesKey, total := range pending {
+		if stored, ok := c.lastTotals[seriesKey]; ok {
+			previous[seriesKey] = stored
+		}
+		c.lastTotals[seriesKey] = counterTotal{seenAt: now, total: total}
+	}
+
+	if now.Sub(c.lastSweep) < seriesTTL {
+		return previous
+	}
+	c.lastSweep = now
+	for seriesKey, stored := range c.lastTotals {
+		if now.Sub(stored.seenAt) > seriesTTL {
+			delete(c.lastTotals, seriesKey)
+		}
+	}
+	return previous
+}
+
+// rollback restores totals replaced by failed request. Series already updated by later request
+// keep their totals, increments of later request are computed from them.
+func (c *RemoteWriteConverter) rollback(pending map[string]float64, previous map[string]counterTotal) {
+	for seriesKey, total := range pending {
+		if stored, ok := c.lastTotals[seriesKey]; !ok || stored.total != total {
+			continue
+		}
+		if stored, ok := previous[seriesKey]; ok {
+			c.lastTotals[seriesKey] = stored
+			continue
+		}
+		delete(c.lastTotals, seriesKey)
+	}
+}
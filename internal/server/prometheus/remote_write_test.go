@@ -0,0 +1,238 @@
+package prometheus
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/Kopleman/metcol/proto/prompb"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func newSeries(labels map[string]string, values ...float64) *prompb.TimeSeries {
+	ts := &prompb.TimeSeries{}
+	protoLabels := make([]*prompb.Label, 0, len(labels))
+	for name, value := range labels {
+		l := &prompb.Label{}
+		l.SetName(name)
+		l.SetValue(value)
+		protoLabels = append(protoLabels, l)
+	}
+	samples := make([]*prompb.Sample, 0, len(values))
+	for i, v := range values {
+		s := &prompb.Sample{}
+		s.SetValue(v)
+		s.SetTimestamp(int64(i) * 1000)
+		samples = append(samples, s)
+	}
+	ts.SetLabels(protoLabels)
+	ts.SetSamples(samples)
+	return ts
+}
+
+func newMetadata(name string, t prompb.MetricMetadata_MetricType) *prompb.MetricMetadata {
+	md := &prompb.MetricMetadata{}
+	md.SetMetricFamilyName(name)
+	md.SetType(t)
+	return md
+}
+
+// convert converts request of default namespace, store always succeeds.
+func convert(c *RemoteWriteConverter, req *prompb.WriteRequest) ([]*dto.MetricDTO, error) {
+	return convertNamespace(c, "", req)
+}
+
+func convertNamespace(c *RemoteWriteConverter, namespace string, req *prompb.WriteRequest) ([]*dto.MetricDTO, error) {
+	var got []*dto.MetricDTO
+	err := c.Convert(namespace, req, func(metrics []*dto.MetricDTO) error {
+		got = metrics
+		return nil
+	})
+	return got, err
+}
+
+func counterRequest(name string, totals ...float64) *prompb.WriteRequest {
+	req := &prompb.WriteRequest{}
+	req.SetTimeseries([]*prompb.TimeSeries{newSeries(map[string]string{"__name__": name}, totals...)})
+	return req
+}
+
+func TestRemoteWriteConverter_Convert(t *testing.T) {
+	tests := []struct {
+		name     string
+		series   []*prompb.TimeSeries
+		metadata []*prompb.MetricMetadata
+		want     []*dto.MetricDTO
+		wantErr  bool
+	}{
+		{
+			name: "gauge with labels",
+			series: []*prompb.TimeSeries{
+				newSeries(map[string]string{"__name__": "temperature", "room": "a"}, 21.5),
+			},
+			want: []*dto.MetricDTO{
+				{ID: "temperature", MType: "gauge", Labels: map[string]string{"room": "a"}, Value: testutils.Pointer(21.5)},
+			},
+		},
+		{
+			name: "counter by suffix emits increments after baseline and handles reset",
+			series: []*prompb.TimeSeries{
+				newSeries(map[string]string{"__name__": "requests_total"}, 10, 15, 3),
+			},
+			want: []*dto.MetricDTO{
+				{ID: "requests_total", MType: "counter", Delta: testutils.Pointer(int64(0))},
+				{ID: "requests_total", MType: "counter", Delta: testutils.Pointer(int64(5))},
+				{ID: "requests_total", MType: "counter", Delta: testutils.Pointer(int64(3))},
+			},
+		},
+		{
+			name: "counter by metadata, stale markers are skipped",
+			series: []*prompb.TimeSeries{
+				newSeries(map[string]string{"__name__": "hits"}, 1, math.NaN()),
+			},
+			metadata: []*prompb.MetricMetadata{newMetadata("hits", prompb.MetricMetadata_COUNTER)},
+			want: []*dto.MetricDTO{
+				{ID: "hits", MType: "counter", Delta: testutils.Pointer(int64(0))},
+			},
+		},
+		{
+			name: "series without name",
+			series: []*prompb.TimeSeries{
+				newSeries(map[string]string{"job": "a"}, 1),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &prompb.WriteRequest{}
+			req.SetTimeseries(tt.series)
+			req.SetMetadata(tt.metadata)
+
+			got, err := convert(NewRemoteWriteConverter(), req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrRemoteWriteParse)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRemoteWriteConverter_KeepsTotalsBetweenRequests(t *testing.T) {
+	c := NewRemoteWriteConverter()
+	for _, tc := range []struct {
+		total float64
+		want  int64
+	}{{total: 1.4, want: 0}, {total: 2.6, want: 2}, {total: 2.6, want: 0}, {total: 4.4, want: 1}} {
+		got, err := convert(c, counterRequest("cpu_seconds_total", tc.total))
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, tc.want, *got[0].Delta)
+	}
+}
+
+func TestRemoteWriteConverter_FailedStore(t *testing.T) {
+	c := NewRemoteWriteConverter()
+	_, err := convert(c, counterRequest("requests_total", 10))
+	require.NoError(t, err)
+
+	// increment of failed request is emitted again on retry
+	storeErr := errors.New("db is down")
+	err = c.Convert("", counterRequest("requests_total", 15), func([]*dto.MetricDTO) error { return storeErr })
+	require.ErrorIs(t, err, storeErr)
+
+	got, err := convert(c, counterRequest("requests_total", 15))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *got[0].Delta)
+}
+
+func TestRemoteWriteConverter_FailedFirstStore(t *testing.T) {
+	c := NewRemoteWriteConverter()
+	storeErr := errors.New("db is down")
+	err := c.Convert("", counterRequest("requests_total", 10), func([]*dto.MetricDTO) error { return storeErr })
+	require.ErrorIs(t, err, storeErr)
+	assert.Empty(t, c.lastTotals)
+}
+
+func TestRemoteWriteConverter_StoreDoesNotBlock(t *testing.T) {
+	c := NewRemoteWriteConverter()
+	_, err := convert(c, counterRequest("requests_total", 10))
+	require.NoError(t, err)
+
+	storing := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- c.Convert("", counterRequest("requests_total", 15), func([]*dto.MetricDTO) error {
+			close(storing)
+			<-release
+			return nil
+		})
+	}()
+	<-storing
+
+	// request is converted while previous one is stored, increment is computed from its total
+	got, err := convert(c, counterRequest("requests_total", 20))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *got[0].Delta)
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.InDelta(t, 20.0, c.lastTotals["/requests_total{}"].total, 0)
+}
+
+func TestRemoteWriteConverter_SeparatesNamespaces(t *testing.T) {
+	c := NewRemoteWriteConverter()
+	_, err := convertNamespace(c, "team-a", counterRequest("requests_total", 100))
+	require.NoError(t, err)
+	_, err = convertNamespace(c, "team-b", counterRequest("requests_total", 10))
+	require.NoError(t, err)
+
+	got, err := convertNamespace(c, "team-a", counterRequest("requests_total", 105))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *got[0].Delta)
+	got, err = convertNamespace(c, "team-b", counterRequest("requests_total", 12))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), *got[0].Delta)
+}
+
+func TestRemoteWriteConverter_ForgetsIdleSeries(t *testing.T) {
+	now := time.Now()
+	c := NewRemoteWriteConverter()
+	c.now = func() time.Time { return now }
+
+	_, err := convert(c, counterRequest("old_total", 10))
+	require.NoError(t, err)
+	now = now.Add(seriesTTL / 2)
+	_, err = convert(c, counterRequest("fresh_total", 10))
+	require.NoError(t, err)
+
+	now = now.Add(seriesTTL)
+	_, err = convert(c, counterRequest("fresh_total", 12))
+	require.NoError(t, err)
+	assert.NotContains(t, c.lastTotals, "/old_total{}")
+	assert.Contains(t, c.lastTotals, "/fresh_total{}")
+}
+
+func TestDecodeWriteRequest(t *testing.T) {
+	req := &prompb.WriteRequest{}
+	req.SetTimeseries([]*prompb.TimeSeries{newSeries(map[string]string{"__name__": "up"}, 1)})
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+
+	decoded, err := DecodeWriteRequest(bytes.NewReader(snappy.Encode(nil, data)))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(req, decoded))
+
+	_, err = DecodeWriteRequest(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrRemoteWriteParse)
+}
@@ -0,0 +1,96 @@
+// Package replay rejects signed requests delivered more than once or signed too long ago.
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/signature"
+)
+
+var (
+	// ErrMissingStamp is returned for signed request without timestamp and nonce.
+	ErrMissingStamp = errors.New("request timestamp and nonce are required")
+	// ErrStale is returned when request timestamp is outside of allowed skew window.
+	ErrStale = errors.New("request timestamp is outside of allowed window")
+	// ErrReplayed is returned when request nonce was already seen.
+	ErrReplayed = errors.New("request nonce was already used")
+)
+
+type seenNonce struct {
+	at    time.Time
+	nonce string
+}
+
+// Guard remembers nonces of requests accepted within skew window. Cache is bounded:
+// when it is full, oldest nonce is forgotten and requests signed before it are
+// rejected as stale, so forgotten nonce still can not be replayed.
+type Guard struct {
+	now      func() time.Time
+	floor    time.Time // requests signed at or before are rejected
+	nonces   map[string]struct{}
+	order    []seenNonce // nonces in order of acceptance
+	window   time.Duration
+	capacity int
+	mu       sync.Mutex
+}
+
+// NewGuard creates guard accepting requests signed at most window ago (or ahead, for clock skew),
+// remembering up to capacity nonces.
+func NewGuard(window time.Duration, capacity int) *Guard {
+	capacity = max(capacity, 1)
+	return &Guard{
+		now:      time.Now,
+		nonces:   make(map[string]struct{}, capacity),
+		window:   window,
+		capacity: capacity,
+	}
+}
+
+// Check accepts stamp once. Second check of the same nonce fails with ErrReplayed.
+func (g *Guard) Check(stamp signature.Stamp) error {
+	if stamp.Empty() {
+		return ErrMissingStamp
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if skew := now.Sub(stamp.Time).Abs(); skew > g.window {
+		return fmt.Errorf("%w: skew %s", ErrStale, skew)
+	}
+	if !stamp.Time.After(g.floor) {
+		return fmt.Errorf("%w: nonce cache overflow", ErrStale)
+	}
+	if _, seen := g.nonces[stamp.Nonce]; seen {
+		return ErrReplayed
+	}
+
+	g.forgetExpired(now)
+	if len(g.order) >= g.capacity {
+		g.forgetOldest()
+	}
+	g.nonces[stamp.Nonce] = struct{}{}
+	g.order = append(g.order, seenNonce{at: stamp.Time, nonce: stamp.Nonce})
+	return nil
+}
+
+// forgetExpired drops nonces of requests that are stale anyway.
+func (g *Guard) forgetExpired(now time.Time) {
+	for len(g.order) > 0 && now.Sub(g.order[0].at) > g.window {
+		delete(g.nonces, g.order[0].nonce)
+		g.order = g.order[1:]
+	}
+}
+
+func (g *Guard) forgetOldest() {
+	oldest := g.order[0]
+	delete(g.nonces, oldest.nonce)
+	g.order = g.order[1:]
+	if oldest.at.After(g.floor) {
+		g.floor = oldest.at
+	}
+}
@@ -0,0 +1,72 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGuard(window time.Duration, capacity int, now time.Time) *Guard {
+	g := NewGuard(window, capacity)
+	g.now = func() time.Time { return now }
+	return g
+}
+
+func TestGuard_Check(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		stamp   signature.Stamp
+		wantErr error
+	}{
+		{name: "fresh stamp", stamp: signature.Stamp{Time: now, Nonce: "fresh"}},
+		{name: "clock skew ahead", stamp: signature.Stamp{Time: now.Add(30 * time.Second), Nonce: "ahead"}},
+		{name: "missing stamp", stamp: signature.Stamp{}, wantErr: ErrMissingStamp},
+		{name: "too old", stamp: signature.Stamp{Time: now.Add(-2 * time.Minute), Nonce: "old"}, wantErr: ErrStale},
+		{name: "too far ahead", stamp: signature.Stamp{Time: now.Add(2 * time.Minute), Nonce: "future"}, wantErr: ErrStale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGuard(time.Minute, 10, now)
+			err := g.Check(tt.stamp)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.ErrorIs(t, g.Check(tt.stamp), ErrReplayed, "second delivery is rejected")
+		})
+	}
+}
+
+func TestGuard_ForgetsExpired(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newTestGuard(time.Minute, 10, now)
+
+	assert.NoError(t, g.Check(signature.Stamp{Time: now, Nonce: "a"}))
+
+	// после окна nonce забывается, а сам запрос уже считается устаревшим
+	g.now = func() time.Time { return now.Add(2 * time.Minute) }
+	assert.NoError(t, g.Check(signature.Stamp{Time: now.Add(2 * time.Minute), Nonce: "b"}))
+	assert.Len(t, g.nonces, 1)
+	assert.ErrorIs(t, g.Check(signature.Stamp{Time: now, Nonce: "a"}), ErrStale)
+}
+
+func TestGuard_Overflow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newTestGuard(time.Minute, 2, now)
+
+	assert.NoError(t, g.Check(signature.Stamp{Time: now.Add(-3 * time.Second), Nonce: "a"}))
+	assert.NoError(t, g.Check(signature.Stamp{Time: now.Add(-2 * time.Second), Nonce: "b"}))
+	assert.NoError(t, g.Check(signature.Stamp{Time: now.Add(-1 * time.Second), Nonce: "c"}))
+	assert.Len(t, g.nonces, 2)
+
+	// вытесненный nonce не проходит повторно, хотя и забыт
+	assert.ErrorIs(t, g.Check(signature.Stamp{Time: now.Add(-3 * time.Second), Nonce: "a"}), ErrStale)
+	assert.ErrorIs(t, g.Check(signature.Stamp{Time: now.Add(-1 * time.Second), Nonce: "c"}), ErrReplayed)
+	assert.NoError(t, g.Check(signature.Stamp{Time: now, Nonce: "d"}))
+}
@@ -35,7 +35,7 @@ func setupRouter(baseMemStore map[string]*dto.MetricDTO) *chi.Mux {
 	metricsService := metrics.NewMetrics(storeService, log.MockLogger{})
 	mockPgx := &noopPgxPool{}
 	mockBd := &noopBodyDecryptor{}
-	routes := BuildServerRoutes(&config.Config{}, &log.MockLogger{}, metricsService, mockPgx, mockBd)
+	routes := BuildServerRoutes(&config.Config{}, &log.MockLogger{}, metricsService, mockPgx, mockBd, nil, nil)
 	return routes
 }
 
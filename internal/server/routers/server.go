@@ -8,9 +8,13 @@ import (
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
 	"github.com/Kopleman/metcol/internal/server/config"
 	"github.com/Kopleman/metcol/internal/server/controllers"
+	"github.com/Kopleman/metcol/internal/server/influx"
 	"github.com/Kopleman/metcol/internal/server/middlewares"
+	"github.com/Kopleman/metcol/internal/server/policy"
+	"github.com/Kopleman/metcol/internal/server/prometheus"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -18,10 +22,24 @@ import (
 type Metrics interface {
 	SetMetric(ctx context.Context, metricType common.MetricType, name string, value string) error
 	SetMetricByDto(ctx context.Context, metricDto *dto.MetricDTO) error
-	GetValueAsString(ctx context.Context, metricType common.MetricType, name string) (string, error)
-	GetMetricAsDTO(ctx context.Context, metricType common.MetricType, name string) (*dto.MetricDTO, error)
+	GetValueAsString(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (string, error)
+	GetMetricAsDTO(
+		ctx context.Context,
+		metricType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (*dto.MetricDTO, error)
 	GetAllValuesAsString(ctx context.Context) (map[string]string, error)
 	SetMetrics(ctx context.Context, metrics []*dto.MetricDTO) error
+	SetMetricsBatch(ctx context.Context, batchID string, metrics []*dto.MetricDTO) ([]*dto.MetricDTO, error)
+	QueryRange(ctx context.Context, q *dto.QueryRequest) (*dto.SeriesDTO, error)
+	ExportMetrics(ctx context.Context) ([]*dto.MetricDTO, error)
+	Watch(ctx context.Context, filter dto.WatchFilter) (<-chan *dto.MetricDTO, func())
 }
 
 type PgxPool interface {
@@ -39,43 +57,89 @@ func BuildServerRoutes(
 	metricsService Metrics,
 	db PgxPool,
 	bd BodyDecryptor,
+	tokens middlewares.TokenAuthenticator,
+	replayGuard middlewares.ReplayGuard,
 ) *chi.Mux {
+	metricsService = policy.NewGuard(metricsService)
+
 	mainPageCtrl := controllers.NewMainPageController(logger, metricsService)
 	updateCtrl := controllers.NewUpdateMetricsController(logger, metricsService, bd)
 	getValCtrl := controllers.NewGetValueController(logger, metricsService)
 	pingCtrl := controllers.NewPingController(db)
+	queryCtrl := controllers.NewQueryController(logger, metricsService)
+	prometheusCtrl := controllers.NewPrometheusController(logger, metricsService)
+	watchCtrl := controllers.NewWatchController(logger, metricsService)
+	influxCtrl := controllers.NewInfluxController(logger, metricsService, bd, influx.NewParser(cfg.InfluxCounters))
+	remoteWriteCtrl := controllers.NewRemoteWriteController(
+		logger,
+		metricsService,
+		bd,
+		prometheus.NewRemoteWriteConverter(),
+	)
 
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
+	r.Use(middlewares.TLSIdentity)
 	// r.Use(middleware.Compress(5, "text/html", "application/json"))
 	r.Use(middlewares.CompressMiddleware)
-	r.Use(middlewares.Hash(logger, cfg.Key))
+	r.Use(middlewares.Hash(logger, cfg.Key, replayGuard))
 	if cfg.TrustedSubnet != "" {
 		r.Use(middlewares.IPFilter(cfg.TrustedSubnet))
 	}
 
-	r.Mount("/debug", middleware.Profiler())
+	r.Get("/ping", pingCtrl.Ping())
 
-	r.Route("/", func(r chi.Router) {
-		r.Get("/", mainPageCtrl.MainPage())
-		r.Get("/ping", pingCtrl.Ping())
-	})
+	// health check stays open, token is required by role-guarded routes only
+	r.Group(func(r chi.Router) {
+		if cfg.AgentTokensPath != "" {
+			r.Use(middlewares.Auth(tokens))
+		}
+		r.Use(middlewares.Namespace)
 
-	r.Route("/update", func(r chi.Router) {
-		r.Use(middlewares.PostFilterMiddleware)
-		r.Post("/", updateCtrl.UpdateOrSetViaDTO())
-		r.Post("/{metricType}/{metricName}/{metricValue}", updateCtrl.UpdateOrSet())
-	})
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequireRole(auth.ActionAdmin))
+			r.Mount("/debug", middleware.Profiler())
+		})
 
-	r.Route("/updates", func(r chi.Router) {
-		r.Use(middlewares.PostFilterMiddleware)
-		r.Post("/", updateCtrl.UpdateMetrics())
-	})
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequireRole(auth.ActionRead))
+			r.Get("/", mainPageCtrl.MainPage())
+			r.Get("/metrics", prometheusCtrl.Metrics())
+			r.Route("/value", func(r chi.Router) {
+				r.Get("/{metricType}/{metricName}", getValCtrl.GetValue())
+				r.Post("/", getValCtrl.GetValueAsDTO())
+			})
+			r.Get("/query", queryCtrl.Query())
+			r.Get("/watch", watchCtrl.Watch())
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequireRole(auth.ActionWrite))
+			// agents sign their reports, other clients (Telegraf, Prometheus) can not
+			r.Route("/update", func(r chi.Router) {
+				r.Use(middlewares.PostFilterMiddleware)
+				r.Use(middlewares.RequireHash(cfg.Key))
+				r.Post("/", updateCtrl.UpdateOrSetViaDTO())
+				r.Post("/{metricType}/{metricName}/{metricValue}", updateCtrl.UpdateOrSet())
+			})
+
+			r.Route("/updates", func(r chi.Router) {
+				r.Use(middlewares.PostFilterMiddleware)
+				r.Use(middlewares.RequireHash(cfg.Key))
+				r.Post("/", updateCtrl.UpdateMetrics())
+			})
+
+			r.Route("/write", func(r chi.Router) {
+				r.Use(middlewares.PostFilterMiddleware)
+				r.Post("/", influxCtrl.Write())
+			})
 
-	r.Route("/value", func(r chi.Router) {
-		r.Get("/{metricType}/{metricName}", getValCtrl.GetValue())
-		r.Post("/", getValCtrl.GetValueAsDTO())
+			r.Route("/api/v1/write", func(r chi.Router) {
+				r.Use(middlewares.PostFilterMiddleware)
+				r.Post("/", remoteWriteCtrl.Write())
+			})
+		})
 	})
 
 	return r
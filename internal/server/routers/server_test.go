@@ -11,6 +11,7 @@ import (
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/server/auth"
 	"github.com/Kopleman/metcol/internal/server/config"
 	"github.com/Kopleman/metcol/internal/server/memstore"
 	"github.com/Kopleman/metcol/internal/server/metrics"
@@ -57,7 +58,7 @@ func TestRouters_Server(t *testing.T) {
 
 	storeService := memstore.NewStore(make(map[string]*dto.MetricDTO))
 	metricsService := metrics.NewMetrics(storeService, log.MockLogger{})
-	routes := BuildServerRoutes(&config.Config{}, &log.MockLogger{}, metricsService, mockPgx, mockBD)
+	routes := BuildServerRoutes(&config.Config{}, &log.MockLogger{}, metricsService, mockPgx, mockBD, nil, nil)
 
 	ts := httptest.NewServer(routes)
 	defer ts.Close()
@@ -145,3 +146,87 @@ func TestRouters_Server(t *testing.T) {
 		assert.JSONEq(t, v.want, gotResponse)
 	}
 }
+
+type mockTokens struct{}
+
+func (m mockTokens) Authenticate(token string) (*auth.Principal, bool) {
+	if token != "secret" {
+		return nil, false
+	}
+	return &auth.Principal{Agent: "host-1", Role: auth.RoleReader}, true
+}
+
+func TestRouters_ServerAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPgx := mock.NewMockPgxPool(ctrl)
+	mockPgx.EXPECT().Ping(gomock.Any()).Return(nil)
+
+	storeService := memstore.NewStore(make(map[string]*dto.MetricDTO))
+	metricsService := metrics.NewMetrics(storeService, log.MockLogger{})
+	cfg := &config.Config{AgentTokensPath: "tokens.json"}
+	routes := BuildServerRoutes(cfg, &log.MockLogger{}, metricsService, mockPgx, &mockBodyDecryptor{}, mockTokens{}, nil)
+
+	ts := httptest.NewServer(routes)
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		token  string
+		status int
+	}{
+		{"ping without token", http.MethodGet, "/ping", "", http.StatusOK},
+		{"read without token", http.MethodGet, "/", "", http.StatusUnauthorized},
+		{"read with token", http.MethodGet, "/", "secret", http.StatusOK},
+		{"write without token", http.MethodPost, "/update/gauge/g/1", "", http.StatusUnauthorized},
+		{"write as reader", http.MethodPost, "/update/gauge/g/1", "secret", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.url, http.NoBody)
+			require.NoError(t, err)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestRouters_ServerHashKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPgx := mock.NewMockPgxPool(ctrl)
+	mockPgx.EXPECT().Ping(gomock.Any()).Return(nil)
+
+	storeService := memstore.NewStore(make(map[string]*dto.MetricDTO))
+	metricsService := metrics.NewMetrics(storeService, log.MockLogger{})
+	cfg := &config.Config{Key: "secret"}
+	routes := BuildServerRoutes(cfg, &log.MockLogger{}, metricsService, mockPgx, &mockBodyDecryptor{}, nil, nil)
+
+	ts := httptest.NewServer(routes)
+	defer ts.Close()
+
+	// подпись обязательна только для отчётов агента
+	tests := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{http.MethodGet, "/ping", http.StatusOK},
+		{http.MethodGet, "/metrics", http.StatusOK},
+		{http.MethodGet, "/", http.StatusOK},
+		{http.MethodPost, "/update/gauge/g/1", http.StatusBadRequest},
+		{http.MethodPost, "/updates/", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			gotStatusCode, _ := testRequest(t, ts, tt.method, tt.url, http.NoBody)
+			assert.Equal(t, tt.status, gotStatusCode)
+		})
+	}
+}
@@ -3,21 +3,33 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Kopleman/metcol/internal/common/dto"
 	"github.com/Kopleman/metcol/internal/common/log"
 	"github.com/Kopleman/metcol/internal/common/profiler"
+	"github.com/Kopleman/metcol/internal/common/tlsconfig"
+	"github.com/Kopleman/metcol/internal/server/auth"
 	bodydecryptor "github.com/Kopleman/metcol/internal/server/body_decryptor"
 	"github.com/Kopleman/metcol/internal/server/config"
 	filestorage "github.com/Kopleman/metcol/internal/server/file_storage"
 	"github.com/Kopleman/metcol/internal/server/grpc"
+	grpcmiddleware "github.com/Kopleman/metcol/internal/server/grpc/middleware"
 	"github.com/Kopleman/metcol/internal/server/memstore"
 	"github.com/Kopleman/metcol/internal/server/metrics"
+	"github.com/Kopleman/metcol/internal/server/middlewares"
 	"github.com/Kopleman/metcol/internal/server/pgxstore"
+	"github.com/Kopleman/metcol/internal/server/policy"
 	"github.com/Kopleman/metcol/internal/server/postgres"
+	"github.com/Kopleman/metcol/internal/server/replay"
 	"github.com/Kopleman/metcol/internal/server/routers"
+	"github.com/Kopleman/metcol/internal/server/statsd"
 	"github.com/Kopleman/metcol/internal/server/store"
 )
 
@@ -30,7 +42,10 @@ type Server struct {
 	fs            *filestorage.FileStorage
 	metricService *metrics.Metrics
 	bd            *bodydecryptor.BodyDecryptor
+	tokens        *auth.TokenStore
+	replay        *replay.Guard
 	grpcServer    *grpc.Server
+	statsdServer  *statsd.Server
 }
 
 // NewServer creates instance of server.
@@ -60,7 +75,10 @@ func (s *Server) prepareStore(ctx context.Context) error {
 	}
 
 	s.logger.Info("no db DSN provided, using memo-store")
-	storeService := memstore.NewStore(make(map[string]*dto.MetricDTO))
+	storeService := memstore.NewStore(
+		make(map[string]*dto.MetricDTO),
+		memstore.WithHistorySize(int(s.config.HistorySize)),
+	)
 	s.store = storeService
 	s.metricService = metrics.NewMetrics(s.store, s.logger)
 	s.fs = filestorage.NewFileStorage(s.config, s.logger, s.metricService)
@@ -82,6 +100,22 @@ func (s *Server) Start(ctx context.Context, runTimeError chan<- error) error {
 	}
 	s.bd = bd
 
+	if s.config.AgentTokensPath != "" {
+		tokens := auth.NewTokenStore(s.logger)
+		if err := tokens.Load(s.config.AgentTokensPath); err != nil {
+			return fmt.Errorf("failed to load agent tokens: %w", err)
+		}
+		s.tokens = tokens
+	}
+
+	if s.config.Key != "" && s.config.ReplayWindow > 0 {
+		s.replay = replay.NewGuard(time.Duration(s.config.ReplayWindow)*time.Second, int(s.config.NonceCacheSize))
+	}
+
+	if s.config.PrivateKeyPath != "" || s.tokens != nil {
+		go s.reloadOnSignal(ctx)
+	}
+
 	if s.fs != nil {
 		go func(ctx context.Context) {
 			err := s.fs.RunBackupJob(ctx)
@@ -91,16 +125,66 @@ func (s *Server) Start(ctx context.Context, runTimeError chan<- error) error {
 		}(ctx)
 	}
 
+	if s.config.HistoryRetention > 0 {
+		retention := time.Duration(s.config.HistoryRetention) * time.Second
+		go s.metricService.RunHistoryRetentionJob(ctx, retention)
+	}
+
+	if s.config.BatchRetention > 0 {
+		retention := time.Duration(s.config.BatchRetention) * time.Second
+		go s.metricService.RunBatchRetentionJob(ctx, retention)
+	}
+
+	var tlsConfig *tls.Config
+	if s.config.TLSEnabled() {
+		cfg, err := tlsconfig.NewServerConfig(s.config.TLSCertPath, s.config.TLSKeyPath, s.config.TLSClientCAPath)
+		if err != nil {
+			return fmt.Errorf("failed to init tls: %w", err)
+		}
+		tlsConfig = cfg
+	}
+
+	// HTTP and gRPC share nonce cache, so request can not be replayed through another transport
+	var httpReplay middlewares.ReplayGuard
+	var grpcReplay grpcmiddleware.ReplayGuard
+	if s.replay != nil {
+		httpReplay = s.replay
+		grpcReplay = s.replay
+	}
+
 	go func() {
-		routes := routers.BuildServerRoutes(s.config, s.logger, s.metricService, s.db, s.bd)
-		if listenAndServeErr := http.ListenAndServe(s.config.NetAddr.String(), routes); listenAndServeErr != nil {
+		httpServer := &http.Server{
+			Addr:      s.config.NetAddr.String(),
+			Handler:   routers.BuildServerRoutes(s.config, s.logger, s.metricService, s.db, s.bd, s.tokens, httpReplay),
+			TLSConfig: tlsConfig,
+		}
+		var listenAndServeErr error
+		if tlsConfig != nil {
+			listenAndServeErr = httpServer.ListenAndServeTLS("", "")
+		} else {
+			listenAndServeErr = httpServer.ListenAndServe()
+		}
+		if listenAndServeErr != nil {
 			runTimeError <- fmt.Errorf("internal server error: %w", listenAndServeErr)
 		}
 	}()
 
-	if s.config.GRPCAddr.String() != "" {
-		grpcMetricsService := grpc.NewMetricsService(s.logger, s.metricService)
-		s.grpcServer = grpc.NewServer(s.logger, grpcMetricsService, s.config.TrustedSubnet, s.config.Key)
+	if s.config.GRPCAddr != nil {
+		grpcMetricsService := grpc.NewMetricsService(s.logger, policy.NewGuard(s.metricService))
+		var tokens grpcmiddleware.TokenAuthenticator
+		if s.tokens != nil {
+			tokens = s.tokens
+		}
+		s.grpcServer = grpc.NewServer(
+			s.logger,
+			grpcMetricsService,
+			s.config.TrustedSubnet,
+			s.config.Key,
+			tlsConfig,
+			s.bd,
+			tokens,
+			grpcReplay,
+		)
 
 		go func() {
 			if err := s.grpcServer.Start(s.config.GRPCAddr.String()); err != nil {
@@ -109,6 +193,25 @@ func (s *Server) Start(ctx context.Context, runTimeError chan<- error) error {
 		}()
 	}
 
+	if s.config.StatsDAddr != nil {
+		statsdServer, err := statsd.NewServer(
+			s.logger,
+			s.metricService,
+			s.config.TrustedSubnet,
+			time.Duration(s.config.StatsDFlushInterval)*time.Second,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to init statsd server: %w", err)
+		}
+		s.statsdServer = statsdServer
+
+		go func(ctx context.Context) {
+			if err := s.statsdServer.Start(ctx, s.config.StatsDAddr.String()); err != nil {
+				runTimeError <- fmt.Errorf("statsd server error: %w", err)
+			}
+		}(ctx)
+	}
+
 	s.logger.Infof("Server started on: %s", s.config.NetAddr.Port)
 
 	go func() {
@@ -126,8 +229,37 @@ func (s *Server) Start(ctx context.Context, runTimeError chan<- error) error {
 	return nil
 }
 
+// reloadOnSignal re-reads private keys and agent tokens on SIGHUP, so they can be rotated without restart.
+func (s *Server) reloadOnSignal(ctx context.Context) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-sighup:
+			if s.config.PrivateKeyPath != "" {
+				if err := s.bd.Reload(); err != nil {
+					s.logger.Errorf("failed to reload private keys: %v", err)
+				}
+			}
+			if s.tokens != nil {
+				if err := s.tokens.Reload(); err != nil {
+					s.logger.Errorf("failed to reload agent tokens: %v", err)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // Shutdown called on shutdown.
 func (s *Server) Shutdown() {
+	if s.statsdServer != nil {
+		s.statsdServer.Stop()
+		s.logger.Info("statsd server stopped")
+	}
 	if s.fs != nil {
 		s.fs.Close()
 		s.logger.Info("file storage closed")
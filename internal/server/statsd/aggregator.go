@@ -0,0 +1,148 @@
+package statsd
+
+import (
+	"math"
+	"sync"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+)
+
+type counterEntry struct {
+	labels map[string]string
+	name   string
+	sum    float64
+}
+
+type gaugeEntry struct {
+	labels map[string]string
+	name   string
+	value  float64
+	idle   int // flushes since last update
+	dirty  bool
+}
+
+// gaugeIdleFlushes how many flushes gauge is kept without updates, so clients sending unique
+// gauge names do not grow memory without bound.
+const gaugeIdleFlushes = 10
+
+// aggregator accumulates samples between flushes: counters are summed, gauges keep last value.
+type aggregator struct {
+	counters map[string]*counterEntry
+	// kept between flushes, relative gauge changes apply to last known value until gauge is
+	// idle for gaugeIdleFlushes
+	gauges map[string]*gaugeEntry
+	mu     sync.Mutex
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		counters: make(map[string]*counterEntry),
+		gauges:   make(map[string]*gaugeEntry),
+	}
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	return name + "{" + dto.LabelsKey(labels) + "}"
+}
+
+func (a *aggregator) add(s *sample) {
+	key := seriesKey(s.name, s.labels)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch s.mType {
+	case common.CounterMetricType:
+		entry, ok := a.counters[key]
+		if !ok {
+			entry = &counterEntry{name: s.name, labels: s.labels}
+			a.counters[key] = entry
+		}
+		entry.sum += s.value
+	case common.GaugeMetricType:
+		entry, ok := a.gauges[key]
+		if !ok {
+			entry = &gaugeEntry{name: s.name, labels: s.labels}
+			a.gauges[key] = entry
+		}
+		if s.relative {
+			entry.value += s.value
+		} else {
+			entry.value = s.value
+		}
+		entry.dirty = true
+		entry.idle = 0
+	default:
+	}
+}
+
+// flush returns metrics accumulated since previous flush and resets counters. Gauges idle
+// for gaugeIdleFlushes are forgotten.
+func (a *aggregator) flush() []*dto.MetricDTO {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := make([]*dto.MetricDTO, 0, len(a.counters))
+	for key, entry := range a.counters {
+		delete(a.counters, key)
+		delta := int64(math.Round(entry.sum))
+		if delta == 0 {
+			continue
+		}
+		result = append(result, &dto.MetricDTO{
+			ID:     entry.name,
+			MType:  common.CounterMetricType,
+			Labels: entry.labels,
+			Delta:  &delta,
+		})
+	}
+
+	for key, entry := range a.gauges {
+		if !entry.dirty {
+			entry.idle++
+			if entry.idle >= gaugeIdleFlushes {
+				delete(a.gauges, key)
+			}
+			continue
+		}
+		entry.dirty = false
+		value := entry.value
+		result = append(result, &dto.MetricDTO{
+			ID:     entry.name,
+			MType:  common.GaugeMetricType,
+			Labels: entry.labels,
+			Value:  &value,
+		})
+	}
+
+	return result
+}
+
+// restore returns flushed metrics which were not stored, so they go with next flush. Counter
+// deltas are added to ones accumulated meanwhile, gauge updated meanwhile keeps newer value.
+func (a *aggregator) restore(metrics []*dto.MetricDTO) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, metric := range metrics {
+		key := seriesKey(metric.ID, metric.Labels)
+		switch {
+		case metric.MType == common.CounterMetricType && metric.Delta != nil:
+			entry, ok := a.counters[key]
+			if !ok {
+				entry = &counterEntry{name: metric.ID, labels: metric.Labels}
+				a.counters[key] = entry
+			}
+			entry.sum += float64(*metric.Delta)
+		case metric.MType == common.GaugeMetricType && metric.Value != nil:
+			entry, ok := a.gauges[key]
+			if !ok {
+				entry = &gaugeEntry{name: metric.ID, labels: metric.Labels, value: *metric.Value}
+				a.gauges[key] = entry
+			}
+			entry.dirty = true
+		default:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package statsd UDP listener for StatsD line protocol.
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Kopleman/metcol/internal/common"
+)
+
+var ErrLineParse = errors.New("unable to parse statsd line")
+
+// sample single parsed statsd line.
+type sample struct {
+	labels   map[string]string
+	name     string
+	mType    common.MetricType
+	value    float64
+	relative bool // gauge change like "+3" or "-3" instead of absolute value
+}
+
+// parseLine parses `name:value|type[|@rate][|#tag:value,...]`, only counters(c) and gauges(g) are supported.
+func parseLine(line string) (*sample, error) {
+	name, rest, found := strings.Cut(line, ":")
+	if !found || name == "" {
+		return nil, fmt.Errorf("%w: '%s': missing name", ErrLineParse, line)
+	}
+
+	parts := strings.Split(rest, "|")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("%w: '%s': missing type", ErrLineParse, line)
+	}
+
+	rawValue := parts[0]
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s': bad value: %w", ErrLineParse, line, err)
+	}
+
+	s := &sample{name: name, value: value}
+	switch parts[1] {
+	case "c":
+		s.mType = common.CounterMetricType
+	case "g":
+		s.mType = common.GaugeMetricType
+		s.relative = strings.HasPrefix(rawValue, "+") || strings.HasPrefix(rawValue, "-")
+	default:
+		return nil, fmt.Errorf("%w: '%s': unsupported type '%s'", ErrLineParse, line, parts[1])
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, rateErr := strconv.ParseFloat(part[1:], 64)
+			if rateErr != nil || rate <= 0 || rate > 1 {
+				return nil, fmt.Errorf("%w: '%s': bad sample rate", ErrLineParse, line)
+			}
+			if s.mType == common.CounterMetricType {
+				s.value /= rate
+			}
+		case strings.HasPrefix(part, "#"):
+			s.labels = parseTags(part[1:])
+		default:
+			return nil, fmt.Errorf("%w: '%s': unknown section '%s'", ErrLineParse, line, part)
+		}
+	}
+
+	return s, nil
+}
+
+// parseTags parses DogStatsD tags `key:value,flag`, tag without value gets empty value.
+func parseTags(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(raw, ",") {
+		if tag == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(tag, ":")
+		tags[k] = v
+	}
+	return tags
+}
@@ -0,0 +1,66 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		want    *sample
+		name    string
+		line    string
+		wantErr bool
+	}{
+		{
+			name: "counter",
+			line: "requests:1|c",
+			want: &sample{name: "requests", mType: common.CounterMetricType, value: 1},
+		},
+		{
+			name: "sampled counter",
+			line: "requests:2|c|@0.5",
+			want: &sample{name: "requests", mType: common.CounterMetricType, value: 4},
+		},
+		{
+			name: "gauge",
+			line: "temperature:3.2|g",
+			want: &sample{name: "temperature", mType: common.GaugeMetricType, value: 3.2},
+		},
+		{
+			name: "relative gauge",
+			line: "temperature:-1.5|g",
+			want: &sample{name: "temperature", mType: common.GaugeMetricType, value: -1.5, relative: true},
+		},
+		{
+			name: "tagged gauge",
+			line: "temperature:3|g|#host:a,ssd",
+			want: &sample{
+				name:   "temperature",
+				mType:  common.GaugeMetricType,
+				value:  3,
+				labels: map[string]string{"host": "a", "ssd": ""},
+			},
+		},
+		{name: "missing name", line: ":1|c", wantErr: true},
+		{name: "missing type", line: "requests:1", wantErr: true},
+		{name: "bad value", line: "requests:abc|c", wantErr: true},
+		{name: "unsupported type", line: "latency:320|ms", wantErr: true},
+		{name: "bad sample rate", line: "requests:1|c|@2", wantErr: true},
+		{name: "unknown section", line: "requests:1|c|foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLine(tt.line)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrLineParse)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+)
+
+const maxPacketSize = 65535
+
+type MetricsForUpdate interface {
+	SetMetrics(ctx context.Context, metrics []*dto.MetricDTO) error
+}
+
+// Server listens StatsD packets over UDP and periodically flushes aggregated metrics.
+type Server struct {
+	logger         log.Logger
+	metricsService MetricsForUpdate
+	trustedSubnet  *net.IPNet // nil means any source allowed
+	aggregator     *aggregator
+	conn           net.PacketConn
+	done           chan struct{}
+	flushInterval  time.Duration
+	stopOnce       sync.Once
+	mu             sync.Mutex // guards conn
+}
+
+// NewServer creates instance of StatsD server.
+func NewServer(
+	logger log.Logger,
+	metricsService MetricsForUpdate,
+	trustedCIDR string,
+	flushInterval time.Duration,
+) (*Server, error) {
+	s := &Server{
+		logger:         logger,
+		metricsService: metricsService,
+		aggregator:     newAggregator(),
+		done:           make(chan struct{}),
+		flushInterval:  flushInterval,
+	}
+
+	if trustedCIDR != "" {
+		_, ipnet, err := net.ParseCIDR(trustedCIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted subnet '%s': %w", trustedCIDR, err)
+		}
+		s.trustedSubnet = ipnet
+	}
+
+	return s, nil
+}
+
+// Start listens UDP address and blocks until Stop called.
+func (s *Server) Start(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		if closeErr := conn.Close(); closeErr != nil {
+			return fmt.Errorf("failed to close listener: %w", closeErr)
+		}
+		return nil
+	default:
+	}
+	s.conn = conn
+	s.mu.Unlock()
+	s.logger.Infof("StatsD server listening on %s", addr)
+
+	go s.runFlushJob(ctx)
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, src, readErr := conn.ReadFrom(buf)
+		if readErr != nil {
+			if errors.Is(readErr, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to read packet: %w", readErr)
+		}
+		s.handlePacket(src, buf[:n])
+	}
+}
+
+// Stop closes listener and flushes pending metrics.
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		close(s.done)
+		conn := s.conn
+		s.mu.Unlock()
+		if conn != nil {
+			if err := conn.Close(); err != nil {
+				s.logger.Errorf("failed to close statsd listener: %v", err)
+			}
+		}
+		s.flush(context.Background())
+	})
+}
+
+func (s *Server) runFlushJob(ctx context.Context) {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.flush(ctx)
+		}
+	}
+}
+
+func (s *Server) flush(ctx context.Context) {
+	batch := s.aggregator.flush()
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.metricsService.SetMetrics(ctx, batch); err != nil {
+		s.logger.Errorf("failed to flush statsd metrics, kept for next flush: %v", err)
+		s.aggregator.restore(batch)
+	}
+}
+
+func (s *Server) isTrusted(src net.Addr) bool {
+	if s.trustedSubnet == nil {
+		return true
+	}
+	udpAddr, ok := src.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+	return s.trustedSubnet.Contains(udpAddr.IP)
+}
+
+func (s *Server) handlePacket(src net.Addr, packet []byte) {
+	if !s.isTrusted(src) {
+		s.logger.Warnf("statsd packet from untrusted address %s dropped", src)
+		return
+	}
+
+	for _, line := range strings.Split(string(packet), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		smpl, err := parseLine(line)
+		if err != nil {
+			s.logger.Warn(err)
+			continue
+		}
+		s.aggregator.add(smpl)
+	}
+}
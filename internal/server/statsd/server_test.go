@@ -0,0 +1,184 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common"
+	"github.com/Kopleman/metcol/internal/common/dto"
+	"github.com/Kopleman/metcol/internal/common/log"
+	"github.com/Kopleman/metcol/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsRecorder struct {
+	err     error
+	batches [][]*dto.MetricDTO
+	mu      sync.Mutex
+}
+
+func (r *metricsRecorder) SetMetrics(_ context.Context, metrics []*dto.MetricDTO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, metrics)
+	return nil
+}
+
+func (r *metricsRecorder) all() []*dto.MetricDTO {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*dto.MetricDTO
+	for _, batch := range r.batches {
+		result = append(result, batch...)
+	}
+	return result
+}
+
+func TestServer_handlePacket(t *testing.T) {
+	tests := []struct {
+		src           net.Addr
+		name          string
+		trustedSubnet string
+		packet        string
+		want          []*dto.MetricDTO
+	}{
+		{
+			name:   "aggregates counters and keeps last gauge",
+			src:    &net.UDPAddr{IP: net.ParseIP("10.0.0.5"), Port: 5000},
+			packet: "requests:1|c\nrequests:2|c\ntemp:1|g\ntemp:3.5|g\nbroken\n",
+			want: []*dto.MetricDTO{
+				{ID: "requests", MType: common.CounterMetricType, Delta: testutils.Pointer(int64(3))},
+				{ID: "temp", MType: common.GaugeMetricType, Value: testutils.Pointer(3.5)},
+			},
+		},
+		{
+			name:          "accepts packets from trusted subnet",
+			trustedSubnet: "10.0.0.0/24",
+			src:           &net.UDPAddr{IP: net.ParseIP("10.0.0.5"), Port: 5000},
+			packet:        "temp:2|g\ntemp:+1|g",
+			want: []*dto.MetricDTO{
+				{ID: "temp", MType: common.GaugeMetricType, Value: testutils.Pointer(3.0)},
+			},
+		},
+		{
+			name:          "drops packets from untrusted address",
+			trustedSubnet: "10.0.0.0/24",
+			src:           &net.UDPAddr{IP: net.ParseIP("192.168.1.5"), Port: 5000},
+			packet:        "requests:1|c",
+			want:          []*dto.MetricDTO{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &metricsRecorder{}
+			s, err := NewServer(log.MockLogger{}, recorder, tt.trustedSubnet, time.Second)
+			require.NoError(t, err)
+
+			s.handlePacket(tt.src, []byte(tt.packet))
+			assert.ElementsMatch(t, tt.want, s.aggregator.flush())
+		})
+	}
+}
+
+func TestNewServer_InvalidSubnet(t *testing.T) {
+	_, err := NewServer(log.MockLogger{}, &metricsRecorder{}, "not-a-cidr", time.Second)
+	assert.Error(t, err)
+}
+
+func TestAggregator_Flush(t *testing.T) {
+	a := newAggregator()
+	a.add(&sample{name: "temp", mType: common.GaugeMetricType, value: 5})
+	a.add(&sample{name: "hits", mType: common.CounterMetricType, value: 2})
+	require.Len(t, a.flush(), 2)
+
+	// counters reset and untouched gauges are not re-sent
+	assert.Empty(t, a.flush())
+
+	// relative change applies to value from previous window
+	a.add(&sample{name: "temp", mType: common.GaugeMetricType, value: -2, relative: true})
+	assert.Equal(t, []*dto.MetricDTO{
+		{ID: "temp", MType: common.GaugeMetricType, Value: testutils.Pointer(3.0)},
+	}, a.flush())
+}
+
+func TestAggregator_FlushForgetsIdleGauges(t *testing.T) {
+	a := newAggregator()
+	a.add(&sample{name: "temp", mType: common.GaugeMetricType, value: 5})
+	require.Len(t, a.flush(), 1)
+	for range gaugeIdleFlushes - 1 {
+		a.flush()
+	}
+	require.Len(t, a.gauges, 1)
+	a.flush()
+	assert.Empty(t, a.gauges)
+
+	// relative change of forgotten gauge applies to zero
+	a.add(&sample{name: "temp", mType: common.GaugeMetricType, value: 1, relative: true})
+	assert.Equal(t, []*dto.MetricDTO{
+		{ID: "temp", MType: common.GaugeMetricType, Value: testutils.Pointer(1.0)},
+	}, a.flush())
+}
+
+func TestServer_FlushFailed(t *testing.T) {
+	recorder := &metricsRecorder{err: errors.New("store is down")}
+	s, err := NewServer(log.MockLogger{}, recorder, "", time.Second)
+	require.NoError(t, err)
+
+	s.aggregator.add(&sample{name: "hits", mType: common.CounterMetricType, value: 2})
+	s.aggregator.add(&sample{name: "temp", mType: common.GaugeMetricType, value: 5})
+	s.flush(context.Background())
+
+	// метрики, не записанные в хранилище, уходят со следующим сбросом
+	s.aggregator.add(&sample{name: "hits", mType: common.CounterMetricType, value: 3})
+	recorder.err = nil
+	s.flush(context.Background())
+	assert.ElementsMatch(t, []*dto.MetricDTO{
+		{ID: "hits", MType: common.CounterMetricType, Labels: map[string]string(nil), Delta: testutils.Pointer(int64(5))},
+		{ID: "temp", MType: common.GaugeMetricType, Labels: map[string]string(nil), Value: testutils.Pointer(5.0)},
+	}, recorder.all())
+}
+
+func TestServer_Start(t *testing.T) {
+	recorder := &metricsRecorder{}
+	s, err := NewServer(log.MockLogger{}, recorder, "", time.Hour)
+	require.NoError(t, err)
+
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := probe.LocalAddr().String()
+	require.NoError(t, probe.Close())
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Start(context.Background(), addr)
+	}()
+
+	conn, err := net.Dial("udp", addr)
+	require.NoError(t, err)
+	defer conn.Close() //nolint:all // test
+
+	assert.Eventually(t, func() bool {
+		if _, writeErr := conn.Write([]byte("hits:1|c")); writeErr != nil {
+			return false
+		}
+		s.aggregator.mu.Lock()
+		defer s.aggregator.mu.Unlock()
+		return len(s.aggregator.counters) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	s.Stop()
+	require.NoError(t, <-errCh)
+
+	got := recorder.all()
+	require.Len(t, got, 1)
+	assert.Equal(t, "hits", got[0].ID)
+	assert.Equal(t, common.CounterMetricType, got[0].MType)
+}
@@ -3,15 +3,61 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/Kopleman/metcol/internal/common"
 	"github.com/Kopleman/metcol/internal/common/dto"
 )
 
+// AllNamespaces selects metrics of every namespace in GetAll.
+const AllNamespaces = "*"
+
+// Store keeps metrics isolated by namespace. Written metrics carry namespace in
+// dto.MetricDTO.Namespace, reads receive it explicitly.
 type Store interface {
 	Create(ctx context.Context, value *dto.MetricDTO) error
-	Read(ctx context.Context, mType common.MetricType, name string) (*dto.MetricDTO, error)
+	Read(
+		ctx context.Context,
+		namespace string,
+		mType common.MetricType,
+		name string,
+		labels map[string]string,
+	) (*dto.MetricDTO, error)
 	Update(ctx context.Context, value *dto.MetricDTO) error
-	GetAll(ctx context.Context) ([]*dto.MetricDTO, error)
+	GetAll(ctx context.Context, namespace string) ([]*dto.MetricDTO, error)
 	BulkCreateOrUpdate(ctx context.Context, metricsDTO []*dto.MetricDTO) error
+	HistoryStore
+	BatchStore
+}
+
+// HistoryStore keeps timestamped samples of every accepted metric update.
+type HistoryStore interface {
+	AppendSamples(ctx context.Context, metricsDTO []*dto.MetricDTO, ts time.Time) error
+	ReadSamples(
+		ctx context.Context,
+		namespace string,
+		mType common.MetricType,
+		name string,
+		labels map[string]string,
+		from, to time.Time,
+	) ([]*dto.SampleDTO, error)
+	DeleteSamplesBefore(ctx context.Context, before time.Time) error
+}
+
+// BatchStore remembers results of recently applied metric batches, so retried batch
+// is answered with original result instead of being applied again.
+type BatchStore interface {
+	// ReadBatch returns result of applied batch, sterrors.ErrNotFound if batch is unknown.
+	ReadBatch(ctx context.Context, namespace string, batchID string) ([]*dto.MetricDTO, error)
+	// ApplyBatch creates or updates metrics and remembers result of batch atomically. Nothing is
+	// written and sterrors.ErrAlreadyExists is returned if batch was applied already.
+	ApplyBatch(
+		ctx context.Context,
+		namespace string,
+		batchID string,
+		metricsDTO []*dto.MetricDTO,
+		result []*dto.MetricDTO,
+		ts time.Time,
+	) error
+	DeleteBatchesBefore(ctx context.Context, before time.Time) error
 }
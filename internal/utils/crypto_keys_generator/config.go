@@ -9,6 +9,8 @@ import (
 type Config struct {
 	PublicKeyPath  string
 	PrivateKeyPath string
+	KeysDir        string // directory of rotated private keys, key pair gets id
+	KeyID          string
 }
 
 // ParseConfig produce config for generator via parsing flags.
@@ -17,11 +19,19 @@ func ParseConfig() (*Config, error) {
 
 	flag.StringVar(&config.PublicKeyPath, "p", "", "path to public key")
 	flag.StringVar(&config.PrivateKeyPath, "r", "", "path to private key")
+	flag.StringVar(&config.KeysDir, "d", "", "directory of private keys, new key is added with id")
+	flag.StringVar(&config.KeyID, "id", "", "key id for -d mode, current UTC time by default")
 	flag.Parse()
 
-	if config.PublicKeyPath == "" || config.PrivateKeyPath == "" {
+	if config.PublicKeyPath == "" || (config.PrivateKeyPath == "" && config.KeysDir == "") {
 		return nil, errors.New("public key and private key are required")
 	}
+	if config.PrivateKeyPath != "" && config.KeysDir != "" {
+		return nil, errors.New("private key path and keys dir are mutually exclusive")
+	}
+	if config.KeyID != "" && config.KeysDir == "" {
+		return nil, errors.New("key id requires keys dir")
+	}
 
 	return config, nil
 }
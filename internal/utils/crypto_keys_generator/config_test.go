@@ -27,6 +27,24 @@ func TestParseConfig(t *testing.T) {
 			want:    &Config{PublicKeyPath: "public.pem", PrivateKeyPath: "private.pem"},
 			wantErr: "",
 		},
+		{
+			name:    "keys dir with id",
+			args:    []string{"cmd", "-p", "public.pem", "-d", "keys", "-id", "k1"},
+			want:    &Config{PublicKeyPath: "public.pem", KeysDir: "keys", KeyID: "k1"},
+			wantErr: "",
+		},
+		{
+			name:        "private key and keys dir",
+			args:        []string{"cmd", "-p", "public.pem", "-r", "private.pem", "-d", "keys"},
+			wantErr:     "private key path and keys dir are mutually exclusive",
+			expectError: true,
+		},
+		{
+			name:        "key id without keys dir",
+			args:        []string{"cmd", "-p", "public.pem", "-r", "private.pem", "-id", "k1"},
+			wantErr:     "key id requires keys dir",
+			expectError: true,
+		},
 		{
 			name:        "missing public key",
 			args:        []string{"cmd", "-r", "private.pem"},
@@ -72,7 +90,7 @@ func TestParseConfig(t *testing.T) {
 				return
 			}
 
-			if got.PublicKeyPath != tt.want.PublicKeyPath || got.PrivateKeyPath != tt.want.PrivateKeyPath {
+			if *got != *tt.want {
 				t.Errorf("ParseConfig() = %v, want %v", got, tt.want)
 			}
 		})
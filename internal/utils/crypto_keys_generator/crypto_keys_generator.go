@@ -9,8 +9,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Kopleman/metcol/internal/common/envelope"
 )
 
+const keyIDTimeFormat = "20060102150405"
+
 // Generator instance.
 type Generator struct {
 }
@@ -20,6 +27,36 @@ func NewGenerator() *Generator {
 }
 
 func (g *Generator) GenerateKeys(privateKeyPath, publicKeyPath string) error {
+	return g.generateKeys(privateKeyPath, publicKeyPath, "")
+}
+
+// GenerateKeysToDir creates key pair with id for rotation: private key is written to <keysDir>/<keyID>.pem,
+// public key to publicKeyPath. Both files carry Key-Id PEM header. Empty keyID is replaced by current UTC time.
+// Returns path of private key file.
+func (g *Generator) GenerateKeysToDir(keysDir, keyID, publicKeyPath string) (string, error) {
+	if keyID == "" {
+		keyID = time.Now().UTC().Format(keyIDTimeFormat)
+	}
+	if strings.ContainsAny(keyID, `/\`) {
+		return "", fmt.Errorf("invalid key id '%s'", keyID)
+	}
+
+	if err := os.MkdirAll(keysDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create keys dir: %w", err)
+	}
+
+	privateKeyPath := filepath.Join(keysDir, keyID+".pem")
+	if _, err := os.Stat(privateKeyPath); err == nil {
+		return "", fmt.Errorf("private key with id '%s' already exists", keyID)
+	}
+
+	if err := g.generateKeys(privateKeyPath, publicKeyPath, keyID); err != nil {
+		return "", err
+	}
+	return privateKeyPath, nil
+}
+
+func (g *Generator) generateKeys(privateKeyPath, publicKeyPath, keyID string) error {
 	if publicKeyPath == "" || privateKeyPath == "" {
 		return errors.New("publicKeyPath or privateKeyPath is empty")
 	}
@@ -33,26 +70,27 @@ func (g *Generator) GenerateKeys(privateKeyPath, publicKeyPath string) error {
 		return fmt.Errorf("failed to generate private key pair: %w", err)
 	}
 
-	if pubExportErr := g.exportPublicKeyToFile(&privateKey.PublicKey, publicKeyPath); pubExportErr != nil {
+	if pubExportErr := g.exportPublicKeyToFile(&privateKey.PublicKey, publicKeyPath, keyID); pubExportErr != nil {
 		return fmt.Errorf("failed to export public key to file: %w", pubExportErr)
 	}
 
-	if privateExportErr := g.exportPrivateKeyToFile(privateKey, privateKeyPath); privateExportErr != nil {
+	if privateExportErr := g.exportPrivateKeyToFile(privateKey, privateKeyPath, keyID); privateExportErr != nil {
 		return fmt.Errorf("failed to export private key to file: %w", privateExportErr)
 	}
 
 	return nil
 }
 
-func (g *Generator) exportPublicKeyToFile(publicKey *rsa.PublicKey, pathToFile string) error {
+func (g *Generator) exportPublicKeyToFile(publicKey *rsa.PublicKey, pathToFile, keyID string) error {
 	pubKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return fmt.Errorf("failed to serialize public key: %w", err)
 	}
 	pubKeyPEM := pem.EncodeToMemory(
 		&pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: pubKeyBytes,
+			Type:    "PUBLIC KEY",
+			Headers: keyHeaders(keyID),
+			Bytes:   pubKeyBytes,
 		},
 	)
 
@@ -68,12 +106,13 @@ func (g *Generator) exportPublicKeyToFile(publicKey *rsa.PublicKey, pathToFile s
 	return nil
 }
 
-func (g *Generator) exportPrivateKeyToFile(privateKey *rsa.PrivateKey, pathToFile string) error {
+func (g *Generator) exportPrivateKeyToFile(privateKey *rsa.PrivateKey, pathToFile, keyID string) error {
 	privKeyBytes := x509.MarshalPKCS1PrivateKey(privateKey)
 	privKeyPEM := pem.EncodeToMemory(
 		&pem.Block{
-			Type:  "RSA PRIVATE KEY",
-			Bytes: privKeyBytes,
+			Type:    "RSA PRIVATE KEY",
+			Headers: keyHeaders(keyID),
+			Bytes:   privKeyBytes,
 		},
 	)
 
@@ -89,6 +128,13 @@ func (g *Generator) exportPrivateKeyToFile(privateKey *rsa.PrivateKey, pathToFil
 	return nil
 }
 
+func keyHeaders(keyID string) map[string]string {
+	if keyID == "" {
+		return nil
+	}
+	return map[string]string{envelope.KeyIDHeader: keyID}
+}
+
 func (g *Generator) getFileDescriptor(path string) (*os.File, error) {
 	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o666) //nolint:all // different lint behavior on perm var
 	if err != nil {
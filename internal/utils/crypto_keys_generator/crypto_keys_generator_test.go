@@ -98,6 +98,53 @@ func TestGenerateKeys_Success(t *testing.T) {
 	checkValidRSAPair(t, privatePath, publicPath)
 }
 
+func TestGenerateKeysToDir(t *testing.T) {
+	tempDir := t.TempDir()
+	keysDir := filepath.Join(tempDir, "keys")
+	publicPath := filepath.Join(tempDir, "public.pem")
+
+	g := NewGenerator()
+	privatePath, err := g.GenerateKeysToDir(keysDir, "k1", publicPath)
+	if err != nil {
+		t.Fatalf("GenerateKeysToDir failed: %v", err)
+	}
+	if privatePath != filepath.Join(keysDir, "k1.pem") {
+		t.Errorf("unexpected private key path: %s", privatePath)
+	}
+
+	checkPEMFile(t, privatePath, "RSA PRIVATE KEY")
+	checkPEMFile(t, publicPath, "PUBLIC KEY")
+	checkValidRSAPair(t, privatePath, publicPath)
+	for _, path := range []string{privatePath, publicPath} {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		block, _ := pem.Decode(data)
+		if block.Headers["Key-Id"] != "k1" {
+			t.Errorf("unexpected key id header in %s: %v", path, block.Headers)
+		}
+	}
+
+	// Существующий ключ не перезаписывается
+	if _, err = g.GenerateKeysToDir(keysDir, "k1", publicPath); err == nil {
+		t.Error("expected error for existing key id")
+	}
+
+	// Id по умолчанию - время генерации
+	privatePath, err = g.GenerateKeysToDir(keysDir, "", publicPath)
+	if err != nil {
+		t.Fatalf("GenerateKeysToDir failed: %v", err)
+	}
+	if _, statErr := os.Stat(privatePath); statErr != nil {
+		t.Errorf("file %q was not created", privatePath)
+	}
+
+	if _, err = g.GenerateKeysToDir(keysDir, "../k2", publicPath); err == nil {
+		t.Error("expected error for key id with path separator")
+	}
+}
+
 func checkPEMFile(t *testing.T, path, expectedType string) {
 	t.Helper()
 	data, err := os.ReadFile(path)
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.5
+// 	protoc-gen-go v1.36.6
 // 	protoc        v5.29.3
 // source: proto/metrics/metrics.proto
 
@@ -25,9 +25,11 @@ const (
 type MetricType int32
 
 const (
-	MetricType_UNKNOWN MetricType = 0
-	MetricType_GAUGE   MetricType = 1
-	MetricType_COUNTER MetricType = 2
+	MetricType_UNKNOWN   MetricType = 0
+	MetricType_GAUGE     MetricType = 1
+	MetricType_COUNTER   MetricType = 2
+	MetricType_HISTOGRAM MetricType = 3
+	MetricType_SUMMARY   MetricType = 4
 )
 
 // Enum value maps for MetricType.
@@ -36,11 +38,15 @@ var (
 		0: "UNKNOWN",
 		1: "GAUGE",
 		2: "COUNTER",
+		3: "HISTOGRAM",
+		4: "SUMMARY",
 	}
 	MetricType_value = map[string]int32{
-		"UNKNOWN": 0,
-		"GAUGE":   1,
-		"COUNTER": 2,
+		"UNKNOWN":   0,
+		"GAUGE":     1,
+		"COUNTER":   2,
+		"HISTOGRAM": 3,
+		"SUMMARY":   4,
 	}
 )
 
@@ -66,33 +72,87 @@ func (x MetricType) Number() protoreflect.EnumNumber {
 	return protoreflect.EnumNumber(x)
 }
 
-// Сообщение для одиночной метрики
-type Metric struct {
+// Агрегация значений внутри временного интервала
+type Aggregation int32
+
+const (
+	Aggregation_AGGREGATION_UNKNOWN Aggregation = 0
+	Aggregation_AGGREGATION_AVG     Aggregation = 1 // Среднее значение
+	Aggregation_AGGREGATION_MIN     Aggregation = 2 // Минимальное значение
+	Aggregation_AGGREGATION_MAX     Aggregation = 3 // Максимальное значение
+	Aggregation_AGGREGATION_SUM     Aggregation = 4 // Сумма значений
+	Aggregation_AGGREGATION_RATE    Aggregation = 5 // Скорость роста в секунду, только для counter
+)
+
+// Enum value maps for Aggregation.
+var (
+	Aggregation_name = map[int32]string{
+		0: "AGGREGATION_UNKNOWN",
+		1: "AGGREGATION_AVG",
+		2: "AGGREGATION_MIN",
+		3: "AGGREGATION_MAX",
+		4: "AGGREGATION_SUM",
+		5: "AGGREGATION_RATE",
+	}
+	Aggregation_value = map[string]int32{
+		"AGGREGATION_UNKNOWN": 0,
+		"AGGREGATION_AVG":     1,
+		"AGGREGATION_MIN":     2,
+		"AGGREGATION_MAX":     3,
+		"AGGREGATION_SUM":     4,
+		"AGGREGATION_RATE":    5,
+	}
+)
+
+func (x Aggregation) Enum() *Aggregation {
+	p := new(Aggregation)
+	*p = x
+	return p
+}
+
+func (x Aggregation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Aggregation) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_metrics_metrics_proto_enumTypes[1].Descriptor()
+}
+
+func (Aggregation) Type() protoreflect.EnumType {
+	return &file_proto_metrics_metrics_proto_enumTypes[1]
+}
+
+func (x Aggregation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Гистограмма: границы бакетов, количество наблюдений в каждом бакете, сумма и общее количество
+type Histogram struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Id          *string                `protobuf:"bytes,1,opt,name=id"`
-	xxx_hidden_Type        MetricType             `protobuf:"varint,2,opt,name=type,enum=metrics.MetricType"`
-	xxx_hidden_Value       float64                `protobuf:"fixed64,3,opt,name=value"`
-	xxx_hidden_Delta       int64                  `protobuf:"varint,4,opt,name=delta"`
+	xxx_hidden_Bounds      []float64              `protobuf:"fixed64,1,rep,packed,name=bounds"`
+	xxx_hidden_Counts      []uint64               `protobuf:"varint,2,rep,packed,name=counts"`
+	xxx_hidden_Sum         float64                `protobuf:"fixed64,3,opt,name=sum"`
+	xxx_hidden_Count       uint64                 `protobuf:"varint,4,opt,name=count"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *Metric) Reset() {
-	*x = Metric{}
+func (x *Histogram) Reset() {
+	*x = Histogram{}
 	mi := &file_proto_metrics_metrics_proto_msgTypes[0]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Metric) String() string {
+func (x *Histogram) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Metric) ProtoMessage() {}
+func (*Histogram) ProtoMessage() {}
 
-func (x *Metric) ProtoReflect() protoreflect.Message {
+func (x *Histogram) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_metrics_metrics_proto_msgTypes[0]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -104,164 +164,127 @@ func (x *Metric) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *Metric) GetId() string {
+func (x *Histogram) GetBounds() []float64 {
 	if x != nil {
-		if x.xxx_hidden_Id != nil {
-			return *x.xxx_hidden_Id
-		}
-		return ""
+		return x.xxx_hidden_Bounds
 	}
-	return ""
+	return nil
 }
 
-func (x *Metric) GetType() MetricType {
+func (x *Histogram) GetCounts() []uint64 {
 	if x != nil {
-		if protoimpl.X.Present(&(x.XXX_presence[0]), 1) {
-			return x.xxx_hidden_Type
-		}
+		return x.xxx_hidden_Counts
 	}
-	return MetricType_UNKNOWN
+	return nil
 }
 
-func (x *Metric) GetValue() float64 {
+func (x *Histogram) GetSum() float64 {
 	if x != nil {
-		return x.xxx_hidden_Value
+		return x.xxx_hidden_Sum
 	}
 	return 0
 }
 
-func (x *Metric) GetDelta() int64 {
+func (x *Histogram) GetCount() uint64 {
 	if x != nil {
-		return x.xxx_hidden_Delta
+		return x.xxx_hidden_Count
 	}
 	return 0
 }
 
-func (x *Metric) SetId(v string) {
-	x.xxx_hidden_Id = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+func (x *Histogram) SetBounds(v []float64) {
+	x.xxx_hidden_Bounds = v
 }
 
-func (x *Metric) SetType(v MetricType) {
-	x.xxx_hidden_Type = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+func (x *Histogram) SetCounts(v []uint64) {
+	x.xxx_hidden_Counts = v
 }
 
-func (x *Metric) SetValue(v float64) {
-	x.xxx_hidden_Value = v
+func (x *Histogram) SetSum(v float64) {
+	x.xxx_hidden_Sum = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
 }
 
-func (x *Metric) SetDelta(v int64) {
-	x.xxx_hidden_Delta = v
+func (x *Histogram) SetCount(v uint64) {
+	x.xxx_hidden_Count = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
 }
 
-func (x *Metric) HasId() bool {
-	if x == nil {
-		return false
-	}
-	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
-}
-
-func (x *Metric) HasType() bool {
-	if x == nil {
-		return false
-	}
-	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
-}
-
-func (x *Metric) HasValue() bool {
+func (x *Histogram) HasSum() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
 }
 
-func (x *Metric) HasDelta() bool {
+func (x *Histogram) HasCount() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
 }
 
-func (x *Metric) ClearId() {
-	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Id = nil
-}
-
-func (x *Metric) ClearType() {
-	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Type = MetricType_UNKNOWN
-}
-
-func (x *Metric) ClearValue() {
+func (x *Histogram) ClearSum() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
-	x.xxx_hidden_Value = 0
+	x.xxx_hidden_Sum = 0
 }
 
-func (x *Metric) ClearDelta() {
+func (x *Histogram) ClearCount() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
-	x.xxx_hidden_Delta = 0
+	x.xxx_hidden_Count = 0
 }
 
-type Metric_builder struct {
+type Histogram_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Id    *string
-	Type  *MetricType
-	Value *float64
-	Delta *int64
+	Bounds []float64
+	Counts []uint64
+	Sum    *float64
+	Count  *uint64
 }
 
-func (b0 Metric_builder) Build() *Metric {
-	m0 := &Metric{}
+func (b0 Histogram_builder) Build() *Histogram {
+	m0 := &Histogram{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Id != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
-		x.xxx_hidden_Id = b.Id
-	}
-	if b.Type != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
-		x.xxx_hidden_Type = *b.Type
-	}
-	if b.Value != nil {
+	x.xxx_hidden_Bounds = b.Bounds
+	x.xxx_hidden_Counts = b.Counts
+	if b.Sum != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
-		x.xxx_hidden_Value = *b.Value
+		x.xxx_hidden_Sum = *b.Sum
 	}
-	if b.Delta != nil {
+	if b.Count != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
-		x.xxx_hidden_Delta = *b.Delta
+		x.xxx_hidden_Count = *b.Count
 	}
 	return m0
 }
 
-// Запрос на получение значения метрики
-type GetMetricRequest struct {
+// Квантиль сводки
+type Quantile struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Id          *string                `protobuf:"bytes,1,opt,name=id"`
-	xxx_hidden_Type        MetricType             `protobuf:"varint,2,opt,name=type,enum=metrics.MetricType"`
+	xxx_hidden_Quantile    float64                `protobuf:"fixed64,1,opt,name=quantile"`
+	xxx_hidden_Value       float64                `protobuf:"fixed64,2,opt,name=value"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *GetMetricRequest) Reset() {
-	*x = GetMetricRequest{}
+func (x *Quantile) Reset() {
+	*x = Quantile{}
 	mi := &file_proto_metrics_metrics_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMetricRequest) String() string {
+func (x *Quantile) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMetricRequest) ProtoMessage() {}
+func (*Quantile) ProtoMessage() {}
 
-func (x *GetMetricRequest) ProtoReflect() protoreflect.Message {
+func (x *Quantile) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_metrics_metrics_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -273,107 +296,102 @@ func (x *GetMetricRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *GetMetricRequest) GetId() string {
+func (x *Quantile) GetQuantile() float64 {
 	if x != nil {
-		if x.xxx_hidden_Id != nil {
-			return *x.xxx_hidden_Id
-		}
-		return ""
+		return x.xxx_hidden_Quantile
 	}
-	return ""
+	return 0
 }
 
-func (x *GetMetricRequest) GetType() MetricType {
+func (x *Quantile) GetValue() float64 {
 	if x != nil {
-		if protoimpl.X.Present(&(x.XXX_presence[0]), 1) {
-			return x.xxx_hidden_Type
-		}
+		return x.xxx_hidden_Value
 	}
-	return MetricType_UNKNOWN
+	return 0
 }
 
-func (x *GetMetricRequest) SetId(v string) {
-	x.xxx_hidden_Id = &v
+func (x *Quantile) SetQuantile(v float64) {
+	x.xxx_hidden_Quantile = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
 }
 
-func (x *GetMetricRequest) SetType(v MetricType) {
-	x.xxx_hidden_Type = v
+func (x *Quantile) SetValue(v float64) {
+	x.xxx_hidden_Value = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
 }
 
-func (x *GetMetricRequest) HasId() bool {
+func (x *Quantile) HasQuantile() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *GetMetricRequest) HasType() bool {
+func (x *Quantile) HasValue() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *GetMetricRequest) ClearId() {
+func (x *Quantile) ClearQuantile() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Id = nil
+	x.xxx_hidden_Quantile = 0
 }
 
-func (x *GetMetricRequest) ClearType() {
+func (x *Quantile) ClearValue() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Type = MetricType_UNKNOWN
+	x.xxx_hidden_Value = 0
 }
 
-type GetMetricRequest_builder struct {
+type Quantile_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Id   *string
-	Type *MetricType
+	Quantile *float64
+	Value    *float64
 }
 
-func (b0 GetMetricRequest_builder) Build() *GetMetricRequest {
-	m0 := &GetMetricRequest{}
+func (b0 Quantile_builder) Build() *Quantile {
+	m0 := &Quantile{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Id != nil {
+	if b.Quantile != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
-		x.xxx_hidden_Id = b.Id
+		x.xxx_hidden_Quantile = *b.Quantile
 	}
-	if b.Type != nil {
+	if b.Value != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
-		x.xxx_hidden_Type = *b.Type
+		x.xxx_hidden_Value = *b.Value
 	}
 	return m0
 }
 
-// Ответ с значением метрики
-type GetMetricResponse struct {
-	state             protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Metric *Metric                `protobuf:"bytes,1,opt,name=metric"`
-	// Deprecated: Do not use. This will be deleted in the near future.
-	XXX_lazyUnmarshalInfo  protoimpl.LazyUnmarshalInfo
+// Сводка: квантили, посчитанные на стороне клиента, сумма и общее количество наблюдений
+type Summary struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Quantiles   *[]*Quantile           `protobuf:"bytes,1,rep,name=quantiles"`
+	xxx_hidden_Sum         float64                `protobuf:"fixed64,2,opt,name=sum"`
+	xxx_hidden_Count       uint64                 `protobuf:"varint,3,opt,name=count"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *GetMetricResponse) Reset() {
-	*x = GetMetricResponse{}
+func (x *Summary) Reset() {
+	*x = Summary{}
 	mi := &file_proto_metrics_metrics_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetMetricResponse) String() string {
+func (x *Summary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetMetricResponse) ProtoMessage() {}
+func (*Summary) ProtoMessage() {}
 
-func (x *GetMetricResponse) ProtoReflect() protoreflect.Message {
+func (x *Summary) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_metrics_metrics_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -385,84 +403,121 @@ func (x *GetMetricResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *GetMetricResponse) GetMetric() *Metric {
+func (x *Summary) GetQuantiles() []*Quantile {
 	if x != nil {
-		if protoimpl.X.Present(&(x.XXX_presence[0]), 0) {
-			if protoimpl.X.AtomicCheckPointerIsNil(&x.xxx_hidden_Metric) {
-				protoimpl.X.UnmarshalField(x, 1)
-			}
-			var rv *Metric
-			protoimpl.X.AtomicLoadPointer(protoimpl.Pointer(&x.xxx_hidden_Metric), protoimpl.Pointer(&rv))
-			return rv
+		if x.xxx_hidden_Quantiles != nil {
+			return *x.xxx_hidden_Quantiles
 		}
 	}
 	return nil
 }
 
-func (x *GetMetricResponse) SetMetric(v *Metric) {
-	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, v)
-	if v == nil {
-		protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	} else {
-		protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+func (x *Summary) GetSum() float64 {
+	if x != nil {
+		return x.xxx_hidden_Sum
 	}
+	return 0
 }
 
-func (x *GetMetricResponse) HasMetric() bool {
+func (x *Summary) GetCount() uint64 {
+	if x != nil {
+		return x.xxx_hidden_Count
+	}
+	return 0
+}
+
+func (x *Summary) SetQuantiles(v []*Quantile) {
+	x.xxx_hidden_Quantiles = &v
+}
+
+func (x *Summary) SetSum(v float64) {
+	x.xxx_hidden_Sum = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *Summary) SetCount(v uint64) {
+	x.xxx_hidden_Count = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 3)
+}
+
+func (x *Summary) HasSum() bool {
 	if x == nil {
 		return false
 	}
-	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *GetMetricResponse) ClearMetric() {
-	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, (*Metric)(nil))
+func (x *Summary) HasCount() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
 }
 
-type GetMetricResponse_builder struct {
+func (x *Summary) ClearSum() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Sum = 0
+}
+
+func (x *Summary) ClearCount() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Count = 0
+}
+
+type Summary_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Metric *Metric
+	Quantiles []*Quantile
+	Sum       *float64
+	Count     *uint64
 }
 
-func (b0 GetMetricResponse_builder) Build() *GetMetricResponse {
-	m0 := &GetMetricResponse{}
+func (b0 Summary_builder) Build() *Summary {
+	m0 := &Summary{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Metric != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
-		x.xxx_hidden_Metric = b.Metric
+	x.xxx_hidden_Quantiles = &b.Quantiles
+	if b.Sum != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Sum = *b.Sum
+	}
+	if b.Count != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 3)
+		x.xxx_hidden_Count = *b.Count
 	}
 	return m0
 }
 
-// Запрос на обновление метрики
-type UpdateMetricRequest struct {
-	state             protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Metric *Metric                `protobuf:"bytes,1,opt,name=metric"`
-	// Deprecated: Do not use. This will be deleted in the near future.
-	XXX_lazyUnmarshalInfo  protoimpl.LazyUnmarshalInfo
+// Сообщение для одиночной метрики
+type Metric struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Id          *string                `protobuf:"bytes,1,opt,name=id"`
+	xxx_hidden_Type        MetricType             `protobuf:"varint,2,opt,name=type,enum=metrics.MetricType"`
+	xxx_hidden_Value       float64                `protobuf:"fixed64,3,opt,name=value"`
+	xxx_hidden_Delta       int64                  `protobuf:"varint,4,opt,name=delta"`
+	xxx_hidden_Histogram   *Histogram             `protobuf:"bytes,5,opt,name=histogram"`
+	xxx_hidden_Labels      map[string]string      `protobuf:"bytes,6,rep,name=labels" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_Summary     *Summary               `protobuf:"bytes,7,opt,name=summary"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *UpdateMetricRequest) Reset() {
-	*x = UpdateMetricRequest{}
+func (x *Metric) Reset() {
+	*x = Metric{}
 	mi := &file_proto_metrics_metrics_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateMetricRequest) String() string {
+func (x *Metric) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateMetricRequest) ProtoMessage() {}
+func (*Metric) ProtoMessage() {}
 
-func (x *UpdateMetricRequest) ProtoReflect() protoreflect.Message {
+func (x *Metric) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_metrics_metrics_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -474,85 +529,1266 @@ func (x *UpdateMetricRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *UpdateMetricRequest) GetMetric() *Metric {
+func (x *Metric) GetId() string {
 	if x != nil {
-		if protoimpl.X.Present(&(x.XXX_presence[0]), 0) {
-			if protoimpl.X.AtomicCheckPointerIsNil(&x.xxx_hidden_Metric) {
-				protoimpl.X.UnmarshalField(x, 1)
-			}
-			var rv *Metric
-			protoimpl.X.AtomicLoadPointer(protoimpl.Pointer(&x.xxx_hidden_Metric), protoimpl.Pointer(&rv))
-			return rv
+		if x.xxx_hidden_Id != nil {
+			return *x.xxx_hidden_Id
 		}
+		return ""
 	}
-	return nil
+	return ""
 }
 
-func (x *UpdateMetricRequest) SetMetric(v *Metric) {
-	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, v)
-	if v == nil {
-		protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	} else {
-		protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+func (x *Metric) GetType() MetricType {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 1) {
+			return x.xxx_hidden_Type
+		}
+	}
+	return MetricType_UNKNOWN
+}
+
+func (x *Metric) GetValue() float64 {
+	if x != nil {
+		return x.xxx_hidden_Value
+	}
+	return 0
+}
+
+func (x *Metric) GetDelta() int64 {
+	if x != nil {
+		return x.xxx_hidden_Delta
+	}
+	return 0
+}
+
+func (x *Metric) GetHistogram() *Histogram {
+	if x != nil {
+		return x.xxx_hidden_Histogram
+	}
+	return nil
+}
+
+func (x *Metric) GetLabels() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_Labels
+	}
+	return nil
+}
+
+func (x *Metric) GetSummary() *Summary {
+	if x != nil {
+		return x.xxx_hidden_Summary
+	}
+	return nil
+}
+
+func (x *Metric) SetId(v string) {
+	x.xxx_hidden_Id = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 7)
+}
+
+func (x *Metric) SetType(v MetricType) {
+	x.xxx_hidden_Type = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 7)
+}
+
+func (x *Metric) SetValue(v float64) {
+	x.xxx_hidden_Value = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 7)
+}
+
+func (x *Metric) SetDelta(v int64) {
+	x.xxx_hidden_Delta = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 7)
+}
+
+func (x *Metric) SetHistogram(v *Histogram) {
+	x.xxx_hidden_Histogram = v
+}
+
+func (x *Metric) SetLabels(v map[string]string) {
+	x.xxx_hidden_Labels = v
+}
+
+func (x *Metric) SetSummary(v *Summary) {
+	x.xxx_hidden_Summary = v
+}
+
+func (x *Metric) HasId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *Metric) HasType() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *Metric) HasValue() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *Metric) HasDelta() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *Metric) HasHistogram() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Histogram != nil
+}
+
+func (x *Metric) HasSummary() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Summary != nil
+}
+
+func (x *Metric) ClearId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Id = nil
+}
+
+func (x *Metric) ClearType() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Type = MetricType_UNKNOWN
+}
+
+func (x *Metric) ClearValue() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Value = 0
+}
+
+func (x *Metric) ClearDelta() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Delta = 0
+}
+
+func (x *Metric) ClearHistogram() {
+	x.xxx_hidden_Histogram = nil
+}
+
+func (x *Metric) ClearSummary() {
+	x.xxx_hidden_Summary = nil
+}
+
+type Metric_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Id        *string
+	Type      *MetricType
+	Value     *float64
+	Delta     *int64
+	Histogram *Histogram
+	Labels    map[string]string
+	Summary   *Summary
+}
+
+func (b0 Metric_builder) Build() *Metric {
+	m0 := &Metric{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Id != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 7)
+		x.xxx_hidden_Id = b.Id
+	}
+	if b.Type != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 7)
+		x.xxx_hidden_Type = *b.Type
+	}
+	if b.Value != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 7)
+		x.xxx_hidden_Value = *b.Value
+	}
+	if b.Delta != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 7)
+		x.xxx_hidden_Delta = *b.Delta
+	}
+	x.xxx_hidden_Histogram = b.Histogram
+	x.xxx_hidden_Labels = b.Labels
+	x.xxx_hidden_Summary = b.Summary
+	return m0
+}
+
+// Запрос на получение значения метрики
+type GetMetricRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Id          *string                `protobuf:"bytes,1,opt,name=id"`
+	xxx_hidden_Type        MetricType             `protobuf:"varint,2,opt,name=type,enum=metrics.MetricType"`
+	xxx_hidden_Labels      map[string]string      `protobuf:"bytes,3,rep,name=labels" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *GetMetricRequest) Reset() {
+	*x = GetMetricRequest{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricRequest) ProtoMessage() {}
+
+func (x *GetMetricRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *GetMetricRequest) GetId() string {
+	if x != nil {
+		if x.xxx_hidden_Id != nil {
+			return *x.xxx_hidden_Id
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *GetMetricRequest) GetType() MetricType {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 1) {
+			return x.xxx_hidden_Type
+		}
+	}
+	return MetricType_UNKNOWN
+}
+
+func (x *GetMetricRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_Labels
+	}
+	return nil
+}
+
+func (x *GetMetricRequest) SetId(v string) {
+	x.xxx_hidden_Id = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *GetMetricRequest) SetType(v MetricType) {
+	x.xxx_hidden_Type = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *GetMetricRequest) SetLabels(v map[string]string) {
+	x.xxx_hidden_Labels = v
+}
+
+func (x *GetMetricRequest) HasId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *GetMetricRequest) HasType() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *GetMetricRequest) ClearId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Id = nil
+}
+
+func (x *GetMetricRequest) ClearType() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Type = MetricType_UNKNOWN
+}
+
+type GetMetricRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Id     *string
+	Type   *MetricType
+	Labels map[string]string
+}
+
+func (b0 GetMetricRequest_builder) Build() *GetMetricRequest {
+	m0 := &GetMetricRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Id != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Id = b.Id
+	}
+	if b.Type != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Type = *b.Type
+	}
+	x.xxx_hidden_Labels = b.Labels
+	return m0
+}
+
+// Ответ с значением метрики
+type GetMetricResponse struct {
+	state             protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Metric *Metric                `protobuf:"bytes,1,opt,name=metric"`
+	// Deprecated: Do not use. This will be deleted in the near future.
+	XXX_lazyUnmarshalInfo  protoimpl.LazyUnmarshalInfo
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *GetMetricResponse) Reset() {
+	*x = GetMetricResponse{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricResponse) ProtoMessage() {}
+
+func (x *GetMetricResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *GetMetricResponse) GetMetric() *Metric {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 0) {
+			if protoimpl.X.AtomicCheckPointerIsNil(&x.xxx_hidden_Metric) {
+				protoimpl.X.UnmarshalField(x, 1)
+			}
+			var rv *Metric
+			protoimpl.X.AtomicLoadPointer(protoimpl.Pointer(&x.xxx_hidden_Metric), protoimpl.Pointer(&rv))
+			return rv
+		}
+	}
+	return nil
+}
+
+func (x *GetMetricResponse) SetMetric(v *Metric) {
+	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, v)
+	if v == nil {
+		protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	} else {
+		protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+	}
+}
+
+func (x *GetMetricResponse) HasMetric() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *GetMetricResponse) ClearMetric() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, (*Metric)(nil))
+}
+
+type GetMetricResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Metric *Metric
+}
+
+func (b0 GetMetricResponse_builder) Build() *GetMetricResponse {
+	m0 := &GetMetricResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Metric != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
+		x.xxx_hidden_Metric = b.Metric
+	}
+	return m0
+}
+
+// Запрос на обновление метрики
+type UpdateMetricRequest struct {
+	state                protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Metric    *Metric                `protobuf:"bytes,1,opt,name=metric"`
+	xxx_hidden_Encrypted []byte                 `protobuf:"bytes,2,opt,name=encrypted"`
+	// Deprecated: Do not use. This will be deleted in the near future.
+	XXX_lazyUnmarshalInfo  protoimpl.LazyUnmarshalInfo
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *UpdateMetricRequest) Reset() {
+	*x = UpdateMetricRequest{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMetricRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMetricRequest) ProtoMessage() {}
+
+func (x *UpdateMetricRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *UpdateMetricRequest) GetMetric() *Metric {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 0) {
+			if protoimpl.X.AtomicCheckPointerIsNil(&x.xxx_hidden_Metric) {
+				protoimpl.X.UnmarshalField(x, 1)
+			}
+			var rv *Metric
+			protoimpl.X.AtomicLoadPointer(protoimpl.Pointer(&x.xxx_hidden_Metric), protoimpl.Pointer(&rv))
+			return rv
+		}
+	}
+	return nil
+}
+
+func (x *UpdateMetricRequest) GetEncrypted() []byte {
+	if x != nil {
+		return x.xxx_hidden_Encrypted
+	}
+	return nil
+}
+
+func (x *UpdateMetricRequest) SetMetric(v *Metric) {
+	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, v)
+	if v == nil {
+		protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	} else {
+		protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+	}
+}
+
+func (x *UpdateMetricRequest) SetEncrypted(v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	x.xxx_hidden_Encrypted = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *UpdateMetricRequest) HasMetric() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *UpdateMetricRequest) HasEncrypted() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *UpdateMetricRequest) ClearMetric() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, (*Metric)(nil))
+}
+
+func (x *UpdateMetricRequest) ClearEncrypted() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Encrypted = nil
+}
+
+type UpdateMetricRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Metric    *Metric
+	Encrypted []byte
+}
+
+func (b0 UpdateMetricRequest_builder) Build() *UpdateMetricRequest {
+	m0 := &UpdateMetricRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Metric != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_Metric = b.Metric
+	}
+	if b.Encrypted != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Encrypted = b.Encrypted
+	}
+	return m0
+}
+
+// Ответ на обновление метрики
+type UpdateMetricResponse struct {
+	state             protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Metric *Metric                `protobuf:"bytes,1,opt,name=metric"`
+	// Deprecated: Do not use. This will be deleted in the near future.
+	XXX_lazyUnmarshalInfo  protoimpl.LazyUnmarshalInfo
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *UpdateMetricResponse) Reset() {
+	*x = UpdateMetricResponse{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMetricResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMetricResponse) ProtoMessage() {}
+
+func (x *UpdateMetricResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *UpdateMetricResponse) GetMetric() *Metric {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 0) {
+			if protoimpl.X.AtomicCheckPointerIsNil(&x.xxx_hidden_Metric) {
+				protoimpl.X.UnmarshalField(x, 1)
+			}
+			var rv *Metric
+			protoimpl.X.AtomicLoadPointer(protoimpl.Pointer(&x.xxx_hidden_Metric), protoimpl.Pointer(&rv))
+			return rv
+		}
+	}
+	return nil
+}
+
+func (x *UpdateMetricResponse) SetMetric(v *Metric) {
+	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, v)
+	if v == nil {
+		protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	} else {
+		protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+	}
+}
+
+func (x *UpdateMetricResponse) HasMetric() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *UpdateMetricResponse) ClearMetric() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, (*Metric)(nil))
+}
+
+type UpdateMetricResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Metric *Metric
+}
+
+func (b0 UpdateMetricResponse_builder) Build() *UpdateMetricResponse {
+	m0 := &UpdateMetricResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Metric != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
+		x.xxx_hidden_Metric = b.Metric
+	}
+	return m0
+}
+
+// Запрос на пакетное обновление метрик
+type UpdateMetricsRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Metrics     *[]*Metric             `protobuf:"bytes,1,rep,name=metrics"`
+	xxx_hidden_Encrypted   []byte                 `protobuf:"bytes,2,opt,name=encrypted"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *UpdateMetricsRequest) Reset() {
+	*x = UpdateMetricsRequest{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMetricsRequest) ProtoMessage() {}
+
+func (x *UpdateMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *UpdateMetricsRequest) GetMetrics() []*Metric {
+	if x != nil {
+		if x.xxx_hidden_Metrics != nil {
+			return *x.xxx_hidden_Metrics
+		}
+	}
+	return nil
+}
+
+func (x *UpdateMetricsRequest) GetEncrypted() []byte {
+	if x != nil {
+		return x.xxx_hidden_Encrypted
+	}
+	return nil
+}
+
+func (x *UpdateMetricsRequest) SetMetrics(v []*Metric) {
+	x.xxx_hidden_Metrics = &v
+}
+
+func (x *UpdateMetricsRequest) SetEncrypted(v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	x.xxx_hidden_Encrypted = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *UpdateMetricsRequest) HasEncrypted() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *UpdateMetricsRequest) ClearEncrypted() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Encrypted = nil
+}
+
+type UpdateMetricsRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Metrics   []*Metric
+	Encrypted []byte
+}
+
+func (b0 UpdateMetricsRequest_builder) Build() *UpdateMetricsRequest {
+	m0 := &UpdateMetricsRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Metrics = &b.Metrics
+	if b.Encrypted != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Encrypted = b.Encrypted
+	}
+	return m0
+}
+
+// Ответ на пакетное обновление метрик
+type UpdateMetricsResponse struct {
+	state              protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Metrics *[]*Metric             `protobuf:"bytes,1,rep,name=metrics"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UpdateMetricsResponse) Reset() {
+	*x = UpdateMetricsResponse{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMetricsResponse) ProtoMessage() {}
+
+func (x *UpdateMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *UpdateMetricsResponse) GetMetrics() []*Metric {
+	if x != nil {
+		if x.xxx_hidden_Metrics != nil {
+			return *x.xxx_hidden_Metrics
+		}
+	}
+	return nil
+}
+
+func (x *UpdateMetricsResponse) SetMetrics(v []*Metric) {
+	x.xxx_hidden_Metrics = &v
+}
+
+type UpdateMetricsResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Metrics []*Metric
+}
+
+func (b0 UpdateMetricsResponse_builder) Build() *UpdateMetricsResponse {
+	m0 := &UpdateMetricsResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Metrics = &b.Metrics
+	return m0
+}
+
+// Запрос на получение всех метрик
+type GetAllMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"opaque.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAllMetricsRequest) Reset() {
+	*x = GetAllMetricsRequest{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllMetricsRequest) ProtoMessage() {}
+
+func (x *GetAllMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+type GetAllMetricsRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+}
+
+func (b0 GetAllMetricsRequest_builder) Build() *GetAllMetricsRequest {
+	m0 := &GetAllMetricsRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	return m0
+}
+
+// Ответ со всеми метриками
+type GetAllMetricsResponse struct {
+	state              protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Metrics *[]*Metric             `protobuf:"bytes,1,rep,name=metrics"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *GetAllMetricsResponse) Reset() {
+	*x = GetAllMetricsResponse{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAllMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAllMetricsResponse) ProtoMessage() {}
+
+func (x *GetAllMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *GetAllMetricsResponse) GetMetrics() []*Metric {
+	if x != nil {
+		if x.xxx_hidden_Metrics != nil {
+			return *x.xxx_hidden_Metrics
+		}
+	}
+	return nil
+}
+
+func (x *GetAllMetricsResponse) SetMetrics(v []*Metric) {
+	x.xxx_hidden_Metrics = &v
+}
+
+type GetAllMetricsResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Metrics []*Metric
+}
+
+func (b0 GetAllMetricsResponse_builder) Build() *GetAllMetricsResponse {
+	m0 := &GetAllMetricsResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Metrics = &b.Metrics
+	return m0
+}
+
+// Запрос на получение истории метрики
+type QueryMetricRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Id          *string                `protobuf:"bytes,1,opt,name=id"`
+	xxx_hidden_Type        MetricType             `protobuf:"varint,2,opt,name=type,enum=metrics.MetricType"`
+	xxx_hidden_Labels      map[string]string      `protobuf:"bytes,3,rep,name=labels" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_From        int64                  `protobuf:"varint,4,opt,name=from"`
+	xxx_hidden_To          int64                  `protobuf:"varint,5,opt,name=to"`
+	xxx_hidden_Step        int64                  `protobuf:"varint,6,opt,name=step"`
+	xxx_hidden_Aggregation Aggregation            `protobuf:"varint,7,opt,name=aggregation,enum=metrics.Aggregation"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *QueryMetricRequest) Reset() {
+	*x = QueryMetricRequest{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryMetricRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryMetricRequest) ProtoMessage() {}
+
+func (x *QueryMetricRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *QueryMetricRequest) GetId() string {
+	if x != nil {
+		if x.xxx_hidden_Id != nil {
+			return *x.xxx_hidden_Id
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *QueryMetricRequest) GetType() MetricType {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 1) {
+			return x.xxx_hidden_Type
+		}
+	}
+	return MetricType_UNKNOWN
+}
+
+func (x *QueryMetricRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_Labels
+	}
+	return nil
+}
+
+func (x *QueryMetricRequest) GetFrom() int64 {
+	if x != nil {
+		return x.xxx_hidden_From
+	}
+	return 0
+}
+
+func (x *QueryMetricRequest) GetTo() int64 {
+	if x != nil {
+		return x.xxx_hidden_To
+	}
+	return 0
+}
+
+func (x *QueryMetricRequest) GetStep() int64 {
+	if x != nil {
+		return x.xxx_hidden_Step
+	}
+	return 0
+}
+
+func (x *QueryMetricRequest) GetAggregation() Aggregation {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 6) {
+			return x.xxx_hidden_Aggregation
+		}
+	}
+	return Aggregation_AGGREGATION_UNKNOWN
+}
+
+func (x *QueryMetricRequest) SetId(v string) {
+	x.xxx_hidden_Id = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 7)
+}
+
+func (x *QueryMetricRequest) SetType(v MetricType) {
+	x.xxx_hidden_Type = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 7)
+}
+
+func (x *QueryMetricRequest) SetLabels(v map[string]string) {
+	x.xxx_hidden_Labels = v
+}
+
+func (x *QueryMetricRequest) SetFrom(v int64) {
+	x.xxx_hidden_From = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 7)
+}
+
+func (x *QueryMetricRequest) SetTo(v int64) {
+	x.xxx_hidden_To = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 7)
+}
+
+func (x *QueryMetricRequest) SetStep(v int64) {
+	x.xxx_hidden_Step = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 7)
+}
+
+func (x *QueryMetricRequest) SetAggregation(v Aggregation) {
+	x.xxx_hidden_Aggregation = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 7)
+}
+
+func (x *QueryMetricRequest) HasId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *QueryMetricRequest) HasType() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *QueryMetricRequest) HasFrom() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *QueryMetricRequest) HasTo() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *QueryMetricRequest) HasStep() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
+}
+
+func (x *QueryMetricRequest) HasAggregation() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 6)
+}
+
+func (x *QueryMetricRequest) ClearId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Id = nil
+}
+
+func (x *QueryMetricRequest) ClearType() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Type = MetricType_UNKNOWN
+}
+
+func (x *QueryMetricRequest) ClearFrom() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_From = 0
+}
+
+func (x *QueryMetricRequest) ClearTo() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_To = 0
+}
+
+func (x *QueryMetricRequest) ClearStep() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 5)
+	x.xxx_hidden_Step = 0
+}
+
+func (x *QueryMetricRequest) ClearAggregation() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 6)
+	x.xxx_hidden_Aggregation = Aggregation_AGGREGATION_UNKNOWN
+}
+
+type QueryMetricRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Id          *string
+	Type        *MetricType
+	Labels      map[string]string
+	From        *int64
+	To          *int64
+	Step        *int64
+	Aggregation *Aggregation
+}
+
+func (b0 QueryMetricRequest_builder) Build() *QueryMetricRequest {
+	m0 := &QueryMetricRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Id != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 7)
+		x.xxx_hidden_Id = b.Id
+	}
+	if b.Type != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 7)
+		x.xxx_hidden_Type = *b.Type
+	}
+	x.xxx_hidden_Labels = b.Labels
+	if b.From != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 7)
+		x.xxx_hidden_From = *b.From
+	}
+	if b.To != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 7)
+		x.xxx_hidden_To = *b.To
+	}
+	if b.Step != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 7)
+		x.xxx_hidden_Step = *b.Step
+	}
+	if b.Aggregation != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 7)
+		x.xxx_hidden_Aggregation = *b.Aggregation
+	}
+	return m0
+}
+
+// Агрегированное значение интервала
+type Point struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Timestamp   int64                  `protobuf:"varint,1,opt,name=timestamp"`
+	xxx_hidden_Value       float64                `protobuf:"fixed64,2,opt,name=value"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Point) GetTimestamp() int64 {
+	if x != nil {
+		return x.xxx_hidden_Timestamp
 	}
+	return 0
 }
 
-func (x *UpdateMetricRequest) HasMetric() bool {
+func (x *Point) GetValue() float64 {
+	if x != nil {
+		return x.xxx_hidden_Value
+	}
+	return 0
+}
+
+func (x *Point) SetTimestamp(v int64) {
+	x.xxx_hidden_Timestamp = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+}
+
+func (x *Point) SetValue(v float64) {
+	x.xxx_hidden_Value = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *Point) HasTimestamp() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *UpdateMetricRequest) ClearMetric() {
+func (x *Point) HasValue() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *Point) ClearTimestamp() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, (*Metric)(nil))
+	x.xxx_hidden_Timestamp = 0
 }
 
-type UpdateMetricRequest_builder struct {
+func (x *Point) ClearValue() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Value = 0
+}
+
+type Point_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Metric *Metric
+	Timestamp *int64
+	Value     *float64
 }
 
-func (b0 UpdateMetricRequest_builder) Build() *UpdateMetricRequest {
-	m0 := &UpdateMetricRequest{}
+func (b0 Point_builder) Build() *Point {
+	m0 := &Point{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Metric != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
-		x.xxx_hidden_Metric = b.Metric
+	if b.Timestamp != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_Timestamp = *b.Timestamp
+	}
+	if b.Value != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Value = *b.Value
 	}
 	return m0
 }
 
-// Ответ на обновление метрики
-type UpdateMetricResponse struct {
-	state             protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Metric *Metric                `protobuf:"bytes,1,opt,name=metric"`
-	// Deprecated: Do not use. This will be deleted in the near future.
-	XXX_lazyUnmarshalInfo  protoimpl.LazyUnmarshalInfo
+// Ответ с историей метрики
+type QueryMetricResponse struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Id          *string                `protobuf:"bytes,1,opt,name=id"`
+	xxx_hidden_Type        MetricType             `protobuf:"varint,2,opt,name=type,enum=metrics.MetricType"`
+	xxx_hidden_Labels      map[string]string      `protobuf:"bytes,3,rep,name=labels" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_Aggregation Aggregation            `protobuf:"varint,4,opt,name=aggregation,enum=metrics.Aggregation"`
+	xxx_hidden_Points      *[]*Point              `protobuf:"bytes,5,rep,name=points"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *UpdateMetricResponse) Reset() {
-	*x = UpdateMetricResponse{}
-	mi := &file_proto_metrics_metrics_proto_msgTypes[4]
+func (x *QueryMetricResponse) Reset() {
+	*x = QueryMetricResponse{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateMetricResponse) String() string {
+func (x *QueryMetricResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateMetricResponse) ProtoMessage() {}
+func (*QueryMetricResponse) ProtoMessage() {}
 
-func (x *UpdateMetricResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metrics_metrics_proto_msgTypes[4]
+func (x *QueryMetricResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -563,81 +1799,359 @@ func (x *UpdateMetricResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *UpdateMetricResponse) GetMetric() *Metric {
+func (x *QueryMetricResponse) GetId() string {
 	if x != nil {
-		if protoimpl.X.Present(&(x.XXX_presence[0]), 0) {
-			if protoimpl.X.AtomicCheckPointerIsNil(&x.xxx_hidden_Metric) {
-				protoimpl.X.UnmarshalField(x, 1)
-			}
-			var rv *Metric
-			protoimpl.X.AtomicLoadPointer(protoimpl.Pointer(&x.xxx_hidden_Metric), protoimpl.Pointer(&rv))
-			return rv
+		if x.xxx_hidden_Id != nil {
+			return *x.xxx_hidden_Id
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *QueryMetricResponse) GetType() MetricType {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 1) {
+			return x.xxx_hidden_Type
 		}
 	}
+	return MetricType_UNKNOWN
+}
+
+func (x *QueryMetricResponse) GetLabels() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_Labels
+	}
 	return nil
 }
 
-func (x *UpdateMetricResponse) SetMetric(v *Metric) {
-	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, v)
+func (x *QueryMetricResponse) GetAggregation() Aggregation {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 3) {
+			return x.xxx_hidden_Aggregation
+		}
+	}
+	return Aggregation_AGGREGATION_UNKNOWN
+}
+
+func (x *QueryMetricResponse) GetPoints() []*Point {
+	if x != nil {
+		if x.xxx_hidden_Points != nil {
+			return *x.xxx_hidden_Points
+		}
+	}
+	return nil
+}
+
+func (x *QueryMetricResponse) SetId(v string) {
+	x.xxx_hidden_Id = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
+}
+
+func (x *QueryMetricResponse) SetType(v MetricType) {
+	x.xxx_hidden_Type = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 5)
+}
+
+func (x *QueryMetricResponse) SetLabels(v map[string]string) {
+	x.xxx_hidden_Labels = v
+}
+
+func (x *QueryMetricResponse) SetAggregation(v Aggregation) {
+	x.xxx_hidden_Aggregation = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 5)
+}
+
+func (x *QueryMetricResponse) SetPoints(v []*Point) {
+	x.xxx_hidden_Points = &v
+}
+
+func (x *QueryMetricResponse) HasId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *QueryMetricResponse) HasType() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *QueryMetricResponse) HasAggregation() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *QueryMetricResponse) ClearId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Id = nil
+}
+
+func (x *QueryMetricResponse) ClearType() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Type = MetricType_UNKNOWN
+}
+
+func (x *QueryMetricResponse) ClearAggregation() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Aggregation = Aggregation_AGGREGATION_UNKNOWN
+}
+
+type QueryMetricResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Id          *string
+	Type        *MetricType
+	Labels      map[string]string
+	Aggregation *Aggregation
+	Points      []*Point
+}
+
+func (b0 QueryMetricResponse_builder) Build() *QueryMetricResponse {
+	m0 := &QueryMetricResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Id != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
+		x.xxx_hidden_Id = b.Id
+	}
+	if b.Type != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 5)
+		x.xxx_hidden_Type = *b.Type
+	}
+	x.xxx_hidden_Labels = b.Labels
+	if b.Aggregation != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 5)
+		x.xxx_hidden_Aggregation = *b.Aggregation
+	}
+	x.xxx_hidden_Points = &b.Points
+	return m0
+}
+
+// Пакет метрик в потоке от агента
+type StreamMetricsRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Seq         uint64                 `protobuf:"varint,1,opt,name=seq"`
+	xxx_hidden_Metrics     *[]*Metric             `protobuf:"bytes,2,rep,name=metrics"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,3,opt,name=hash"`
+	xxx_hidden_Encrypted   []byte                 `protobuf:"bytes,4,opt,name=encrypted"`
+	xxx_hidden_BatchId     *string                `protobuf:"bytes,5,opt,name=batch_id,json=batchId"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *StreamMetricsRequest) Reset() {
+	*x = StreamMetricsRequest{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamMetricsRequest) ProtoMessage() {}
+
+func (x *StreamMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *StreamMetricsRequest) GetSeq() uint64 {
+	if x != nil {
+		return x.xxx_hidden_Seq
+	}
+	return 0
+}
+
+func (x *StreamMetricsRequest) GetMetrics() []*Metric {
+	if x != nil {
+		if x.xxx_hidden_Metrics != nil {
+			return *x.xxx_hidden_Metrics
+		}
+	}
+	return nil
+}
+
+func (x *StreamMetricsRequest) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *StreamMetricsRequest) GetEncrypted() []byte {
+	if x != nil {
+		return x.xxx_hidden_Encrypted
+	}
+	return nil
+}
+
+func (x *StreamMetricsRequest) GetBatchId() string {
+	if x != nil {
+		if x.xxx_hidden_BatchId != nil {
+			return *x.xxx_hidden_BatchId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *StreamMetricsRequest) SetSeq(v uint64) {
+	x.xxx_hidden_Seq = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
+}
+
+func (x *StreamMetricsRequest) SetMetrics(v []*Metric) {
+	x.xxx_hidden_Metrics = &v
+}
+
+func (x *StreamMetricsRequest) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 5)
+}
+
+func (x *StreamMetricsRequest) SetEncrypted(v []byte) {
 	if v == nil {
-		protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	} else {
-		protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+		v = []byte{}
 	}
+	x.xxx_hidden_Encrypted = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 5)
 }
 
-func (x *UpdateMetricResponse) HasMetric() bool {
+func (x *StreamMetricsRequest) SetBatchId(v string) {
+	x.xxx_hidden_BatchId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 5)
+}
+
+func (x *StreamMetricsRequest) HasSeq() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *UpdateMetricResponse) ClearMetric() {
+func (x *StreamMetricsRequest) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *StreamMetricsRequest) HasEncrypted() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *StreamMetricsRequest) HasBatchId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *StreamMetricsRequest) ClearSeq() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	protoimpl.X.AtomicSetPointer(&x.xxx_hidden_Metric, (*Metric)(nil))
+	x.xxx_hidden_Seq = 0
+}
+
+func (x *StreamMetricsRequest) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Hash = nil
+}
+
+func (x *StreamMetricsRequest) ClearEncrypted() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Encrypted = nil
 }
 
-type UpdateMetricResponse_builder struct {
+func (x *StreamMetricsRequest) ClearBatchId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_BatchId = nil
+}
+
+type StreamMetricsRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Metric *Metric
+	Seq       *uint64
+	Metrics   []*Metric
+	Hash      *string
+	Encrypted []byte
+	BatchId   *string
 }
 
-func (b0 UpdateMetricResponse_builder) Build() *UpdateMetricResponse {
-	m0 := &UpdateMetricResponse{}
+func (b0 StreamMetricsRequest_builder) Build() *StreamMetricsRequest {
+	m0 := &StreamMetricsRequest{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Metric != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
-		x.xxx_hidden_Metric = b.Metric
+	if b.Seq != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
+		x.xxx_hidden_Seq = *b.Seq
+	}
+	x.xxx_hidden_Metrics = &b.Metrics
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 5)
+		x.xxx_hidden_Hash = b.Hash
+	}
+	if b.Encrypted != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 5)
+		x.xxx_hidden_Encrypted = b.Encrypted
+	}
+	if b.BatchId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 5)
+		x.xxx_hidden_BatchId = b.BatchId
 	}
 	return m0
 }
 
-// Запрос на пакетное обновление метрик
-type UpdateMetricsRequest struct {
-	state              protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Metrics *[]*Metric             `protobuf:"bytes,1,rep,name=metrics"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+// Подтверждение приёма пакета
+type StreamMetricsAck struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Seq         uint64                 `protobuf:"varint,1,opt,name=seq"`
+	xxx_hidden_Accepted    uint32                 `protobuf:"varint,2,opt,name=accepted"`
+	xxx_hidden_Error       *string                `protobuf:"bytes,3,opt,name=error"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,4,opt,name=hash"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *UpdateMetricsRequest) Reset() {
-	*x = UpdateMetricsRequest{}
-	mi := &file_proto_metrics_metrics_proto_msgTypes[5]
+func (x *StreamMetricsAck) Reset() {
+	*x = StreamMetricsAck{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateMetricsRequest) String() string {
+func (x *StreamMetricsAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateMetricsRequest) ProtoMessage() {}
+func (*StreamMetricsAck) ProtoMessage() {}
 
-func (x *UpdateMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metrics_metrics_proto_msgTypes[5]
+func (x *StreamMetricsAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -648,56 +2162,167 @@ func (x *UpdateMetricsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *UpdateMetricsRequest) GetMetrics() []*Metric {
+func (x *StreamMetricsAck) GetSeq() uint64 {
 	if x != nil {
-		if x.xxx_hidden_Metrics != nil {
-			return *x.xxx_hidden_Metrics
+		return x.xxx_hidden_Seq
+	}
+	return 0
+}
+
+func (x *StreamMetricsAck) GetAccepted() uint32 {
+	if x != nil {
+		return x.xxx_hidden_Accepted
+	}
+	return 0
+}
+
+func (x *StreamMetricsAck) GetError() string {
+	if x != nil {
+		if x.xxx_hidden_Error != nil {
+			return *x.xxx_hidden_Error
 		}
+		return ""
 	}
-	return nil
+	return ""
 }
 
-func (x *UpdateMetricsRequest) SetMetrics(v []*Metric) {
-	x.xxx_hidden_Metrics = &v
+func (x *StreamMetricsAck) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
 }
 
-type UpdateMetricsRequest_builder struct {
+func (x *StreamMetricsAck) SetSeq(v uint64) {
+	x.xxx_hidden_Seq = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *StreamMetricsAck) SetAccepted(v uint32) {
+	x.xxx_hidden_Accepted = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *StreamMetricsAck) SetError(v string) {
+	x.xxx_hidden_Error = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *StreamMetricsAck) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *StreamMetricsAck) HasSeq() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *StreamMetricsAck) HasAccepted() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *StreamMetricsAck) HasError() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *StreamMetricsAck) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *StreamMetricsAck) ClearSeq() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Seq = 0
+}
+
+func (x *StreamMetricsAck) ClearAccepted() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Accepted = 0
+}
+
+func (x *StreamMetricsAck) ClearError() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Error = nil
+}
+
+func (x *StreamMetricsAck) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Hash = nil
+}
+
+type StreamMetricsAck_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Metrics []*Metric
+	Seq      *uint64
+	Accepted *uint32
+	Error    *string
+	Hash     *string
 }
 
-func (b0 UpdateMetricsRequest_builder) Build() *UpdateMetricsRequest {
-	m0 := &UpdateMetricsRequest{}
+func (b0 StreamMetricsAck_builder) Build() *StreamMetricsAck {
+	m0 := &StreamMetricsAck{}
 	b, x := &b0, m0
 	_, _ = b, x
-	x.xxx_hidden_Metrics = &b.Metrics
+	if b.Seq != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_Seq = *b.Seq
+	}
+	if b.Accepted != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Accepted = *b.Accepted
+	}
+	if b.Error != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Error = b.Error
+	}
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_Hash = b.Hash
+	}
 	return m0
 }
 
-// Ответ на пакетное обновление метрик
-type UpdateMetricsResponse struct {
-	state              protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Metrics *[]*Metric             `protobuf:"bytes,1,rep,name=metrics"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+// Подписка на изменения метрик
+type WatchMetricsRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Prefix      *string                `protobuf:"bytes,1,opt,name=prefix"`
+	xxx_hidden_Types       []MetricType           `protobuf:"varint,2,rep,packed,name=types,enum=metrics.MetricType"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,3,opt,name=hash"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *UpdateMetricsResponse) Reset() {
-	*x = UpdateMetricsResponse{}
-	mi := &file_proto_metrics_metrics_proto_msgTypes[6]
+func (x *WatchMetricsRequest) Reset() {
+	*x = WatchMetricsRequest{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateMetricsResponse) String() string {
+func (x *WatchMetricsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateMetricsResponse) ProtoMessage() {}
+func (*WatchMetricsRequest) ProtoMessage() {}
 
-func (x *UpdateMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metrics_metrics_proto_msgTypes[6]
+func (x *WatchMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -708,100 +2333,121 @@ func (x *UpdateMetricsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *UpdateMetricsResponse) GetMetrics() []*Metric {
+func (x *WatchMetricsRequest) GetPrefix() string {
 	if x != nil {
-		if x.xxx_hidden_Metrics != nil {
-			return *x.xxx_hidden_Metrics
+		if x.xxx_hidden_Prefix != nil {
+			return *x.xxx_hidden_Prefix
 		}
+		return ""
 	}
-	return nil
+	return ""
 }
 
-func (x *UpdateMetricsResponse) SetMetrics(v []*Metric) {
-	x.xxx_hidden_Metrics = &v
+func (x *WatchMetricsRequest) GetTypes() []MetricType {
+	if x != nil {
+		return x.xxx_hidden_Types
+	}
+	return nil
 }
 
-type UpdateMetricsResponse_builder struct {
-	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+func (x *WatchMetricsRequest) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
+}
 
-	Metrics []*Metric
+func (x *WatchMetricsRequest) SetPrefix(v string) {
+	x.xxx_hidden_Prefix = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
 }
 
-func (b0 UpdateMetricsResponse_builder) Build() *UpdateMetricsResponse {
-	m0 := &UpdateMetricsResponse{}
-	b, x := &b0, m0
-	_, _ = b, x
-	x.xxx_hidden_Metrics = &b.Metrics
-	return m0
+func (x *WatchMetricsRequest) SetTypes(v []MetricType) {
+	x.xxx_hidden_Types = v
 }
 
-// Запрос на получение всех метрик
-type GetAllMetricsRequest struct {
-	state         protoimpl.MessageState `protogen:"opaque.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *WatchMetricsRequest) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 3)
 }
 
-func (x *GetAllMetricsRequest) Reset() {
-	*x = GetAllMetricsRequest{}
-	mi := &file_proto_metrics_metrics_proto_msgTypes[7]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *WatchMetricsRequest) HasPrefix() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *GetAllMetricsRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *WatchMetricsRequest) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
 }
 
-func (*GetAllMetricsRequest) ProtoMessage() {}
+func (x *WatchMetricsRequest) ClearPrefix() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Prefix = nil
+}
 
-func (x *GetAllMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metrics_metrics_proto_msgTypes[7]
-	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
-	}
-	return mi.MessageOf(x)
+func (x *WatchMetricsRequest) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Hash = nil
 }
 
-type GetAllMetricsRequest_builder struct {
+type WatchMetricsRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
+	Prefix *string
+	Types  []MetricType
+	Hash   *string
 }
 
-func (b0 GetAllMetricsRequest_builder) Build() *GetAllMetricsRequest {
-	m0 := &GetAllMetricsRequest{}
+func (b0 WatchMetricsRequest_builder) Build() *WatchMetricsRequest {
+	m0 := &WatchMetricsRequest{}
 	b, x := &b0, m0
 	_, _ = b, x
+	if b.Prefix != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Prefix = b.Prefix
+	}
+	x.xxx_hidden_Types = b.Types
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 3)
+		x.xxx_hidden_Hash = b.Hash
+	}
 	return m0
 }
 
-// Ответ со всеми метриками
-type GetAllMetricsResponse struct {
-	state              protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Metrics *[]*Metric             `protobuf:"bytes,1,rep,name=metrics"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+// Изменение метрики
+type WatchMetricsResponse struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Metric      *Metric                `protobuf:"bytes,1,opt,name=metric"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,2,opt,name=hash"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *GetAllMetricsResponse) Reset() {
-	*x = GetAllMetricsResponse{}
-	mi := &file_proto_metrics_metrics_proto_msgTypes[8]
+func (x *WatchMetricsResponse) Reset() {
+	*x = WatchMetricsResponse{}
+	mi := &file_proto_metrics_metrics_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetAllMetricsResponse) String() string {
+func (x *WatchMetricsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetAllMetricsResponse) ProtoMessage() {}
+func (*WatchMetricsResponse) ProtoMessage() {}
 
-func (x *GetAllMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metrics_metrics_proto_msgTypes[8]
+func (x *WatchMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metrics_metrics_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -812,145 +2458,260 @@ func (x *GetAllMetricsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *GetAllMetricsResponse) GetMetrics() []*Metric {
+func (x *WatchMetricsResponse) GetMetric() *Metric {
 	if x != nil {
-		if x.xxx_hidden_Metrics != nil {
-			return *x.xxx_hidden_Metrics
-		}
+		return x.xxx_hidden_Metric
 	}
 	return nil
 }
 
-func (x *GetAllMetricsResponse) SetMetrics(v []*Metric) {
-	x.xxx_hidden_Metrics = &v
+func (x *WatchMetricsResponse) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
 }
 
-type GetAllMetricsResponse_builder struct {
+func (x *WatchMetricsResponse) SetMetric(v *Metric) {
+	x.xxx_hidden_Metric = v
+}
+
+func (x *WatchMetricsResponse) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *WatchMetricsResponse) HasMetric() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Metric != nil
+}
+
+func (x *WatchMetricsResponse) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *WatchMetricsResponse) ClearMetric() {
+	x.xxx_hidden_Metric = nil
+}
+
+func (x *WatchMetricsResponse) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Hash = nil
+}
+
+type WatchMetricsResponse_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Metrics []*Metric
+	Metric *Metric
+	Hash   *string
 }
 
-func (b0 GetAllMetricsResponse_builder) Build() *GetAllMetricsResponse {
-	m0 := &GetAllMetricsResponse{}
+func (b0 WatchMetricsResponse_builder) Build() *WatchMetricsResponse {
+	m0 := &WatchMetricsResponse{}
 	b, x := &b0, m0
 	_, _ = b, x
-	x.xxx_hidden_Metrics = &b.Metrics
+	x.xxx_hidden_Metric = b.Metric
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Hash = b.Hash
+	}
 	return m0
 }
 
 var File_proto_metrics_metrics_proto protoreflect.FileDescriptor
 
-var file_proto_metrics_metrics_proto_rawDesc = string([]byte{
-	0x0a, 0x1b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x6d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x1a, 0x21, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x67, 0x6f, 0x5f, 0x66, 0x65, 0x61, 0x74, 0x75,
-	0x72, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x6d, 0x0a, 0x06, 0x4d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x02, 0x69, 0x64, 0x12, 0x27, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x13, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x05, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x22, 0x4b, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x4d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02,
-	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x27, 0x0a, 0x04,
-	0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52,
-	0x04, 0x74, 0x79, 0x70, 0x65, 0x22, 0x40, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x06, 0x6d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x42, 0x02, 0x28, 0x01, 0x52,
-	0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x22, 0x42, 0x0a, 0x13, 0x55, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2b,
-	0x0a, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
-	0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x42,
-	0x02, 0x28, 0x01, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x22, 0x43, 0x0a, 0x14, 0x55,
-	0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x42, 0x02, 0x28, 0x01, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x22, 0x41, 0x0a, 0x14, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x22, 0x42, 0x0a, 0x15, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x07,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x07,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x22, 0x16, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x41, 0x6c,
-	0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
-	0x42, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x41, 0x6c, 0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x2a, 0x31, 0x0a, 0x0a, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70,
-	0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x09,
-	0x0a, 0x05, 0x47, 0x41, 0x55, 0x47, 0x45, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x4f, 0x55,
-	0x4e, 0x54, 0x45, 0x52, 0x10, 0x02, 0x32, 0xc1, 0x02, 0x0a, 0x0e, 0x4d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x47, 0x65, 0x74,
-	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x19, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x2e, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1a, 0x2e, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x4d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4b, 0x0a,
-	0x0c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x1c, 0x2e,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x55, 0x70,
-	0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x1d, 0x2e, 0x6d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x47, 0x65,
-	0x74, 0x41, 0x6c, 0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x1d, 0x2e, 0x6d, 0x65,
-	0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x6c, 0x6c, 0x4d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x2e, 0x47, 0x65, 0x74, 0x41, 0x6c, 0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x32, 0x5a, 0x28, 0x67, 0x69,
-	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x4b, 0x6f, 0x70, 0x6c, 0x65, 0x6d, 0x61,
-	0x6e, 0x2f, 0x6d, 0x65, 0x74, 0x63, 0x6f, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x92, 0x03, 0x05, 0xd2, 0x3e, 0x02, 0x10, 0x03, 0x62, 0x08,
-	0x65, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x70, 0xe8, 0x07,
-})
-
-var file_proto_metrics_metrics_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_metrics_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+const file_proto_metrics_metrics_proto_rawDesc = "" +
+	"\n" +
+	"\x1bproto/metrics/metrics.proto\x12\ametrics\x1a!google/protobuf/go_features.proto\"c\n" +
+	"\tHistogram\x12\x16\n" +
+	"\x06bounds\x18\x01 \x03(\x01R\x06bounds\x12\x16\n" +
+	"\x06counts\x18\x02 \x03(\x04R\x06counts\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x04R\x05count\"<\n" +
+	"\bQuantile\x12\x1a\n" +
+	"\bquantile\x18\x01 \x01(\x01R\bquantile\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\"b\n" +
+	"\aSummary\x12/\n" +
+	"\tquantiles\x18\x01 \x03(\v2\x11.metrics.QuantileR\tquantiles\x12\x10\n" +
+	"\x03sum\x18\x02 \x01(\x01R\x03sum\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x04R\x05count\"\xbb\x02\n" +
+	"\x06Metric\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.metrics.MetricTypeR\x04type\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x01R\x05value\x12\x14\n" +
+	"\x05delta\x18\x04 \x01(\x03R\x05delta\x120\n" +
+	"\thistogram\x18\x05 \x01(\v2\x12.metrics.HistogramR\thistogram\x123\n" +
+	"\x06labels\x18\x06 \x03(\v2\x1b.metrics.Metric.LabelsEntryR\x06labels\x12*\n" +
+	"\asummary\x18\a \x01(\v2\x10.metrics.SummaryR\asummary\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc5\x01\n" +
+	"\x10GetMetricRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.metrics.MetricTypeR\x04type\x12=\n" +
+	"\x06labels\x18\x03 \x03(\v2%.metrics.GetMetricRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"@\n" +
+	"\x11GetMetricResponse\x12+\n" +
+	"\x06metric\x18\x01 \x01(\v2\x0f.metrics.MetricB\x02(\x01R\x06metric\"`\n" +
+	"\x13UpdateMetricRequest\x12+\n" +
+	"\x06metric\x18\x01 \x01(\v2\x0f.metrics.MetricB\x02(\x01R\x06metric\x12\x1c\n" +
+	"\tencrypted\x18\x02 \x01(\fR\tencrypted\"C\n" +
+	"\x14UpdateMetricResponse\x12+\n" +
+	"\x06metric\x18\x01 \x01(\v2\x0f.metrics.MetricB\x02(\x01R\x06metric\"_\n" +
+	"\x14UpdateMetricsRequest\x12)\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\x12\x1c\n" +
+	"\tencrypted\x18\x02 \x01(\fR\tencrypted\"B\n" +
+	"\x15UpdateMetricsResponse\x12)\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\"\x16\n" +
+	"\x14GetAllMetricsRequest\"B\n" +
+	"\x15GetAllMetricsResponse\x12)\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0f.metrics.MetricR\ametrics\"\xb9\x02\n" +
+	"\x12QueryMetricRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.metrics.MetricTypeR\x04type\x12?\n" +
+	"\x06labels\x18\x03 \x03(\v2'.metrics.QueryMetricRequest.LabelsEntryR\x06labels\x12\x12\n" +
+	"\x04from\x18\x04 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x05 \x01(\x03R\x02to\x12\x12\n" +
+	"\x04step\x18\x06 \x01(\x03R\x04step\x126\n" +
+	"\vaggregation\x18\a \x01(\x0e2\x14.metrics.AggregationR\vaggregation\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\";\n" +
+	"\x05Point\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\"\xab\x02\n" +
+	"\x13QueryMetricResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.metrics.MetricTypeR\x04type\x12@\n" +
+	"\x06labels\x18\x03 \x03(\v2(.metrics.QueryMetricResponse.LabelsEntryR\x06labels\x126\n" +
+	"\vaggregation\x18\x04 \x01(\x0e2\x14.metrics.AggregationR\vaggregation\x12&\n" +
+	"\x06points\x18\x05 \x03(\v2\x0e.metrics.PointR\x06points\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa0\x01\n" +
+	"\x14StreamMetricsRequest\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12)\n" +
+	"\ametrics\x18\x02 \x03(\v2\x0f.metrics.MetricR\ametrics\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x1c\n" +
+	"\tencrypted\x18\x04 \x01(\fR\tencrypted\x12\x19\n" +
+	"\bbatch_id\x18\x05 \x01(\tR\abatchId\"j\n" +
+	"\x10StreamMetricsAck\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x1a\n" +
+	"\baccepted\x18\x02 \x01(\rR\baccepted\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\"l\n" +
+	"\x13WatchMetricsRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12)\n" +
+	"\x05types\x18\x02 \x03(\x0e2\x13.metrics.MetricTypeR\x05types\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\"S\n" +
+	"\x14WatchMetricsResponse\x12'\n" +
+	"\x06metric\x18\x01 \x01(\v2\x0f.metrics.MetricR\x06metric\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash*M\n" +
+	"\n" +
+	"MetricType\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\t\n" +
+	"\x05GAUGE\x10\x01\x12\v\n" +
+	"\aCOUNTER\x10\x02\x12\r\n" +
+	"\tHISTOGRAM\x10\x03\x12\v\n" +
+	"\aSUMMARY\x10\x04*\x90\x01\n" +
+	"\vAggregation\x12\x17\n" +
+	"\x13AGGREGATION_UNKNOWN\x10\x00\x12\x13\n" +
+	"\x0fAGGREGATION_AVG\x10\x01\x12\x13\n" +
+	"\x0fAGGREGATION_MIN\x10\x02\x12\x13\n" +
+	"\x0fAGGREGATION_MAX\x10\x03\x12\x13\n" +
+	"\x0fAGGREGATION_SUM\x10\x04\x12\x14\n" +
+	"\x10AGGREGATION_RATE\x10\x052\xa9\x04\n" +
+	"\x0eMetricsService\x12B\n" +
+	"\tGetMetric\x12\x19.metrics.GetMetricRequest\x1a\x1a.metrics.GetMetricResponse\x12K\n" +
+	"\fUpdateMetric\x12\x1c.metrics.UpdateMetricRequest\x1a\x1d.metrics.UpdateMetricResponse\x12N\n" +
+	"\rUpdateMetrics\x12\x1d.metrics.UpdateMetricsRequest\x1a\x1e.metrics.UpdateMetricsResponse\x12N\n" +
+	"\rGetAllMetrics\x12\x1d.metrics.GetAllMetricsRequest\x1a\x1e.metrics.GetAllMetricsResponse\x12H\n" +
+	"\vQueryMetric\x12\x1b.metrics.QueryMetricRequest\x1a\x1c.metrics.QueryMetricResponse\x12M\n" +
+	"\rStreamMetrics\x12\x1d.metrics.StreamMetricsRequest\x1a\x19.metrics.StreamMetricsAck(\x010\x01\x12M\n" +
+	"\fWatchMetrics\x12\x1c.metrics.WatchMetricsRequest\x1a\x1d.metrics.WatchMetricsResponse0\x01B2Z(github.com/Kopleman/metcol/proto/metrics\x92\x03\x05\xd2>\x02\x10\x03b\beditionsp\xe8\a"
+
+var file_proto_metrics_metrics_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_metrics_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_proto_metrics_metrics_proto_goTypes = []any{
 	(MetricType)(0),               // 0: metrics.MetricType
-	(*Metric)(nil),                // 1: metrics.Metric
-	(*GetMetricRequest)(nil),      // 2: metrics.GetMetricRequest
-	(*GetMetricResponse)(nil),     // 3: metrics.GetMetricResponse
-	(*UpdateMetricRequest)(nil),   // 4: metrics.UpdateMetricRequest
-	(*UpdateMetricResponse)(nil),  // 5: metrics.UpdateMetricResponse
-	(*UpdateMetricsRequest)(nil),  // 6: metrics.UpdateMetricsRequest
-	(*UpdateMetricsResponse)(nil), // 7: metrics.UpdateMetricsResponse
-	(*GetAllMetricsRequest)(nil),  // 8: metrics.GetAllMetricsRequest
-	(*GetAllMetricsResponse)(nil), // 9: metrics.GetAllMetricsResponse
+	(Aggregation)(0),              // 1: metrics.Aggregation
+	(*Histogram)(nil),             // 2: metrics.Histogram
+	(*Quantile)(nil),              // 3: metrics.Quantile
+	(*Summary)(nil),               // 4: metrics.Summary
+	(*Metric)(nil),                // 5: metrics.Metric
+	(*GetMetricRequest)(nil),      // 6: metrics.GetMetricRequest
+	(*GetMetricResponse)(nil),     // 7: metrics.GetMetricResponse
+	(*UpdateMetricRequest)(nil),   // 8: metrics.UpdateMetricRequest
+	(*UpdateMetricResponse)(nil),  // 9: metrics.UpdateMetricResponse
+	(*UpdateMetricsRequest)(nil),  // 10: metrics.UpdateMetricsRequest
+	(*UpdateMetricsResponse)(nil), // 11: metrics.UpdateMetricsResponse
+	(*GetAllMetricsRequest)(nil),  // 12: metrics.GetAllMetricsRequest
+	(*GetAllMetricsResponse)(nil), // 13: metrics.GetAllMetricsResponse
+	(*QueryMetricRequest)(nil),    // 14: metrics.QueryMetricRequest
+	(*Point)(nil),                 // 15: metrics.Point
+	(*QueryMetricResponse)(nil),   // 16: metrics.QueryMetricResponse
+	(*StreamMetricsRequest)(nil),  // 17: metrics.StreamMetricsRequest
+	(*StreamMetricsAck)(nil),      // 18: metrics.StreamMetricsAck
+	(*WatchMetricsRequest)(nil),   // 19: metrics.WatchMetricsRequest
+	(*WatchMetricsResponse)(nil),  // 20: metrics.WatchMetricsResponse
+	nil,                           // 21: metrics.Metric.LabelsEntry
+	nil,                           // 22: metrics.GetMetricRequest.LabelsEntry
+	nil,                           // 23: metrics.QueryMetricRequest.LabelsEntry
+	nil,                           // 24: metrics.QueryMetricResponse.LabelsEntry
 }
 var file_proto_metrics_metrics_proto_depIdxs = []int32{
-	0,  // 0: metrics.Metric.type:type_name -> metrics.MetricType
-	0,  // 1: metrics.GetMetricRequest.type:type_name -> metrics.MetricType
-	1,  // 2: metrics.GetMetricResponse.metric:type_name -> metrics.Metric
-	1,  // 3: metrics.UpdateMetricRequest.metric:type_name -> metrics.Metric
-	1,  // 4: metrics.UpdateMetricResponse.metric:type_name -> metrics.Metric
-	1,  // 5: metrics.UpdateMetricsRequest.metrics:type_name -> metrics.Metric
-	1,  // 6: metrics.UpdateMetricsResponse.metrics:type_name -> metrics.Metric
-	1,  // 7: metrics.GetAllMetricsResponse.metrics:type_name -> metrics.Metric
-	2,  // 8: metrics.MetricsService.GetMetric:input_type -> metrics.GetMetricRequest
-	4,  // 9: metrics.MetricsService.UpdateMetric:input_type -> metrics.UpdateMetricRequest
-	6,  // 10: metrics.MetricsService.UpdateMetrics:input_type -> metrics.UpdateMetricsRequest
-	8,  // 11: metrics.MetricsService.GetAllMetrics:input_type -> metrics.GetAllMetricsRequest
-	3,  // 12: metrics.MetricsService.GetMetric:output_type -> metrics.GetMetricResponse
-	5,  // 13: metrics.MetricsService.UpdateMetric:output_type -> metrics.UpdateMetricResponse
-	7,  // 14: metrics.MetricsService.UpdateMetrics:output_type -> metrics.UpdateMetricsResponse
-	9,  // 15: metrics.MetricsService.GetAllMetrics:output_type -> metrics.GetAllMetricsResponse
-	12, // [12:16] is the sub-list for method output_type
-	8,  // [8:12] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	3,  // 0: metrics.Summary.quantiles:type_name -> metrics.Quantile
+	0,  // 1: metrics.Metric.type:type_name -> metrics.MetricType
+	2,  // 2: metrics.Metric.histogram:type_name -> metrics.Histogram
+	21, // 3: metrics.Metric.labels:type_name -> metrics.Metric.LabelsEntry
+	4,  // 4: metrics.Metric.summary:type_name -> metrics.Summary
+	0,  // 5: metrics.GetMetricRequest.type:type_name -> metrics.MetricType
+	22, // 6: metrics.GetMetricRequest.labels:type_name -> metrics.GetMetricRequest.LabelsEntry
+	5,  // 7: metrics.GetMetricResponse.metric:type_name -> metrics.Metric
+	5,  // 8: metrics.UpdateMetricRequest.metric:type_name -> metrics.Metric
+	5,  // 9: metrics.UpdateMetricResponse.metric:type_name -> metrics.Metric
+	5,  // 10: metrics.UpdateMetricsRequest.metrics:type_name -> metrics.Metric
+	5,  // 11: metrics.UpdateMetricsResponse.metrics:type_name -> metrics.Metric
+	5,  // 12: metrics.GetAllMetricsResponse.metrics:type_name -> metrics.Metric
+	0,  // 13: metrics.QueryMetricRequest.type:type_name -> metrics.MetricType
+	23, // 14: metrics.QueryMetricRequest.labels:type_name -> metrics.QueryMetricRequest.LabelsEntry
+	1,  // 15: metrics.QueryMetricRequest.aggregation:type_name -> metrics.Aggregation
+	0,  // 16: metrics.QueryMetricResponse.type:type_name -> metrics.MetricType
+	24, // 17: metrics.QueryMetricResponse.labels:type_name -> metrics.QueryMetricResponse.LabelsEntry
+	1,  // 18: metrics.QueryMetricResponse.aggregation:type_name -> metrics.Aggregation
+	15, // 19: metrics.QueryMetricResponse.points:type_name -> metrics.Point
+	5,  // 20: metrics.StreamMetricsRequest.metrics:type_name -> metrics.Metric
+	0,  // 21: metrics.WatchMetricsRequest.types:type_name -> metrics.MetricType
+	5,  // 22: metrics.WatchMetricsResponse.metric:type_name -> metrics.Metric
+	6,  // 23: metrics.MetricsService.GetMetric:input_type -> metrics.GetMetricRequest
+	8,  // 24: metrics.MetricsService.UpdateMetric:input_type -> metrics.UpdateMetricRequest
+	10, // 25: metrics.MetricsService.UpdateMetrics:input_type -> metrics.UpdateMetricsRequest
+	12, // 26: metrics.MetricsService.GetAllMetrics:input_type -> metrics.GetAllMetricsRequest
+	14, // 27: metrics.MetricsService.QueryMetric:input_type -> metrics.QueryMetricRequest
+	17, // 28: metrics.MetricsService.StreamMetrics:input_type -> metrics.StreamMetricsRequest
+	19, // 29: metrics.MetricsService.WatchMetrics:input_type -> metrics.WatchMetricsRequest
+	7,  // 30: metrics.MetricsService.GetMetric:output_type -> metrics.GetMetricResponse
+	9,  // 31: metrics.MetricsService.UpdateMetric:output_type -> metrics.UpdateMetricResponse
+	11, // 32: metrics.MetricsService.UpdateMetrics:output_type -> metrics.UpdateMetricsResponse
+	13, // 33: metrics.MetricsService.GetAllMetrics:output_type -> metrics.GetAllMetricsResponse
+	16, // 34: metrics.MetricsService.QueryMetric:output_type -> metrics.QueryMetricResponse
+	18, // 35: metrics.MetricsService.StreamMetrics:output_type -> metrics.StreamMetricsAck
+	20, // 36: metrics.MetricsService.WatchMetrics:output_type -> metrics.WatchMetricsResponse
+	30, // [30:37] is the sub-list for method output_type
+	23, // [23:30] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_proto_metrics_metrics_proto_init() }
@@ -963,8 +2724,8 @@ func file_proto_metrics_metrics_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_metrics_metrics_proto_rawDesc), len(file_proto_metrics_metrics_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   9,
+			NumEnums:      2,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,10 @@
+DELETE FROM metrics WHERE type = 'histogram';
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS value_delta_null_check;
+ALTER TABLE metrics ADD CONSTRAINT value_delta_null_check CHECK (NOT(value IS NULL AND delta IS NULL));
+ALTER TABLE metrics DROP COLUMN IF EXISTS histogram;
+
+ALTER TYPE METRIC_TYPE RENAME TO METRIC_TYPE_OLD;
+CREATE TYPE METRIC_TYPE AS ENUM ('gauge', 'counter');
+ALTER TABLE metrics ALTER COLUMN type TYPE METRIC_TYPE USING type::text::METRIC_TYPE;
+DROP TYPE METRIC_TYPE_OLD;
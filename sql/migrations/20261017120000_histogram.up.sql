@@ -0,0 +1,7 @@
+ALTER TYPE METRIC_TYPE ADD VALUE IF NOT EXISTS 'histogram';
+
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS histogram JSONB NULL;
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS value_delta_null_check;
+ALTER TABLE metrics ADD CONSTRAINT value_delta_null_check
+    CHECK (NOT(value IS NULL AND delta IS NULL AND histogram IS NULL));
@@ -0,0 +1,5 @@
+DELETE FROM metrics WHERE labels <> '{}'::jsonb;
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS name_type_uniq;
+ALTER TABLE metrics ADD CONSTRAINT name_type_uniq UNIQUE(name, type);
+ALTER TABLE metrics DROP COLUMN IF EXISTS labels;
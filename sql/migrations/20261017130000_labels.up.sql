@@ -0,0 +1,4 @@
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS name_type_uniq;
+ALTER TABLE metrics ADD CONSTRAINT name_type_uniq UNIQUE(name, type, labels);
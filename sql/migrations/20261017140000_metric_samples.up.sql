@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS metric_samples
+(
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    type       METRIC_TYPE  NOT NULL,
+    labels     JSONB        NOT NULL DEFAULT '{}'::jsonb,
+    value      DOUBLE PRECISION NULL,
+    delta      BIGINT NULL,
+    histogram  JSONB NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS metric_samples_series_idx ON metric_samples (name, type, created_at);
+CREATE INDEX IF NOT EXISTS metric_samples_created_at_idx ON metric_samples (created_at);
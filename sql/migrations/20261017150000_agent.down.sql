@@ -0,0 +1,2 @@
+ALTER TABLE metric_samples DROP COLUMN IF EXISTS agent;
+ALTER TABLE metrics DROP COLUMN IF EXISTS agent;
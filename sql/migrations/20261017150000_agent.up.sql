@@ -0,0 +1,2 @@
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS agent VARCHAR(255) NULL;
+ALTER TABLE metric_samples ADD COLUMN IF NOT EXISTS agent VARCHAR(255) NULL;
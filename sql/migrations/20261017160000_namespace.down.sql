@@ -0,0 +1,11 @@
+DELETE FROM metrics WHERE namespace <> '';
+DELETE FROM metric_samples WHERE namespace <> '';
+
+DROP INDEX IF EXISTS metric_samples_series_idx;
+CREATE INDEX IF NOT EXISTS metric_samples_series_idx ON metric_samples (name, type, created_at);
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS name_type_uniq;
+ALTER TABLE metrics ADD CONSTRAINT name_type_uniq UNIQUE(name, type, labels);
+
+ALTER TABLE metric_samples DROP COLUMN IF EXISTS namespace;
+ALTER TABLE metrics DROP COLUMN IF EXISTS namespace;
@@ -0,0 +1,8 @@
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS namespace VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE metric_samples ADD COLUMN IF NOT EXISTS namespace VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS name_type_uniq;
+ALTER TABLE metrics ADD CONSTRAINT name_type_uniq UNIQUE(namespace, name, type, labels);
+
+DROP INDEX IF EXISTS metric_samples_series_idx;
+CREATE INDEX IF NOT EXISTS metric_samples_series_idx ON metric_samples (namespace, name, type, created_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS applied_batches
+(
+    namespace  VARCHAR(255) NOT NULL DEFAULT '',
+    batch_id   VARCHAR(255) NOT NULL,
+    result     JSONB        NOT NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (namespace, batch_id)
+);
+
+CREATE INDEX IF NOT EXISTS applied_batches_created_at_idx ON applied_batches (created_at);
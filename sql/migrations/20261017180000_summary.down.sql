@@ -0,0 +1,14 @@
+DELETE FROM metrics WHERE type = 'summary';
+DELETE FROM metric_samples WHERE type = 'summary';
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS value_delta_null_check;
+ALTER TABLE metrics ADD CONSTRAINT value_delta_null_check
+    CHECK (NOT(value IS NULL AND delta IS NULL AND histogram IS NULL));
+ALTER TABLE metric_samples DROP COLUMN IF EXISTS summary;
+ALTER TABLE metrics DROP COLUMN IF EXISTS summary;
+
+ALTER TYPE METRIC_TYPE RENAME TO METRIC_TYPE_OLD;
+CREATE TYPE METRIC_TYPE AS ENUM ('gauge', 'counter', 'histogram');
+ALTER TABLE metrics ALTER COLUMN type TYPE METRIC_TYPE USING type::text::METRIC_TYPE;
+ALTER TABLE metric_samples ALTER COLUMN type TYPE METRIC_TYPE USING type::text::METRIC_TYPE;
+DROP TYPE METRIC_TYPE_OLD;
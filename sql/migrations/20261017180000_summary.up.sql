@@ -0,0 +1,8 @@
+ALTER TYPE METRIC_TYPE ADD VALUE IF NOT EXISTS 'summary';
+
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS summary JSONB NULL;
+ALTER TABLE metric_samples ADD COLUMN IF NOT EXISTS summary JSONB NULL;
+
+ALTER TABLE metrics DROP CONSTRAINT IF EXISTS value_delta_null_check;
+ALTER TABLE metrics ADD CONSTRAINT value_delta_null_check
+    CHECK (NOT(value IS NULL AND delta IS NULL AND histogram IS NULL AND summary IS NULL));
@@ -0,0 +1,11 @@
+-- name: CreateAppliedBatch :execrows
+INSERT INTO applied_batches (namespace, batch_id, result, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (namespace, batch_id) DO NOTHING;
+
+-- name: GetAppliedBatch :one
+SELECT namespace, batch_id, result, created_at FROM applied_batches
+WHERE namespace = $1 AND batch_id = $2;
+
+-- name: DeleteAppliedBatchesBefore :exec
+DELETE FROM applied_batches WHERE created_at < $1;
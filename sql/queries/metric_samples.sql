@@ -0,0 +1,12 @@
+-- name: CreateMetricSample :exec
+INSERT INTO metric_samples (name, type, labels, value, delta, histogram, created_at, agent, namespace, summary)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: GetMetricSamples :many
+SELECT id, name, type, labels, value, delta, histogram, created_at, agent, namespace, summary FROM metric_samples
+WHERE namespace = sqlc.arg(namespace) AND type = sqlc.arg(type) AND name = sqlc.arg(name) AND labels = sqlc.arg(labels)
+  AND created_at >= sqlc.arg(from_time) AND created_at <= sqlc.arg(to_time)
+ORDER BY created_at ASC;
+
+-- name: DeleteMetricSamplesBefore :exec
+DELETE FROM metric_samples WHERE created_at < $1;
\ No newline at end of file
@@ -1,30 +1,33 @@
 -- name: GetAllMetrics :many
-SELECT id, name, type, value, delta, created_at, updated_at, deleted_at FROM metrics ORDER BY name ASC;
+SELECT id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary FROM metrics ORDER BY name ASC;
+
+-- name: GetNamespaceMetrics :many
+SELECT id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary FROM metrics WHERE namespace=$1 ORDER BY name ASC;
 
 -- name: GetMetric :one
-SELECT id, name, type, value, delta, created_at, updated_at, deleted_at FROM metrics WHERE type=$1 AND name=$2 LIMIT 1;
+SELECT id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary FROM metrics WHERE type=$1 AND name=$2 AND labels=$3 AND namespace=$4 LIMIT 1;
 
 -- name: UpdateMetricAndGet :one
 UPDATE metrics
-SET value=$1, delta=$2, updated_at=now()
-WHERE type=$3 AND name=$4
-    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at;
+SET value=$1, delta=$2, histogram=$3, agent=$7, summary=$9, updated_at=now()
+WHERE type=$4 AND name=$5 AND labels=$6 AND namespace=$8
+    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary;
 
 -- name: UpdateMetric :exec
 UPDATE metrics
-SET value=$1, delta=$2, updated_at=now()
-WHERE type=$3 AND name=$4;
+SET value=$1, delta=$2, histogram=$3, agent=$7, summary=$9, updated_at=now()
+WHERE type=$4 AND name=$5 AND labels=$6 AND namespace=$8;
 
 -- name: CreateMetric :one
-INSERT INTO metrics (name, type, value, delta, created_at)
-VALUES ($1, $2, $3, $4, now())
-    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at;
+INSERT INTO metrics (name, type, value, delta, histogram, labels, agent, namespace, summary, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())
+    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary;
 
 -- name: ExistsMetric :one
-SELECT EXISTS (SELECT * FROM metrics WHERE name=$1 AND type=$2)::boolean;
+SELECT EXISTS (SELECT * FROM metrics WHERE name=$1 AND type=$2 AND labels=$3 AND namespace=$4)::boolean;
 
 -- name: CreateOrUpdateMetric :one
-INSERT INTO metrics (name, type, value, delta, created_at)
-VALUES ($1, $2, $3, $4, now())
-    ON CONFLICT ON CONSTRAINT name_type_uniq DO UPDATE SET value=$3, delta=$4, updated_at=now()
-    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at;
\ No newline at end of file
+INSERT INTO metrics (name, type, value, delta, histogram, labels, agent, namespace, summary, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())
+    ON CONFLICT ON CONSTRAINT name_type_uniq DO UPDATE SET value=$3, delta=$4, histogram=$5, agent=$7, summary=$9, updated_at=now()
+    RETURNING id, name, type, value, delta, created_at, updated_at, deleted_at, histogram, labels, agent, namespace, summary;